@@ -0,0 +1,163 @@
+package ui
+
+import (
+	"fmt"
+	"image"
+	_ "image/gif"  // register GIF decoding for image assets
+	_ "image/jpeg" // register JPEG decoding for image assets
+	_ "image/png"  // register PNG decoding for image assets
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// ============================================================================
+// Asset resolution
+// ============================================================================
+
+// AssetResolver opens the assets referenced by stylesheets and layouts, such
+// as url(...) values and <image src>. Names are passed through unchanged.
+type AssetResolver interface {
+	OpenAsset(name string) (io.ReadCloser, error)
+}
+
+// AssetResolverFunc adapts a function to the AssetResolver interface.
+type AssetResolverFunc func(name string) (io.ReadCloser, error)
+
+// OpenAsset calls f(name).
+func (f AssetResolverFunc) OpenAsset(name string) (io.ReadCloser, error) { return f(name) }
+
+// FSAssetResolver resolves assets from a file system such as an embed.FS.
+func FSAssetResolver(fsys fs.FS) AssetResolver {
+	return AssetResolverFunc(func(name string) (io.ReadCloser, error) {
+		return fsys.Open(strings.TrimPrefix(name, "/"))
+	})
+}
+
+// SetAssetResolver sets the resolver used to load assets. The default opens
+// names as paths on the local file system. Previously loaded images stay
+// cached; images registered with RegisterImage always take precedence.
+func (ui *UI) SetAssetResolver(resolver AssetResolver) {
+	ui.assetResolver = resolver
+	for name, img := range ui.images {
+		if img == nil {
+			delete(ui.images, name)
+		}
+	}
+}
+
+// openAsset opens a named asset through the configured resolver.
+func (ui *UI) openAsset(name string) (io.ReadCloser, error) {
+	if ui.assetResolver != nil {
+		return ui.assetResolver.OpenAsset(name)
+	}
+	return os.Open(name)
+}
+
+// LoadImage returns the image registered or previously loaded under name,
+// decoding it through the asset resolver on first use. Failed loads are
+// remembered and not retried until the resolver changes. Names starting with
+// "atlas:" resolve to frames of registered atlases.
+func (ui *UI) LoadImage(name string) (*ebiten.Image, error) {
+	if name == "" {
+		return nil, fmt.Errorf("empty image name")
+	}
+	if img, ok := ui.images[name]; ok {
+		if img == nil {
+			return nil, fmt.Errorf("image %q failed to load", name)
+		}
+		return img, nil
+	}
+	if ref, ok := strings.CutPrefix(name, atlasPrefix); ok {
+		// Atlases cache their own frame images and may be registered later.
+		atlas, frame := ui.atlasFrame(ref)
+		if frame == nil {
+			return nil, fmt.Errorf("no atlas frame %q", ref)
+		}
+		if img := atlas.FrameImage(frame.Name); img != nil {
+			return img, nil
+		}
+		return nil, fmt.Errorf("atlas frame %q lies outside its atlas image", ref)
+	}
+	img, err := ui.decodeImageAsset(name)
+	if err != nil {
+		ui.images[name] = nil
+		return nil, err
+	}
+	ui.images[name] = img
+	return img, nil
+}
+
+func (ui *UI) decodeImageAsset(name string) (*ebiten.Image, error) {
+	rc, err := ui.openAsset(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open image %q: %w", name, err)
+	}
+	defer rc.Close()
+	decoded, _, err := image.Decode(rc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image %q: %w", name, err)
+	}
+	return ebiten.NewImageFromImage(decoded), nil
+}
+
+// cssImageReference returns the asset name of an image value: the target of
+// url(...), or a bare path as used by JSON styles. none yields "".
+func cssImageReference(value string) string {
+	value = strings.TrimSpace(value)
+	if value == "" || strings.EqualFold(value, "none") {
+		return ""
+	}
+	if name, ok := cssURLValue(value); ok {
+		return name
+	}
+	if strings.Contains(value, "(") {
+		return ""
+	}
+	return strings.Trim(value, `"'`)
+}
+
+// loadImageAssets resolves the images used by a widget tree: background
+// layers and border images of every style variant, and the src of <image> and
+// <sprite> widgets.
+func (ui *UI) loadImageAssets(widget Widget) {
+	if widget == nil {
+		return
+	}
+	ui.resolveStyleImages(widget.Style())
+	if bw := baseWidgetOf(widget); bw != nil {
+		bw.shaders = ui.shaders
+	}
+	if img, ok := widget.(*Image); ok && img.Source == nil {
+		if src := img.Attr("src"); src != "" {
+			img.Source, _ = ui.LoadImage(src)
+			img.Slice = ui.atlasNineSlice(src)
+		}
+	}
+	if sprite, ok := widget.(*Sprite); ok {
+		ui.loadSpriteFrames(sprite)
+	}
+	for _, child := range widget.Children() {
+		ui.loadImageAssets(child)
+	}
+}
+
+// resolveStyleImages loads the images of a style and its nested state and
+// pseudo-element styles.
+func (ui *UI) resolveStyleImages(style *Style) {
+	if style == nil {
+		return
+	}
+	style.parsedBackgroundLayers = ui.parseBackgroundLayers(style.BackgroundImage)
+	style.parsedMaskLayers = ui.parseBackgroundLayers(style.MaskImage)
+	style.parsedBorderImage = nil
+	if name := cssImageReference(style.BorderImage); name != "" {
+		style.parsedBorderImage, _ = ui.LoadImage(name)
+	}
+	for _, nested := range []*Style{style.HoverStyle, style.ActiveStyle, style.DisabledStyle, style.FocusStyle, style.BeforeStyle, style.AfterStyle, style.MarkerStyle} {
+		ui.resolveStyleImages(nested)
+	}
+}
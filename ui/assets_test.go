@@ -0,0 +1,75 @@
+package ui
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"io"
+	"io/fs"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// mapAssets serves in-memory files as assets and counts how often each is
+// opened.
+type mapAssets struct {
+	files  map[string][]byte
+	opened map[string]int
+}
+
+func newMapAssets(files map[string][]byte) *mapAssets {
+	return &mapAssets{files: files, opened: map[string]int{}}
+}
+
+func (a *mapAssets) OpenAsset(name string) (io.ReadCloser, error) {
+	a.opened[name]++
+	data, ok := a.files[name]
+	if !ok {
+		return nil, fs.ErrNotExist
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// testPNG encodes a transparent PNG of the given size.
+func testPNG(t *testing.T, w, h int) []byte {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, w, h))); err != nil {
+		t.Fatalf("png.Encode: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestLoadImage(t *testing.T) {
+	t.Run("caches resolved assets", func(t *testing.T) {
+		ui := New(320, 200)
+		assets := newMapAssets(map[string][]byte{"tiles/grass.png": testPNG(t, 16, 8)})
+		ui.SetAssetResolver(assets)
+		for i := 0; i < 2; i++ {
+			if img, err := ui.LoadImage("tiles/grass.png"); err != nil || img.Bounds().Dx() != 16 {
+				t.Fatalf("LoadImage(tiles/grass.png) = %v, %v", img, err)
+			}
+		}
+		if assets.opened["tiles/grass.png"] != 1 {
+			t.Fatalf("grass.png opened %d times, want 1 (cached)", assets.opened["tiles/grass.png"])
+		}
+	})
+
+	t.Run("reports missing asset", func(t *testing.T) {
+		ui := New(320, 200)
+		ui.SetAssetResolver(newMapAssets(nil))
+		if _, err := ui.LoadImage("missing.png"); err == nil {
+			t.Fatal("LoadImage(missing.png) should report the failed load")
+		}
+	})
+
+	t.Run("registered images take precedence", func(t *testing.T) {
+		ui := New(320, 200)
+		ui.SetAssetResolver(newMapAssets(map[string][]byte{"tiles/grass.png": testPNG(t, 16, 8)}))
+		registered := ebiten.NewImage(2, 2)
+		ui.RegisterImage("tiles/grass.png", registered)
+		if img, err := ui.LoadImage("tiles/grass.png"); err != nil || img != registered {
+			t.Fatalf("registered images should take precedence: %v %v", img, err)
+		}
+	})
+}
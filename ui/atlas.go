@@ -0,0 +1,405 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"io"
+	"math"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// ============================================================================
+// Texture atlases
+// ============================================================================
+
+// atlasPrefix marks image references that name an atlas frame, as in
+// backgroundImage: "atlas:ui/button_idle" or <image src="atlas:icons/sword">.
+const atlasPrefix = "atlas:"
+
+// Atlas is a texture atlas: one image holding many named frames, described by
+// TexturePacker or Aseprite JSON (hash or array format).
+type Atlas struct {
+	Image  *ebiten.Image
+	Frames map[string]*AtlasFrame
+	Order  []string   // frame names in file order
+	Tags   []AtlasTag // Aseprite frame tags
+
+	aliases map[string]string // frame names without their file extension
+	images  map[string]*ebiten.Image
+	slices  map[string]*NineSlice
+}
+
+// AtlasFrame locates one sprite inside an atlas image.
+type AtlasFrame struct {
+	Name       string
+	Rect       image.Rectangle // packed region in the atlas image
+	Rotated    bool            // packed rotated 90° clockwise (TexturePacker)
+	Offset     image.Point     // position of the trimmed region in the source sprite
+	SourceSize image.Point     // untrimmed sprite size
+	Duration   time.Duration   // Aseprite frame duration, 0 when absent
+	NineSlice  *NineSliceInsets
+}
+
+// NineSliceInsets are the fixed borders of a 9-slice frame, in pixels.
+type NineSliceInsets struct {
+	Left, Right, Top, Bottom int
+}
+
+// AtlasTag is a named frame range, such as an Aseprite animation tag.
+type AtlasTag struct {
+	Name      string
+	From, To  int    // frame indexes into Order, inclusive
+	Direction string // forward, reverse or pingpong
+}
+
+type atlasJSONRect struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+type atlasJSONFrame struct {
+	Filename         string         `json:"filename"`
+	Frame            atlasJSONRect  `json:"frame"`
+	Rotated          bool           `json:"rotated"`
+	Trimmed          bool           `json:"trimmed"`
+	SpriteSourceSize atlasJSONRect  `json:"spriteSourceSize"`
+	SourceSize       atlasJSONRect  `json:"sourceSize"`
+	Duration         int            `json:"duration"`
+	Scale9Borders    *atlasJSONRect `json:"scale9Borders"`
+}
+
+type atlasJSONMeta struct {
+	Image     string `json:"image"`
+	FrameTags []struct {
+		Name      string `json:"name"`
+		From      int    `json:"from"`
+		To        int    `json:"to"`
+		Direction string `json:"direction"`
+	} `json:"frameTags"`
+	Slices []struct {
+		Name string `json:"name"`
+		Keys []struct {
+			Frame  int            `json:"frame"`
+			Bounds atlasJSONRect  `json:"bounds"`
+			Center *atlasJSONRect `json:"center"`
+		} `json:"keys"`
+	} `json:"slices"`
+}
+
+type atlasJSON struct {
+	Frames json.RawMessage `json:"frames"`
+	Meta   atlasJSONMeta   `json:"meta"`
+}
+
+// NewAtlas builds an atlas from its image and JSON metadata. Frames may carry
+// TexturePacker "scale9Borders"; Aseprite slices with a 9-slice center apply
+// to the frames from their key onward.
+func NewAtlas(img *ebiten.Image, data []byte) (*Atlas, error) {
+	if img == nil {
+		return nil, fmt.Errorf("atlas image is nil")
+	}
+	var doc atlasJSON
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid atlas JSON: %w", err)
+	}
+	frames, err := decodeAtlasFrames(doc.Frames)
+	if err != nil {
+		return nil, err
+	}
+	atlas := newAtlas(img)
+	for _, f := range frames {
+		if f.Filename == "" {
+			return nil, fmt.Errorf("atlas frame without a name")
+		}
+		frame := &AtlasFrame{
+			Name:       f.Filename,
+			Rect:       image.Rect(f.Frame.X, f.Frame.Y, f.Frame.X+f.Frame.W, f.Frame.Y+f.Frame.H),
+			Rotated:    f.Rotated,
+			SourceSize: image.Pt(f.Frame.W, f.Frame.H),
+			Duration:   time.Duration(f.Duration) * time.Millisecond,
+		}
+		if f.Rotated {
+			// frame holds the unrotated size; the packed region is transposed.
+			frame.Rect = image.Rect(f.Frame.X, f.Frame.Y, f.Frame.X+f.Frame.H, f.Frame.Y+f.Frame.W)
+		}
+		if f.Trimmed {
+			frame.Offset = image.Pt(f.SpriteSourceSize.X, f.SpriteSourceSize.Y)
+		}
+		if f.SourceSize.W > 0 && f.SourceSize.H > 0 {
+			frame.SourceSize = image.Pt(f.SourceSize.W, f.SourceSize.H)
+		}
+		if b := f.Scale9Borders; b != nil {
+			frame.NineSlice = centerInsets(frame.SourceSize, *b)
+		}
+		atlas.AddFrame(frame)
+	}
+	for _, tag := range doc.Meta.FrameTags {
+		atlas.Tags = append(atlas.Tags, AtlasTag{Name: tag.Name, From: tag.From, To: tag.To, Direction: tag.Direction})
+	}
+	for _, slice := range doc.Meta.Slices {
+		for k, key := range slice.Keys {
+			if key.Center == nil {
+				continue
+			}
+			last := len(atlas.Order) - 1
+			if k+1 < len(slice.Keys) {
+				last = slice.Keys[k+1].Frame - 1
+			}
+			center := *key.Center
+			center.X += key.Bounds.X
+			center.Y += key.Bounds.Y
+			for i := key.Frame; i <= last && i < len(atlas.Order); i++ {
+				if i < 0 {
+					continue
+				}
+				frame := atlas.Frames[atlas.Order[i]]
+				frame.NineSlice = centerInsets(frame.SourceSize, center)
+			}
+		}
+	}
+	return atlas, nil
+}
+
+func newAtlas(img *ebiten.Image) *Atlas {
+	return &Atlas{
+		Image:   img,
+		Frames:  make(map[string]*AtlasFrame),
+		aliases: make(map[string]string),
+		images:  make(map[string]*ebiten.Image),
+		slices:  make(map[string]*NineSlice),
+	}
+}
+
+// decodeAtlasFrames reads the frames of either JSON format, keeping the file
+// order of hash keys.
+func decodeAtlasFrames(raw json.RawMessage) ([]atlasJSONFrame, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("atlas JSON has no frames")
+	}
+	var frames []atlasJSONFrame
+	if raw[0] == '[' {
+		if err := json.Unmarshal(raw, &frames); err != nil {
+			return nil, fmt.Errorf("invalid atlas frames: %w", err)
+		}
+		return frames, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	if _, err := dec.Token(); err != nil {
+		return nil, fmt.Errorf("invalid atlas frames: %w", err)
+	}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("invalid atlas frames: %w", err)
+		}
+		var frame atlasJSONFrame
+		if err := dec.Decode(&frame); err != nil {
+			return nil, fmt.Errorf("invalid atlas frame %v: %w", key, err)
+		}
+		frame.Filename, _ = key.(string)
+		frames = append(frames, frame)
+	}
+	return frames, nil
+}
+
+// centerInsets converts a 9-slice center rectangle to border insets.
+func centerInsets(size image.Point, center atlasJSONRect) *NineSliceInsets {
+	return &NineSliceInsets{
+		Left:   center.X,
+		Top:    center.Y,
+		Right:  max0(size.X - center.X - center.W),
+		Bottom: max0(size.Y - center.Y - center.H),
+	}
+}
+
+func max0(v int) int {
+	if v < 0 {
+		return 0
+	}
+	return v
+}
+
+// AddFrame adds or replaces a frame. Frames can also be found by their name
+// without a file extension ("button.png" as "button").
+func (a *Atlas) AddFrame(frame *AtlasFrame) {
+	if _, exists := a.Frames[frame.Name]; !exists {
+		a.Order = append(a.Order, frame.Name)
+	}
+	a.Frames[frame.Name] = frame
+	if ext := path.Ext(frame.Name); ext != "" {
+		a.aliases[strings.TrimSuffix(frame.Name, ext)] = frame.Name
+	}
+	delete(a.images, frame.Name)
+	delete(a.slices, frame.Name)
+}
+
+// Frame returns the named frame, or nil.
+func (a *Atlas) Frame(name string) *AtlasFrame {
+	if frame, ok := a.Frames[name]; ok {
+		return frame
+	}
+	if full, ok := a.aliases[name]; ok {
+		return a.Frames[full]
+	}
+	return nil
+}
+
+// Tag returns the named frame tag.
+func (a *Atlas) Tag(name string) (AtlasTag, bool) {
+	for _, tag := range a.Tags {
+		if tag.Name == name {
+			return tag, true
+		}
+	}
+	return AtlasTag{}, false
+}
+
+// FrameImage returns a frame as a standalone image of its untrimmed source
+// size. Plain frames are sub-images of the atlas; trimmed or rotated frames
+// are restored into a new image once and cached.
+func (a *Atlas) FrameImage(name string) *ebiten.Image {
+	frame := a.Frame(name)
+	if frame == nil {
+		return nil
+	}
+	if img, ok := a.images[frame.Name]; ok {
+		return img
+	}
+	region := frame.Rect.Add(a.Image.Bounds().Min).Intersect(a.Image.Bounds())
+	if region.Empty() || frame.SourceSize.X <= 0 || frame.SourceSize.Y <= 0 {
+		return nil
+	}
+	src := a.Image.SubImage(region).(*ebiten.Image)
+	img := src
+	if frame.Rotated || frame.Offset != (image.Point{}) || frame.SourceSize != frame.Rect.Size() {
+		img = ebiten.NewImage(frame.SourceSize.X, frame.SourceSize.Y)
+		op := &ebiten.DrawImageOptions{}
+		if frame.Rotated {
+			op.GeoM.Rotate(-math.Pi / 2)
+			op.GeoM.Translate(0, float64(frame.Rect.Dx()))
+		}
+		op.GeoM.Translate(float64(frame.Offset.X), float64(frame.Offset.Y))
+		img.DrawImage(src, op)
+	}
+	a.images[frame.Name] = img
+	return img
+}
+
+// NineSlice returns the 9-slice of a frame that declares insets, or nil.
+func (a *Atlas) NineSlice(name string) *NineSlice {
+	frame := a.Frame(name)
+	if frame == nil || frame.NineSlice == nil {
+		return nil
+	}
+	if ns, ok := a.slices[frame.Name]; ok {
+		return ns
+	}
+	img := a.FrameImage(frame.Name)
+	if img == nil {
+		return nil
+	}
+	insets := frame.NineSlice
+	ns := NewNineSlice(img, insets.Left, insets.Right, insets.Top, insets.Bottom)
+	a.slices[frame.Name] = ns
+	return ns
+}
+
+// RegisterAtlas registers an atlas under a name. "atlas:<frame>" references
+// search every atlas in registration order; "atlas:<name>/<frame>" selects
+// one atlas when no atlas has a frame with the full path as its name.
+func (ui *UI) RegisterAtlas(name string, atlas *Atlas) {
+	if name == "" {
+		return
+	}
+	if atlas == nil {
+		delete(ui.atlases, name)
+		for i, registered := range ui.atlasOrder {
+			if registered == name {
+				ui.atlasOrder = append(ui.atlasOrder[:i], ui.atlasOrder[i+1:]...)
+				break
+			}
+		}
+		return
+	}
+	if ui.atlases == nil {
+		ui.atlases = make(map[string]*Atlas)
+	}
+	if _, exists := ui.atlases[name]; !exists {
+		ui.atlasOrder = append(ui.atlasOrder, name)
+	}
+	ui.atlases[name] = atlas
+}
+
+// LoadAtlas reads atlas JSON through the asset resolver, loads the image
+// named by its meta.image (relative to the JSON file) and registers the atlas
+// under the JSON file's base name without extension.
+func (ui *UI) LoadAtlas(name string) (*Atlas, error) {
+	rc, err := ui.openAsset(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open atlas %q: %w", name, err)
+	}
+	data, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read atlas %q: %w", name, err)
+	}
+	var doc atlasJSON
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid atlas %q: %w", name, err)
+	}
+	if doc.Meta.Image == "" {
+		return nil, fmt.Errorf("atlas %q has no meta.image", name)
+	}
+	img, err := ui.LoadImage(path.Join(path.Dir(name), doc.Meta.Image))
+	if err != nil {
+		return nil, err
+	}
+	atlas, err := NewAtlas(img, data)
+	if err != nil {
+		return nil, fmt.Errorf("atlas %q: %w", name, err)
+	}
+	ui.RegisterAtlas(strings.TrimSuffix(path.Base(name), path.Ext(name)), atlas)
+	return atlas, nil
+}
+
+// atlasFrame resolves an atlas reference without its "atlas:" prefix.
+func (ui *UI) atlasFrame(ref string) (*Atlas, *AtlasFrame) {
+	for _, name := range ui.atlasOrder {
+		if atlas := ui.atlases[name]; atlas != nil {
+			if frame := atlas.Frame(ref); frame != nil {
+				return atlas, frame
+			}
+		}
+	}
+	if atlasName, frameName, ok := strings.Cut(ref, "/"); ok {
+		if atlas := ui.atlases[atlasName]; atlas != nil {
+			if frame := atlas.Frame(frameName); frame != nil {
+				return atlas, frame
+			}
+		}
+	}
+	return nil, nil
+}
+
+// atlasNineSlice returns the 9-slice of an "atlas:" image reference whose
+// frame declares insets, or nil.
+func (ui *UI) atlasNineSlice(name string) *NineSlice {
+	ref, ok := strings.CutPrefix(name, atlasPrefix)
+	if !ok {
+		return nil
+	}
+	atlas, frame := ui.atlasFrame(ref)
+	if frame == nil {
+		return nil
+	}
+	return atlas.NineSlice(frame.Name)
+}
@@ -0,0 +1,101 @@
+package ui
+
+import (
+	"image"
+	"testing"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+func TestAtlasParsesTexturePackerHash(t *testing.T) {
+	atlas, err := NewAtlas(ebiten.NewImage(64, 64), []byte(`{
+		"frames": {
+			"ui/button_idle.png": {"frame": {"x": 0, "y": 0, "w": 24, "h": 12}, "sourceSize": {"w": 24, "h": 12},
+				"scale9Borders": {"x": 4, "y": 3, "w": 16, "h": 6}},
+			"icons/sword.png": {"frame": {"x": 24, "y": 0, "w": 10, "h": 20}, "rotated": true, "trimmed": true,
+				"spriteSourceSize": {"x": 3, "y": 2, "w": 10, "h": 20}, "sourceSize": {"w": 16, "h": 24}}
+		},
+		"meta": {"image": "ui.png"}
+	}`))
+	if err != nil {
+		t.Fatalf("NewAtlas TexturePacker: %v", err)
+	}
+
+	if len(atlas.Order) != 2 || atlas.Order[0] != "ui/button_idle.png" {
+		t.Errorf("frame order = %v, want file order", atlas.Order)
+	}
+	button := atlas.Frame("ui/button_idle")
+	if button == nil || button.NineSlice == nil || *button.NineSlice != (NineSliceInsets{Left: 4, Right: 4, Top: 3, Bottom: 3}) {
+		t.Errorf("button frame = %+v", button)
+	}
+	sword := atlas.Frame("icons/sword")
+	if sword == nil || sword.Rect != image.Rect(24, 0, 44, 10) || sword.Offset != image.Pt(3, 2) || sword.SourceSize != image.Pt(16, 24) {
+		t.Errorf("rotated trimmed frame = %+v", sword)
+	}
+
+	// Rotated or trimmed frames are restored to their source size; plain
+	// frames are sub-images of the sheet.
+	for _, tc := range []struct {
+		name string
+		want image.Rectangle
+	}{
+		{"icons/sword", image.Rect(0, 0, 16, 24)},
+		{"ui/button_idle.png", image.Rect(0, 0, 24, 12)},
+	} {
+		if img := atlas.FrameImage(tc.name); img == nil || img.Bounds() != tc.want {
+			t.Errorf("FrameImage(%q) = %v, want bounds %v", tc.name, img, tc.want)
+		}
+	}
+}
+
+func TestAtlasParsesAsepriteArray(t *testing.T) {
+	atlas, err := NewAtlas(ebiten.NewImage(64, 64), []byte(`{
+		"frames": [
+			{"filename": "hero 0.aseprite", "frame": {"x": 0, "y": 0, "w": 16, "h": 16}, "sourceSize": {"w": 16, "h": 16}, "duration": 100},
+			{"filename": "hero 1.aseprite", "frame": {"x": 16, "y": 0, "w": 16, "h": 16}, "sourceSize": {"w": 16, "h": 16}, "duration": 150}
+		],
+		"meta": {"image": "hero.png",
+			"frameTags": [{"name": "walk", "from": 0, "to": 1, "direction": "pingpong"}],
+			"slices": [{"name": "panel", "keys": [{"frame": 0, "bounds": {"x": 0, "y": 0, "w": 16, "h": 16}, "center": {"x": 5, "y": 5, "w": 6, "h": 4}}]}]}
+	}`))
+	if err != nil {
+		t.Fatalf("NewAtlas Aseprite: %v", err)
+	}
+	if f := atlas.Frame("hero 1"); f == nil || f.Duration != 150*time.Millisecond || f.NineSlice == nil || f.NineSlice.Bottom != 7 {
+		t.Errorf("aseprite frame = %+v", f)
+	}
+	if tag, ok := atlas.Tag("walk"); !ok || tag.To != 1 || tag.Direction != "pingpong" {
+		t.Errorf("walk tag = %+v %v", tag, ok)
+	}
+}
+
+func TestAtlasReferencesInCSSAndImages(t *testing.T) {
+	ui := New(320, 200)
+	ui.SetAssetResolver(newMapAssets(map[string][]byte{
+		"atlases/ui.png": testPNG(t, 64, 64),
+		"atlases/ui.json": []byte(`{"frames": {
+			"button_idle.png": {"frame": {"x": 0, "y": 0, "w": 24, "h": 12}, "scale9Borders": {"x": 4, "y": 4, "w": 16, "h": 4}},
+			"sword.png": {"frame": {"x": 24, "y": 0, "w": 8, "h": 8}}
+		}, "meta": {"image": "ui.png"}}`),
+	}))
+	if _, err := ui.LoadAtlas("atlases/ui.json"); err != nil {
+		t.Fatalf("LoadAtlas: %v", err)
+	}
+	if err := ui.LoadCSS(`#btn { width: 80px; height: 30px; background-image: url("atlas:ui/button_idle"); }`); err != nil {
+		t.Fatalf("LoadCSS: %v", err)
+	}
+	if err := ui.LoadLayout(`<panel id="root"><panel id="btn"/><image id="icon" src="atlas:sword"/></panel>`); err != nil {
+		t.Fatalf("LoadLayout: %v", err)
+	}
+
+	if layers := ui.GetPanel("btn").Style().parsedBackgroundLayers; len(layers) != 1 || layers[0].nineSlice == nil || layers[0].nineSlice.Left != 4 {
+		t.Errorf("atlas background layer = %+v, want a 9-slice", layers)
+	}
+	if icon, ok := ui.GetWidget("icon").(*Image); !ok || icon.Source == nil || icon.Source.Bounds().Dx() != 8 || icon.Slice != nil {
+		t.Errorf("atlas <image> = %+v", icon)
+	}
+	if _, err := ui.LoadImage("atlas:missing"); err == nil {
+		t.Error("unknown atlas frames should fail to load")
+	}
+}
@@ -0,0 +1,68 @@
+package ui
+
+import (
+	"math"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+func TestParseBackdropFilterChain(t *testing.T) {
+	bf := ParseBackdropFilter("blur(8px) grayscale(1) brightness(120%) hue-rotate(0.5turn) invert(50%) saturate(2) sepia()")
+	if bf == nil || bf.Blur != 8 || bf.Brightness != 1.2 || bf.Saturate != 2 {
+		t.Fatalf("ParseBackdropFilter legacy fields = %+v", bf)
+	}
+	want := []FilterStep{{"blur", 8}, {"grayscale", 1}, {"brightness", 1.2}, {"hue-rotate", 180}, {"invert", 0.5}, {"saturate", 2}, {"sepia", 1}}
+	if len(bf.Steps) != len(want) {
+		t.Fatalf("steps = %+v, want %+v", bf.Steps, want)
+	}
+	for i := range want {
+		if bf.Steps[i].Name != want[i].Name || math.Abs(bf.Steps[i].Amount-want[i].Amount) > 1e-9 {
+			t.Fatalf("step %d = %+v, want %+v", i, bf.Steps[i], want[i])
+		}
+	}
+
+	// Functions that come earlier in the filter shader's stage order than
+	// their predecessor (hue-rotate after brightness, saturate after invert,
+	// sepia after saturate) start a new pass.
+	passes := planFilterPasses(bf.Steps)
+	if len(passes) != 5 || passes[0].blur != 8 || passes[1].filter == nil ||
+		passes[1].filter.Grayscale != 1 || passes[1].filter.Brightness != 1.2 ||
+		passes[2].filter.HueRotate != 180 || passes[2].filter.Invert != 0.5 ||
+		passes[3].filter.Saturate != 2 || passes[4].filter.Sepia != 1 {
+		t.Fatalf("passes = %+v", passes)
+	}
+}
+
+func TestBackdropFilterIsDefault(t *testing.T) {
+	_, glass := GlassmorphismStyle(10, 0.2)
+	for _, tc := range []struct {
+		name string
+		bf   *BackdropFilter
+		want bool
+	}{
+		{"identity functions", ParseBackdropFilter("brightness(1) saturate(100%) blur(0)"), true},
+		{"programmatic filter without steps", glass, false},
+	} {
+		if got := backdropFilterIsDefault(tc.bf); got != tc.want {
+			t.Errorf("%s: backdropFilterIsDefault = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestCSSBackdropFilterParsesAndDraws(t *testing.T) {
+	ui := New(320, 200)
+	if err := ui.LoadCSS(`
+		#glass { width: 120px; height: 80px; border-radius: 12px; backdrop-filter: blur(4px) sepia(1) contrast(1.5); }
+		#cut { width: 80px; height: 80px; opacity: 0.8; clip-path: circle(50%); backdrop-filter: invert(1); }
+	`); err != nil {
+		t.Fatalf("LoadCSS: %v", err)
+	}
+	if err := ui.LoadLayout(`<panel id="root"><panel id="glass"/><panel id="cut"/></panel>`); err != nil {
+		t.Fatalf("LoadLayout: %v", err)
+	}
+	if got := ui.GetPanel("glass").Style().parsedBackdropFilter; got == nil || len(got.Steps) != 3 {
+		t.Fatalf("parsed backdrop filter = %+v", got)
+	}
+	ui.Draw(ebiten.NewImage(320, 200))
+}
@@ -0,0 +1,793 @@
+package ui
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// ============================================================================
+// background-image / border-image
+// ============================================================================
+
+// maxImageTiles bounds the tiles drawn for one image so that tiny tile sizes
+// cannot stall a frame.
+const maxImageTiles = 4096
+
+// cssLayerShorthand holds the comma-separated longhand lists of a background
+// or mask shorthand. Lists are empty when no layer set that part.
+type cssLayerShorthand struct {
+	images, positions, sizes, repeats string
+	color                             string
+}
+
+// parseCSSLayerShorthand splits a background or mask shorthand into longhand
+// lists. Each comma-separated layer contributes an image (url(...) or a
+// gradient), a repeat, a position and, after "/", a size; the color may only
+// appear in the final layer. Multi-layer values fill the omitted parts of a
+// layer with their initial values so that the longhand lists line up.
+func parseCSSLayerShorthand(value string) cssLayerShorthand {
+	var out cssLayerShorthand
+	layers := splitCSSList(value)
+	images := make([]string, len(layers))
+	positions := make([]string, len(layers))
+	sizes := make([]string, len(layers))
+	repeats := make([]string, len(layers))
+	var hasImage, hasPosition, hasSize, hasRepeat bool
+	for i, layer := range layers {
+		var position, size, repeat []string
+		afterSlash := false
+		images[i] = "none"
+		for _, part := range splitCSSComponents(layer) {
+			lower := strings.ToLower(part)
+			switch {
+			case part == "/":
+				afterSlash = true
+			case strings.HasPrefix(lower, "url(") || isCSSGradient(lower):
+				images[i] = part
+				hasImage = true
+			case isBackgroundRepeatKeyword(lower):
+				repeat = append(repeat, lower)
+			case afterSlash && (cssIsLengthText(lower) || lower == "auto" || lower == "cover" || lower == "contain"):
+				size = append(size, lower)
+			case isBackgroundPositionKeyword(lower) || cssIsLengthText(lower):
+				position = append(position, lower)
+			case i == len(layers)-1:
+				out.color = part
+			}
+		}
+		positions[i], hasPosition = joinOr(position, "0% 0%"), hasPosition || len(position) > 0
+		sizes[i], hasSize = joinOr(size, "auto"), hasSize || len(size) > 0
+		repeats[i], hasRepeat = joinOr(repeat, "repeat"), hasRepeat || len(repeat) > 0
+	}
+	if hasImage {
+		out.images = strings.Join(images, ", ")
+	}
+	if hasPosition {
+		out.positions = strings.Join(positions, ", ")
+	}
+	if hasSize {
+		out.sizes = strings.Join(sizes, ", ")
+	}
+	if hasRepeat {
+		out.repeats = strings.Join(repeats, ", ")
+	}
+	return out
+}
+
+// applyCSSBackgroundShorthand splits a background shorthand into the
+// background longhands.
+func applyCSSBackgroundShorthand(style *Style, value string) {
+	parts := parseCSSLayerShorthand(value)
+	if parts.color != "" {
+		style.Background = parts.color
+	}
+	if parts.images != "" {
+		style.BackgroundImage = parts.images
+	}
+	if parts.positions != "" {
+		style.BackgroundPosition = parts.positions
+	}
+	if parts.sizes != "" {
+		style.BackgroundSize = parts.sizes
+	}
+	if parts.repeats != "" {
+		style.BackgroundRepeat = parts.repeats
+	}
+}
+
+// applyCSSMaskShorthand splits a mask shorthand into the mask longhands. It
+// shares the background layer grammar; colors are ignored.
+func applyCSSMaskShorthand(style *Style, value string) {
+	parts := parseCSSLayerShorthand(value)
+	if parts.images != "" {
+		style.MaskImage = parts.images
+	}
+	if parts.positions != "" {
+		style.MaskPosition = parts.positions
+	}
+	if parts.sizes != "" {
+		style.MaskSize = parts.sizes
+	}
+	if parts.repeats != "" {
+		style.MaskRepeat = parts.repeats
+	}
+}
+
+func joinOr(parts []string, fallback string) string {
+	if len(parts) == 0 {
+		return fallback
+	}
+	return strings.Join(parts, " ")
+}
+
+// cssBackgroundHasLayers reports whether a background value needs the
+// shorthand expansion: it has an image, several layers, or a gradient
+// combined with other components. A lone color or gradient stays in
+// Background.
+func cssBackgroundHasLayers(value string) bool {
+	lower := strings.ToLower(value)
+	if strings.Contains(lower, "url(") || len(splitCSSList(value)) > 1 {
+		return true
+	}
+	return isCSSGradient(lower) && len(splitCSSComponents(value)) > 1
+}
+
+// isCSSGradient reports whether a value is one of the gradient functions.
+func isCSSGradient(value string) bool {
+	name, _, ok := strings.Cut(strings.ToLower(strings.TrimSpace(value)), "(")
+	if !ok {
+		return false
+	}
+	switch strings.TrimPrefix(name, "repeating-") {
+	case "linear-gradient", "radial-gradient", "conic-gradient":
+		return true
+	}
+	return false
+}
+
+// cssLayerValue returns the entry of a comma-separated background longhand
+// for layer i, repeating the list when it is shorter than the layer count.
+func cssLayerValue(value string, i int) string {
+	items := splitCSSList(value)
+	if len(items) == 0 {
+		return ""
+	}
+	return items[i%len(items)]
+}
+
+// applyCSSBorderImageShorthand expands "source slice [/ width] repeat".
+func applyCSSBorderImageShorthand(style *Style, value string) {
+	var slice, width, repeat []string
+	afterSlash := false
+	for _, part := range splitCSSComponents(value) {
+		lower := strings.ToLower(part)
+		switch {
+		case part == "/":
+			afterSlash = true
+		case strings.HasPrefix(lower, "url("):
+			style.BorderImage = part
+		case isBorderImageRepeatKeyword(lower):
+			repeat = append(repeat, lower)
+		case afterSlash:
+			width = append(width, lower)
+		default:
+			slice = append(slice, lower)
+		}
+	}
+	if len(slice) > 0 {
+		style.BorderImageSlice = strings.Join(slice, " ")
+	}
+	if len(width) > 0 {
+		style.BorderImageWidth = strings.Join(width, " ")
+	}
+	if len(repeat) > 0 {
+		style.BorderImageRepeat = strings.Join(repeat, " ")
+	}
+}
+
+func isBackgroundRepeatKeyword(value string) bool {
+	switch value {
+	case "repeat", "repeat-x", "repeat-y", "no-repeat", "round", "space":
+		return true
+	}
+	return false
+}
+
+func isBorderImageRepeatKeyword(value string) bool {
+	switch value {
+	case "stretch", "repeat", "round", "space":
+		return true
+	}
+	return false
+}
+
+func isBackgroundPositionKeyword(value string) bool {
+	switch value {
+	case "left", "right", "top", "bottom", "center":
+		return true
+	}
+	return false
+}
+
+// cssIsLengthText reports whether a component is a number, percentage or
+// length.
+func cssIsLengthText(value string) bool {
+	tokens := tokenizeCSS(value)
+	return len(tokens) == 1 && cssTokenIsLength(tokens[0])
+}
+
+// parseBackgroundRepeat returns the horizontal and vertical repeat modes.
+func parseBackgroundRepeat(value string) (string, string) {
+	parts := strings.Fields(strings.ToLower(value))
+	switch len(parts) {
+	case 0:
+		return "repeat", "repeat"
+	case 1:
+		switch parts[0] {
+		case "repeat-x":
+			return "repeat", "no-repeat"
+		case "repeat-y":
+			return "no-repeat", "repeat"
+		}
+		return parts[0], parts[0]
+	}
+	return parts[0], parts[1]
+}
+
+// backgroundTileSize resolves background-size against the positioning area
+// for an image of the given natural size.
+func backgroundTileSize(value string, area Rect, imgW, imgH float64) (float64, float64) {
+	parts := strings.Fields(strings.ToLower(value))
+	if len(parts) == 1 {
+		switch parts[0] {
+		case "cover":
+			scale := math.Max(area.W/imgW, area.H/imgH)
+			return imgW * scale, imgH * scale
+		case "contain":
+			scale := math.Min(area.W/imgW, area.H/imgH)
+			return imgW * scale, imgH * scale
+		}
+	}
+	w, wSet, h, hSet := backgroundSizeParts(parts, area)
+	switch {
+	case wSet && hSet:
+		return w, h
+	case wSet:
+		return w, imgH * w / imgW
+	case hSet:
+		return imgW * h / imgH, h
+	}
+	return imgW, imgH
+}
+
+// gradientTileSize resolves background-size for a gradient, which has no
+// natural size: auto, cover and contain fill the positioning area.
+func gradientTileSize(value string, area Rect) (float64, float64) {
+	w, wSet, h, hSet := backgroundSizeParts(strings.Fields(strings.ToLower(value)), area)
+	if !wSet {
+		w = area.W
+	}
+	if !hSet {
+		h = area.H
+	}
+	return w, h
+}
+
+// backgroundSizeParts resolves the width and height of a two-value
+// background-size; auto (or a missing value) is reported as unset.
+func backgroundSizeParts(parts []string, area Rect) (float64, bool, float64, bool) {
+	resolve := func(index int, basis float64) (float64, bool) {
+		if index >= len(parts) {
+			return 0, false
+		}
+		part := parts[index]
+		switch part {
+		case "auto", "cover", "contain":
+			return 0, false
+		}
+		if strings.HasSuffix(part, "%") {
+			percent, err := strconv.ParseFloat(strings.TrimSuffix(part, "%"), 64)
+			return basis * percent / 100, err == nil
+		}
+		return parseCSSPixels(part), true
+	}
+	w, wSet := resolve(0, area.W)
+	h, hSet := resolve(1, area.H)
+	return w, wSet, h, hSet
+}
+
+// backgroundPositionOffset resolves background-position to an offset of the
+// tile inside the positioning area, where availW/availH are the area size
+// minus the tile size. Supports one or two values (keywords, percentages or
+// lengths) and the four-value edge-offset form.
+func backgroundPositionOffset(value string, availW, availH float64) (float64, float64) {
+	parts := strings.Fields(strings.ToLower(value))
+	resolve := func(part string, avail float64) float64 {
+		switch part {
+		case "left", "top":
+			return 0
+		case "center":
+			return avail / 2
+		case "right", "bottom":
+			return avail
+		}
+		if strings.HasSuffix(part, "%") {
+			percent, _ := strconv.ParseFloat(strings.TrimSuffix(part, "%"), 64)
+			return avail * percent / 100
+		}
+		return parseCSSPixels(part)
+	}
+	if len(parts) == 4 {
+		var x, y float64
+		for i := 0; i < 4; i += 2 {
+			edge, offset := parts[i], parseCSSPixels(parts[i+1])
+			switch edge {
+			case "left":
+				x = offset
+			case "right":
+				x = availW - offset
+			case "top":
+				y = offset
+			case "bottom":
+				y = availH - offset
+			}
+		}
+		return x, y
+	}
+	horizontal, vertical := "0%", "0%"
+	switch len(parts) {
+	case 0:
+	case 1:
+		horizontal, vertical = parts[0], "center"
+		if parts[0] == "top" || parts[0] == "bottom" {
+			horizontal, vertical = "center", parts[0]
+		}
+	default:
+		horizontal, vertical = parts[0], parts[1]
+		if horizontal == "top" || horizontal == "bottom" || vertical == "left" || vertical == "right" {
+			horizontal, vertical = vertical, horizontal
+		}
+	}
+	return resolve(horizontal, availW), resolve(vertical, availH)
+}
+
+// tileAxis lays tiles along one axis of a region. anchor is where a single
+// tile is placed; repeat tiles outward from it, round resizes tiles to fit a
+// whole number, space spreads whole tiles with equal gaps and stretch fills
+// the region with one tile. It returns the tile origins and the tile length.
+func tileAxis(start, length, tile, anchor float64, mode string) ([]float64, float64) {
+	switch mode {
+	case "stretch":
+		return []float64{start}, length
+	case "no-repeat":
+		return []float64{anchor}, tile
+	case "round":
+		n := math.Max(1, math.Round(length/tile))
+		tile = length / n
+		anchor = start
+	case "space":
+		n := math.Min(math.Floor(length/tile), maxImageTiles)
+		if n < 2 {
+			return []float64{anchor}, tile
+		}
+		gap := (length - n*tile) / (n - 1)
+		positions := make([]float64, 0, int(n))
+		for i := 0.0; i < n; i++ {
+			positions = append(positions, start+i*(tile+gap))
+		}
+		return positions, tile
+	}
+	first := anchor - math.Ceil((anchor-start)/tile)*tile
+	var positions []float64
+	for p := first; p < start+length-1e-6 && len(positions) < maxImageTiles; p += tile {
+		positions = append(positions, p)
+	}
+	return positions, tile
+}
+
+// drawImageTiles draws src scaled to tileW x tileH at every combination of
+// the x and y origins, clipped to clip. When there are more tiles than
+// maxImageTiles, blocks of tiles are drawn once offscreen and the blocks are
+// tiled instead, so tiny tile sizes still fill the region.
+func drawImageTiles(dst, src *ebiten.Image, clip Rect, xs, ys []float64, tileW, tileH float64) {
+	if src == nil || tileW <= 0 || tileH <= 0 || len(xs) == 0 || len(ys) == 0 {
+		return
+	}
+	bounds := image.Rect(int(math.Floor(clip.X)), int(math.Floor(clip.Y)), int(math.Ceil(clip.X+clip.W)), int(math.Ceil(clip.Y+clip.H)))
+	target, ok := dst.SubImage(bounds).(*ebiten.Image)
+	if !ok || target.Bounds().Empty() {
+		return
+	}
+	gx, gy := imageTileGroups(len(xs), len(ys))
+	if gx == 1 && gy == 1 {
+		drawImageGrid(target, src, xs, ys, tileW, tileH)
+		return
+	}
+
+	blockXs, innerXs, w := groupTileOrigins(xs, tileW, gx)
+	blockYs, innerYs, h := groupTileOrigins(ys, tileH, gy)
+	pooled := globalImagePool.Get(w, h)
+	defer globalImagePool.Put(pooled)
+	block := pooled.SubImage(image.Rect(0, 0, w, h)).(*ebiten.Image)
+	drawImageGrid(block, src, innerXs, innerYs, tileW, tileH)
+	// The last block in a row or column may overhang the final tile; the
+	// clip hides whatever lies past the region.
+	drawImageGrid(target, block, blockXs, blockYs, float64(w), float64(h))
+}
+
+// imageTileGroups returns how many tiles across and down to merge into one
+// block so that neither the blocks nor the tiles within a block exceed
+// maxImageTiles. It returns 1, 1 when the tiles fit the budget as they are.
+func imageTileGroups(nx, ny int) (int, int) {
+	gx, gy := 1, 1
+	blocks := func() int { return ((nx + gx - 1) / gx) * ((ny + gy - 1) / gy) }
+	for blocks() > maxImageTiles {
+		if (nx+gx-1)/gx >= (ny+gy-1)/gy {
+			gx *= 2
+		} else {
+			gy *= 2
+		}
+	}
+	if gx > nx {
+		gx = nx
+	}
+	if gy > ny {
+		gy = ny
+	}
+	return gx, gy
+}
+
+// groupTileOrigins merges every g consecutive tile origins into one block.
+// Origins from tileAxis are evenly spaced, so one block drawn at each block
+// origin covers the same tiles. It returns the block origins, the tile
+// origins within a block and the block length in whole pixels.
+func groupTileOrigins(origins []float64, tile float64, g int) ([]float64, []float64, int) {
+	step := tile
+	if len(origins) > 1 {
+		step = origins[1] - origins[0]
+	}
+	var blocks []float64
+	for i := 0; i < len(origins); i += g {
+		blocks = append(blocks, origins[i])
+	}
+	inner := make([]float64, g)
+	for i := range inner {
+		inner[i] = float64(i) * step
+	}
+	return blocks, inner, int(math.Ceil(inner[g-1] + tile))
+}
+
+// drawImageGrid draws src scaled to tileW x tileH at every combination of the
+// x and y origins.
+func drawImageGrid(dst, src *ebiten.Image, xs, ys []float64, tileW, tileH float64) {
+	srcBounds := src.Bounds()
+	sx := tileW / float64(srcBounds.Dx())
+	sy := tileH / float64(srcBounds.Dy())
+	for _, y := range ys {
+		for _, x := range xs {
+			op := &ebiten.DrawImageOptions{Filter: ebiten.FilterLinear}
+			op.GeoM.Scale(sx, sy)
+			op.GeoM.Translate(x, y)
+			dst.DrawImage(src, op)
+		}
+	}
+}
+
+// backgroundPaintArea returns the padding box used to size and position
+// background images.
+func backgroundPaintArea(r Rect, style *Style) Rect {
+	sides := resolveBorderSides(style)
+	width := func(i int) float64 {
+		if sides[i].style == "none" || sides[i].style == "hidden" {
+			return 0
+		}
+		return sides[i].width
+	}
+	return Rect{
+		X: r.X + width(borderLeft),
+		Y: r.Y + width(borderTop),
+		W: math.Max(0, r.W-width(borderLeft)-width(borderRight)),
+		H: math.Max(0, r.H-width(borderTop)-width(borderBottom)),
+	}
+}
+
+// backgroundLayer is one layer of background-image: a loaded image or a
+// gradient. Gradients are rasterized once per tile size and then tiled like
+// images.
+type backgroundLayer struct {
+	image     *ebiten.Image
+	gradient  *Gradient
+	nineSlice *NineSlice // atlas frames with 9-slice insets stretch over the box
+	tiles     map[[2]int]*ebiten.Image
+}
+
+// parseBackgroundLayers resolves a comma-separated background-image value,
+// top layer first. Layers that are none or fail to load stay in the list
+// (and draw nothing) so that they keep their size, position and repeat.
+func (ui *UI) parseBackgroundLayers(value string) []*backgroundLayer {
+	var layers []*backgroundLayer
+	for _, item := range splitCSSList(value) {
+		layer := &backgroundLayer{}
+		if isCSSGradient(item) {
+			layer.gradient = ParseGradient(item)
+		} else if name := cssImageReference(item); name != "" {
+			layer.image, _ = ui.LoadImage(name)
+			layer.nineSlice = ui.atlasNineSlice(name)
+		}
+		layers = append(layers, layer)
+	}
+	return layers
+}
+
+// gradientTile returns the gradient rendered at the given tile size.
+func (l *backgroundLayer) gradientTile(w, h float64) *ebiten.Image {
+	key := [2]int{int(math.Ceil(w)), int(math.Ceil(h))}
+	if key[0] <= 0 || key[1] <= 0 {
+		return nil
+	}
+	if tile, ok := l.tiles[key]; ok {
+		return tile
+	}
+	if l.tiles == nil || len(l.tiles) >= maxResolvedGradients {
+		for _, tile := range l.tiles {
+			tile.Deallocate()
+		}
+		l.tiles = make(map[[2]int]*ebiten.Image)
+	}
+	tile := ebiten.NewImage(key[0], key[1])
+	drawGradientFill(tile, Rect{W: float64(key[0]), H: float64(key[1])}, l.gradient)
+	l.tiles[key] = tile
+	return tile
+}
+
+// drawBackgroundLayers paints the background-image layers bottom-up over the
+// border box, each sized and positioned against the padding box, clipped to
+// the rounded border edge.
+func (w *BaseWidget) drawBackgroundLayers(screen *ebiten.Image, r Rect, style *Style) {
+	layers := style.parsedBackgroundLayers
+	if len(layers) == 0 || r.W <= 0 || r.H <= 0 {
+		return
+	}
+	area := backgroundPaintArea(r, style)
+	paint := func(dst *ebiten.Image, box, area Rect) {
+		drawImageLayers(dst, layers, box, area, style.BackgroundSize, style.BackgroundPosition, style.BackgroundRepeat)
+	}
+
+	tl, tr, br, bl := w.getCornerRadii(style)
+	if tl <= 0 && tr <= 0 && br <= 0 && bl <= 0 {
+		paint(screen, r, area)
+		return
+	}
+
+	iw, ih := int(math.Ceil(r.W)), int(math.Ceil(r.H))
+	local := Rect{W: r.W, H: r.H}
+	localArea := Rect{X: area.X - r.X, Y: area.Y - r.Y, W: area.W, H: area.H}
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(r.X, r.Y)
+	clipComposite(screen, iw, ih,
+		func(content *ebiten.Image) {
+			paint(content, local, localArea)
+		},
+		func(mask *ebiten.Image) {
+			DrawRoundedRectPathEx(mask, local, tl, tr, br, bl, color.White)
+		},
+		op,
+	)
+}
+
+// drawImageLayers paints layers bottom-up, taking each layer's size,
+// position and repeat from the comma-separated longhand lists.
+func drawImageLayers(dst *ebiten.Image, layers []*backgroundLayer, box, area Rect, sizes, positions, repeats string) {
+	for i := len(layers) - 1; i >= 0; i-- {
+		drawBackgroundLayer(dst, layers[i], box, area,
+			cssLayerValue(sizes, i),
+			cssLayerValue(positions, i),
+			cssLayerValue(repeats, i))
+	}
+}
+
+// drawBackgroundLayer tiles one layer over box, clipped to it. 9-slice atlas
+// frames ignore size, position and repeat and stretch over the whole box.
+func drawBackgroundLayer(dst *ebiten.Image, layer *backgroundLayer, box, area Rect, size, position, repeat string) {
+	if layer.nineSlice != nil {
+		layer.nineSlice.Draw(dst, box.X, box.Y, box.W, box.H, nil)
+		return
+	}
+	var img *ebiten.Image
+	var tileW, tileH float64
+	switch {
+	case layer.gradient != nil:
+		tileW, tileH = gradientTileSize(size, area)
+		img = layer.gradientTile(tileW, tileH)
+	case layer.image != nil:
+		img = layer.image
+		imgW, imgH := float64(img.Bounds().Dx()), float64(img.Bounds().Dy())
+		if imgW <= 0 || imgH <= 0 {
+			return
+		}
+		tileW, tileH = backgroundTileSize(size, area, imgW, imgH)
+	}
+	if img == nil || tileW <= 0 || tileH <= 0 {
+		return
+	}
+	repeatX, repeatY := parseBackgroundRepeat(repeat)
+	offsetX, offsetY := backgroundPositionOffset(position, area.W-tileW, area.H-tileH)
+
+	xs, tw := tileAxis(area.X, area.W, tileW, area.X+offsetX, repeatX)
+	ys, th := tileAxis(area.Y, area.H, tileH, area.Y+offsetY, repeatY)
+	// Repeated tiles also cover the border area.
+	if repeatX == "repeat" {
+		xs, tw = tileAxis(box.X, box.W, tileW, area.X+offsetX, "repeat")
+	}
+	if repeatY == "repeat" {
+		ys, th = tileAxis(box.Y, box.H, tileH, area.Y+offsetY, "repeat")
+	}
+	drawImageTiles(dst, img, box, xs, ys, tw, th)
+}
+
+// parseBorderImageSlice resolves border-image-slice (numbers are image
+// pixels, percentages are relative to the image size) to top, right, bottom
+// and left insets plus the fill keyword. The initial value is 100%.
+func parseBorderImageSlice(value string, imgW, imgH int) ([4]int, bool) {
+	fill := false
+	var values []string
+	for _, part := range strings.Fields(strings.ToLower(value)) {
+		if part == "fill" {
+			fill = true
+			continue
+		}
+		values = append(values, part)
+	}
+	if len(values) == 0 {
+		values = []string{"100%"}
+	}
+	expanded := expandBoxValues(values)
+	var insets [4]int
+	for i, part := range expanded {
+		basis := imgH
+		if i == borderRight || i == borderLeft {
+			basis = imgW
+		}
+		var v float64
+		if strings.HasSuffix(part, "%") {
+			percent, _ := strconv.ParseFloat(strings.TrimSuffix(part, "%"), 64)
+			v = float64(basis) * percent / 100
+		} else {
+			v = parseCSSPixels(part)
+		}
+		insets[i] = int(math.Round(math.Max(0, math.Min(v, float64(basis)))))
+	}
+	return insets, fill
+}
+
+// expandBoxValues expands one to four values to top, right, bottom, left.
+func expandBoxValues(values []string) [4]string {
+	switch len(values) {
+	case 0:
+		return [4]string{}
+	case 1:
+		return [4]string{values[0], values[0], values[0], values[0]}
+	case 2:
+		return [4]string{values[0], values[1], values[0], values[1]}
+	case 3:
+		return [4]string{values[0], values[1], values[2], values[1]}
+	}
+	return [4]string{values[0], values[1], values[2], values[3]}
+}
+
+// borderImageWidths resolves border-image-width. Plain numbers multiply the
+// border width, lengths are used as-is and auto uses the slice size. A side
+// without a border width falls back to its slice size so that border-image
+// works without also declaring border-width.
+func borderImageWidths(value string, style *Style, slices [4]int) [4]float64 {
+	parts := strings.Fields(strings.ToLower(value))
+	if len(parts) == 0 {
+		parts = []string{"1"}
+	}
+	sides := resolveBorderSides(style)
+	var widths [4]float64
+	for i, part := range expandBoxValues(parts) {
+		switch {
+		case part == "auto":
+			widths[i] = float64(slices[i])
+		case isCSSNumeric(part):
+			n, _ := strconv.ParseFloat(part, 64)
+			widths[i] = n * sides[i].width
+		default:
+			widths[i] = parseCSSPixels(part)
+		}
+		if widths[i] <= 0 && sides[i].width <= 0 {
+			widths[i] = float64(slices[i])
+		}
+	}
+	return widths
+}
+
+// drawBorderImage draws style.parsedBorderImage as a CSS border-image: the
+// image is cut into nine regions by border-image-slice, corners are scaled to
+// the border-image widths and edges (and the center with fill) are stretched
+// or tiled per border-image-repeat.
+func (w *BaseWidget) drawBorderImage(screen *ebiten.Image, r Rect, style *Style) {
+	img := style.parsedBorderImage
+	if img == nil || r.W <= 0 || r.H <= 0 {
+		return
+	}
+	bounds := img.Bounds()
+	slices, fill := parseBorderImageSlice(style.BorderImageSlice, bounds.Dx(), bounds.Dy())
+	ns := style.parsed9Slice
+	if ns == nil || ns.image != img || ns.Top != slices[borderTop] || ns.Right != slices[borderRight] || ns.Bottom != slices[borderBottom] || ns.Left != slices[borderLeft] {
+		ns = NewNineSlice(img, slices[borderLeft], slices[borderRight], slices[borderTop], slices[borderBottom])
+		style.parsed9Slice = ns
+	}
+
+	widths := borderImageWidths(style.BorderImageWidth, style, slices)
+	// Scale all widths down together when opposite sides would overlap.
+	scale := 1.0
+	if sum := widths[borderLeft] + widths[borderRight]; sum > r.W {
+		scale = math.Min(scale, r.W/sum)
+	}
+	if sum := widths[borderTop] + widths[borderBottom]; sum > r.H {
+		scale = math.Min(scale, r.H/sum)
+	}
+	top, right, bottom, left := widths[borderTop]*scale, widths[borderRight]*scale, widths[borderBottom]*scale, widths[borderLeft]*scale
+
+	modes := strings.Fields(strings.ToLower(style.BorderImageRepeat))
+	modeX, modeY := "stretch", "stretch"
+	if len(modes) > 0 {
+		modeX, modeY = modes[0], modes[0]
+	}
+	if len(modes) > 1 {
+		modeY = modes[1]
+	}
+
+	midW := math.Max(0, r.W-left-right)
+	midH := math.Max(0, r.H-top-bottom)
+
+	// Corners
+	ns.drawPart(screen, ns.topLeft, r.X, r.Y, left, top, nil)
+	ns.drawPart(screen, ns.topRight, r.X+r.W-right, r.Y, right, top, nil)
+	ns.drawPart(screen, ns.bottomLeft, r.X, r.Y+r.H-bottom, left, bottom, nil)
+	ns.drawPart(screen, ns.bottomRight, r.X+r.W-right, r.Y+r.H-bottom, right, bottom, nil)
+
+	// Edges keep their thickness; their length is stretched or tiled.
+	edge := func(part *ebiten.Image, region Rect, horizontal bool) {
+		if part == nil || region.W <= 0 || region.H <= 0 {
+			return
+		}
+		pw, ph := float64(part.Bounds().Dx()), float64(part.Bounds().Dy())
+		if horizontal {
+			tile := pw * region.H / ph
+			xs, tw := tileAxis(region.X, region.W, tile, region.X+(region.W-tile)/2, modeX)
+			drawImageTiles(screen, part, region, xs, []float64{region.Y}, tw, region.H)
+			return
+		}
+		tile := ph * region.W / pw
+		ys, th := tileAxis(region.Y, region.H, tile, region.Y+(region.H-tile)/2, modeY)
+		drawImageTiles(screen, part, region, []float64{region.X}, ys, region.W, th)
+	}
+	edge(ns.top, Rect{X: r.X + left, Y: r.Y, W: midW, H: top}, true)
+	edge(ns.bottom, Rect{X: r.X + left, Y: r.Y + r.H - bottom, W: midW, H: bottom}, true)
+	edge(ns.left, Rect{X: r.X, Y: r.Y + top, W: left, H: midH}, false)
+	edge(ns.right, Rect{X: r.X + r.W - right, Y: r.Y + top, W: right, H: midH}, false)
+
+	// The center is scaled like the top and left edges.
+	if fill && ns.center != nil && midW > 0 && midH > 0 {
+		region := Rect{X: r.X + left, Y: r.Y + top, W: midW, H: midH}
+		cw, ch := float64(ns.center.Bounds().Dx()), float64(ns.center.Bounds().Dy())
+		tileW, tileH := cw, ch
+		if ns.top != nil && slices[borderTop] > 0 {
+			tileW = cw * top / float64(slices[borderTop])
+		}
+		if ns.left != nil && slices[borderLeft] > 0 {
+			tileH = ch * left / float64(slices[borderLeft])
+		}
+		xs, tw := tileAxis(region.X, region.W, tileW, region.X+(region.W-tileW)/2, modeX)
+		ys, th := tileAxis(region.Y, region.H, tileH, region.Y+(region.H-tileH)/2, modeY)
+		drawImageTiles(screen, ns.center, region, xs, ys, tw, th)
+	}
+}
@@ -0,0 +1,223 @@
+package ui
+
+import (
+	"math"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+func TestCSSBackgroundAndBorderImages(t *testing.T) {
+	ui := New(320, 200)
+	assets := newMapAssets(map[string][]byte{"tiles/grass.png": testPNG(t, 16, 8), "frame.png": testPNG(t, 12, 12)})
+	ui.SetAssetResolver(assets)
+	if err := ui.LoadCSS(`
+		.hero { width: 100px; height: 40px; background: url("tiles/grass.png") no-repeat right 25% / cover #102030; }
+		.hero:hover { background-image: url(missing.png); }
+		#framed { border-image: url(frame.png) 4 fill / 8px round stretch; }
+	`); err != nil {
+		t.Fatalf("LoadCSS images: %v", err)
+	}
+	if err := ui.LoadLayout(`<panel id="root"><panel id="a" class="hero"/><panel id="b" class="hero"/><panel id="framed"/></panel>`); err != nil {
+		t.Fatalf("LoadLayout images: %v", err)
+	}
+
+	style := ui.GetPanel("a").Style()
+	if style.BackgroundRepeat != "no-repeat" || style.BackgroundPosition != "right 25%" || style.BackgroundSize != "cover" || style.Background != "#102030" {
+		t.Errorf("background shorthand = repeat %q position %q size %q color %q", style.BackgroundRepeat, style.BackgroundPosition, style.BackgroundSize, style.Background)
+	}
+	if layers := style.parsedBackgroundLayers; len(layers) != 1 || layers[0].image == nil || layers[0].image.Bounds().Dx() != 16 {
+		t.Errorf("background image should load through the resolver, got %v", layers)
+	}
+	if assets.opened["tiles/grass.png"] != 1 {
+		t.Errorf("grass.png opened %d times for two widgets, want 1 (cached)", assets.opened["tiles/grass.png"])
+	}
+	if hover := style.HoverStyle; hover == nil || len(hover.parsedBackgroundLayers) != 1 || hover.parsedBackgroundLayers[0].image != nil {
+		t.Error("a missing hover image should resolve to nil")
+	}
+
+	framed := ui.GetPanel("framed").Style()
+	if framed.BorderImageSlice != "4 fill" || framed.BorderImageWidth != "8px" || framed.BorderImageRepeat != "round stretch" {
+		t.Errorf("border-image shorthand = slice %q width %q repeat %q", framed.BorderImageSlice, framed.BorderImageWidth, framed.BorderImageRepeat)
+	}
+	if framed.parsedBorderImage == nil {
+		t.Error("border image should load through the resolver")
+	}
+}
+
+func TestBackgroundTileSize(t *testing.T) {
+	area := Rect{X: 10, Y: 20, W: 200, H: 100}
+	for _, tc := range []struct {
+		size string
+		w, h float64
+	}{
+		{"cover", 200, 200},
+		{"contain", 100, 100},
+		{"50px", 50, 50},
+		{"auto 25%", 25, 25},
+		{"50% 10px", 100, 10},
+		{"", 40, 40},
+	} {
+		if w, h := backgroundTileSize(tc.size, area, 40, 40); w != tc.w || h != tc.h {
+			t.Errorf("background-size %q = %vx%v, want %vx%v", tc.size, w, h, tc.w, tc.h)
+		}
+	}
+}
+
+func TestBackgroundPositionOffset(t *testing.T) {
+	for _, tc := range []struct {
+		position string
+		x, y     float64
+	}{
+		{"", 0, 0},
+		{"center", 80, 30},
+		{"bottom", 80, 60},
+		{"top right", 160, 0},
+		{"25% 10px", 40, 10},
+		{"right 10px bottom 5px", 150, 55},
+	} {
+		if x, y := backgroundPositionOffset(tc.position, 160, 60); x != tc.x || y != tc.y {
+			t.Errorf("background-position %q = (%v, %v), want (%v, %v)", tc.position, x, y, tc.x, tc.y)
+		}
+	}
+}
+
+func TestBackgroundTileAxis(t *testing.T) {
+	for _, tc := range []struct {
+		mode     string
+		anchor   float64
+		want     []float64
+		wantTile float64
+	}{
+		{"repeat", 15, []float64{-15, 15, 45, 75}, 30},
+		{"round", 0, []float64{0, 100.0 / 3, 200.0 / 3}, 100.0 / 3},
+		{"space", 0, []float64{0, 35, 70}, 30},
+		{"no-repeat", 15, []float64{15}, 30},
+	} {
+		xs, tile := tileAxis(0, 100, 30, tc.anchor, tc.mode)
+		if math.Abs(tile-tc.wantTile) > 1e-9 || len(xs) != len(tc.want) {
+			t.Errorf("%s tiles = %v (%v), want %v (%v)", tc.mode, xs, tile, tc.want, tc.wantTile)
+			continue
+		}
+		for i := range xs {
+			if math.Abs(xs[i]-tc.want[i]) > 1e-9 {
+				t.Errorf("%s tiles = %v, want %v", tc.mode, xs, tc.want)
+				break
+			}
+		}
+	}
+}
+
+func TestParseBackgroundRepeat(t *testing.T) {
+	for _, tc := range []struct {
+		value string
+		x, y  string
+	}{
+		{"", "repeat", "repeat"},
+		{"repeat-x", "repeat", "no-repeat"},
+		{"repeat-y", "no-repeat", "repeat"},
+		{"space", "space", "space"},
+		{"round no-repeat", "round", "no-repeat"},
+	} {
+		if x, y := parseBackgroundRepeat(tc.value); x != tc.x || y != tc.y {
+			t.Errorf("background-repeat %q = %q %q, want %q %q", tc.value, x, y, tc.x, tc.y)
+		}
+	}
+}
+
+func TestBackgroundTilesOverBudgetStillCoverRegion(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		w, h, tile float64
+		mode       string
+	}{
+		{"8px tiles on 640x480", 640, 480, 8, "repeat"},
+		{"1px tiles on 4096x4096", 4096, 4096, 1, "repeat"},
+		{"spaced 3px tiles", 1000, 600, 3, "space"},
+	} {
+		xs, tw := tileAxis(0, tc.w, tc.tile, 0, tc.mode)
+		ys, th := tileAxis(0, tc.h, tc.tile, 0, tc.mode)
+		if len(xs)*len(ys) <= maxImageTiles {
+			t.Fatalf("%s: %d tiles, want more than the %d tile budget", tc.name, len(xs)*len(ys), maxImageTiles)
+		}
+		gx, gy := imageTileGroups(len(xs), len(ys))
+		blockXs, innerXs, _ := groupTileOrigins(xs, tw, gx)
+		blockYs, innerYs, _ := groupTileOrigins(ys, th, gy)
+		if draws := len(blockXs) * len(blockYs); draws > maxImageTiles || gx*gy > maxImageTiles {
+			t.Fatalf("%s: %d block draws of %d tiles each, want both within %d", tc.name, draws, gx*gy, maxImageTiles)
+		}
+		// Tile k is painted as tile k%g of block k/g.
+		for k, o := range xs {
+			if got := blockXs[k/gx] + innerXs[k%gx]; math.Abs(got-o) > 1e-6 {
+				t.Fatalf("%s: x tile %d is painted at %v, want %v", tc.name, k, got, o)
+			}
+		}
+		for k, o := range ys {
+			if got := blockYs[k/gy] + innerYs[k%gy]; math.Abs(got-o) > 1e-6 {
+				t.Fatalf("%s: y tile %d is painted at %v, want %v", tc.name, k, got, o)
+			}
+		}
+		dst := ebiten.NewImage(int(tc.w), int(tc.h))
+		drawImageTiles(dst, ebiten.NewImage(4, 4), Rect{W: tc.w, H: tc.h}, xs, ys, tw, th)
+		dst.Deallocate()
+	}
+}
+
+func TestBorderImageSliceAndWidths(t *testing.T) {
+	insets, fill := parseBorderImageSlice("25% 3 fill", 40, 20)
+	if insets != [4]int{5, 3, 5, 3} || !fill {
+		t.Fatalf("border-image-slice = %v fill=%v, want [5 3 5 3] fill", insets, fill)
+	}
+	style := &Style{BorderWidth: 2, BorderLeftWidth: 0}
+	if widths := borderImageWidths("2 auto", style, insets); widths != [4]float64{4, 3, 4, 3} {
+		t.Fatalf("border-image-width = %v, want [4 3 4 3]", widths)
+	}
+	if widths := borderImageWidths("", &Style{}, insets); widths != [4]float64{5, 3, 5, 3} {
+		t.Fatalf("border-image-width without borders = %v, want the slice sizes", widths)
+	}
+}
+
+func TestCSSBackgroundLayers(t *testing.T) {
+	ui := New(320, 200)
+	ui.RegisterImage("dot.png", ebiten.NewImage(4, 4))
+	if err := ui.LoadCSS(`
+		.card { width: 100px; height: 50px;
+			background: url(dot.png) no-repeat center / 20px, linear-gradient(red, blue) repeat-x, #fff; }
+		.striped { background-image: repeating-linear-gradient(45deg, red 0 4px, blue 4px 8px), conic-gradient(red, blue);
+			background-repeat: no-repeat, repeat; }
+	`); err != nil {
+		t.Fatalf("LoadCSS layers: %v", err)
+	}
+	if err := ui.LoadLayout(`<panel id="root"><panel id="card" class="card"/><panel id="striped" class="striped"/></panel>`); err != nil {
+		t.Fatalf("LoadLayout layers: %v", err)
+	}
+
+	// The shorthand splits into per-layer longhand lists; only the final
+	// layer carries the color.
+	card := ui.GetPanel("card").Style()
+	if card.Background != "#fff" || card.BackgroundImage != "url(dot.png), linear-gradient(red, blue), none" {
+		t.Errorf("background layers = color %q images %q", card.Background, card.BackgroundImage)
+	}
+	if card.BackgroundRepeat != "no-repeat, repeat-x, repeat" || card.BackgroundSize != "20px, auto, auto" {
+		t.Errorf("per-layer lists = repeat %q size %q", card.BackgroundRepeat, card.BackgroundSize)
+	}
+	if position := cssLayerValue(card.BackgroundPosition, 0); position != "center" {
+		t.Errorf("first layer position = %q, want center", position)
+	}
+	if layers := card.parsedBackgroundLayers; len(layers) != 3 || layers[0].image == nil || layers[1].gradient == nil || layers[2].image != nil || layers[2].gradient != nil {
+		t.Errorf("card layers = %+v, want an image, a gradient and an empty layer", layers)
+	}
+	striped := ui.GetPanel("striped").Style()
+	if layers := striped.parsedBackgroundLayers; len(layers) != 2 || layers[0].gradient == nil || !layers[0].gradient.Repeating || layers[1].gradient == nil || layers[1].gradient.Type != GradientConic {
+		t.Errorf("striped layers = %+v, want a repeating and a conic gradient", layers)
+	}
+}
+
+func TestCSSLayerValueAndGradientTileSize(t *testing.T) {
+	if cssLayerValue("center, top", 1) != "top" || cssLayerValue("no-repeat", 2) != "no-repeat" {
+		t.Error("layer values should index into the list and repeat short lists")
+	}
+	if w, h := gradientTileSize("20px", Rect{W: 100, H: 50}); w != 20 || h != 50 {
+		t.Errorf("gradient tile = %vx%v, want 20x50", w, h)
+	}
+}
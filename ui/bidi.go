@@ -0,0 +1,517 @@
+package ui
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+	"golang.org/x/text/unicode/bidi"
+)
+
+// ============================================================================
+// Bidirectional text
+// ============================================================================
+
+// bidiDirection is the base direction of a paragraph.
+type bidiDirection int
+
+const (
+	bidiAuto bidiDirection = iota // from the first strong character, LTR if none
+	bidiLTR
+	bidiRTL
+)
+
+// styleDirection returns the base direction set by the CSS direction
+// property. Text without one takes its direction from its content.
+func styleDirection(style *Style) bidiDirection {
+	if style == nil {
+		return bidiAuto
+	}
+	switch style.TextDirection {
+	case "ltr":
+		return bidiLTR
+	case "rtl":
+		return bidiRTL
+	}
+	return bidiAuto
+}
+
+// isRTLStyle reports whether a style lays out right to left: flex rows
+// start at the right, text-align start is right and inline padding swaps.
+func isRTLStyle(style *Style) bool {
+	return style != nil && style.TextDirection == "rtl"
+}
+
+// mirrorRTLX returns the x of a child placed in a right-to-left container
+// whose inline axis spans availX..availX+availW. Children are laid out left
+// to right and then their margin boxes are mirrored, so the first child
+// starts at the right edge while margin-left and margin-right stay on their
+// physical sides. Centred children ignore margins and mirror their border
+// box.
+func mirrorRTLX(rect Rect, margin Margin, availX, availW float64, centred bool) float64 {
+	if centred {
+		return availX + availW - (rect.X - availX) - rect.W
+	}
+	outerX := rect.X - margin.Left
+	outerW := margin.Left + rect.W + margin.Right
+	return availX + availW - (outerX - availX) - outerW + margin.Left
+}
+
+// resolveTextAlign maps text-align start, end and the unset default to left
+// or right for the style's direction.
+func resolveTextAlign(style *Style) string {
+	switch style.TextAlign {
+	case "", "start":
+		if isRTLStyle(style) {
+			return "right"
+		}
+		return "left"
+	case "end":
+		if isRTLStyle(style) {
+			return "left"
+		}
+		return "right"
+	}
+	return style.TextAlign
+}
+
+// resolveInlinePadding applies padding-inline-start and -end to the left or
+// right padding for the style's direction.
+func resolveInlinePadding(style *Style) {
+	if !style.PaddingInlineStartSet && !style.PaddingInlineEndSet {
+		return
+	}
+	start, end := &style.Padding.Left, &style.Padding.Right
+	if isRTLStyle(style) {
+		start, end = end, start
+	}
+	if style.PaddingInlineStartSet {
+		*start = style.PaddingInlineStart
+	}
+	if style.PaddingInlineEndSet {
+		*end = style.PaddingInlineEnd
+	}
+	style.PaddingSet = true
+}
+
+// bidiClassOf returns the bidi class of r. Explicit embeddings, overrides
+// and isolates are not supported and count as other neutrals.
+func bidiClassOf(r rune) bidi.Class {
+	props, _ := bidi.LookupRune(r)
+	switch class := props.Class(); class {
+	case bidi.LRO, bidi.RLO, bidi.LRE, bidi.RLE, bidi.PDF, bidi.LRI, bidi.RLI, bidi.FSI, bidi.PDI, bidi.BN, bidi.Control:
+		return bidi.ON
+	default:
+		return class
+	}
+}
+
+// containsRTL reports whether s has right-to-left letters.
+func containsRTL(s string) bool {
+	for _, r := range s {
+		if r < 0x0590 {
+			continue
+		}
+		if class := bidiClassOf(r); class == bidi.R || class == bidi.AL {
+			return true
+		}
+	}
+	return false
+}
+
+// needsBidi reports whether text in a base direction needs reordering.
+func needsBidi(s string, base bidiDirection) bool {
+	return base == bidiRTL || containsRTL(s)
+}
+
+// bidiText holds the resolved embedding levels of a text, one entry per rune.
+type bidiText struct {
+	classes    []bidi.Class // original classes, for rule L1
+	levels     []uint8
+	paragraphs []uint8 // paragraph embedding level of each rune
+}
+
+// resolveBidi runs the Unicode Bidirectional Algorithm over runes, one
+// paragraph per line feed. It implements the implicit rules (W1-W7, N1-N2,
+// I1-I2) for a single isolating run sequence per paragraph; explicit
+// formatting characters and bracket pairs (N0) are not supported.
+func resolveBidi(runes []rune, base bidiDirection) *bidiText {
+	info := &bidiText{
+		classes:    make([]bidi.Class, len(runes)),
+		levels:     make([]uint8, len(runes)),
+		paragraphs: make([]uint8, len(runes)),
+	}
+	for i, r := range runes {
+		info.classes[i] = bidiClassOf(r)
+	}
+	for start := 0; start < len(runes); {
+		end := start
+		for end < len(runes) && runes[end] != '\n' {
+			end++
+		}
+		level := bidiParagraphLevel(info.classes[start:end], base)
+		resolveBidiParagraph(info.classes[start:end], level, info.levels[start:end])
+		for i := start; i < end; i++ {
+			info.paragraphs[i] = level
+		}
+		if end < len(runes) {
+			info.levels[end] = level
+			info.paragraphs[end] = level
+		}
+		start = end + 1
+	}
+	return info
+}
+
+// bidiParagraphLevel returns the paragraph embedding level (rules P2-P3).
+func bidiParagraphLevel(classes []bidi.Class, base bidiDirection) uint8 {
+	switch base {
+	case bidiLTR:
+		return 0
+	case bidiRTL:
+		return 1
+	}
+	for _, class := range classes {
+		switch class {
+		case bidi.L:
+			return 0
+		case bidi.R, bidi.AL:
+			return 1
+		}
+	}
+	return 0
+}
+
+// resolveBidiParagraph resolves weak and neutral types and sets the implicit
+// embedding level of each character of a paragraph.
+func resolveBidiParagraph(classes []bidi.Class, level uint8, levels []uint8) {
+	n := len(classes)
+	types := slices.Clone(classes)
+	edge := bidi.L
+	if level%2 == 1 {
+		edge = bidi.R
+	}
+
+	// W1: nonspacing marks take the type of the previous character.
+	prev := edge
+	for i, t := range types {
+		if t == bidi.NSM {
+			types[i] = prev
+		} else {
+			prev = t
+		}
+	}
+	// W2: European numbers after Arabic letters are Arabic numbers.
+	// W3: Arabic letters are right-to-left.
+	strong := edge
+	for i, t := range types {
+		switch t {
+		case bidi.L, bidi.R, bidi.AL:
+			strong = t
+		case bidi.EN:
+			if strong == bidi.AL {
+				types[i] = bidi.AN
+			}
+		}
+	}
+	for i, t := range types {
+		if t == bidi.AL {
+			types[i] = bidi.R
+		}
+	}
+	// W4: a single separator between two numbers of the same kind joins them.
+	for i := 1; i < n-1; i++ {
+		before, after := types[i-1], types[i+1]
+		switch {
+		case types[i] == bidi.ES && before == bidi.EN && after == bidi.EN:
+			types[i] = bidi.EN
+		case types[i] == bidi.CS && before == after && (before == bidi.EN || before == bidi.AN):
+			types[i] = before
+		}
+	}
+	// W5: terminators next to European numbers become numbers.
+	for i := 0; i < n; {
+		if types[i] != bidi.ET {
+			i++
+			continue
+		}
+		j := i
+		for j < n && types[j] == bidi.ET {
+			j++
+		}
+		if (i > 0 && types[i-1] == bidi.EN) || (j < n && types[j] == bidi.EN) {
+			for k := i; k < j; k++ {
+				types[k] = bidi.EN
+			}
+		}
+		i = j
+	}
+	// W6: remaining separators and terminators are neutral.
+	// W7: European numbers in left-to-right context are left-to-right.
+	strong = edge
+	for i, t := range types {
+		switch t {
+		case bidi.ES, bidi.ET, bidi.CS:
+			types[i] = bidi.ON
+		case bidi.L, bidi.R:
+			strong = t
+		case bidi.EN:
+			if strong == bidi.L {
+				types[i] = bidi.L
+			}
+		}
+	}
+	// N1-N2: neutrals between characters of the same direction take that
+	// direction, others the embedding direction. Numbers count as R.
+	strongOf := func(t bidi.Class) bidi.Class {
+		if t == bidi.L {
+			return bidi.L
+		}
+		return bidi.R
+	}
+	isNeutral := func(t bidi.Class) bool {
+		return t == bidi.B || t == bidi.S || t == bidi.WS || t == bidi.ON
+	}
+	for i := 0; i < n; {
+		if !isNeutral(types[i]) {
+			i++
+			continue
+		}
+		j := i
+		for j < n && isNeutral(types[j]) {
+			j++
+		}
+		before, after := edge, edge
+		if i > 0 {
+			before = strongOf(types[i-1])
+		}
+		if j < n {
+			after = strongOf(types[j])
+		}
+		resolved := edge
+		if before == after {
+			resolved = before
+		}
+		for k := i; k < j; k++ {
+			types[k] = resolved
+		}
+		i = j
+	}
+	// I1-I2: implicit levels.
+	for i, t := range types {
+		levels[i] = level
+		switch {
+		case level%2 == 0 && t == bidi.R:
+			levels[i] = level + 1
+		case level%2 == 0 && (t == bidi.AN || t == bidi.EN):
+			levels[i] = level + 2
+		case level%2 == 1 && (t == bidi.L || t == bidi.AN || t == bidi.EN):
+			levels[i] = level + 1
+		}
+	}
+}
+
+// resetBidiWhitespace applies rule L1 to one line: segment separators, the
+// whitespace before them and trailing whitespace return to the paragraph
+// level.
+func resetBidiWhitespace(classes []bidi.Class, levels []uint8, paragraph uint8) {
+	trailing := true
+	for i := len(classes) - 1; i >= 0; i-- {
+		switch {
+		case classes[i] == bidi.S || classes[i] == bidi.B:
+			levels[i] = paragraph
+			trailing = true
+		case classes[i] == bidi.WS && trailing:
+			levels[i] = paragraph
+		default:
+			trailing = false
+		}
+	}
+}
+
+// bidiReorder returns the visual order of items with the given levels (rule
+// L2): from the highest level down to the lowest odd level, every sequence at
+// that level or above is reversed.
+func bidiReorder(levels []uint8) []int {
+	order := make([]int, len(levels))
+	var highest, lowestOdd uint8 = 0, 255
+	for i, level := range levels {
+		order[i] = i
+		if level > highest {
+			highest = level
+		}
+		if level%2 == 1 && level < lowestOdd {
+			lowestOdd = level
+		}
+	}
+	for level := highest; level >= lowestOdd && level > 0; level-- {
+		for i := 0; i < len(order); {
+			if levels[order[i]] < level {
+				i++
+				continue
+			}
+			j := i
+			for j < len(order) && levels[order[j]] >= level {
+				j++
+			}
+			slices.Reverse(order[i:j])
+			i = j
+		}
+	}
+	return order
+}
+
+// bidiRun is a maximal range [start, end) of items at one level.
+type bidiRun struct {
+	start, end int
+	level      uint8
+}
+
+func (r bidiRun) rtl() bool {
+	return r.level%2 == 1
+}
+
+// bidiVisualRuns splits items into same-level runs and returns them in
+// visual order, left to right. Right-to-left runs still hold their items in
+// logical order; the shaper reverses them.
+func bidiVisualRuns(levels []uint8) []bidiRun {
+	var runs []bidiRun
+	var runLevels []uint8
+	for i := 0; i < len(levels); {
+		j := i + 1
+		for j < len(levels) && levels[j] == levels[i] {
+			j++
+		}
+		runs = append(runs, bidiRun{start: i, end: j, level: levels[i]})
+		runLevels = append(runLevels, levels[i])
+		i = j
+	}
+	visual := make([]bidiRun, len(runs))
+	for i, index := range bidiReorder(runLevels) {
+		visual[i] = runs[index]
+	}
+	return visual
+}
+
+// bidiTextRun is a single-direction piece of a line.
+type bidiTextRun struct {
+	text string
+	rtl  bool
+}
+
+// bidiLineRuns reorders one line of text into runs in visual order.
+func bidiLineRuns(line string, base bidiDirection) []bidiTextRun {
+	runes := []rune(line)
+	if len(runes) == 0 {
+		return nil
+	}
+	info := resolveBidi(runes, base)
+	resetBidiWhitespace(info.classes, info.levels, info.paragraphs[0])
+	var runs []bidiTextRun
+	for _, run := range bidiVisualRuns(info.levels) {
+		runs = append(runs, bidiTextRun{text: string(runes[run.start:run.end]), rtl: run.rtl()})
+	}
+	return runs
+}
+
+// ============================================================================
+// Right-to-left shaping
+// ============================================================================
+
+// rtlFace returns a copy of face that shapes right to left through go-text,
+// or nil for faces that cannot, such as bitmap faces. Copies of chains a UI
+// built are kept with the chain; copies of single faces are cheap and share
+// the glyph caches of their source.
+func rtlFace(face text.Face) text.Face {
+	if face == nil {
+		return nil
+	}
+	built := fontChainFaceOf(face)
+	if built != nil && built.rtlDone {
+		return built.rtl
+	}
+	var rtl text.Face
+	switch f := face.(type) {
+	case *text.GoTextFace:
+		copied := *f
+		copied.Direction = text.DirectionRightToLeft
+		rtl = &copied
+	case *text.MultiFace:
+		if built == nil {
+			break
+		}
+		var faces []text.Face
+		for _, member := range built.members {
+			if m := rtlFace(member); m != nil {
+				faces = append(faces, m)
+			}
+		}
+		if len(faces) == 0 {
+			break
+		}
+		if multi, err := text.NewMultiFace(faces...); err == nil {
+			rtl = multi
+		}
+	}
+	if built != nil {
+		built.rtl, built.rtlDone = rtl, true
+		if rtl != nil {
+			builtFaces.Store(rtl, &fontChainFace{face: rtl, synth: built.synth, rtl: rtl, rtlDone: true})
+		}
+	}
+	return rtl
+}
+
+// measureTextRun returns the advance of a single-direction run.
+func measureTextRun(s string, face text.Face, rtl bool) float64 {
+	if rtl {
+		if f := rtlFace(face); f != nil {
+			face = f
+		}
+	}
+	return text.Advance(s, face)
+}
+
+// drawTextRun draws a single-direction run with its left edge at the origin
+// of op and returns its advance. Right-to-left runs are shaped with a
+// right-to-left copy of the face; bitmap faces get the run reversed instead.
+func drawTextRun(dst *ebiten.Image, s string, face text.Face, op *text.DrawOptions, rtl bool) float64 {
+	if rtl {
+		if f := rtlFace(face); f != nil {
+			align := op.PrimaryAlign
+			op.PrimaryAlign = text.AlignEnd
+			drawSynthesizedText(dst, s, f, op)
+			op.PrimaryAlign = align
+			return text.Advance(s, f)
+		}
+		if _, ok := face.(*text.GoXFace); ok {
+			s = bidi.ReverseString(s)
+		}
+	}
+	drawSynthesizedText(dst, s, face, op)
+	return text.Advance(s, face)
+}
+
+// drawTextDirection draws text like drawText in a base direction, reordering
+// each line of bidirectional text into visual runs.
+func drawTextDirection(dst *ebiten.Image, s string, face text.Face, op *text.DrawOptions, base bidiDirection) {
+	if !needsBidi(s, base) {
+		drawSynthesizedText(dst, s, face, op)
+		return
+	}
+	if op == nil {
+		op = &text.DrawOptions{}
+	}
+	origin := op.GeoM
+	for i, line := range strings.Split(s, "\n") {
+		x := 0.0
+		for _, run := range bidiLineRuns(line, base) {
+			op.GeoM.Reset()
+			op.GeoM.Translate(x, float64(i)*op.LineSpacing)
+			op.GeoM.Concat(origin)
+			x += drawTextRun(dst, run.text, face, op, run.rtl)
+		}
+	}
+	op.GeoM = origin
+}
@@ -0,0 +1,211 @@
+package ui
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+func testShapedFace(t *testing.T) text.Face {
+	source, err := text.NewGoTextFaceSource(bytes.NewReader(goregular.TTF))
+	if err != nil {
+		t.Fatalf("NewGoTextFaceSource: %v", err)
+	}
+	return &text.GoTextFace{Source: source, Size: 16}
+}
+
+func TestBidiLineRunsReorderVisually(t *testing.T) {
+	tests := []struct {
+		name  string
+		line  string
+		base  bidiDirection
+		texts []string
+		rtl   []bool
+	}{
+		{
+			name:  "hebrew with number",
+			line:  "שלום 123 עולם",
+			base:  bidiAuto,
+			texts: []string{" עולם", "123", "שלום "},
+			rtl:   []bool{true, false, true},
+		},
+		{
+			name:  "latin in rtl paragraph",
+			line:  "hello world!",
+			base:  bidiRTL,
+			texts: []string{"!", "hello world"},
+			rtl:   []bool{true, false},
+		},
+		{
+			name:  "latin only",
+			line:  "hello world!",
+			base:  bidiAuto,
+			texts: []string{"hello world!"},
+			rtl:   []bool{false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runs := bidiLineRuns(tt.line, tt.base)
+			if len(runs) != len(tt.texts) {
+				t.Fatalf("bidiLineRuns() = %#v, want %d runs", runs, len(tt.texts))
+			}
+			for i, run := range runs {
+				if run.text != tt.texts[i] || run.rtl != tt.rtl[i] {
+					t.Errorf("run %d = {%q, rtl=%v}, want {%q, rtl=%v}", i, run.text, run.rtl, tt.texts[i], tt.rtl[i])
+				}
+			}
+		})
+	}
+}
+
+func TestBidiReorderReversesLevelRuns(t *testing.T) {
+	got := bidiReorder([]uint8{0, 1, 1, 2, 2, 1, 0})
+	want := []int{0, 5, 3, 4, 2, 1, 6}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("bidiReorder() = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestTextLayoutPlacesRTLClustersRightToLeft(t *testing.T) {
+	face := testShapedFace(t)
+	layout := newTextLayout("abc אבג", face, textLayoutOptions{
+		WhiteSpace: textWhiteSpacePreWrap,
+		LineHeight: measureLineHeight(face),
+	})
+	line := layout.lines[0]
+	if line.RTL {
+		t.Fatal("line starting with Latin should be left-to-right")
+	}
+	if len(line.Runs) != 2 || line.Runs[0].RTL || !line.Runs[1].RTL {
+		t.Fatalf("Runs = %#v, want an LTR run followed by an RTL run", line.Runs)
+	}
+
+	alef, gimel := layout.clusters[4], layout.clusters[6]
+	if alef.X <= gimel.X {
+		t.Fatalf("alef X = %v, gimel X = %v; want alef right of gimel", alef.X, gimel.X)
+	}
+	if _, x := layout.CaretPosition(4); math.Abs(x-(alef.X+alef.Width)) > 0.01 {
+		t.Errorf("CaretPosition(4) x = %v, want alef's right edge %v", x, alef.X+alef.Width)
+	}
+	if got := layout.CaretRuneIndexAt(alef.X+alef.Width*0.75, 0); got != 4 {
+		t.Errorf("CaretRuneIndexAt(right half of alef) = %d, want 4", got)
+	}
+	if got := layout.CaretRuneIndexAt(line.Width+10, 0); got != 7 {
+		t.Errorf("CaretRuneIndexAt(past the line) = %d, want 7", got)
+	}
+
+	rects := layout.SelectionRects(4, 7)
+	if len(rects) != 1 {
+		t.Fatalf("SelectionRects(4, 7) = %#v, want one merged rect", rects)
+	}
+	if run := line.Runs[1]; math.Abs(rects[0].X-run.X) > 0.01 || math.Abs(rects[0].W-run.Width) > 0.01 {
+		t.Errorf("selection rect = %#v, want the RTL run [%v, +%v]", rects[0], run.X, run.Width)
+	}
+}
+
+func TestTextLayoutDetectsRTLParagraph(t *testing.T) {
+	face := testShapedFace(t)
+	layout := newTextLayout("אבג abc", face, textLayoutOptions{
+		WhiteSpace: textWhiteSpacePreWrap,
+		LineHeight: measureLineHeight(face),
+	})
+	line := layout.lines[0]
+	if !line.RTL {
+		t.Fatal("line starting with Hebrew should be right-to-left")
+	}
+	if len(line.Runs) != 2 || line.Runs[0].Text != "abc" || !line.Runs[1].RTL {
+		t.Fatalf("Runs = %#v, want \"abc\" left of the Hebrew run", line.Runs)
+	}
+}
+
+func TestTextInputArrowKeysFollowRTLParagraph(t *testing.T) {
+	ti := NewTextInput("input")
+	ti.FontFace = testShapedFace(t)
+	ti.SetText("שלום")
+	ti.CursorPos = 0
+
+	simulateTextInputKeyPress(ti, ebiten.KeyArrowLeft, false, false)
+	if ti.CursorPos != 1 {
+		t.Fatalf("CursorPos after Left = %d, want 1", ti.CursorPos)
+	}
+	simulateTextInputKeyPress(ti, ebiten.KeyArrowRight, false, false)
+	if ti.CursorPos != 0 {
+		t.Fatalf("CursorPos after Right = %d, want 0", ti.CursorPos)
+	}
+}
+
+func TestDirectionRTLMirrorsRowAndInlinePadding(t *testing.T) {
+	ui := New(320, 200)
+	if err := ui.LoadCSS(`
+		#root { direction: rtl; padding-inline-start: 12px; }
+		#title { text-align: start; }
+	`); err != nil {
+		t.Fatalf("LoadCSS() error = %v", err)
+	}
+	if err := ui.LoadLayout(`<panel id="root" direction="row">
+		<panel id="a" width="30" height="20"/>
+		<panel id="b" width="50" height="20"/>
+		<text id="title">שלום</text>
+	</panel>`); err != nil {
+		t.Fatalf("LoadLayout() error = %v", err)
+	}
+
+	root := ui.GetPanel("root")
+	if root.Style().Padding.Right != 12 || root.Style().Padding.Left != 0 {
+		t.Fatalf("padding = %#v, want padding-inline-start on the right", root.Style().Padding)
+	}
+	a := ui.GetWidget("a").ComputedRect()
+	b := ui.GetWidget("b").ComputedRect()
+	if right := root.ComputedRect().X + root.ComputedRect().W - 12; math.Abs(a.X+a.W-right) > 0.5 {
+		t.Errorf("first child right edge = %v, want %v", a.X+a.W, right)
+	}
+	if b.X+b.W > a.X+0.5 {
+		t.Errorf("second child %#v should sit left of the first %#v", b, a)
+	}
+	if got := resolveTextAlign(ui.GetText("title").Style()); got != "right" {
+		t.Errorf("resolveTextAlign(start) in rtl = %q, want right", got)
+	}
+}
+
+func TestDirectionRTLKeepsMarginsPhysical(t *testing.T) {
+	ui := New(320, 200)
+	if err := ui.LoadCSS(`
+		#row, #col { direction: rtl; width: 200px; height: 40px; }
+		#row { flex-direction: row; }
+		#col { flex-direction: column; }
+		#a, #c { width: 30px; height: 10px; margin-left: 4px; margin-right: 10px; }
+		#b { width: 50px; height: 10px; margin-left: 6px; margin-right: 2px; }
+	`); err != nil {
+		t.Fatalf("LoadCSS() error = %v", err)
+	}
+	if err := ui.LoadLayout(`<panel id="root">
+		<panel id="row"><panel id="a"/><panel id="b"/></panel>
+		<panel id="col"><panel id="c"/></panel>
+	</panel>`); err != nil {
+		t.Fatalf("LoadLayout() error = %v", err)
+	}
+
+	// Children run from the right edge; each keeps margin-right on its
+	// right and margin-left on its left.
+	for _, tt := range []struct {
+		id, container string
+		want          float64
+	}{
+		{"a", "row", 200 - 10 - 30},
+		{"b", "row", 200 - 10 - 30 - 4 - 2 - 50},
+		{"c", "col", 200 - 10 - 30},
+	} {
+		x := ui.GetWidget(tt.id).ComputedRect().X - ui.GetWidget(tt.container).ComputedRect().X
+		if x != tt.want {
+			t.Errorf("%s x = %v, want %v", tt.id, x, tt.want)
+		}
+	}
+}
@@ -0,0 +1,354 @@
+package ui
+
+import (
+	"image/color"
+	"math"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// ============================================================================
+// Styled borders (dashed, dotted, double, groove, ridge, inset, outset)
+// ============================================================================
+
+// borderSide is the resolved width, color and line style of one border side.
+type borderSide struct {
+	width float64
+	color color.Color
+	style string
+}
+
+// Side indices, clockwise from the top as in the CSS box shorthands.
+const (
+	borderTop = iota
+	borderRight
+	borderBottom
+	borderLeft
+)
+
+// resolveBorderSides returns the top, right, bottom and left border sides,
+// falling back from the per-side properties to the uniform ones.
+func resolveBorderSides(style *Style) [4]borderSide {
+	widths := [4]float64{style.BorderTopWidth, style.BorderRightWidth, style.BorderBottomWidth, style.BorderLeftWidth}
+	widthSet := [4]bool{style.BorderTopWidthSet, style.BorderRightWidthSet, style.BorderBottomWidthSet, style.BorderLeftWidthSet}
+	colors := [4]color.Color{style.BorderTopColor, style.BorderRightColor, style.BorderBottomColor, style.BorderLeftColor}
+	styles := [4]string{style.BorderTopStyle, style.BorderRightStyle, style.BorderBottomStyle, style.BorderLeftStyle}
+
+	var sides [4]borderSide
+	for i := range sides {
+		side := borderSide{width: widths[i], color: colors[i], style: strings.ToLower(styles[i])}
+		if !widthSet[i] && side.width == 0 {
+			side.width = style.BorderWidth
+		}
+		if side.color == nil {
+			side.color = style.BorderColor
+		}
+		if side.style == "" {
+			side.style = strings.ToLower(style.BorderStyle)
+		}
+		if side.style == "" {
+			side.style = "solid"
+		}
+		sides[i] = side
+	}
+	return sides
+}
+
+// drawBorder paints the widget border. A loaded border-image replaces the
+// line border entirely. Solid borders keep the stroked rounded-rect and
+// per-side fast paths; any other line style goes through drawStyledBorder so
+// corners, widths and colors are honored per side.
+func (w *BaseWidget) drawBorder(screen *ebiten.Image, r Rect, style *Style) {
+	if style.parsedBorderImage != nil {
+		w.drawBorderImage(screen, r, style)
+		return
+	}
+	sides := resolveBorderSides(style)
+	solid := true
+	for _, side := range sides {
+		if side.style != "solid" {
+			solid = false
+			break
+		}
+	}
+	if solid {
+		if style.BorderColor != nil && style.BorderWidth > 0 {
+			tl, tr, br, bl := w.getCornerRadii(style)
+			drawRoundedRectStrokeEx(screen, r, tl, tr, br, bl, style.BorderWidth, style.BorderColor)
+		}
+		w.drawIndividualBorders(screen, r, style)
+		return
+	}
+	tl, tr, br, bl := w.getCornerRadii(style)
+	drawStyledBorder(screen, r, sides, [4]float64{tl, tr, br, bl})
+}
+
+// drawStyledBorder draws a border whose sides may differ in width, color and
+// line style. radii are the outer corner radii (top-left, top-right,
+// bottom-right, bottom-left). Adjacent sides meet on the corner diagonal.
+func drawStyledBorder(screen *ebiten.Image, r Rect, sides [4]borderSide, radii [4]float64) {
+	if r.W <= 0 || r.H <= 0 {
+		return
+	}
+	geom := newBorderGeometry(r, sides, radii)
+	for i, side := range sides {
+		if side.width <= 0 || side.color == nil || side.style == "none" || side.style == "hidden" {
+			continue
+		}
+		if _, _, _, a := side.color.RGBA(); a == 0 {
+			continue
+		}
+		dark, light := borderShades(side.color)
+		// Top and left sides are lit from above-left.
+		litFirst := i == borderTop || i == borderLeft
+		switch side.style {
+		case "dashed":
+			geom.fillDashes(screen, i, side)
+		case "dotted":
+			geom.fillDots(screen, i, side)
+		case "double":
+			if side.width < 3 {
+				geom.fillBand(screen, i, 0, 1, side.color)
+				continue
+			}
+			geom.fillBand(screen, i, 0, 1.0/3, side.color)
+			geom.fillBand(screen, i, 2.0/3, 1, side.color)
+		case "groove", "ridge":
+			outer, inner := dark, light
+			if litFirst == (side.style == "ridge") {
+				outer, inner = light, dark
+			}
+			geom.fillBand(screen, i, 0, 0.5, outer)
+			geom.fillBand(screen, i, 0.5, 1, inner)
+		case "inset", "outset":
+			clr := light
+			if litFirst == (side.style == "inset") {
+				clr = dark
+			}
+			geom.fillBand(screen, i, 0, 1, clr)
+		default:
+			geom.fillBand(screen, i, 0, 1, side.color)
+		}
+	}
+}
+
+// borderShades returns the darker and lighter variants used by the 3D
+// border styles. Black still gets a visible lighter shade.
+func borderShades(clr color.Color) (color.Color, color.Color) {
+	c := color.NRGBAModel.Convert(clr).(color.NRGBA)
+	dark := color.NRGBA{R: uint8(float64(c.R) * 0.55), G: uint8(float64(c.G) * 0.55), B: uint8(float64(c.B) * 0.55), A: c.A}
+	lighten := func(v uint8) uint8 { return uint8(float64(v) + (255-float64(v))*0.35) }
+	light := color.NRGBA{R: lighten(c.R), G: lighten(c.G), B: lighten(c.B), A: c.A}
+	return dark, light
+}
+
+// borderGeometry describes the border area of a box with CSS-clamped corner
+// radii. A position inside a side's border is addressed by a fraction f
+// across the border (0 at the outer edge, 1 at the inner edge) and a distance
+// along the side's centerline.
+type borderGeometry struct {
+	rect   Rect
+	widths [4]float64
+	radii  [4]float64
+}
+
+func newBorderGeometry(r Rect, sides [4]borderSide, radii [4]float64) *borderGeometry {
+	g := &borderGeometry{rect: r}
+	for i, side := range sides {
+		if side.style != "none" && side.style != "hidden" {
+			g.widths[i] = side.width
+		}
+	}
+	// Scale radii down uniformly when adjacent corners would overlap.
+	scale := 1.0
+	for _, pair := range [][3]float64{
+		{radii[0], radii[1], r.W}, {radii[3], radii[2], r.W},
+		{radii[0], radii[3], r.H}, {radii[1], radii[2], r.H},
+	} {
+		if sum := pair[0] + pair[1]; sum > pair[2] && sum > 0 {
+			scale = min(scale, pair[2]/sum)
+		}
+	}
+	for i, radius := range radii {
+		g.radii[i] = max(0, radius*scale)
+	}
+	return g
+}
+
+// cornerPoint returns the point at angle theta (degrees, y down) on corner
+// c's curve at fraction f across the border. Corners are numbered top-left,
+// top-right, bottom-right, bottom-left.
+func (g *borderGeometry) cornerPoint(c int, f, theta float64) (float64, float64) {
+	r := g.rect
+	x0 := r.X + f*g.widths[borderLeft]
+	y0 := r.Y + f*g.widths[borderTop]
+	x1 := r.X + r.W - f*g.widths[borderRight]
+	y1 := r.Y + r.H - f*g.widths[borderBottom]
+
+	// The horizontal radius shrinks by the vertical side's width and vice versa.
+	vertical, horizontal := borderLeft, borderTop
+	switch c {
+	case 1:
+		vertical, horizontal = borderRight, borderTop
+	case 2:
+		vertical, horizontal = borderRight, borderBottom
+	case 3:
+		vertical, horizontal = borderLeft, borderBottom
+	}
+	rx := max(0, g.radii[c]-f*g.widths[vertical])
+	ry := max(0, g.radii[c]-f*g.widths[horizontal])
+
+	var cx, cy float64
+	switch c {
+	case 0:
+		cx, cy = x0+rx, y0+ry
+	case 1:
+		cx, cy = x1-rx, y0+ry
+	case 2:
+		cx, cy = x1-rx, y1-ry
+	default:
+		cx, cy = x0+rx, y1-ry
+	}
+	rad := theta * math.Pi / 180
+	return cx + rx*math.Cos(rad), cy + ry*math.Sin(rad)
+}
+
+// sideSamples returns the points of side i at fraction f, running clockwise
+// from the diagonal of its starting corner to the diagonal of its ending one.
+func (g *borderGeometry) sideSamples(side int, f float64) [][2]float64 {
+	start, end := side, (side+1)%4
+	base := 225 + 90*float64(side)
+	steps := func(c int) int {
+		if g.radii[c] <= 0 {
+			return 1
+		}
+		return int(math.Max(2, math.Ceil(g.radii[c]/3)))
+	}
+	var points [][2]float64
+	n := steps(start)
+	for k := 0; k <= n; k++ {
+		x, y := g.cornerPoint(start, f, base+45*float64(k)/float64(n))
+		points = append(points, [2]float64{x, y})
+	}
+	n = steps(end)
+	for k := 0; k <= n; k++ {
+		x, y := g.cornerPoint(end, f, base+45+45*float64(k)/float64(n))
+		points = append(points, [2]float64{x, y})
+	}
+	return points
+}
+
+// sideLengths returns the cumulative centerline length at each sample.
+func (g *borderGeometry) sideLengths(side int) []float64 {
+	center := g.sideSamples(side, 0.5)
+	lengths := make([]float64, len(center))
+	for i := 1; i < len(center); i++ {
+		lengths[i] = lengths[i-1] + math.Hypot(center[i][0]-center[i-1][0], center[i][1]-center[i-1][1])
+	}
+	return lengths
+}
+
+// fillBand fills side i between fractions f0 and f1 across the border.
+func (g *borderGeometry) fillBand(screen *ebiten.Image, side int, f0, f1 float64, clr color.Color) {
+	path := &vector.Path{}
+	appendBorderQuadStrip(path, g.sideSamples(side, f0), g.sideSamples(side, f1))
+	fillBorderPath(screen, path, clr)
+}
+
+// fillDashes fills evenly spaced dashes (three widths long) along side i,
+// stretching the gaps so dashes land on both corners.
+func (g *borderGeometry) fillDashes(screen *ebiten.Image, side int, s borderSide) {
+	outer, inner := g.sideSamples(side, 0), g.sideSamples(side, 1)
+	lengths := g.sideLengths(side)
+	total := lengths[len(lengths)-1]
+	dash := 3 * s.width
+	n := int(math.Floor((total + dash) / (2 * dash)))
+	path := &vector.Path{}
+	if n <= 1 {
+		appendBorderQuadStrip(path, outer, inner)
+		fillBorderPath(screen, path, s.color)
+		return
+	}
+	gap := (total - float64(n)*dash) / float64(n-1)
+	for k := 0; k < n; k++ {
+		from := float64(k) * (dash + gap)
+		o, in := sliceBorderSamples(outer, inner, lengths, from, from+dash)
+		appendBorderQuadStrip(path, o, in)
+	}
+	fillBorderPath(screen, path, s.color)
+}
+
+// fillDots draws round dots one width across, spaced two widths apart.
+func (g *borderGeometry) fillDots(screen *ebiten.Image, side int, s borderSide) {
+	center := g.sideSamples(side, 0.5)
+	lengths := g.sideLengths(side)
+	total := lengths[len(lengths)-1]
+	n := int(math.Max(1, math.Round(total/(2*s.width))))
+	step := total / float64(n)
+	for k := 0; k < n; k++ {
+		x, y := interpolateBorderSamples(center, lengths, (float64(k)+0.5)*step)
+		vector.DrawFilledCircle(screen, float32(x), float32(y), float32(s.width/2), s.color, true)
+	}
+}
+
+// sliceBorderSamples returns the outer and inner edges of a side between two
+// centerline distances.
+func sliceBorderSamples(outer, inner [][2]float64, lengths []float64, from, to float64) ([][2]float64, [][2]float64) {
+	ox, oy := interpolateBorderSamples(outer, lengths, from)
+	ix, iy := interpolateBorderSamples(inner, lengths, from)
+	o := [][2]float64{{ox, oy}}
+	in := [][2]float64{{ix, iy}}
+	for i, length := range lengths {
+		if length > from && length < to {
+			o = append(o, outer[i])
+			in = append(in, inner[i])
+		}
+	}
+	ox, oy = interpolateBorderSamples(outer, lengths, to)
+	ix, iy = interpolateBorderSamples(inner, lengths, to)
+	return append(o, [2]float64{ox, oy}), append(in, [2]float64{ix, iy})
+}
+
+func interpolateBorderSamples(points [][2]float64, lengths []float64, at float64) (float64, float64) {
+	for i := 1; i < len(points); i++ {
+		if at > lengths[i] && i < len(points)-1 {
+			continue
+		}
+		span := lengths[i] - lengths[i-1]
+		t := 0.0
+		if span > 0 {
+			t = math.Max(0, math.Min(1, (at-lengths[i-1])/span))
+		}
+		return points[i-1][0] + (points[i][0]-points[i-1][0])*t, points[i-1][1] + (points[i][1]-points[i-1][1])*t
+	}
+	return points[0][0], points[0][1]
+}
+
+// appendBorderQuadStrip adds the closed polygon between two edges.
+func appendBorderQuadStrip(path *vector.Path, outer, inner [][2]float64) {
+	if len(outer) == 0 || len(inner) == 0 {
+		return
+	}
+	path.MoveTo(float32(outer[0][0]), float32(outer[0][1]))
+	for _, p := range outer[1:] {
+		path.LineTo(float32(p[0]), float32(p[1]))
+	}
+	for i := len(inner) - 1; i >= 0; i-- {
+		path.LineTo(float32(inner[i][0]), float32(inner[i][1]))
+	}
+	path.Close()
+}
+
+func fillBorderPath(screen *ebiten.Image, path *vector.Path, clr color.Color) {
+	vs, is := path.AppendVerticesAndIndicesForFilling(nil, nil)
+	if len(is) == 0 {
+		return
+	}
+	applyColorToVertices(vs, clr)
+	screen.DrawTriangles(vs, is, whiteImage, &ebiten.DrawTrianglesOptions{
+		AntiAlias: true,
+		FillRule:  ebiten.FillRuleNonZero,
+	})
+}
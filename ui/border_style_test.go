@@ -0,0 +1,94 @@
+package ui
+
+import (
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestCSSBorderAndOutlineStyles(t *testing.T) {
+	ui := New(200, 120)
+	if err := ui.LoadCSS(`
+		#box {
+			width: 100px; height: 50px;
+			border: 2px dashed #ff0000;
+			border-left: 4px double rgb(0, 255, 0);
+			border-bottom-style: none;
+			border-style-typo: solid;
+			outline: 1px dotted #0000ff;
+		}
+		#bad { border-style: solid wavy; }
+	`); err != nil {
+		t.Fatalf("LoadCSS border styles: %v", err)
+	}
+	if err := ui.LoadLayout(`<panel id="root"><panel id="box"/></panel>`); err != nil {
+		t.Fatalf("LoadLayout border styles: %v", err)
+	}
+	if got := len(ui.CSSDiagnostics()); got != 2 {
+		t.Errorf("diagnostics = %v, want unknown property and invalid border-style", ui.CSSDiagnostics())
+	}
+
+	// The shorthand sets every side; later side declarations override it.
+	style := ui.GetPanel("box").Style()
+	sides := resolveBorderSides(style)
+	for _, tc := range []struct {
+		name  string
+		side  int
+		style string
+		width float64
+	}{
+		{"top", borderTop, "dashed", 2},
+		{"left", borderLeft, "double", 4},
+		{"bottom", borderBottom, "none", 2},
+	} {
+		if got := sides[tc.side]; got.style != tc.style || got.width != tc.width {
+			t.Errorf("%s side = %+v, want %vpx %s", tc.name, got, tc.width, tc.style)
+		}
+	}
+	if got := color.RGBAModel.Convert(sides[borderLeft].color).(color.RGBA); got.G != 0xff || got.R != 0 {
+		t.Errorf("left color = %#v, want green", got)
+	}
+	if outline := ParseOutline(style.Outline); outline == nil || outline.Style != "dotted" || outline.Width != 1 {
+		t.Errorf("outline = %+v, want 1px dotted", outline)
+	}
+}
+
+func TestBorderGeometryMitersSquareCorners(t *testing.T) {
+	solid := borderSide{width: 4, color: color.Black, style: "solid"}
+	wide := borderSide{width: 10, color: color.Black, style: "solid"}
+	sides := [4]borderSide{solid, solid, solid, wide}
+	square := newBorderGeometry(Rect{X: 10, Y: 20, W: 100, H: 50}, sides, [4]float64{})
+
+	outer := square.sideSamples(borderTop, 0)
+	inner := square.sideSamples(borderTop, 1)
+	if p := outer[0]; p[0] != 10 || p[1] != 20 {
+		t.Fatalf("square top should start at the outer corner, got %v", p)
+	}
+	if p := inner[0]; p[0] != 20 || p[1] != 24 {
+		t.Fatalf("square top inner edge should meet the left side on the miter, got %v", p)
+	}
+	if p := inner[len(inner)-1]; p[0] != 106 || p[1] != 24 {
+		t.Fatalf("square top inner end = %v, want (106, 24)", p)
+	}
+}
+
+func TestBorderGeometryRoundsCorners(t *testing.T) {
+	solid := borderSide{width: 4, color: color.Black, style: "solid"}
+	rounded := newBorderGeometry(Rect{W: 100, H: 50}, [4]borderSide{solid, solid, solid, solid}, [4]float64{80, 80, 80, 80})
+	if got := rounded.radii[0]; math.Abs(got-25) > 1e-9 {
+		t.Fatalf("oversized radii should scale to fit, got %v", got)
+	}
+	top := rounded.sideSamples(borderTop, 0)
+	start, end := top[0], top[len(top)-1]
+	wantX := 25 - 25*math.Sqrt2/2
+	if math.Abs(start[0]-wantX) > 1e-6 || math.Abs(start[1]-wantX) > 1e-6 {
+		t.Fatalf("rounded top should start on the corner diagonal, got %v want %v", start, wantX)
+	}
+	if math.Abs(end[0]-(100-wantX)) > 1e-6 {
+		t.Fatalf("rounded top should end on the top-right diagonal, got %v", end)
+	}
+	lengths := rounded.sideLengths(borderTop)
+	if total := lengths[len(lengths)-1]; total < 50 || total > 100 {
+		t.Fatalf("top centerline length = %v, want between the straight run and the full width", total)
+	}
+}
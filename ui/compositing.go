@@ -0,0 +1,119 @@
+package ui
+
+import (
+	"image"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// ============================================================================
+// mix-blend-mode / mask-image
+// ============================================================================
+
+// cssBlendModes lists the mix-blend-mode keywords; add is accepted as an
+// alias of plus-lighter.
+var cssBlendModes = []string{"normal", "plus-lighter", "add", "screen",
+	"multiply", "overlay", "darken", "lighten", "color-dodge", "color-burn", "hard-light",
+	"soft-light", "difference", "exclusion", "hue", "saturation", "color", "luminosity"}
+
+// shaderBlendModes maps the modes drawn by the blend shader to its Mode
+// uniform.
+var shaderBlendModes = map[string]int{
+	"multiply": 1, "screen": 2, "overlay": 3, "darken": 4, "lighten": 5,
+	"color-dodge": 6, "color-burn": 7, "hard-light": 8, "soft-light": 9,
+	"difference": 10, "exclusion": 11, "hue": 12, "saturation": 13,
+	"color": 14, "luminosity": 15,
+}
+
+// blendScreen is the screen blend mode on premultiplied colours,
+// S + D - S*D, which fixed-function blending computes exactly.
+var blendScreen = ebiten.Blend{
+	BlendFactorSourceRGB:        ebiten.BlendFactorOne,
+	BlendFactorSourceAlpha:      ebiten.BlendFactorOne,
+	BlendFactorDestinationRGB:   ebiten.BlendFactorOneMinusSourceColor,
+	BlendFactorDestinationAlpha: ebiten.BlendFactorOneMinusSourceAlpha,
+	BlendOperationRGB:           ebiten.BlendOperationAdd,
+	BlendOperationAlpha:         ebiten.BlendOperationAdd,
+}
+
+// hasCompositeEffects reports whether a style blends with its backdrop or
+// masks its content, both of which need the widget drawn offscreen first.
+func hasCompositeEffects(style *Style) bool {
+	if mode := style.MixBlendMode; mode != "" && mode != "normal" {
+		return true
+	}
+	return hasMaskLayers(style)
+}
+
+func hasMaskLayers(style *Style) bool {
+	for _, layer := range style.parsedMaskLayers {
+		if layer.image != nil || layer.gradient != nil || layer.nineSlice != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// applyCSSMask multiplies the alpha of target by the mask-image layers,
+// sized and positioned against the border box r. Content outside the mask
+// layers, including outside r, becomes transparent.
+func applyCSSMask(target *ebiten.Image, r Rect, style *Style) {
+	if !hasMaskLayers(style) {
+		return
+	}
+	bounds := target.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w <= 0 || h <= 0 {
+		return
+	}
+	mask := globalImagePool.Get(w, h)
+	drawImageLayers(mask, style.parsedMaskLayers, r, r, style.MaskSize, style.MaskPosition, style.MaskRepeat)
+	cropped := mask.SubImage(image.Rect(0, 0, w, h)).(*ebiten.Image)
+	target.DrawImage(cropped, &ebiten.DrawImageOptions{Blend: ebiten.BlendDestinationIn})
+	globalImagePool.Put(mask)
+}
+
+// drawBlended draws a composited widget layer onto screen with a
+// mix-blend-mode. plus-lighter and screen map to ebiten blend factors; the
+// other modes read the backdrop in the blend shader.
+func drawBlended(screen, layer *ebiten.Image, op *ebiten.DrawImageOptions, mode string) {
+	mode = strings.ToLower(strings.TrimSpace(mode))
+	switch mode {
+	case "plus-lighter", "add":
+		op.Blend = ebiten.BlendLighter
+	case "screen":
+		op.Blend = blendScreen
+	default:
+		if index, ok := shaderBlendModes[mode]; ok {
+			drawBlendShader(screen, layer, op, index)
+			return
+		}
+	}
+	screen.DrawImage(layer, op)
+}
+
+func drawBlendShader(screen, layer *ebiten.Image, op *ebiten.DrawImageOptions, mode int) {
+	bounds := screen.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w <= 0 || h <= 0 {
+		return
+	}
+	// Place the layer in screen space, then blend it with a copy of the
+	// backdrop: the shader cannot read the image it draws to.
+	placed := globalImagePool.Get(w, h)
+	placed.DrawImage(layer, op)
+	backdrop := globalImagePool.Get(w, h)
+	backdrop.DrawImage(screen, nil)
+
+	sop := &ebiten.DrawRectShaderOptions{}
+	sop.Images[0] = placed.SubImage(image.Rect(0, 0, w, h)).(*ebiten.Image)
+	sop.Images[1] = backdrop.SubImage(image.Rect(0, 0, w, h)).(*ebiten.Image)
+	sop.Uniforms = map[string]any{
+		"Mode": mode,
+	}
+	screen.DrawRectShader(w, h, getBlendShader(), sop)
+
+	globalImagePool.Put(placed)
+	globalImagePool.Put(backdrop)
+}
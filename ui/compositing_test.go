@@ -0,0 +1,61 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+func TestCSSMaskAndBlendModes(t *testing.T) {
+	ui := New(320, 200)
+	if err := ui.LoadCSS(`
+		#fade { width: 100px; height: 60px; background: #fff; mask: linear-gradient(to bottom, black 80%, transparent) no-repeat center / 100% 100%; }
+		#glow { width: 40px; height: 40px; background: red; mix-blend-mode: multiply; }
+		#add { width: 40px; height: 40px; background: blue; mix-blend-mode: plus-lighter; }
+		#plain { width: 10px; height: 10px; mix-blend-mode: normal; mask-image: none; }
+		#bad { mix-blend-mode: sparkle; }
+	`); err != nil {
+		t.Fatalf("LoadCSS: %v", err)
+	}
+	if err := ui.LoadLayout(`<panel id="root"><panel id="fade"/><panel id="glow"/><panel id="add"/><panel id="plain"/><panel id="bad"/></panel>`); err != nil {
+		t.Fatalf("LoadLayout: %v", err)
+	}
+
+	fade := ui.GetPanel("fade").Style()
+	if !strings.HasPrefix(fade.MaskImage, "linear-gradient(") || fade.MaskRepeat != "no-repeat" ||
+		fade.MaskPosition != "center" || fade.MaskSize != "100% 100%" {
+		t.Errorf("mask shorthand = image %q repeat %q position %q size %q", fade.MaskImage, fade.MaskRepeat, fade.MaskPosition, fade.MaskSize)
+	}
+	if len(fade.parsedMaskLayers) != 1 || fade.parsedMaskLayers[0].gradient == nil {
+		t.Errorf("gradient mask layers = %+v", fade.parsedMaskLayers)
+	}
+
+	// Only a mask or a non-normal blend mode routes drawing through an
+	// offscreen layer; an invalid blend mode is dropped.
+	for _, tc := range []struct {
+		id        string
+		blend     string
+		composite bool
+	}{
+		{"fade", "", true},
+		{"glow", "multiply", true},
+		{"add", "plus-lighter", true},
+		{"plain", "normal", false},
+		{"bad", "", false},
+	} {
+		style := ui.GetPanel(tc.id).Style()
+		if style.MixBlendMode != tc.blend || hasCompositeEffects(style) != tc.composite {
+			t.Errorf("#%s mix-blend-mode = %q composite=%v, want %q %v", tc.id, style.MixBlendMode, hasCompositeEffects(style), tc.blend, tc.composite)
+		}
+	}
+	found := false
+	for _, d := range ui.CSSDiagnostics() {
+		found = found || strings.Contains(d.Message, "sparkle")
+	}
+	if !found {
+		t.Errorf("diagnostics = %+v, want the invalid blend mode reported", ui.CSSDiagnostics())
+	}
+
+	ui.Draw(ebiten.NewImage(320, 200))
+}
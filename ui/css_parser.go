@@ -0,0 +1,1164 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// ============================================================================
+// CSS Tokenizer
+// ============================================================================
+
+// cssTokenKind identifies a CSS Syntax Level 3 token type.
+type cssTokenKind int
+
+const (
+	cssTokenEOF cssTokenKind = iota
+	cssTokenWhitespace
+	cssTokenIdent
+	cssTokenFunction
+	cssTokenAtKeyword
+	cssTokenHash
+	cssTokenString
+	cssTokenBadString
+	cssTokenURL
+	cssTokenBadURL
+	cssTokenNumber
+	cssTokenPercentage
+	cssTokenDimension
+	cssTokenDelim
+	cssTokenColon
+	cssTokenSemicolon
+	cssTokenComma
+	cssTokenOpenSquare
+	cssTokenCloseSquare
+	cssTokenOpenParen
+	cssTokenCloseParen
+	cssTokenOpenCurly
+	cssTokenCloseCurly
+	cssTokenCDO
+	cssTokenCDC
+)
+
+// cssToken is a single token with its source text and location.
+type cssToken struct {
+	Kind   cssTokenKind
+	Value  string // ident/function/at-keyword/hash name, string contents, numeric text, or delim
+	Unit   string // dimension unit
+	Raw    string // exact source text
+	Line   int
+	Column int
+}
+
+type cssTokenizer struct {
+	src    string
+	pos    int
+	line   int
+	column int
+}
+
+// tokenizeCSS splits a stylesheet into tokens. Comments are discarded;
+// everything else is kept so values can be re-serialized from their tokens.
+func tokenizeCSS(src string) []cssToken {
+	t := &cssTokenizer{src: src, line: 1, column: 1}
+	var tokens []cssToken
+	for {
+		tok := t.next()
+		if tok.Kind == cssTokenEOF {
+			return tokens
+		}
+		tokens = append(tokens, tok)
+	}
+}
+
+func (t *cssTokenizer) peek(offset int) byte {
+	if t.pos+offset < len(t.src) {
+		return t.src[t.pos+offset]
+	}
+	return 0
+}
+
+func (t *cssTokenizer) advance(n int) {
+	for i := 0; i < n && t.pos < len(t.src); i++ {
+		if t.src[t.pos] == '\n' {
+			t.line++
+			t.column = 1
+		} else if t.src[t.pos]&0xC0 != 0x80 {
+			t.column++
+		}
+		t.pos++
+	}
+}
+
+func (t *cssTokenizer) skipComments() {
+	for t.peek(0) == '/' && t.peek(1) == '*' {
+		end := strings.Index(t.src[t.pos+2:], "*/")
+		if end < 0 {
+			t.advance(len(t.src) - t.pos)
+			return
+		}
+		t.advance(end + 4)
+	}
+}
+
+func (t *cssTokenizer) next() cssToken {
+	t.skipComments()
+	if t.pos >= len(t.src) {
+		return cssToken{Kind: cssTokenEOF, Line: t.line, Column: t.column}
+	}
+	start, line, column := t.pos, t.line, t.column
+	tok := t.consume()
+	tok.Raw = t.src[start:t.pos]
+	tok.Line = line
+	tok.Column = column
+	return tok
+}
+
+func (t *cssTokenizer) consume() cssToken {
+	c := t.peek(0)
+	switch {
+	case isCSSWhitespace(c):
+		for isCSSWhitespace(t.peek(0)) {
+			t.advance(1)
+		}
+		return cssToken{Kind: cssTokenWhitespace}
+	case c == '"' || c == '\'':
+		return t.consumeString(c)
+	case c == '#':
+		if isCSSNameChar(t.peek(1)) || t.startsEscape(1) {
+			t.advance(1)
+			return cssToken{Kind: cssTokenHash, Value: t.consumeName()}
+		}
+	case c == '(':
+		t.advance(1)
+		return cssToken{Kind: cssTokenOpenParen}
+	case c == ')':
+		t.advance(1)
+		return cssToken{Kind: cssTokenCloseParen}
+	case c == '[':
+		t.advance(1)
+		return cssToken{Kind: cssTokenOpenSquare}
+	case c == ']':
+		t.advance(1)
+		return cssToken{Kind: cssTokenCloseSquare}
+	case c == '{':
+		t.advance(1)
+		return cssToken{Kind: cssTokenOpenCurly}
+	case c == '}':
+		t.advance(1)
+		return cssToken{Kind: cssTokenCloseCurly}
+	case c == ':':
+		t.advance(1)
+		return cssToken{Kind: cssTokenColon}
+	case c == ';':
+		t.advance(1)
+		return cssToken{Kind: cssTokenSemicolon}
+	case c == ',':
+		t.advance(1)
+		return cssToken{Kind: cssTokenComma}
+	case c == '<' && strings.HasPrefix(t.src[t.pos:], "<!--"):
+		t.advance(4)
+		return cssToken{Kind: cssTokenCDO}
+	case c == '-' && strings.HasPrefix(t.src[t.pos:], "-->"):
+		t.advance(3)
+		return cssToken{Kind: cssTokenCDC}
+	case c == '@':
+		if t.startsIdent(1) {
+			t.advance(1)
+			return cssToken{Kind: cssTokenAtKeyword, Value: t.consumeName()}
+		}
+	}
+	if t.startsNumber(0) {
+		return t.consumeNumeric()
+	}
+	if t.startsIdent(0) {
+		return t.consumeIdentLike()
+	}
+	_, size := utf8.DecodeRuneInString(t.src[t.pos:])
+	value := t.src[t.pos : t.pos+size]
+	t.advance(size)
+	return cssToken{Kind: cssTokenDelim, Value: value}
+}
+
+func (t *cssTokenizer) consumeString(quote byte) cssToken {
+	t.advance(1)
+	var sb strings.Builder
+	for t.pos < len(t.src) {
+		c := t.peek(0)
+		switch {
+		case c == quote:
+			t.advance(1)
+			return cssToken{Kind: cssTokenString, Value: sb.String()}
+		case c == '\n':
+			return cssToken{Kind: cssTokenBadString, Value: sb.String()}
+		case c == '\\':
+			if t.peek(1) == '\n' {
+				t.advance(2)
+				continue
+			}
+			if t.pos+1 >= len(t.src) {
+				t.advance(1)
+				continue
+			}
+			sb.WriteString(t.consumeEscape())
+		default:
+			sb.WriteByte(c)
+			t.advance(1)
+		}
+	}
+	return cssToken{Kind: cssTokenString, Value: sb.String()}
+}
+
+func (t *cssTokenizer) consumeNumeric() cssToken {
+	start := t.pos
+	if c := t.peek(0); c == '+' || c == '-' {
+		t.advance(1)
+	}
+	for isCSSDigit(t.peek(0)) {
+		t.advance(1)
+	}
+	if t.peek(0) == '.' && isCSSDigit(t.peek(1)) {
+		t.advance(1)
+		for isCSSDigit(t.peek(0)) {
+			t.advance(1)
+		}
+	}
+	if c := t.peek(0); c == 'e' || c == 'E' {
+		if isCSSDigit(t.peek(1)) || ((t.peek(1) == '+' || t.peek(1) == '-') && isCSSDigit(t.peek(2))) {
+			t.advance(2)
+			for isCSSDigit(t.peek(0)) {
+				t.advance(1)
+			}
+		}
+	}
+	number := t.src[start:t.pos]
+	if t.startsIdent(0) {
+		return cssToken{Kind: cssTokenDimension, Value: number, Unit: t.consumeName()}
+	}
+	if t.peek(0) == '%' {
+		t.advance(1)
+		return cssToken{Kind: cssTokenPercentage, Value: number}
+	}
+	return cssToken{Kind: cssTokenNumber, Value: number}
+}
+
+func (t *cssTokenizer) consumeIdentLike() cssToken {
+	name := t.consumeName()
+	if t.peek(0) != '(' {
+		return cssToken{Kind: cssTokenIdent, Value: name}
+	}
+	t.advance(1)
+	if strings.EqualFold(name, "url") {
+		offset := 0
+		for isCSSWhitespace(t.peek(offset)) {
+			offset++
+		}
+		if c := t.peek(offset); c != '"' && c != '\'' {
+			return t.consumeURL()
+		}
+	}
+	return cssToken{Kind: cssTokenFunction, Value: name}
+}
+
+func (t *cssTokenizer) consumeURL() cssToken {
+	for isCSSWhitespace(t.peek(0)) {
+		t.advance(1)
+	}
+	var sb strings.Builder
+	for t.pos < len(t.src) {
+		c := t.peek(0)
+		switch {
+		case c == ')':
+			t.advance(1)
+			return cssToken{Kind: cssTokenURL, Value: sb.String()}
+		case isCSSWhitespace(c):
+			for isCSSWhitespace(t.peek(0)) {
+				t.advance(1)
+			}
+			if t.peek(0) == ')' || t.pos >= len(t.src) {
+				continue
+			}
+			return t.consumeBadURL()
+		case c == '"' || c == '\'' || c == '(':
+			return t.consumeBadURL()
+		case c == '\\':
+			sb.WriteString(t.consumeEscape())
+		default:
+			sb.WriteByte(c)
+			t.advance(1)
+		}
+	}
+	return cssToken{Kind: cssTokenURL, Value: sb.String()}
+}
+
+func (t *cssTokenizer) consumeBadURL() cssToken {
+	for t.pos < len(t.src) && t.peek(0) != ')' {
+		t.advance(1)
+	}
+	t.advance(1)
+	return cssToken{Kind: cssTokenBadURL}
+}
+
+func (t *cssTokenizer) consumeName() string {
+	var sb strings.Builder
+	for t.pos < len(t.src) {
+		c := t.peek(0)
+		if isCSSNameChar(c) {
+			sb.WriteByte(c)
+			t.advance(1)
+			continue
+		}
+		if t.startsEscape(0) {
+			sb.WriteString(t.consumeEscape())
+			continue
+		}
+		break
+	}
+	return sb.String()
+}
+
+func (t *cssTokenizer) consumeEscape() string {
+	t.advance(1) // backslash
+	hex := 0
+	for hex < 6 && isCSSHexDigit(t.peek(hex)) {
+		hex++
+	}
+	if hex == 0 {
+		_, size := utf8.DecodeRuneInString(t.src[t.pos:])
+		value := t.src[t.pos : t.pos+size]
+		t.advance(size)
+		return value
+	}
+	var code rune
+	fmt.Sscanf(t.src[t.pos:t.pos+hex], "%x", &code)
+	t.advance(hex)
+	if isCSSWhitespace(t.peek(0)) {
+		t.advance(1)
+	}
+	if code == 0 || code > utf8.MaxRune || (code >= 0xD800 && code <= 0xDFFF) {
+		code = utf8.RuneError
+	}
+	return string(code)
+}
+
+func (t *cssTokenizer) startsEscape(offset int) bool {
+	return t.peek(offset) == '\\' && t.peek(offset+1) != '\n' && t.pos+offset+1 < len(t.src)
+}
+
+func (t *cssTokenizer) startsIdent(offset int) bool {
+	c := t.peek(offset)
+	switch {
+	case c == '-':
+		next := t.peek(offset + 1)
+		return isCSSNameStart(next) || next == '-' || t.startsEscape(offset+1)
+	case isCSSNameStart(c):
+		return true
+	case c == '\\':
+		return t.startsEscape(offset)
+	}
+	return false
+}
+
+func (t *cssTokenizer) startsNumber(offset int) bool {
+	c := t.peek(offset)
+	switch {
+	case c == '+' || c == '-':
+		next := t.peek(offset + 1)
+		return isCSSDigit(next) || (next == '.' && isCSSDigit(t.peek(offset+2)))
+	case c == '.':
+		return isCSSDigit(t.peek(offset + 1))
+	}
+	return isCSSDigit(c)
+}
+
+func isCSSWhitespace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+func isCSSDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isCSSHexDigit(c byte) bool {
+	return isCSSDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+func isCSSNameStart(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_' || c >= 0x80
+}
+
+func isCSSNameChar(c byte) bool {
+	return isCSSNameStart(c) || isCSSDigit(c) || c == '-'
+}
+
+// ============================================================================
+// CSS Diagnostics
+// ============================================================================
+
+// CSSSeverity classifies a stylesheet diagnostic.
+type CSSSeverity int
+
+const (
+	CSSWarning CSSSeverity = iota
+	CSSError
+)
+
+// String returns "warning" or "error".
+func (s CSSSeverity) String() string {
+	if s == CSSError {
+		return "error"
+	}
+	return "warning"
+}
+
+// CSSDiagnostic describes a problem found while parsing or applying a
+// stylesheet, located by file, line and column.
+type CSSDiagnostic struct {
+	Severity CSSSeverity
+	File     string
+	Line     int
+	Column   int
+	Message  string
+}
+
+// String formats the diagnostic as "file:line:column: severity: message".
+func (d CSSDiagnostic) String() string {
+	file := d.File
+	if file == "" {
+		file = "<css>"
+	}
+	return fmt.Sprintf("%s:%d:%d: %s: %s", file, d.Line, d.Column, d.Severity, d.Message)
+}
+
+// CSSParseError is returned when a stylesheet contains error-level
+// diagnostics. Warnings are only available through the diagnostics accessors.
+type CSSParseError struct {
+	Diagnostics []CSSDiagnostic
+}
+
+// Error reports the first error and how many others followed it.
+func (e *CSSParseError) Error() string {
+	var first *CSSDiagnostic
+	count := 0
+	for i := range e.Diagnostics {
+		if e.Diagnostics[i].Severity != CSSError {
+			continue
+		}
+		if first == nil {
+			first = &e.Diagnostics[i]
+		}
+		count++
+	}
+	if first == nil {
+		return "failed to parse CSS"
+	}
+	if count > 1 {
+		return fmt.Sprintf("failed to parse CSS: %s (and %d more errors)", first, count-1)
+	}
+	return fmt.Sprintf("failed to parse CSS: %s", first)
+}
+
+func cssDiagnosticsError(diagnostics []CSSDiagnostic) error {
+	for _, d := range diagnostics {
+		if d.Severity == CSSError {
+			return &CSSParseError{Diagnostics: diagnostics}
+		}
+	}
+	return nil
+}
+
+// ============================================================================
+// CSS AST and Parser
+// ============================================================================
+
+// cssStylesheet is the parsed form of a stylesheet.
+type cssStylesheet struct {
+	Rules []*cssRule
+}
+
+// cssRule is either a qualified (style/keyframe) rule or an at-rule.
+type cssRule struct {
+	AtKeyword    string // lower-case at-rule name, empty for qualified rules
+	Prelude      string // serialized selector list or at-rule prelude
+	PreludeToks  []cssToken
+	Declarations []cssDeclaration
+	Rules        []*cssRule // nested rules for grouping at-rules and @keyframes
+	HasBlock     bool
+	Line         int
+	Column       int
+}
+
+// cssDeclaration is a single "name: value" pair inside a block.
+type cssDeclaration struct {
+	Name      string // lower-cased unless it is a custom property
+	Value     string
+	Tokens    []cssToken
+	Important bool
+	Line      int
+	Column    int
+}
+
+// cssReporter appends source-located diagnostics for one stylesheet.
+type cssReporter struct {
+	file        string
+	diagnostics *[]CSSDiagnostic
+}
+
+func (r cssReporter) report(severity CSSSeverity, line, column int, format string, args ...interface{}) {
+	*r.diagnostics = append(*r.diagnostics, CSSDiagnostic{
+		Severity: severity,
+		File:     r.file,
+		Line:     line,
+		Column:   column,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+type cssParser struct {
+	cssReporter
+	tokens    []cssToken
+	pos       int
+	eofLine   int
+	eofColumn int
+}
+
+// parseCSSStylesheet tokenizes and parses a stylesheet, collecting
+// source-located diagnostics for malformed input.
+func parseCSSStylesheet(src, file string) (*cssStylesheet, []CSSDiagnostic) {
+	var diagnostics []CSSDiagnostic
+	tokens := tokenizeCSS(src)
+	eof := &cssTokenizer{src: src, line: 1, column: 1}
+	eof.advance(len(src))
+	p := &cssParser{
+		cssReporter: cssReporter{file: file, diagnostics: &diagnostics},
+		tokens:      tokens,
+		eofLine:     eof.line,
+		eofColumn:   eof.column,
+	}
+	sheet := &cssStylesheet{Rules: p.parseRuleList(true)}
+	return sheet, diagnostics
+}
+
+// substituteCSSValues rewrites every declaration value containing var()
+// through substitute. It runs after parsing, so diagnostics keep the line and
+// column of the original source rather than of the substituted text.
+func substituteCSSValues(rules []*cssRule, substitute func(string) string) {
+	for _, rule := range rules {
+		for i := range rule.Declarations {
+			decl := &rule.Declarations[i]
+			if !strings.Contains(decl.Value, "var(") {
+				continue
+			}
+			decl.Value = substitute(decl.Value)
+			decl.Tokens = trimCSSWhitespace(tokenizeCSS(decl.Value))
+		}
+		substituteCSSValues(rule.Rules, substitute)
+	}
+}
+
+// cssGroupingAtRules contain nested rule lists rather than declarations.
+var cssGroupingAtRules = map[string]bool{
+	"media":     true,
+	"container": true,
+	"keyframes": true,
+}
+
+func (p *cssParser) sub(tokens []cssToken) *cssParser {
+	return &cssParser{cssReporter: p.cssReporter, tokens: tokens, eofLine: p.eofLine, eofColumn: p.eofColumn}
+}
+
+func (p *cssParser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *cssParser) current() cssToken {
+	if p.done() {
+		return cssToken{Kind: cssTokenEOF, Line: p.eofLine, Column: p.eofColumn}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *cssParser) parseRuleList(topLevel bool) []*cssRule {
+	var rules []*cssRule
+	for !p.done() {
+		tok := p.current()
+		switch tok.Kind {
+		case cssTokenWhitespace, cssTokenSemicolon:
+			p.pos++
+		case cssTokenCDO, cssTokenCDC:
+			p.pos++
+			if !topLevel {
+				p.report(CSSWarning, tok.Line, tok.Column, "unexpected %q", tok.Raw)
+			}
+		case cssTokenCloseCurly:
+			p.report(CSSError, tok.Line, tok.Column, "unexpected '}'")
+			p.pos++
+		case cssTokenAtKeyword:
+			if rule := p.parseAtRule(); rule != nil {
+				rules = append(rules, rule)
+			}
+		default:
+			if rule := p.parseQualifiedRule(); rule != nil {
+				rules = append(rules, rule)
+			}
+		}
+	}
+	return rules
+}
+
+func (p *cssParser) parseAtRule() *cssRule {
+	start := p.current()
+	p.pos++
+	rule := &cssRule{AtKeyword: strings.ToLower(start.Value), Line: start.Line, Column: start.Column}
+	prelude := p.consumePrelude(true)
+	rule.PreludeToks = prelude
+	rule.Prelude = serializeCSSTokens(prelude)
+	if p.done() {
+		p.report(CSSError, start.Line, start.Column, "@%s: unexpected end of stylesheet", rule.AtKeyword)
+		return nil
+	}
+	if p.current().Kind == cssTokenSemicolon {
+		p.pos++
+		return rule
+	}
+	block, ok := p.consumeBlock()
+	if !ok {
+		p.report(CSSError, start.Line, start.Column, "@%s: unclosed block", rule.AtKeyword)
+		return nil
+	}
+	rule.HasBlock = true
+	if cssGroupingAtRules[rule.AtKeyword] {
+		rule.Rules = p.sub(block).parseRuleList(false)
+	} else {
+		rule.Declarations = p.sub(block).parseDeclarationList()
+	}
+	return rule
+}
+
+func (p *cssParser) parseQualifiedRule() *cssRule {
+	start := p.current()
+	prelude := p.consumePrelude(false)
+	if p.done() {
+		p.report(CSSError, start.Line, start.Column, "missing block after %q", serializeCSSTokens(prelude))
+		return nil
+	}
+	rule := &cssRule{
+		Prelude:     serializeCSSTokens(prelude),
+		PreludeToks: prelude,
+		Line:        start.Line,
+		Column:      start.Column,
+	}
+	block, ok := p.consumeBlock()
+	if !ok {
+		if rule.Prelude == "" {
+			p.report(CSSError, start.Line, start.Column, "unclosed block")
+		} else {
+			p.report(CSSError, start.Line, start.Column, "unclosed block for %q", rule.Prelude)
+		}
+		return nil
+	}
+	if rule.Prelude == "" {
+		p.report(CSSError, start.Line, start.Column, "empty selector")
+		return nil
+	}
+	rule.HasBlock = true
+	rule.Declarations = p.sub(block).parseDeclarationList()
+	return rule
+}
+
+// consumePrelude collects component values up to the opening '{' (or ';'
+// for at-rules), leaving the parser positioned on that token.
+func (p *cssParser) consumePrelude(allowSemicolon bool) []cssToken {
+	start := p.pos
+	depth := 0
+	for !p.done() {
+		tok := p.current()
+		switch tok.Kind {
+		case cssTokenOpenParen, cssTokenFunction, cssTokenOpenSquare:
+			depth++
+		case cssTokenCloseParen, cssTokenCloseSquare:
+			if depth > 0 {
+				depth--
+			}
+		case cssTokenOpenCurly:
+			if depth == 0 {
+				return p.tokens[start:p.pos]
+			}
+		case cssTokenSemicolon:
+			if depth == 0 && allowSemicolon {
+				return p.tokens[start:p.pos]
+			}
+		case cssTokenCloseCurly:
+			if depth == 0 && !allowSemicolon {
+				p.report(CSSError, tok.Line, tok.Column, "unexpected '}' in selector")
+			}
+		}
+		p.pos++
+	}
+	return p.tokens[start:p.pos]
+}
+
+// consumeBlock consumes a {}-block starting at the current '{' token and
+// returns its inner tokens.
+func (p *cssParser) consumeBlock() ([]cssToken, bool) {
+	p.pos++ // '{'
+	start := p.pos
+	depth := 0
+	for !p.done() {
+		switch p.current().Kind {
+		case cssTokenOpenCurly:
+			depth++
+		case cssTokenCloseCurly:
+			if depth == 0 {
+				inner := p.tokens[start:p.pos]
+				p.pos++
+				return inner, true
+			}
+			depth--
+		}
+		p.pos++
+	}
+	return nil, false
+}
+
+func (p *cssParser) parseDeclarationList() []cssDeclaration {
+	var declarations []cssDeclaration
+	for !p.done() {
+		tok := p.current()
+		switch tok.Kind {
+		case cssTokenWhitespace, cssTokenSemicolon:
+			p.pos++
+		case cssTokenAtKeyword:
+			p.report(CSSWarning, tok.Line, tok.Column, "nested @%s is not supported", strings.ToLower(tok.Value))
+			p.skipDeclaration()
+		case cssTokenIdent:
+			if decl, ok := p.parseDeclaration(); ok {
+				declarations = append(declarations, decl)
+			}
+		default:
+			p.report(CSSWarning, tok.Line, tok.Column, "invalid declaration starting with %q", tok.Raw)
+			p.skipDeclaration()
+		}
+	}
+	return declarations
+}
+
+func (p *cssParser) parseDeclaration() (cssDeclaration, bool) {
+	nameTok := p.current()
+	start := p.pos
+	terminated := p.skipDeclaration()
+	end := p.pos
+	if terminated {
+		end--
+	}
+	tokens := trimCSSWhitespace(p.tokens[start+1 : end])
+	if len(tokens) == 0 || tokens[0].Kind != cssTokenColon {
+		p.report(CSSWarning, nameTok.Line, nameTok.Column, "expected ':' after %q", nameTok.Value)
+		return cssDeclaration{}, false
+	}
+	value := trimCSSWhitespace(tokens[1:])
+	important := false
+	if n := len(value); n >= 2 && value[n-1].Kind == cssTokenIdent && strings.EqualFold(value[n-1].Value, "important") {
+		bang := trimCSSWhitespace(value[:n-1])
+		if m := len(bang); m > 0 && bang[m-1].Kind == cssTokenDelim && bang[m-1].Value == "!" {
+			important = true
+			value = trimCSSWhitespace(bang[:m-1])
+		}
+	}
+	name := nameTok.Value
+	if !strings.HasPrefix(name, "--") {
+		name = strings.ToLower(name)
+	}
+	if len(value) == 0 && !strings.HasPrefix(name, "--") {
+		p.report(CSSWarning, nameTok.Line, nameTok.Column, "empty value for %q", name)
+		return cssDeclaration{}, false
+	}
+	for _, tok := range value {
+		if tok.Kind == cssTokenBadString || tok.Kind == cssTokenBadURL {
+			p.report(CSSWarning, tok.Line, tok.Column, "malformed %s in value for %q", cssTokenDescription(tok.Kind), name)
+			return cssDeclaration{}, false
+		}
+	}
+	return cssDeclaration{
+		Name:      name,
+		Value:     serializeCSSTokens(value),
+		Tokens:    value,
+		Important: important,
+		Line:      nameTok.Line,
+		Column:    nameTok.Column,
+	}, true
+}
+
+// skipDeclaration advances past the next top-level ';' (inclusive), keeping
+// nested blocks and functions intact. It reports whether a ';' was consumed.
+func (p *cssParser) skipDeclaration() bool {
+	depth := 0
+	for !p.done() {
+		switch p.current().Kind {
+		case cssTokenOpenParen, cssTokenFunction, cssTokenOpenSquare, cssTokenOpenCurly:
+			depth++
+		case cssTokenCloseParen, cssTokenCloseSquare, cssTokenCloseCurly:
+			if depth > 0 {
+				depth--
+			}
+		case cssTokenSemicolon:
+			if depth == 0 {
+				p.pos++
+				return true
+			}
+		}
+		p.pos++
+	}
+	return false
+}
+
+func cssTokenDescription(kind cssTokenKind) string {
+	switch kind {
+	case cssTokenBadString:
+		return "string"
+	case cssTokenBadURL:
+		return "url"
+	default:
+		return "token"
+	}
+}
+
+func trimCSSWhitespace(tokens []cssToken) []cssToken {
+	for len(tokens) > 0 && tokens[0].Kind == cssTokenWhitespace {
+		tokens = tokens[1:]
+	}
+	for len(tokens) > 0 && tokens[len(tokens)-1].Kind == cssTokenWhitespace {
+		tokens = tokens[:len(tokens)-1]
+	}
+	return tokens
+}
+
+// serializeCSSTokens rebuilds source text from tokens, collapsing whitespace
+// runs (and therefore dropped comments) to single spaces.
+func serializeCSSTokens(tokens []cssToken) string {
+	tokens = trimCSSWhitespace(tokens)
+	var sb strings.Builder
+	for _, tok := range tokens {
+		if tok.Kind == cssTokenWhitespace {
+			sb.WriteByte(' ')
+			continue
+		}
+		sb.WriteString(tok.Raw)
+	}
+	return sb.String()
+}
+
+// splitCSSTokensOnComma splits top-level component values on commas, used for
+// selector lists and comma-separated preludes.
+func splitCSSTokensOnComma(tokens []cssToken) [][]cssToken {
+	return splitCSSTokensOn(tokens, cssTokenComma, "")
+}
+
+// splitCSSTokensOnSlash splits a value on top-level '/' delimiters, as in
+// border-radius: 4px 8px / 2px.
+func splitCSSTokensOnSlash(tokens []cssToken) [][]cssToken {
+	return splitCSSTokensOn(tokens, cssTokenDelim, "/")
+}
+
+// splitCSSTokensOn splits a value on top-level separator tokens of a kind
+// (and, for delimiters, value), trimming whitespace around each part.
+func splitCSSTokensOn(tokens []cssToken, kind cssTokenKind, value string) [][]cssToken {
+	var parts [][]cssToken
+	depth := 0
+	start := 0
+	for i, tok := range tokens {
+		switch tok.Kind {
+		case cssTokenOpenParen, cssTokenFunction, cssTokenOpenSquare:
+			depth++
+		case cssTokenCloseParen, cssTokenCloseSquare:
+			if depth > 0 {
+				depth--
+			}
+		case kind:
+			if depth == 0 && tok.Value == value {
+				parts = append(parts, trimCSSWhitespace(tokens[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, trimCSSWhitespace(tokens[start:]))
+}
+
+// splitCSSComponents splits a value into its whitespace-separated component
+// values, keeping function calls such as rgb(0, 0, 0) whole.
+func splitCSSComponents(value string) []string {
+	tokens := tokenizeCSS(value)
+	var components []string
+	for i := 0; i < len(tokens); i++ {
+		switch tokens[i].Kind {
+		case cssTokenWhitespace:
+			continue
+		case cssTokenFunction:
+			_, end := cssFunctionArguments(tokens, i+1)
+			components = append(components, serializeCSSTokens(tokens[i:end+1]))
+			i = end
+		default:
+			components = append(components, tokens[i].Raw)
+		}
+	}
+	return components
+}
+
+// ============================================================================
+// Declaration value validation
+// ============================================================================
+
+var cssLengthUnits = map[string]bool{
+	"px": true, "em": true, "rem": true, "%": true, "vw": true, "vh": true,
+	"vmin": true, "vmax": true, "pt": true, "ch": true, "ex": true,
+}
+
+var cssLengthProperties = map[string]bool{
+	"width": true, "height": true, "min-width": true, "min-height": true,
+	"max-width": true, "max-height": true, "top": true, "right": true,
+	"bottom": true, "left": true, "border-width": true, "font-size": true,
+	"line-height": true, "letter-spacing": true, "outline-offset": true,
+	"border-top-width": true, "border-right-width": true, "border-bottom-width": true, "border-left-width": true,
+	"padding-inline-start": true, "padding-inline-end": true,
+	"text-decoration-thickness": true,
+}
+
+var cssLengthListProperties = map[string]bool{
+	"padding": true, "margin": true, "gap": true, "border-radius": true, "padding-inline": true,
+}
+
+var cssNumberProperties = map[string]bool{
+	"opacity": true, "flex-grow": true, "flex-shrink": true, "z-index": true,
+	"tab-size": true,
+}
+
+// cssPercentNumberProperties accept a percentage wherever they take a number.
+var cssPercentNumberProperties = map[string]bool{
+	"opacity": true,
+}
+
+// cssValueKeywords lists the keywords a length or number property accepts
+// besides its numeric forms.
+var cssValueKeywords = map[string][]string{
+	"font-size":                 cssFontSizeKeywords,
+	"border-width":              cssBorderWidthKeywords,
+	"border-top-width":          cssBorderWidthKeywords,
+	"border-right-width":        cssBorderWidthKeywords,
+	"border-bottom-width":       cssBorderWidthKeywords,
+	"border-left-width":         cssBorderWidthKeywords,
+	"text-decoration-thickness": {"from-font"},
+	"z-index":                   {"auto"},
+}
+
+var cssColorProperties = map[string]bool{
+	"color": true, "background-color": true, "border-color": true, "text-decoration-color": true,
+	"border-top-color": true, "border-right-color": true, "border-bottom-color": true, "border-left-color": true,
+}
+
+var cssKeywordProperties = map[string][]string{
+	"display":             {"flex", "block", "inline", "inline-block", "inline-flex", "grid", "none", "contents"},
+	"flex-direction":      {"row", "column", "row-reverse", "column-reverse"},
+	"justify-content":     {"start", "end", "flex-start", "flex-end", "center", "space-between", "space-around", "space-evenly", "stretch"},
+	"align-items":         {"start", "end", "flex-start", "flex-end", "center", "stretch", "baseline"},
+	"flex-wrap":           {"nowrap", "wrap", "wrap-reverse"},
+	"box-sizing":          {"content-box", "border-box"},
+	"position":            {"static", "relative", "absolute", "fixed", "sticky"},
+	"overflow":            {"visible", "hidden", "scroll", "auto", "clip"},
+	"overflow-x":          {"visible", "hidden", "scroll", "auto", "clip"},
+	"overflow-y":          {"visible", "hidden", "scroll", "auto", "clip"},
+	"visibility":          {"visible", "hidden", "collapse"},
+	"container-type":      {"normal", "size", "inline-size"},
+	"list-style-position": {"inside", "outside"},
+	"mix-blend-mode":      cssBlendModes,
+	"transform-style":     {"flat", "preserve-3d"},
+	"pointer-events":      {"auto", "none"},
+	"user-select":         {"auto", "none", "text"},
+	"-webkit-user-select": {"auto", "none", "text"},
+	"direction":           {"ltr", "rtl"},
+	"text-align":          {"left", "center", "right", "start", "end"},
+	"text-overflow":       {"clip", "ellipsis"},
+	"text-transform":      {"none", "uppercase", "lowercase", "capitalize"},
+	"white-space":         {"normal", "nowrap", "pre", "pre-wrap", "pre-line"},
+	"word-break":          {"normal", "break-all", "keep-all", "break-word"},
+	"overflow-wrap":       {"normal", "anywhere", "break-word"},
+	"word-wrap":           {"normal", "anywhere", "break-word"},
+	"backface-visibility": {"visible", "hidden"},
+	"border-top-style":    cssBorderLineStyles,
+	"border-right-style":  cssBorderLineStyles,
+	"border-bottom-style": cssBorderLineStyles,
+	"border-left-style":   cssBorderLineStyles,
+}
+
+// cssKeywordListProperties accept a short list (up to four) of keywords, such
+// as one per box side or one per axis.
+var cssKeywordListProperties = map[string][]string{
+	"border-style":        cssBorderLineStyles,
+	"background-repeat":   {"repeat", "repeat-x", "repeat-y", "no-repeat", "round", "space"},
+	"mask-repeat":         {"repeat", "repeat-x", "repeat-y", "no-repeat", "round", "space"},
+	"border-image-repeat": {"stretch", "repeat", "round", "space"},
+}
+
+// cssLayeredProperties take one value per background or mask layer,
+// separated by commas.
+var cssLayeredProperties = map[string]bool{
+	"background-repeat": true,
+	"mask-repeat":       true,
+}
+
+// cssDeclarationValueProblem describes why a declaration's value cannot be
+// applied, or returns "" when the value is acceptable. Properties without a
+// known grammar are accepted as-is.
+func cssDeclarationValueProblem(decl cssDeclaration) string {
+	if cssValueIsDeferred(decl.Tokens) {
+		return ""
+	}
+	values := cssValueComponents(decl.Tokens)
+	switch {
+	case cssLengthProperties[decl.Name]:
+		if len(values) != 1 || !cssTokenIsLength(values[0]) && !cssTokenIsKeyword(values[0], cssValueKeywords[decl.Name]) {
+			return fmt.Sprintf("invalid length %q for %q", decl.Value, decl.Name)
+		}
+	case cssLengthListProperties[decl.Name]:
+		groups := [][]cssToken{decl.Tokens}
+		if decl.Name == "border-radius" {
+			// Horizontal radii, then optional vertical radii after a '/'.
+			groups = splitCSSTokensOnSlash(decl.Tokens)
+		}
+		if len(groups) > 2 {
+			return fmt.Sprintf("invalid value %q for %q", decl.Value, decl.Name)
+		}
+		for _, group := range groups {
+			values := cssValueComponents(group)
+			if len(values) == 0 || len(values) > 4 {
+				return fmt.Sprintf("invalid value %q for %q", decl.Value, decl.Name)
+			}
+			for _, tok := range values {
+				if !cssTokenIsLength(tok) {
+					return fmt.Sprintf("invalid length %q for %q", tok.Raw, decl.Name)
+				}
+			}
+		}
+	case cssNumberProperties[decl.Name]:
+		if len(values) != 1 || !cssTokenIsNumber(values[0], cssPercentNumberProperties[decl.Name]) && !cssTokenIsKeyword(values[0], cssValueKeywords[decl.Name]) {
+			return fmt.Sprintf("invalid number %q for %q", decl.Value, decl.Name)
+		}
+	case cssColorProperties[decl.Name]:
+		lower := strings.ToLower(decl.Value)
+		if lower != "currentcolor" && parseColor(lower) == nil {
+			return fmt.Sprintf("invalid color %q for %q", decl.Value, decl.Name)
+		}
+	case cssKeywordListProperties[decl.Name] != nil:
+		groups := [][]cssToken{decl.Tokens}
+		if cssLayeredProperties[decl.Name] {
+			groups = splitCSSTokensOnComma(decl.Tokens)
+		}
+		for _, group := range groups {
+			values := cssValueComponents(group)
+			if len(values) == 0 || len(values) > 4 {
+				return fmt.Sprintf("invalid value %q for %q", decl.Value, decl.Name)
+			}
+			for _, tok := range values {
+				if tok.Kind != cssTokenIdent || !cssKeywordAllowed(cssKeywordListProperties[decl.Name], tok.Value) {
+					return fmt.Sprintf("invalid value %q for %q", tok.Raw, decl.Name)
+				}
+			}
+		}
+	default:
+		keywords, ok := cssKeywordProperties[decl.Name]
+		if !ok {
+			return ""
+		}
+		if cssKeywordAllowed(keywords, decl.Value) {
+			return ""
+		}
+		return fmt.Sprintf("invalid value %q for %q", decl.Value, decl.Name)
+	}
+	return ""
+}
+
+func cssKeywordAllowed(keywords []string, value string) bool {
+	value = strings.ToLower(value)
+	for _, keyword := range keywords {
+		if value == keyword {
+			return true
+		}
+	}
+	return false
+}
+
+// cssTokenIsKeyword reports whether a token is one of the given keywords.
+func cssTokenIsKeyword(tok cssToken, keywords []string) bool {
+	return tok.Kind == cssTokenIdent && cssKeywordAllowed(keywords, tok.Value)
+}
+
+// cssTokenIsNumber reports whether a token is a number, or a percentage when
+// the property allows one.
+func cssTokenIsNumber(tok cssToken, percent bool) bool {
+	return tok.Kind == cssTokenNumber || percent && tok.Kind == cssTokenPercentage
+}
+
+// cssValueIsDeferred reports whether a value can only be checked after
+// substitution: var() references and the CSS-wide keywords.
+func cssValueIsDeferred(tokens []cssToken) bool {
+	for _, tok := range tokens {
+		if tok.Kind == cssTokenFunction && strings.EqualFold(tok.Value, "var") {
+			return true
+		}
+	}
+	if len(tokens) == 1 && tokens[0].Kind == cssTokenIdent {
+		switch strings.ToLower(tokens[0].Value) {
+		case "inherit", "initial", "unset", "revert":
+			return true
+		}
+	}
+	return false
+}
+
+// cssValueComponents returns the top-level component values of a value,
+// collapsing each function call into its function token.
+func cssValueComponents(tokens []cssToken) []cssToken {
+	var components []cssToken
+	depth := 0
+	for _, tok := range tokens {
+		switch tok.Kind {
+		case cssTokenFunction, cssTokenOpenParen, cssTokenOpenSquare:
+			if depth == 0 {
+				components = append(components, tok)
+			}
+			depth++
+			continue
+		case cssTokenCloseParen, cssTokenCloseSquare:
+			if depth > 0 {
+				depth--
+			}
+			continue
+		case cssTokenWhitespace:
+			continue
+		}
+		if depth == 0 {
+			components = append(components, tok)
+		}
+	}
+	return components
+}
+
+func cssTokenIsLength(tok cssToken) bool {
+	switch tok.Kind {
+	case cssTokenNumber, cssTokenPercentage:
+		return true
+	case cssTokenDimension:
+		return cssLengthUnits[strings.ToLower(tok.Unit)]
+	case cssTokenIdent:
+		switch strings.ToLower(tok.Value) {
+		case "auto", "none", "normal":
+			return true
+		}
+	case cssTokenFunction:
+		switch strings.ToLower(tok.Value) {
+		case "calc", "min", "max", "clamp":
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+package ui
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestCSSWarningsReportLocationsAndKeepValidDeclarations(t *testing.T) {
+	engine := NewStyleEngine()
+	err := engine.LoadCSS(".card {\n  width: 40px;\n  colr: red;\n  height: tall;\n  opacity: 0.5;\n}\n")
+	if err != nil {
+		t.Fatalf("warnings should not fail LoadCSS: %v", err)
+	}
+	diagnostics := engine.Diagnostics()
+	if len(diagnostics) != 2 {
+		t.Fatalf("diagnostics = %v, want 2 warnings", diagnostics)
+	}
+	for i, want := range []struct {
+		line, column int
+		mentions     string
+	}{
+		{3, 3, "colr"},
+		{4, 3, "tall"},
+	} {
+		d := diagnostics[i]
+		if d.Severity != CSSWarning || d.Line != want.line || d.Column != want.column || !strings.Contains(d.Message, want.mentions) {
+			t.Errorf("diagnostic %d = %+v, want a warning about %q at %d:%d", i, d, want.mentions, want.line, want.column)
+		}
+	}
+	if style := engine.GetStyle(".card"); style == nil || style.Width != 40 || style.HeightSet || style.Opacity != 0.5 {
+		t.Fatalf("valid declarations should still apply around invalid ones: %+v", style)
+	}
+}
+
+func TestCSSDiagnosticsUseSourcePositionsAroundVariables(t *testing.T) {
+	ui := New(200, 100)
+	ui.SetVariable("--accent", "rgba(255, 0, 0, 0.5)")
+	if err := ui.LoadCSS(".a { color: var(--accent); colr: red; }"); err != nil {
+		t.Fatalf("LoadCSS: %v", err)
+	}
+	diagnostics := ui.CSSDiagnostics()
+	if len(diagnostics) != 1 || diagnostics[0].Line != 1 || diagnostics[0].Column != 28 {
+		t.Fatalf("diagnostics = %+v, want one warning at 1:28 in the unsubstituted source", diagnostics)
+	}
+	if style := ui.styleEngine.GetStyle(".a"); style == nil || style.Color != "rgba(255, 0, 0, 0.5)" {
+		t.Fatalf("var() should still be substituted, style = %+v", style)
+	}
+}
+
+func TestCSSKeywordAndPercentValuesApply(t *testing.T) {
+	for _, tc := range []struct {
+		decl  string
+		check func(*Style) bool
+	}{
+		{"z-index: auto", func(s *Style) bool { return s.ZIndexSet && s.ZIndex == 0 }},
+		{"opacity: 50%", func(s *Style) bool { return s.OpacitySet && s.Opacity == 0.5 }},
+		{"font-size: small", func(s *Style) bool { return s.FontSize == 13 }},
+		{"font-size: larger", func(s *Style) bool { return s.FontSizeSet && s.fontSizeScale == cssFontSizeScaleStep }},
+		{"border-width: thick", func(s *Style) bool { return s.BorderWidthSet && s.BorderWidth == 5 }},
+		{"border-left-width: thin", func(s *Style) bool { return s.BorderLeftWidthSet && s.BorderLeftWidth == 1 }},
+		{"border: medium solid red", func(s *Style) bool { return s.BorderWidth == 3 && s.BorderStyle == "solid" }},
+		{"text-decoration-thickness: from-font", func(s *Style) bool { return true }},
+		{"border-radius: 8px 4px / 2px 6px", func(s *Style) bool { return s.BorderRadiusSet && s.BorderRadius == 8 }},
+	} {
+		engine := NewStyleEngine()
+		if err := engine.LoadCSS(".x { " + tc.decl + "; }"); err != nil {
+			t.Errorf("%s: LoadCSS: %v", tc.decl, err)
+			continue
+		}
+		if diagnostics := engine.Diagnostics(); len(diagnostics) != 0 {
+			t.Errorf("%s: diagnostics = %v, want none", tc.decl, diagnostics)
+		}
+		if style := engine.GetStyle(".x"); style == nil || !tc.check(style) {
+			t.Errorf("%s: not applied, style = %+v", tc.decl, style)
+		}
+	}
+}
+
+func TestRelativeFontSizesScaleInheritedSize(t *testing.T) {
+	ui := New(200, 100)
+	if err := ui.LoadCSS(`
+		#root { font-size: 20px; }
+		.larger { font-size: larger; }
+		.smaller { font-size: smaller; }
+		.pct { font-size: 150%; }
+		.em { font-size: 0.5em; }
+	`); err != nil {
+		t.Fatalf("LoadCSS: %v", err)
+	}
+	if err := ui.LoadLayout(`<panel id="root">
+		<text id="larger" class="larger">A</text>
+		<text id="smaller" class="smaller">A</text>
+		<text id="pct" class="pct">A</text>
+		<panel id="nested" class="larger"><text id="em" class="em">A</text></panel>
+	</panel>`); err != nil {
+		t.Fatalf("LoadLayout: %v", err)
+	}
+	// Inheritance runs again on every relayout; relative sizes must not
+	// compound.
+	ui.inheritCSSProperties(ui.root, nil)
+	for id, want := range map[string]float64{
+		"larger":  24,
+		"smaller": 20 / cssFontSizeScaleStep,
+		"pct":     30,
+		"em":      12,
+	} {
+		if got := ui.GetText(id).Style().FontSize; math.Abs(got-want) > 1e-9 {
+			t.Errorf("%s font size = %v, want %v", id, got, want)
+		}
+	}
+}
+
+func TestCSSParseErrorReportsLocationAndBlocksLoad(t *testing.T) {
+	ui := New(200, 100)
+	err := ui.LoadCSS(".ok { width: 10px; }\n\n.broken {\n  width: 20px;\n")
+	var parseErr *CSSParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("err = %v, want *CSSParseError", err)
+	}
+	if len(parseErr.Diagnostics) == 0 {
+		t.Fatal("expected diagnostics on parse error")
+	}
+	if d := parseErr.Diagnostics[0]; d.Severity != CSSError || d.Line != 3 || d.Column != 1 {
+		t.Fatalf("unclosed block diagnostic = %+v, want error at 3:1", d)
+	}
+	if !strings.Contains(parseErr.Error(), "<css>:3:1: error:") {
+		t.Fatalf("error text = %q, want source location prefix", parseErr.Error())
+	}
+	if ui.styleEngine.GetStyle(".ok") != nil {
+		t.Fatal("a stylesheet with errors should not apply any rules")
+	}
+}
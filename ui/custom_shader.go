@@ -0,0 +1,325 @@
+package ui
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// ============================================================================
+// Custom Kage shaders — filter: shader(name, uniform=value, ...) and
+// background-shader
+// ============================================================================
+
+// ShaderEffect is a reference to a registered shader with the uniform values
+// written in CSS, keyed by shaderUniformKey.
+type ShaderEffect struct {
+	Name     string
+	Uniforms map[string]string
+}
+
+// customShader is a shader registered with UI.RegisterShader.
+type customShader struct {
+	shader     *ebiten.Shader
+	uniforms   map[string]kageUniform // declared uniforms by shaderUniformKey
+	registered time.Time
+}
+
+// kageUniform is a uniform variable declared in Kage source.
+type kageUniform struct {
+	name  string
+	size  int // float components, e.g. 2 for vec2 and 16 for mat4
+	isInt bool
+}
+
+// kageUniformSizes maps Kage types to their component counts.
+var kageUniformSizes = map[string]int{
+	"float": 1, "vec2": 2, "vec3": 3, "vec4": 4,
+	"int": 1, "ivec2": 2, "ivec3": 3, "ivec4": 4,
+	"mat2": 4, "mat3": 9, "mat4": 16,
+}
+
+var (
+	kageComments    = regexp.MustCompile(`(?s)/\*.*?\*/|//[^\n]*`)
+	kageVarBlock    = regexp.MustCompile(`^var\s*\($`)
+	kageUniformDecl = regexp.MustCompile(`^([A-Za-z_]\w*(?:\s*,\s*[A-Za-z_]\w*)*)\s+(?:\[\s*(\d+)\s*\])?\s*(\w+)$`)
+)
+
+// RegisterShader compiles Kage source and registers it under a name for
+// filter: shader(name, ...) and background-shader. A nil source removes the
+// shader. Besides the uniforms set in CSS, shaders receive Time (seconds
+// since registration), Origin and Size (the widget's border box in target
+// pixels) when they declare them.
+func (ui *UI) RegisterShader(name string, src []byte) error {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return fmt.Errorf("empty shader name")
+	}
+	if src == nil {
+		delete(ui.shaders, name)
+		return nil
+	}
+	shader, err := ebiten.NewShader(src)
+	if err != nil {
+		return fmt.Errorf("failed to compile shader %q: %w", name, err)
+	}
+	ui.shaders[name] = &customShader{
+		shader:     shader,
+		uniforms:   scanKageUniforms(string(src)),
+		registered: time.Now(),
+	}
+	return nil
+}
+
+// scanKageUniforms lists the top-level var declarations of Kage source.
+func scanKageUniforms(src string) map[string]kageUniform {
+	uniforms := make(map[string]kageUniform)
+	depth := 0
+	inBlock := false
+	for _, line := range strings.Split(kageComments.ReplaceAllString(src, ""), "\n") {
+		line = strings.TrimSpace(line)
+		if depth == 0 {
+			switch {
+			case inBlock:
+				if strings.HasPrefix(line, ")") {
+					inBlock = false
+				} else {
+					addKageUniforms(uniforms, line)
+				}
+			case kageVarBlock.MatchString(line):
+				inBlock = true
+			case strings.HasPrefix(line, "var "):
+				addKageUniforms(uniforms, strings.TrimSpace(line[len("var "):]))
+			}
+		}
+		depth += strings.Count(line, "{") - strings.Count(line, "}")
+	}
+	return uniforms
+}
+
+func addKageUniforms(uniforms map[string]kageUniform, decl string) {
+	m := kageUniformDecl.FindStringSubmatch(decl)
+	if m == nil {
+		return
+	}
+	size, ok := kageUniformSizes[m[3]]
+	if !ok {
+		return
+	}
+	if m[2] != "" {
+		n, _ := strconv.Atoi(m[2])
+		size *= n
+	}
+	for _, name := range strings.Split(m[1], ",") {
+		name = strings.TrimSpace(name)
+		uniforms[shaderUniformKey(name)] = kageUniform{
+			name:  name,
+			size:  size,
+			isInt: strings.HasPrefix(m[3], "i"),
+		}
+	}
+}
+
+// shaderUniformKey matches CSS and binding uniform names to Kage names
+// regardless of case, dashes and underscores: scan-lines finds ScanLines.
+func shaderUniformKey(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	return strings.NewReplacer("-", "", "_", "").Replace(name)
+}
+
+// parseShaderArgs parses the arguments of shader(name, uniform=value, ...).
+func parseShaderArgs(arg string) *ShaderEffect {
+	parts := splitCSSList(arg)
+	if len(parts) == 0 {
+		return nil
+	}
+	name := strings.ToLower(strings.Trim(parts[0], `"'`))
+	if name == "" {
+		return nil
+	}
+	effect := &ShaderEffect{Name: name}
+	for _, part := range parts[1:] {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			continue
+		}
+		if effect.Uniforms == nil {
+			effect.Uniforms = make(map[string]string)
+		}
+		effect.Uniforms[shaderUniformKey(key)] = strings.TrimSpace(value)
+	}
+	return effect
+}
+
+// ParseBackgroundShader parses a background-shader value: none, a shader
+// name, or shader(name, uniform=value, ...).
+func ParseBackgroundShader(s string) *ShaderEffect {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.EqualFold(s, "none") {
+		return nil
+	}
+	if open := strings.Index(s, "("); open > 0 && strings.EqualFold(strings.TrimSpace(s[:open]), "shader") {
+		if end := closingParen(s, open); end > open {
+			return parseShaderArgs(s[open+1 : end])
+		}
+		return nil
+	}
+	return parseShaderArgs(s)
+}
+
+// parseUniformComponents reads a uniform value: space-separated numbers,
+// where percentages are fractions and units are ignored, or a colour given
+// as non-premultiplied RGBA in 0-1.
+func parseUniformComponents(value string) []float64 {
+	fields := strings.Fields(value)
+	components := make([]float64, 0, len(fields))
+	for _, field := range fields {
+		number := strings.TrimRight(field, "abcdefghijklmnopqrstuvwxyz%")
+		f, err := strconv.ParseFloat(number, 64)
+		if err != nil || number == "" {
+			components = nil
+			break
+		}
+		if strings.HasSuffix(field, "%") {
+			f /= 100
+		}
+		components = append(components, f)
+	}
+	if components != nil {
+		return components
+	}
+	if c := parseColor(value); c != nil {
+		n := color.NRGBAModel.Convert(c).(color.NRGBA)
+		return []float64{float64(n.R) / 255, float64(n.G) / 255, float64(n.B) / 255, float64(n.A) / 255}
+	}
+	return nil
+}
+
+// convert returns components as the uniform's Go value, broadcasting a
+// single number across vectors. Colours fill vec3 with RGB and vec4 with
+// RGBA. Values that do not fit the declared type are dropped rather than
+// handed to ebiten, which panics on a size mismatch.
+func (u kageUniform) convert(components []float64) any {
+	switch {
+	case len(components) == 1 && u.size > 1:
+		single := components[0]
+		components = make([]float64, u.size)
+		for i := range components {
+			components[i] = single
+		}
+	case len(components) == 4 && u.size == 3:
+		components = components[:3]
+	case len(components) != u.size:
+		return nil
+	}
+	if u.isInt {
+		ints := make([]int32, len(components))
+		for i, c := range components {
+			ints[i] = int32(math.Round(c))
+		}
+		if u.size == 1 {
+			return ints[0]
+		}
+		return ints
+	}
+	floats := make([]float32, len(components))
+	for i, c := range components {
+		floats[i] = float32(c)
+	}
+	if u.size == 1 {
+		return floats[0]
+	}
+	return floats
+}
+
+// uniformValues builds the uniforms for one shader pass: the automatic
+// Time, Origin and Size, then the CSS values, then bound overrides.
+func (cs *customShader) uniformValues(effect ShaderEffect, overrides map[string]string, r Rect) map[string]any {
+	values := make(map[string]any)
+	set := func(key string, components []float64) {
+		if u, ok := cs.uniforms[key]; ok {
+			if v := u.convert(components); v != nil {
+				values[u.name] = v
+			}
+		}
+	}
+	set("time", []float64{time.Since(cs.registered).Seconds()})
+	set("origin", []float64{r.X, r.Y})
+	set("size", []float64{r.W, r.H})
+	for key, value := range effect.Uniforms {
+		set(key, parseUniformComponents(value))
+	}
+	for key, value := range overrides {
+		set(key, parseUniformComponents(value))
+	}
+	return values
+}
+
+// applyShaderFilters runs the filter's shader() functions over the
+// widget's offscreen composite, with the composite as imageSrc0. As with
+// applyCSSFilter, src is returned when no shader ran; otherwise the result
+// is a pooled image and src is left for the caller to release.
+func (w *BaseWidget) applyShaderFilters(src *ebiten.Image, r Rect, style *Style) *ebiten.Image {
+	f := style.parsedFilter
+	if f == nil || len(f.Shaders) == 0 {
+		return src
+	}
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= 0 || height <= 0 {
+		return src
+	}
+
+	current := src
+	for _, effect := range f.Shaders {
+		cs := w.shaders[effect.Name]
+		if cs == nil {
+			continue
+		}
+		dst := globalImagePool.Get(width, height)
+		op := &ebiten.DrawRectShaderOptions{}
+		op.Images[0] = current
+		op.Uniforms = cs.uniformValues(effect, style.ShaderUniforms, r)
+		dst.DrawRectShader(width, height, cs.shader, op)
+		if current != src {
+			globalImagePool.Put(current)
+		}
+		current = dst
+	}
+	return current
+}
+
+// drawBackgroundShader paints the background-shader over the border box,
+// above the background colour or gradient and clipped to the corner radii.
+// The shader has no source image and draws in local pixels, with dstPos
+// (0, 0) at the top-left corner of the box.
+func (w *BaseWidget) drawBackgroundShader(screen *ebiten.Image, r Rect, style *Style) {
+	effect := style.parsedBackgroundShader
+	if effect == nil {
+		return
+	}
+	cs := w.shaders[effect.Name]
+	iw, ih := int(math.Ceil(r.W)), int(math.Ceil(r.H))
+	if cs == nil || iw <= 0 || ih <= 0 {
+		return
+	}
+
+	localRect := Rect{W: r.W, H: r.H}
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(r.X, r.Y)
+	paint := func(content *ebiten.Image) {
+		content.DrawRectShader(iw, ih, cs.shader, &ebiten.DrawRectShaderOptions{
+			Uniforms: cs.uniformValues(*effect, style.ShaderUniforms, r),
+		})
+	}
+	clipComposite(screen, iw, ih, paint, func(mask *ebiten.Image) {
+		radTL, radTR, radBR, radBL := w.getCornerRadii(style)
+		DrawRoundedRectPathEx(mask, localRect, radTL, radTR, radBR, radBL, color.White)
+	}, op)
+}
@@ -0,0 +1,96 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const testGlitchShader = `//kage:unit pixels
+
+package main
+
+var Time float
+var Amount float
+var (
+	Tint   vec3
+	Offset vec2 // pixels
+)
+var Steps int
+
+func Fragment(dstPos vec4, srcPos vec2, color vec4) vec4 {
+	c := imageSrc0At(srcPos + Offset*Amount*sin(Time))
+	return vec4(c.rgb*Tint, c.a) + vec4(float(Steps)*0)
+}
+`
+
+func TestRegisterShaderReportsCompileErrors(t *testing.T) {
+	ui := New(320, 200)
+	if err := ui.RegisterShader("broken", []byte("package main\nfunc Fragment(")); err == nil {
+		t.Fatal("RegisterShader should report compile errors")
+	}
+}
+
+func TestCustomShaderFilterAndBackground(t *testing.T) {
+	ui := New(320, 200)
+	if err := ui.RegisterShader("Glitch", []byte(testGlitchShader)); err != nil {
+		t.Fatalf("RegisterShader: %v", err)
+	}
+	if err := ui.LoadCSS(`
+		#crt { width: 100px; height: 60px; background: #333; filter: blur(2px) shader(glitch, amount=0.5, tint=#ff8000, offset=2 4, steps=3); }
+		#plasma { width: 80px; height: 40px; border-radius: 8px; background-shader: shader(glitch, amount=25%); }
+	`); err != nil {
+		t.Fatalf("LoadCSS: %v", err)
+	}
+	if err := ui.LoadLayout(`<panel id="root"><panel id="crt" bind-style-uniform-amount="level"/><panel id="plasma"/></panel>`); err != nil {
+		t.Fatalf("LoadLayout: %v", err)
+	}
+
+	crt := ui.GetPanel("crt")
+	f := crt.Style().parsedFilter
+	if f == nil || f.Blur != 2 || len(f.Shaders) != 1 || f.Shaders[0].Name != "glitch" {
+		t.Fatalf("parsed filter = %+v", f)
+	}
+	if got := f.Shaders[0].Uniforms; got["amount"] != "0.5" || got["tint"] != "#ff8000" || got["offset"] != "2 4" || got["steps"] != "3" {
+		t.Fatalf("shader uniforms = %v", got)
+	}
+	cs := crt.shaders["glitch"]
+	if cs == nil {
+		t.Fatal("registered shader should be attached to widgets")
+	}
+
+	// Uniform values convert to the declared types and include the
+	// automatic time uniform.
+	values := cs.uniformValues(f.Shaders[0], crt.Style().ShaderUniforms, Rect{X: 10, Y: 20, W: 100, H: 60})
+	if values["Amount"] != float32(0.5) || values["Steps"] != int32(3) {
+		t.Errorf("scalar uniforms = %v", values)
+	}
+	if tint, ok := values["Tint"].([]float32); !ok || len(tint) != 3 || tint[0] != 1 || tint[2] != 0 {
+		t.Errorf("Tint = %#v, want the colour as RGB", values["Tint"])
+	}
+	if offset, ok := values["Offset"].([]float32); !ok || len(offset) != 2 || offset[1] != 4 {
+		t.Errorf("Offset = %#v", values["Offset"])
+	}
+	if _, ok := values["Time"].(float32); !ok {
+		t.Errorf("Time = %#v, want the automatic time uniform", values["Time"])
+	}
+	mismatched := cs.uniformValues(ShaderEffect{Uniforms: map[string]string{"offset": "1 2 3"}}, nil, Rect{W: 100, H: 60})
+	if mismatched["Offset"] != nil {
+		t.Errorf("a vec3 value for a vec2 uniform should be dropped, got %v", mismatched["Offset"])
+	}
+	ui.Bind("level", 0.9)
+	bound := crt.Style()
+	if values := crt.shaders["glitch"].uniformValues(bound.parsedFilter.Shaders[0], bound.ShaderUniforms, Rect{W: 100, H: 60}); values["Amount"] != float32(0.9) {
+		t.Errorf("bound Amount = %v, want 0.9", values["Amount"])
+	}
+
+	plasma := ui.GetPanel("plasma").Style().parsedBackgroundShader
+	if plasma == nil || plasma.Name != "glitch" || plasma.Uniforms["amount"] != "25%" {
+		t.Fatalf("background-shader = %+v", plasma)
+	}
+	if bare := ParseBackgroundShader("glitch"); bare == nil || bare.Name != "glitch" {
+		t.Fatalf("bare background-shader name = %+v", bare)
+	}
+
+	ui.Draw(ebiten.NewImage(320, 200))
+}
@@ -0,0 +1,234 @@
+package ui
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// ============================================================================
+// Undo/redo history for editable text
+// ============================================================================
+
+// defaultUndoLimit is the number of undo steps kept when a widget's
+// UndoLimit is 0.
+const defaultUndoLimit = 100
+
+// textEditState is a snapshot of editable text with its caret and selection.
+type textEditState struct {
+	text        string
+	cursor      int
+	selectStart int
+	selectEnd   int
+}
+
+// editKind classifies edits for coalescing undo steps.
+type editKind int
+
+const (
+	editOther     editKind = iota // paste, cut and other edits, never merged
+	editTyping                    // typed characters
+	editBackspace                 // characters deleted before the caret
+	editDelete                    // characters deleted after the caret
+)
+
+// editHistory holds the undo and redo stacks of an editable widget. Each
+// undo step is the state before an edit. Typing at the caret merges into one
+// step until a space or newline is typed, and runs of Backspace or Delete
+// merge likewise, so undo takes back a word or a run of deletions at once.
+type editHistory struct {
+	undo  []textEditState
+	redo  []textEditState
+	depth int // nesting of tracked edits; only the outermost records
+
+	lastKind   editKind // kind of the last recorded edit, for coalescing
+	lastCursor int      // caret after the last recorded edit
+	wordEnded  bool     // the last typed character ended a word
+}
+
+// track snapshots the state before an edit and returns the function that
+// records the edit once it is done, if it changed the text. Edits made
+// inside another tracked edit are part of it. A negative limit keeps no
+// history; 0 keeps defaultUndoLimit steps.
+func (h *editHistory) track(state func() textEditState, kind editKind, limit int) func() {
+	h.depth++
+	if h.depth > 1 || limit < 0 {
+		return func() { h.depth-- }
+	}
+	before := state()
+	return func() {
+		h.depth--
+		after := state()
+		if after.text != before.text {
+			h.push(before, after, kind, limit)
+		}
+	}
+}
+
+func (h *editHistory) push(before, after textEditState, kind editKind, limit int) {
+	merge := kind != editOther && kind == h.lastKind && len(h.undo) > 0 &&
+		before.cursor == h.lastCursor && before.selectStart == before.selectEnd &&
+		!(kind == editTyping && h.wordEnded)
+	h.redo = h.redo[:0]
+	h.lastKind = kind
+	h.lastCursor = after.cursor
+	if kind == editTyping && after.cursor > 0 {
+		runes := []rune(after.text)
+		h.wordEnded = after.cursor <= len(runes) && unicode.IsSpace(runes[after.cursor-1])
+	}
+	if merge {
+		return
+	}
+	if limit == 0 {
+		limit = defaultUndoLimit
+	}
+	h.undo = append(h.undo, before)
+	if len(h.undo) > limit {
+		h.undo = append(h.undo[:0], h.undo[len(h.undo)-limit:]...)
+	}
+}
+
+// undoState pops the state to restore for an undo, pushing the current
+// state for redo.
+func (h *editHistory) undoState(current textEditState) (textEditState, bool) {
+	if len(h.undo) == 0 {
+		return textEditState{}, false
+	}
+	state := h.undo[len(h.undo)-1]
+	h.undo = h.undo[:len(h.undo)-1]
+	h.redo = append(h.redo, current)
+	h.lastKind = editOther
+	return state, true
+}
+
+// redoState pops the state to restore for a redo, pushing the current state
+// for undo.
+func (h *editHistory) redoState(current textEditState) (textEditState, bool) {
+	if len(h.redo) == 0 {
+		return textEditState{}, false
+	}
+	state := h.redo[len(h.redo)-1]
+	h.redo = h.redo[:len(h.redo)-1]
+	h.undo = append(h.undo, current)
+	h.lastKind = editOther
+	return state, true
+}
+
+// clear drops all history.
+func (h *editHistory) clear() {
+	h.undo = nil
+	h.redo = nil
+	h.lastKind = editOther
+}
+
+// TextInput undo/redo
+
+func (ti *TextInput) editState() textEditState {
+	return textEditState{text: ti.Text, cursor: ti.CursorPos, selectStart: ti.SelectStart, selectEnd: ti.SelectEnd}
+}
+
+// trackEdit records the edit that follows as one undo step; call the
+// returned function once it is done.
+func (ti *TextInput) trackEdit(kind editKind) func() {
+	return ti.history.track(ti.editState, kind, ti.UndoLimit)
+}
+
+func (ti *TextInput) restoreEditState(state textEditState) {
+	ti.Text = state.text
+	ti.CursorPos = state.cursor
+	ti.SelectStart = state.selectStart
+	ti.SelectEnd = state.selectEnd
+	ti.clampIndices()
+	ti.cursorBlink = 0
+	ti.cursorVisible = true
+	ti.revealCursor()
+	if ti.OnChange != nil {
+		ti.OnChange(ti.Text)
+	}
+}
+
+// Undo reverts the last edit, reporting whether there was one. OnChange
+// fires, so bound values follow.
+func (ti *TextInput) Undo() bool {
+	state, ok := ti.history.undoState(ti.editState())
+	if ok {
+		ti.restoreEditState(state)
+	}
+	return ok
+}
+
+// Redo reapplies the last undone edit, reporting whether there was one.
+func (ti *TextInput) Redo() bool {
+	state, ok := ti.history.redoState(ti.editState())
+	if ok {
+		ti.restoreEditState(state)
+	}
+	return ok
+}
+
+// CanUndo reports whether there is an edit to undo.
+func (ti *TextInput) CanUndo() bool { return len(ti.history.undo) > 0 }
+
+// CanRedo reports whether there is an undone edit to redo.
+func (ti *TextInput) CanRedo() bool { return len(ti.history.redo) > 0 }
+
+// ClearHistory drops the undo and redo history.
+func (ti *TextInput) ClearHistory() { ti.history.clear() }
+
+// TextArea undo/redo
+
+func (ta *TextArea) editState() textEditState {
+	return textEditState{text: ta.Text, cursor: ta.CursorPos, selectStart: ta.SelectStart, selectEnd: ta.SelectEnd}
+}
+
+// trackEdit records the edit that follows as one undo step; call the
+// returned function once it is done.
+func (ta *TextArea) trackEdit(kind editKind) func() {
+	return ta.history.track(ta.editState, kind, ta.UndoLimit)
+}
+
+func (ta *TextArea) restoreEditState(state textEditState) {
+	ta.Text = state.text
+	ta.CursorPos = state.cursor
+	ta.SelectStart = state.selectStart
+	ta.SelectEnd = state.selectEnd
+	if runeLen := utf8.RuneCountInString(ta.Text); ta.SelectStart > runeLen || ta.SelectEnd > runeLen {
+		ta.SelectStart, ta.SelectEnd = 0, 0
+	}
+	ta.clampCursorPos()
+	ta.updateLines()
+	ta.updateCursorLineCol()
+	ta.cursorBlink = 0
+	ta.cursorVisible = true
+	ta.revealCursor()
+	if ta.OnChange != nil {
+		ta.OnChange(ta.Text)
+	}
+}
+
+// Undo reverts the last edit, reporting whether there was one. OnChange
+// fires, so bound values follow.
+func (ta *TextArea) Undo() bool {
+	state, ok := ta.history.undoState(ta.editState())
+	if ok {
+		ta.restoreEditState(state)
+	}
+	return ok
+}
+
+// Redo reapplies the last undone edit, reporting whether there was one.
+func (ta *TextArea) Redo() bool {
+	state, ok := ta.history.redoState(ta.editState())
+	if ok {
+		ta.restoreEditState(state)
+	}
+	return ok
+}
+
+// CanUndo reports whether there is an edit to undo.
+func (ta *TextArea) CanUndo() bool { return len(ta.history.undo) > 0 }
+
+// CanRedo reports whether there is an undone edit to redo.
+func (ta *TextArea) CanRedo() bool { return len(ta.history.redo) > 0 }
+
+// ClearHistory drops the undo and redo history.
+func (ta *TextArea) ClearHistory() { ta.history.clear() }
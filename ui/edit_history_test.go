@@ -0,0 +1,103 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+func TestTextInputUndoCoalescesTypingByWord(t *testing.T) {
+	ti := NewTextInput("chat")
+	var changes []string
+	ti.OnChange = func(text string) { changes = append(changes, text) }
+	for _, r := range "hello world" {
+		ti.insertChar(r)
+	}
+
+	if !ti.Undo() || ti.Text != "hello " {
+		t.Fatalf("first Undo() left %q, want %q", ti.Text, "hello ")
+	}
+	if ti.CursorPos != 6 {
+		t.Fatalf("CursorPos after undo = %d, want 6", ti.CursorPos)
+	}
+	if !ti.Undo() || ti.Text != "" {
+		t.Fatalf("second Undo() left %q, want empty", ti.Text)
+	}
+	if ti.Undo() {
+		t.Fatal("Undo() with no history should report false")
+	}
+	if got := changes[len(changes)-1]; got != "" {
+		t.Fatalf("OnChange last saw %q, want the undone text", got)
+	}
+
+	simulateTextInputKeyPress(ti, ebiten.KeyZ, true, true)
+	simulateTextInputKeyPress(ti, ebiten.KeyY, false, true)
+	if ti.Text != "hello world" || ti.CanRedo() {
+		t.Fatalf("after Ctrl+Shift+Z and Ctrl+Y Text = %q, CanRedo = %v", ti.Text, ti.CanRedo())
+	}
+
+	for i := 0; i < 3; i++ {
+		simulateTextInputKeyPress(ti, ebiten.KeyBackspace, false, false)
+	}
+	if ti.Text != "hello wo" {
+		t.Fatalf("Text after backspaces = %q", ti.Text)
+	}
+	simulateTextInputKeyPress(ti, ebiten.KeyZ, false, true)
+	if ti.Text != "hello world" {
+		t.Fatalf("Ctrl+Z after backspaces = %q, want them undone at once", ti.Text)
+	}
+
+	ti.insertChar('!')
+	if ti.CanRedo() {
+		t.Fatal("a new edit should drop the redo history")
+	}
+}
+
+func TestTextInputUndoSelectionReplacementIsOneStep(t *testing.T) {
+	ti := NewTextInput("name")
+	ti.SetText("Ada Lovelace")
+	simulateTextInputKeyPress(ti, ebiten.KeyA, false, true)
+	ti.insertChar('G')
+
+	if ti.Text != "G" {
+		t.Fatalf("Text = %q, want the selection replaced", ti.Text)
+	}
+	if !ti.Undo() || ti.Text != "Ada Lovelace" {
+		t.Fatalf("Undo() left %q, want the replaced text back", ti.Text)
+	}
+	if ti.SelectStart != 0 || ti.SelectEnd != 12 {
+		t.Fatalf("selection after undo = [%d:%d], want it restored", ti.SelectStart, ti.SelectEnd)
+	}
+}
+
+func TestTextAreaUndoLimitAndBoundValue(t *testing.T) {
+	ui := New(320, 200)
+	if err := ui.LoadLayout(`<textarea id="notes" bind-value="notes" />`); err != nil {
+		t.Fatalf("LoadLayout() error = %v", err)
+	}
+	ui.Bind("notes", "")
+	ta := ui.GetTextArea("notes")
+	ta.UndoLimit = 2
+	for _, s := range []string{"one", "\ntwo", "\nthree"} {
+		ta.insertString(s)
+	}
+
+	undos := 0
+	for ta.Undo() {
+		undos++
+	}
+	if undos != 2 {
+		t.Fatalf("undid %d steps, want the limit of 2", undos)
+	}
+	if ta.Text != "one" {
+		t.Fatalf("Text = %q, want %q", ta.Text, "one")
+	}
+	if got := ui.Bindings().GetString("notes"); got != "one" {
+		t.Fatalf("bound value = %q, want it to follow the undo", got)
+	}
+
+	ta.Redo()
+	if ta.Text != "one\ntwo" || len(ta.lines) != 2 || ta.CursorLine != 1 {
+		t.Fatalf("after Redo() Text = %q, lines = %d, CursorLine = %d", ta.Text, len(ta.lines), ta.CursorLine)
+	}
+}
@@ -189,9 +189,16 @@ const (
 // Extended CSS-like gradient support
 type Gradient struct {
 	Type       GradientType
-	Angle      float64 // for linear gradients, in degrees
+	Angle      float64 // linear: direction; conic: starting angle; in degrees
 	ColorStops []ColorStop
+	Repeating  bool          // repeating-*-gradient: the stops repeat along the gradient line
+	Center     string        // radial and conic: the "at" position; empty means center
 	strip      *ebiten.Image // cached 1D gradient strip texture (lazy, see shader.go)
+
+	// Stops with pixel positions are kept unresolved and resolved per
+	// gradient line length (see gradient.go).
+	stopSpecs []gradientStopSpec
+	resolved  map[int]*Gradient
 }
 
 type GradientType int
@@ -199,11 +206,13 @@ type GradientType int
 const (
 	GradientLinear GradientType = iota
 	GradientRadial
+	GradientConic
 )
 
 type ColorStop struct {
 	Color    color.Color
 	Position float64 // 0-1
+	Hint     bool    // a color hint: Position is the midpoint of the transition, Color is unused
 }
 
 // DrawGradient draws a linear gradient in the given rectangle with angle support.
@@ -253,6 +262,7 @@ func DrawGradient(screen *ebiten.Image, r Rect, g *Gradient) {
 	gradB := sinA / dotRange
 	gradC := (-centerX*cosA - centerY*sinA - minDot) / dotRange
 
+	g = g.forLength(dotRange)
 	shader := getLinearGradientShader()
 	strip := g.ensureGradientStrip()
 
@@ -277,34 +287,75 @@ func DrawGradient(screen *ebiten.Image, r Rect, g *Gradient) {
 
 	top := &ebiten.DrawTrianglesShaderOptions{}
 	top.Images[0] = strip
-	top.Uniforms = map[string]any{
+	top.Uniforms = g.periodUniforms(map[string]any{
 		"GradA": float32(gradA),
 		"GradB": float32(gradB),
 		"GradC": float32(gradC),
-	}
+	})
 	screen.DrawTrianglesShader(vertices, indices, shader, top)
 }
 
-// interpolateGradient finds the color at position t
+// interpolateGradient finds the color at position t. Colors are mixed in
+// premultiplied space, so fading to transparent does not darken, and a color
+// hint between two stops moves the midpoint of their transition.
 func interpolateGradient(stops []ColorStop, t float64) color.Color {
-	if t <= stops[0].Position {
-		return stops[0].Color
+	prev, hint := -1, -1
+	for i, stop := range stops {
+		if stop.Hint {
+			if prev >= 0 {
+				hint = i
+			}
+			continue
+		}
+		if t <= stop.Position {
+			if prev < 0 {
+				return stop.Color
+			}
+			from := stops[prev]
+			span := stop.Position - from.Position
+			if span <= 0 {
+				return stop.Color
+			}
+			local := (t - from.Position) / span
+			if hint >= 0 {
+				local = applyColorHint(local, (stops[hint].Position-from.Position)/span)
+			}
+			return mixPremultiplied(from.Color, stop.Color, local)
+		}
+		prev, hint = i, -1
 	}
-	if t >= stops[len(stops)-1].Position {
-		return stops[len(stops)-1].Color
+	if prev < 0 {
+		return color.Transparent
 	}
+	return stops[prev].Color
+}
 
-	// Find surrounding stops
-	for i := 0; i < len(stops)-1; i++ {
-		if t >= stops[i].Position && t <= stops[i+1].Position {
-			// Interpolate between stops[i] and stops[i+1]
-			range_ := stops[i+1].Position - stops[i].Position
-			localT := (t - stops[i].Position) / range_
-			return lerpColor(stops[i].Color, stops[i+1].Color, localT)
+// applyColorHint remaps a local position so that the transition reaches its
+// midpoint at the hint h (both relative to the two stops).
+func applyColorHint(t, h float64) float64 {
+	switch {
+	case h <= 0:
+		if t > 0 {
+			return 1
+		}
+		return 0
+	case h >= 1:
+		if t < 1 {
+			return 0
 		}
+		return 1
 	}
+	return math.Pow(t, math.Log(0.5)/math.Log(h))
+}
 
-	return stops[0].Color
+// mixPremultiplied interpolates two colors in premultiplied RGBA.
+func mixPremultiplied(c1, c2 color.Color, t float64) color.Color {
+	r1, g1, b1, a1 := c1.RGBA()
+	r2, g2, b2, a2 := c2.RGBA()
+	mix := func(a, b uint32) uint16 {
+		return uint16(math.Round(float64(a)*(1-t) + float64(b)*t))
+	}
+	return color.RGBA64{R: mix(r1, r2), G: mix(g1, g2), B: mix(b1, b2), A: mix(a1, a2)}
 }
 
 // lerpColor linearly interpolates between two colors
@@ -320,68 +371,11 @@ func lerpColor(c1, c2 color.Color, t float64) color.Color {
 	}
 }
 
-// ParseGradient parses a CSS-like gradient string
+// ParseGradient parses a CSS gradient: linear-gradient, radial-gradient,
+// conic-gradient or one of their repeating- forms.
 // e.g., "linear-gradient(90deg, #ff0000, #0000ff)"
 func ParseGradient(s string) *Gradient {
-	s = strings.TrimSpace(s)
-
-	if strings.HasPrefix(s, "linear-gradient(") {
-		return parseLinearGradient(s)
-	}
-
-	return nil
-}
-
-func parseLinearGradient(s string) *Gradient {
-	// Remove "linear-gradient(" prefix and ")" suffix
-	s = strings.TrimPrefix(s, "linear-gradient(")
-	s = strings.TrimSuffix(s, ")")
-
-	parts := strings.Split(s, ",")
-	if len(parts) < 2 {
-		return nil
-	}
-
-	g := &Gradient{
-		Type:       GradientLinear,
-		Angle:      90, // default to horizontal
-		ColorStops: make([]ColorStop, 0),
-	}
-
-	startIdx := 0
-	// Check if first part is an angle
-	first := strings.TrimSpace(parts[0])
-	if strings.HasSuffix(first, "deg") {
-		angle := strings.TrimSuffix(first, "deg")
-		g.Angle = parseFloatValue(angle)
-		startIdx = 1
-	}
-
-	// Parse color stops
-	numColors := len(parts) - startIdx
-	for i := startIdx; i < len(parts); i++ {
-		colorStr := strings.TrimSpace(parts[i])
-		clr := parseColor(colorStr)
-		if clr != nil {
-			stopPos := float64(i-startIdx) / float64(numColors-1)
-			g.ColorStops = append(g.ColorStops, ColorStop{
-				Color:    clr,
-				Position: stopPos,
-			})
-		}
-	}
-
-	if len(g.ColorStops) < 2 {
-		return nil
-	}
-
-	return g
-}
-
-func parseFloatValue(s string) float64 {
-	s = strings.TrimSpace(s)
-	f, _ := strconv.ParseFloat(s, 64)
-	return f
+	return parseCSSGradient(s)
 }
 
 // DrawRoundedRectPath draws a rounded rectangle with the same radius on all corners.
@@ -678,7 +672,7 @@ type TextShadow struct {
 // Outline represents a CSS-like outline
 type Outline struct {
 	Width  float64
-	Style  string // "solid", "dashed", "dotted"
+	Style  string // "solid", "dashed", "dotted", "double", "groove", "ridge", "inset", "outset"
 	Color  color.Color
 	Offset float64 // distance from border
 }
@@ -697,6 +691,17 @@ func DrawOutline(screen *ebiten.Image, r Rect, outline *Outline, borderRadius fl
 		H: r.H + (outline.Width+outline.Offset)*2,
 	}
 
+	if outline.Style != "" && outline.Style != "solid" {
+		side := borderSide{width: outline.Width, color: outline.Color, style: outline.Style}
+		// The outline's outer edge sits Offset+Width outside the border box,
+		// so its corners follow the border radius grown by that distance.
+		radius := 0.0
+		if borderRadius > 0 {
+			radius = borderRadius + outline.Offset + outline.Width
+		}
+		drawStyledBorder(screen, outlineRect, [4]borderSide{side, side, side, side}, [4]float64{radius, radius, radius, radius})
+		return
+	}
 	drawRoundedRectStroke(screen, outlineRect, borderRadius+outline.Offset, outline.Width, outline.Color)
 }
 
@@ -845,6 +850,8 @@ func DrawRadialGradient(screen *ebiten.Image, r Rect, g *Gradient) {
 		return
 	}
 
+	g = g.forLength(r.W / 2)
+	cx, cy := g.centerIn(r)
 	shader := getRadialGradientShader()
 	strip := g.ensureGradientStrip()
 
@@ -869,12 +876,12 @@ func DrawRadialGradient(screen *ebiten.Image, r Rect, g *Gradient) {
 
 	top := &ebiten.DrawTrianglesShaderOptions{}
 	top.Images[0] = strip
-	top.Uniforms = map[string]any{
-		"CenterX": float32(r.X + r.W/2),
-		"CenterY": float32(r.Y + r.H/2),
+	top.Uniforms = g.periodUniforms(map[string]any{
+		"CenterX": float32(cx),
+		"CenterY": float32(cy),
 		"RadiusX": float32(r.W / 2),
 		"RadiusY": float32(r.H / 2),
-	}
+	})
 	screen.DrawTrianglesShader(vertices, indices, shader, top)
 }
 
@@ -896,6 +903,9 @@ type Filter struct {
 	Sepia      float64 // 0-1
 	HueRotate  float64 // degrees
 	Invert     float64 // 0-1
+
+	// Shaders run after the built-in functions, in declaration order
+	Shaders []ShaderEffect
 }
 
 // NewFilter creates a default filter (no effect)
@@ -912,6 +922,34 @@ type BackdropFilter struct {
 	Blur       float64
 	Brightness float64
 	Saturate   float64
+
+	// Steps, when set, replace the fields above with the full filter function
+	// list applied in declaration order, as parsed from CSS.
+	Steps []FilterStep
+}
+
+// FilterStep is one CSS filter function: blur (px), brightness, contrast,
+// saturate, grayscale, sepia, invert (amounts, 1 = 100%) or hue-rotate (deg).
+type FilterStep struct {
+	Name   string
+	Amount float64
+}
+
+// isIdentity reports whether the step leaves its input unchanged.
+func (s FilterStep) isIdentity() bool {
+	switch s.Name {
+	case "brightness", "contrast", "saturate":
+		return s.Amount == 1
+	}
+	return s.Amount == 0
+}
+
+// steps returns the filter functions to apply, in order.
+func (bf *BackdropFilter) steps() []FilterStep {
+	if len(bf.Steps) > 0 {
+		return bf.Steps
+	}
+	return []FilterStep{{"blur", bf.Blur}, {"saturate", bf.Saturate}, {"brightness", bf.Brightness}}
 }
 
 // GlassmorphismStyle creates a glassmorphism effect style
@@ -935,7 +973,12 @@ func NewBackdropFilter() *BackdropFilter {
 
 // backdropFilterIsDefault returns true when the filter would have no visible effect.
 func backdropFilterIsDefault(bf *BackdropFilter) bool {
-	return bf.Blur == 0 && bf.Brightness == 1 && bf.Saturate == 1
+	for _, step := range bf.steps() {
+		if !step.isIdentity() {
+			return false
+		}
+	}
+	return true
 }
 
 // filterIsDefault returns true when a CSS filter would have no visible effect.
@@ -946,8 +989,15 @@ func filterIsDefault(f *Filter) bool {
 }
 
 // ApplyBackdropFilter captures the screen region behind a widget, applies
-// blur/brightness/saturate effects, and composites the result back.
+// its filter functions in order, and composites the result back.
 func ApplyBackdropFilter(screen *ebiten.Image, r Rect, bf *BackdropFilter) {
+	applyBackdropFilter(screen, r, bf, nil)
+}
+
+// applyBackdropFilter is ApplyBackdropFilter with an optional shape that
+// masks the filtered backdrop (in coordinates local to r) before it is
+// composited back, such as rounded corners or a clip-path.
+func applyBackdropFilter(screen *ebiten.Image, r Rect, bf *BackdropFilter, shape func(buf *ebiten.Image, local Rect)) {
 	if bf == nil || backdropFilterIsDefault(bf) {
 		return
 	}
@@ -968,59 +1018,110 @@ func ApplyBackdropFilter(screen *ebiten.Image, r Rect, bf *BackdropFilter) {
 	op.GeoM.Translate(float64(-x0), float64(-y0))
 	buf.DrawImage(captured, op)
 
-	// 2-pass separable Gaussian blur
-	if bf.Blur > 0 {
-		shader := getBackdropBlurShader()
-		if shader != nil {
-			tmp := globalImagePool.Get(w, h)
-
-			// Horizontal pass: buf → tmp
-			sop := &ebiten.DrawRectShaderOptions{}
-			sop.Images[0] = buf
-			sop.Uniforms = map[string]interface{}{
-				"Sigma":     float32(bf.Blur),
-				"Direction": [2]float32{1, 0},
-			}
-			tmp.DrawRectShader(w, h, shader, sop)
-
-			// Vertical pass: tmp → buf
-			buf.Clear()
-			sop2 := &ebiten.DrawRectShaderOptions{}
-			sop2.Images[0] = tmp
-			sop2.Uniforms = map[string]interface{}{
-				"Sigma":     float32(bf.Blur),
-				"Direction": [2]float32{0, 1},
-			}
-			buf.DrawRectShader(w, h, shader, sop2)
-
-			globalImagePool.Put(tmp)
-		}
+	filtered := applyFilterSteps(buf, bf.steps())
+	if filtered != buf {
+		globalImagePool.Put(buf)
 	}
-
-	// Apply brightness/saturate using the CSS filter shader
-	if bf.Brightness != 1 || bf.Saturate != 1 {
-		f := &Filter{
-			Brightness: bf.Brightness,
-			Contrast:   1,
-			Saturate:   bf.Saturate,
-			Grayscale:  0,
-			Sepia:      0,
-			HueRotate:  0,
-			Invert:     0,
-		}
-		filtered := applyCSSFilter(buf, f)
-		if filtered != buf {
-			globalImagePool.Put(buf)
-			buf = filtered
-		}
+	if shape != nil {
+		shape(filtered, Rect{X: r.X - float64(x0), Y: r.Y - float64(y0), W: r.W, H: r.H})
 	}
 
 	// Composite back onto screen at the original position
 	compOp := &ebiten.DrawImageOptions{}
 	compOp.GeoM.Translate(float64(x0), float64(y0))
-	screen.DrawImage(buf, compOp)
+	screen.DrawImage(filtered.SubImage(image.Rect(0, 0, w, h)).(*ebiten.Image), compOp)
+
+	globalImagePool.Put(filtered)
+}
+
+// filterStages orders the colour functions as the filter shader applies them
+// in one pass.
+var filterStages = map[string]int{
+	"grayscale": 1, "sepia": 2, "saturate": 3, "hue-rotate": 4,
+	"brightness": 5, "contrast": 6, "invert": 7,
+}
 
-	globalImagePool.Put(buf)
+// filterPass is one rendering pass of a filter chain: a Gaussian blur or
+// one run of the colour-matrix filter shader.
+type filterPass struct {
+	blur   float64
+	filter *Filter
+}
+
+// planFilterPasses groups filter functions into passes. Consecutive colour
+// functions that already follow the filter shader's stage order share one
+// pass; identity functions are dropped.
+func planFilterPasses(steps []FilterStep) []filterPass {
+	var passes []filterPass
+	var pending *Filter
+	stage := 0
+	flush := func() {
+		if pending != nil {
+			passes = append(passes, filterPass{filter: pending})
+		}
+		pending, stage = nil, 0
+	}
+	for _, step := range steps {
+		if step.isIdentity() {
+			continue
+		}
+		if step.Name == "blur" {
+			flush()
+			passes = append(passes, filterPass{blur: step.Amount})
+			continue
+		}
+		next, ok := filterStages[step.Name]
+		if !ok {
+			continue
+		}
+		if next <= stage {
+			flush()
+		}
+		if pending == nil {
+			pending = NewFilter()
+		}
+		switch step.Name {
+		case "grayscale":
+			pending.Grayscale = step.Amount
+		case "sepia":
+			pending.Sepia = step.Amount
+		case "saturate":
+			pending.Saturate = step.Amount
+		case "hue-rotate":
+			pending.HueRotate = step.Amount
+		case "brightness":
+			pending.Brightness = step.Amount
+		case "contrast":
+			pending.Contrast = step.Amount
+		case "invert":
+			pending.Invert = step.Amount
+		}
+		stage = next
+	}
+	flush()
+	return passes
+}
+
+// applyFilterSteps applies filter functions in order, sharing the CSS filter
+// shader and blur passes with applyCSSFilter. As with applyCSSFilter, the
+// caller must release the result when it differs from src.
+func applyFilterSteps(src *ebiten.Image, steps []FilterStep) *ebiten.Image {
+	current := src
+	for _, pass := range planFilterPasses(steps) {
+		var next *ebiten.Image
+		if pass.filter != nil {
+			next = applyCSSFilter(current, pass.filter)
+		} else {
+			next = applyGaussianBlur(current, pass.blur)
+		}
+		if next != current {
+			if current != src {
+				globalImagePool.Put(current)
+			}
+			current = next
+		}
+	}
+	return current
 }
 
 // ParseBoxShadow parses a CSS-like box-shadow string
@@ -1200,7 +1301,10 @@ func ParseOutline(s string) *Outline {
 
 	if len(parts) >= 2 {
 		style := strings.ToLower(parts[1])
-		if style == "solid" || style == "dashed" || style == "dotted" {
+		if style == "none" {
+			return nil
+		}
+		if isBorderLineStyle(style) {
 			outline.Style = style
 			if len(parts) >= 3 {
 				outline.Color = parseColor(strings.Join(parts[2:], " "))
@@ -1250,11 +1354,7 @@ func DrawGradientClipped(screen *ebiten.Image, r Rect, g *Gradient, radTL, radTR
 
 	// If no rounding, use fast GPU shader path
 	if radTL <= 0 && radTR <= 0 && radBR <= 0 && radBL <= 0 {
-		if g.Type == GradientRadial {
-			DrawRadialGradient(screen, r, g)
-		} else {
-			DrawGradient(screen, r, g)
-		}
+		drawGradientFill(screen, r, g)
 		return
 	}
 
@@ -1309,9 +1409,12 @@ func DrawGradientClipped(screen *ebiten.Image, r Rect, g *Gradient, radTL, radTR
 	}
 
 	var gradTex *ebiten.Image
-	if g.Type == GradientRadial {
+	switch g.Type {
+	case GradientRadial:
 		gradTex = buildRadialGradientTexture(g, gradW, gradH)
-	} else {
+	case GradientConic:
+		gradTex = buildConicGradientTexture(g, gradW, gradH)
+	default:
 		gradTex = buildLinearGradientTexture(g, gradW, gradH)
 	}
 	if gradTex == nil {
@@ -1362,6 +1465,7 @@ func buildLinearGradientTexture(g *Gradient, w, h int) *ebiten.Image {
 	if dotRange == 0 {
 		dotRange = 1
 	}
+	g = g.forLength(dotRange)
 
 	img := ebiten.NewImage(w, h)
 	pix := make([]byte, w*h*4)
@@ -1371,14 +1475,7 @@ func buildLinearGradientTexture(g *Gradient, w, h int) *ebiten.Image {
 			rx := float64(px) - hw
 			ry := float64(py) - hh
 			dot := rx*cosA + ry*sinA
-			t := (dot - minDot) / dotRange
-			if t < 0 {
-				t = 0
-			}
-			if t > 1 {
-				t = 1
-			}
-			c := interpolateGradient(g.ColorStops, t)
+			c := g.colorAt((dot - minDot) / dotRange)
 			cr, cg, cb, ca := c.RGBA()
 			off := (py*w + px) * 4
 			pix[off+0] = uint8(cr >> 8)
@@ -1401,24 +1498,21 @@ func buildRadialGradientTexture(g *Gradient, w, h int) *ebiten.Image {
 	img := ebiten.NewImage(w, h)
 	pix := make([]byte, w*h*4)
 
-	cx, cy := float64(w)/2, float64(h)/2
-	rx, ry := cx, cy
+	rx, ry := float64(w)/2, float64(h)/2
 	if rx <= 0 {
 		rx = 1
 	}
 	if ry <= 0 {
 		ry = 1
 	}
+	g = g.forLength(rx)
+	cx, cy := g.centerIn(Rect{W: float64(w), H: float64(h)})
 
 	for py := 0; py < h; py++ {
 		for px := 0; px < w; px++ {
 			dx := (float64(px) - cx) / rx
 			dy := (float64(py) - cy) / ry
-			t := math.Sqrt(dx*dx + dy*dy)
-			if t > 1 {
-				t = 1
-			}
-			c := interpolateGradient(g.ColorStops, t)
+			c := g.colorAt(math.Sqrt(dx*dx + dy*dy))
 			cr, cg, cb, ca := c.RGBA()
 			off := (py*w + px) * 4
 			pix[off+0] = uint8(cr >> 8)
@@ -1,6 +1,8 @@
 package ui
 
 import (
+	"fmt"
+	"strings"
 	"sync"
 
 	"github.com/hajimehoshi/ebiten/v2/text/v2"
@@ -8,7 +10,8 @@ import (
 
 // fontCacheKey identifies a unique font face configuration.
 type fontCacheKey struct {
-	size float64
+	size       float64
+	variations string
 }
 
 // FontCache caches text.GoTextFace instances keyed by size to avoid redundant
@@ -31,11 +34,20 @@ func NewFontCache(source *text.GoTextFaceSource) *FontCache {
 // GetFace returns a cached GoTextFace for the given size, creating one if
 // needed. Uses double-checked locking to minimise write-lock contention.
 func (fc *FontCache) GetFace(size float64) *text.GoTextFace {
+	return fc.variedFace(size, nil)
+}
+
+// variedFace returns a cached face for a size with variation axis values.
+func (fc *FontCache) variedFace(size float64, variations []fontVariation) *text.GoTextFace {
 	if size <= 0 {
 		size = 14
 	}
 
-	key := fontCacheKey{size: size}
+	var axes strings.Builder
+	for _, v := range variations {
+		fmt.Fprintf(&axes, "%s=%g;", v.tag, v.value)
+	}
+	key := fontCacheKey{size: size, variations: axes.String()}
 
 	// Fast path: read lock
 	fc.mu.RLock()
@@ -58,6 +70,9 @@ func (fc *FontCache) GetFace(size float64) *text.GoTextFace {
 		Source: fc.source,
 		Size:   size,
 	}
+	for _, v := range variations {
+		face.SetVariation(v.tag, v.value)
+	}
 	fc.faces[key] = face
 	return face
 }
@@ -0,0 +1,272 @@
+package ui
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+)
+
+// ============================================================================
+// @font-face and font matching
+// ============================================================================
+
+// cssFontFace is a compiled @font-face rule.
+type cssFontFace struct {
+	family    string
+	sources   []string // asset names from src url(...), in order
+	weightMin int
+	weightMax int
+	style     string // normal, italic or oblique
+	line      int
+	column    int
+}
+
+// fontVariant is one weight/style face of a registered font family. Variable
+// fonts cover a weight range.
+type fontVariant struct {
+	weightMin int
+	weightMax int
+	style     string
+	cache     *FontCache
+}
+
+// fontFormats lists the src format() hints that GoTextFaceSource can read.
+var fontFormats = map[string]bool{
+	"truetype": true, "opentype": true, "truetype-variations": true, "opentype-variations": true,
+}
+
+func (c *cssCompiler) compileFontFace(rule *cssRule) {
+	if !rule.HasBlock {
+		c.report(CSSError, rule.Line, rule.Column, "@font-face: missing block")
+		return
+	}
+	face := cssFontFace{weightMin: 400, weightMax: 400, style: "normal", line: rule.Line, column: rule.Column}
+	for _, decl := range rule.Declarations {
+		switch decl.Name {
+		case "font-family":
+			if families := parseFontFamilyList(decl.Value); len(families) == 1 {
+				face.family = families[0]
+			}
+		case "src":
+			face.sources = parseFontFaceSources(decl.Tokens)
+		case "font-weight":
+			weights := strings.Fields(decl.Value)
+			if len(weights) == 0 || len(weights) > 2 {
+				c.report(CSSWarning, decl.Line, decl.Column, "@font-face: invalid font-weight %q", decl.Value)
+				continue
+			}
+			face.weightMin = cssFontWeight(weights[0])
+			face.weightMax = cssFontWeight(weights[len(weights)-1])
+			if face.weightMin > face.weightMax {
+				face.weightMin, face.weightMax = face.weightMax, face.weightMin
+			}
+		case "font-style":
+			face.style = cssFontStyle(decl.Value)
+		case "font-display", "unicode-range":
+			// Faces load eagerly and cover every character they have glyphs for.
+		default:
+			c.report(CSSWarning, decl.Line, decl.Column, "@font-face: unsupported descriptor %q", decl.Name)
+		}
+	}
+	if face.family == "" {
+		c.report(CSSWarning, rule.Line, rule.Column, "@font-face: missing or invalid font-family")
+		return
+	}
+	if len(face.sources) == 0 {
+		c.report(CSSWarning, rule.Line, rule.Column, "@font-face %q: no loadable src", face.family)
+		return
+	}
+	c.fontFaces = append(c.fontFaces, face)
+}
+
+// parseFontFaceSources returns the url(...) entries of a src descriptor,
+// skipping local() and formats other than TrueType and OpenType.
+func parseFontFaceSources(tokens []cssToken) []string {
+	var sources []string
+	for _, part := range splitCSSTokensOnComma(tokens) {
+		name := ""
+		supported := true
+		for _, component := range splitCSSComponents(serializeCSSTokens(part)) {
+			if url, ok := cssURLValue(component); ok {
+				name = url
+				continue
+			}
+			lower := strings.ToLower(component)
+			if strings.HasPrefix(lower, "format(") {
+				format := strings.Trim(strings.TrimSuffix(lower[len("format("):], ")"), ` "'`)
+				supported = fontFormats[format]
+			}
+		}
+		if name != "" && supported {
+			sources = append(sources, name)
+		}
+	}
+	return sources
+}
+
+// cssFontWeight converts a font-weight value to a number in 1-1000. bolder
+// and lighter resolve against the normal weight.
+func cssFontWeight(value string) int {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "normal":
+		return 400
+	case "bold", "bolder":
+		return 700
+	case "lighter":
+		return 300
+	}
+	weight, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return 400
+	}
+	return int(min(max(weight, 1), 1000))
+}
+
+// cssFontStyle reduces a font-style value to normal, italic or oblique.
+func cssFontStyle(value string) string {
+	fields := strings.Fields(strings.ToLower(value))
+	if len(fields) > 0 && (fields[0] == "italic" || fields[0] == "oblique") {
+		return fields[0]
+	}
+	return "normal"
+}
+
+// loadFontFaces loads the @font-face rules of a stylesheet through the asset
+// resolver, using the first src that opens and parses. Faces that fail to
+// load are reported as warnings so text falls back as in browsers.
+func (ui *UI) loadFontFaces(faces []cssFontFace, file string) []CSSDiagnostic {
+	var diagnostics []CSSDiagnostic
+	for _, face := range faces {
+		var source *text.GoTextFaceSource
+		var lastErr error
+		for _, name := range face.sources {
+			if source, lastErr = ui.loadFontSource(name); lastErr == nil {
+				break
+			}
+		}
+		if source == nil {
+			diagnostics = append(diagnostics, CSSDiagnostic{
+				Severity: CSSWarning,
+				File:     file,
+				Line:     face.line,
+				Column:   face.column,
+				Message:  fmt.Sprintf("@font-face %q: %v", face.family, lastErr),
+			})
+			continue
+		}
+		ui.addFontVariant(face.family, fontVariant{
+			weightMin: face.weightMin,
+			weightMax: face.weightMax,
+			style:     face.style,
+			cache:     NewFontCache(source),
+		})
+	}
+	return diagnostics
+}
+
+// loadFontSource reads a TrueType or OpenType font asset, caching the parsed
+// source by name.
+func (ui *UI) loadFontSource(name string) (*text.GoTextFaceSource, error) {
+	if source, ok := ui.fontFiles[name]; ok {
+		return source, nil
+	}
+	rc, err := ui.openAsset(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open font %q: %w", name, err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read font %q: %w", name, err)
+	}
+	source, err := text.NewGoTextFaceSource(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse font %q: %w", name, err)
+	}
+	ui.fontFiles[name] = source
+	return source, nil
+}
+
+// addFontVariant registers a face of a family, replacing any face with the
+// same weight range and style.
+func (ui *UI) addFontVariant(family string, variant fontVariant) {
+	name := normalizeFontFamilyName(family)
+	if name == "" || variant.cache == nil {
+		return
+	}
+	variants := ui.fontVariants[name]
+	for i, existing := range variants {
+		if existing.weightMin == variant.weightMin && existing.weightMax == variant.weightMax && existing.style == variant.style {
+			variants[i] = variant
+			return
+		}
+	}
+	ui.fontVariants[name] = append(variants, variant)
+}
+
+// matchFontVariant picks the face of a family for a weight and style as the
+// CSS font matching algorithm does: the style first (italic falls back to
+// oblique then normal, normal to oblique then italic), then the nearest
+// weight, where 400-500 look up to 500 before going lighter, lighter weights
+// look lighter first and bolder weights look bolder first.
+func matchFontVariant(variants []fontVariant, weight int, style string) *fontVariant {
+	var styleOrder []string
+	switch style {
+	case "italic":
+		styleOrder = []string{"italic", "oblique", "normal"}
+	case "oblique":
+		styleOrder = []string{"oblique", "italic", "normal"}
+	default:
+		styleOrder = []string{"normal", "oblique", "italic"}
+	}
+	for _, want := range styleOrder {
+		var best *fontVariant
+		bestTier, bestDistance := 0, 0
+		for i := range variants {
+			v := &variants[i]
+			if v.style != want {
+				continue
+			}
+			tier, distance := fontWeightRank(v.weightMin, v.weightMax, weight)
+			if best == nil || tier < bestTier || (tier == bestTier && distance < bestDistance) {
+				best, bestTier, bestDistance = v, tier, distance
+			}
+		}
+		if best != nil {
+			return best
+		}
+	}
+	return nil
+}
+
+// fontWeightRank orders a face's weight range against a desired weight:
+// lower tiers win, then smaller distances.
+func fontWeightRank(weightMin, weightMax, desired int) (tier, distance int) {
+	switch {
+	case desired >= weightMin && desired <= weightMax:
+		return 0, 0
+	case desired >= 400 && desired <= 500:
+		switch {
+		case weightMin > desired && weightMin <= 500:
+			return 1, weightMin - desired
+		case weightMax < desired:
+			return 2, desired - weightMax
+		default:
+			return 3, weightMin - desired
+		}
+	case desired < 400:
+		if weightMax < desired {
+			return 1, desired - weightMax
+		}
+		return 2, weightMin - desired
+	default:
+		if weightMin > desired {
+			return 1, weightMin - desired
+		}
+		return 2, desired - weightMax
+	}
+}
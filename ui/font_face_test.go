@@ -0,0 +1,109 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goitalic"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+func TestCSSFontFaceLoadsAndMatchesFaces(t *testing.T) {
+	ui := New(320, 200)
+	ui.SetAssetResolver(newMapAssets(map[string][]byte{
+		"fonts/regular.ttf": goregular.TTF,
+		"fonts/bold.ttf":    gobold.TTF,
+		"fonts/italic.ttf":  goitalic.TTF,
+	}))
+	if err := ui.LoadCSS(`
+		@font-face { font-family: "Go Sans"; src: url(fonts/regular.woff2) format("woff2"), url(fonts/regular.ttf) format("truetype"); }
+		@font-face { font-family: "Go Sans"; src: url(missing.ttf), url("fonts/bold.ttf"); font-weight: bold; }
+		@font-face { font-family: "Go Sans"; src: url(fonts/italic.ttf); font-style: italic; font-display: swap; }
+		@font-face { font-family: Broken; src: url(missing.ttf); }
+		.t { font-family: "Go Sans"; font-size: 20px; }
+		.w300 { font-weight: 300; }
+		.w500 { font-weight: 500; }
+		.w600 { font-weight: 600; }
+		.w900 { font-weight: 900; }
+		.italic { font-style: italic; font-weight: bold; }
+	`); err != nil {
+		t.Fatalf("LoadCSS @font-face: %v", err)
+	}
+	if err := ui.LoadLayout(`<panel id="root">
+		<text id="normal" class="t">Aa</text>
+		<text id="w300" class="t w300">Aa</text>
+		<text id="w500" class="t w500">Aa</text>
+		<text id="w600" class="t w600">Aa</text>
+		<text id="w900" class="t w900">Aa</text>
+		<text id="italic" class="t italic">Aa</text>
+	</panel>`); err != nil {
+		t.Fatalf("LoadLayout: %v", err)
+	}
+
+	// Each face loads its first available source; a face with none is
+	// reported once.
+	for _, name := range []string{"fonts/regular.ttf", "fonts/bold.ttf", "fonts/italic.ttf"} {
+		if ui.fontFiles[name] == nil {
+			t.Errorf("font source %s was not loaded", name)
+		}
+	}
+	warnings := 0
+	for _, d := range ui.CSSDiagnostics() {
+		if d.Severity == CSSWarning && strings.Contains(d.Message, "Broken") {
+			warnings++
+		}
+	}
+	if warnings != 1 {
+		t.Errorf("diagnostics = %v, want one warning for the unloadable face", ui.CSSDiagnostics())
+	}
+
+	regular, bold, italic := ui.fontFiles["fonts/regular.ttf"], ui.fontFiles["fonts/bold.ttf"], ui.fontFiles["fonts/italic.ttf"]
+	for id, want := range map[string]*text.GoTextFaceSource{
+		"normal": regular,
+		"w300":   regular, // lighter than 400 looks heavier when nothing is lighter
+		"w500":   regular, // 400-500 looks up to 500, then lighter
+		"w600":   bold,
+		"w900":   bold,
+		"italic": italic, // style matches before weight
+	} {
+		face, ok := ui.GetText(id).FontFace.(*text.GoTextFace)
+		if !ok {
+			t.Errorf("%s FontFace = %T, want *text.GoTextFace", id, ui.GetText(id).FontFace)
+			continue
+		}
+		if face.Source != want {
+			t.Errorf("%s picked the wrong @font-face source", id)
+		}
+		if face.Size != 20 {
+			t.Errorf("%s face size = %v, want 20", id, face.Size)
+		}
+	}
+}
+
+func TestMatchFontVariant(t *testing.T) {
+	variants := []fontVariant{
+		{weightMin: 300, weightMax: 300, style: "normal"},
+		{weightMin: 600, weightMax: 600, style: "normal"},
+		{weightMin: 100, weightMax: 900, style: "oblique"},
+	}
+	for _, tc := range []struct {
+		weight int
+		style  string
+		want   int
+	}{
+		{400, "normal", 0}, // nothing up to 500, so lighter
+		{450, "normal", 0},
+		{500, "normal", 0},
+		{550, "normal", 1},
+		{200, "normal", 0},
+		{800, "normal", 1},
+		{800, "italic", 2}, // italic falls back to the oblique variable face
+	} {
+		got := matchFontVariant(variants, tc.weight, tc.style)
+		if got != &variants[tc.want] {
+			t.Errorf("matchFontVariant(%d, %s) = %+v, want variant %d", tc.weight, tc.style, got, tc.want)
+		}
+	}
+}
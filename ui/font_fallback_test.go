@@ -0,0 +1,73 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+)
+
+// newLatinFace returns a test face limited to printable ASCII, so other
+// glyphs have to come from a fallback.
+func newLatinFace() *text.LimitedFace {
+	latin := text.NewLimitedFace(testTextFace())
+	latin.AddUnicodeRange(0x20, 0x7e)
+	return latin
+}
+
+func TestSingleFontFamilyIsUsedAsIs(t *testing.T) {
+	latin := newLatinFace()
+	ui := New(200, 120)
+	ui.RegisterFontFace("Latin UI", latin)
+	plain := NewText("plain", "A한")
+	plain.SetStyle(&Style{FontFamily: "Latin UI"})
+	ui.SetRoot(plain)
+	if plain.FontFace != latin {
+		t.Fatal("a single matching family should be used as is")
+	}
+}
+
+func TestFontFallbackChain(t *testing.T) {
+	latin, fallback := newLatinFace(), testTextFace()
+	ui := New(200, 120)
+	ui.RegisterFontFace("Latin UI", latin)
+	ui.RegisterFontFace("Pixel CJK", fallback)
+	ui.SetFontFallbacks("Missing", "Pixel CJK")
+	root := NewPanel("root")
+	mixed, other := NewText("mixed", "A한"), NewText("other", "B")
+	for _, w := range []*Text{mixed, other} {
+		w.SetStyle(&Style{FontFamily: "Latin UI"})
+		root.AddChild(w)
+	}
+	ui.SetRoot(root)
+
+	if _, ok := mixed.FontFace.(*text.MultiFace); !ok {
+		t.Fatalf("FontFace = %T, want a MultiFace fallback chain", mixed.FontFace)
+	}
+	if other.FontFace != mixed.FontFace {
+		t.Fatal("widgets with the same chain should share one face")
+	}
+
+	// Each glyph is measured and hit tested in the face that covers it.
+	layout := newTextLayout("A한", mixed.FontFace, textLayoutOptions{WhiteSpace: textWhiteSpacePreWrap})
+	if len(layout.clusters) != 2 {
+		t.Fatalf("clusters = %d, want 2", len(layout.clusters))
+	}
+	latinWidth, _ := text.Measure("A", latin, 0)
+	hangulWidth, _ := text.Measure("한", fallback, 0)
+	if hangulWidth <= 0 {
+		t.Fatal("expected the fallback face to measure the Hangul glyph")
+	}
+	if got := layout.clusters[0].Width; got != latinWidth {
+		t.Errorf("Latin cluster width = %v, want %v", got, latinWidth)
+	}
+	if got := layout.clusters[1].Width; got != hangulWidth {
+		t.Errorf("Hangul cluster width = %v, want %v from the fallback face", got, hangulWidth)
+	}
+	if layout.width != latinWidth+hangulWidth {
+		t.Errorf("line width = %v, want %v", layout.width, latinWidth+hangulWidth)
+	}
+	hit, ok := layout.HitTest(latinWidth+hangulWidth/2, layout.lineHeight/2)
+	if !ok || hit.Text != "한" {
+		t.Errorf("HitTest = %#v, %v; want the Hangul cluster", hit, ok)
+	}
+}
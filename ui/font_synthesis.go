@@ -0,0 +1,237 @@
+package ui
+
+import (
+	"image"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+)
+
+// ============================================================================
+// Font synthesis and variation axes
+// ============================================================================
+
+// fontSynthesis records how text drawn with a face is emboldened or slanted
+// because the family has no face of the requested weight or style.
+type fontSynthesis struct {
+	bold    float64 // horizontal stroke offset in pixels, 0 for none
+	oblique bool
+}
+
+// syntheticObliqueAngle is the slant of synthesized oblique text, the CSS
+// default oblique angle.
+const syntheticObliqueAngle = 14 * math.Pi / 180
+
+// fontChainFace is a face a UI built for a font fallback chain, with what
+// the package-level text helpers need to draw it: its synthesis, its member
+// faces and its right-to-left copy.
+type fontChainFace struct {
+	face    text.Face
+	synth   fontSynthesis
+	members []text.Face
+	rtl     text.Face // built on first use; nil when a member cannot shape RTL
+	rtlDone bool
+	pass    int // UI.fontPass in which the chain was last resolved
+}
+
+// builtFaces indexes the chain faces of every UI, and their right-to-left
+// copies, by face. Faces are drawn by package-level helpers, so the record
+// is found through the face itself. Each UI removes its faces when it evicts
+// the chains.
+var builtFaces sync.Map // text.Face → *fontChainFace
+
+// fontChainFaceOf returns the chain record of a face a UI built, or nil.
+func fontChainFaceOf(face text.Face) *fontChainFace {
+	if face == nil {
+		return nil
+	}
+	if built, ok := builtFaces.Load(face); ok {
+		return built.(*fontChainFace)
+	}
+	return nil
+}
+
+// forget removes the chain and its right-to-left copy from builtFaces.
+func (c *fontChainFace) forget() {
+	builtFaces.Delete(c.face)
+	if c.rtl != nil {
+		builtFaces.Delete(c.rtl)
+	}
+}
+
+// needsFontSynthesis decides synthesis for a face of weightMax and faceStyle
+// standing in for the requested weight and style: bold when 600 or more is
+// asked of a face lighter than 600, oblique when italic or oblique is asked
+// of an upright face.
+func needsFontSynthesis(weight int, fontStyle string, weightMax int, faceStyle string, size float64) fontSynthesis {
+	var synth fontSynthesis
+	if weight >= 600 && weightMax < 600 {
+		synth.bold = max(size/24, 0.5)
+	}
+	if fontStyle != "normal" && faceStyle == "normal" {
+		synth.oblique = true
+	}
+	return synth
+}
+
+// fontSynthesisOf returns the synthesis recorded for a face.
+func fontSynthesisOf(face text.Face) fontSynthesis {
+	if built := fontChainFaceOf(face); built != nil {
+		return built.synth
+	}
+	return fontSynthesis{}
+}
+
+// drawText draws text like text.Draw, reordering bidirectional lines from
+// their first strong character and applying the face's synthetic bold and
+// oblique.
+func drawText(dst *ebiten.Image, s string, face text.Face, op *text.DrawOptions) {
+	drawTextDirection(dst, s, face, op, bidiAuto)
+}
+
+// drawSynthesizedText draws text like text.Draw with the face's synthetic
+// bold and oblique. Oblique text is slanted about the first line's baseline
+// and bold text is overdrawn with a small horizontal offset; advances are
+// unchanged.
+func drawSynthesizedText(dst *ebiten.Image, s string, face text.Face, op *text.DrawOptions) {
+	synth := fontSynthesisOf(face)
+	if synth == (fontSynthesis{}) {
+		text.Draw(dst, s, face, op)
+		return
+	}
+	if op == nil {
+		op = &text.DrawOptions{}
+	}
+	base := op.GeoM
+	if synth.oblique {
+		ascent := face.Metrics().HAscent
+		op.GeoM.Reset()
+		op.GeoM.Translate(0, -ascent)
+		op.GeoM.Skew(-syntheticObliqueAngle, 0)
+		op.GeoM.Translate(0, ascent)
+		op.GeoM.Concat(base)
+	}
+	if synth.bold > 0 {
+		drawSyntheticBold(dst, s, face, op, synth.bold)
+	} else {
+		text.Draw(dst, s, face, op)
+	}
+	op.GeoM = base
+}
+
+// drawSyntheticBold draws text overdrawn at horizontal offsets of up to bold
+// pixels. The copies are drawn opaque into an offscreen layer that is
+// composited once with the colour scale, so translucent text does not
+// darken where the copies overlap.
+func drawSyntheticBold(dst *ebiten.Image, s string, face text.Face, op *text.DrawOptions, bold float64) {
+	area := syntheticBoldBounds(s, face, op, bold).Intersect(dst.Bounds())
+	if area.Empty() {
+		return
+	}
+	geoM, colorScale, blend := op.GeoM, op.ColorScale, op.Blend
+	pooled := globalImagePool.Get(area.Dx(), area.Dy())
+	defer globalImagePool.Put(pooled)
+	layer := pooled.SubImage(image.Rect(0, 0, area.Dx(), area.Dy())).(*ebiten.Image)
+
+	op.ColorScale = ebiten.ColorScale{}
+	op.Blend = ebiten.Blend{}
+	steps := int(math.Ceil(bold))
+	for i := 0; i <= steps; i++ {
+		op.GeoM.Reset()
+		op.GeoM.Translate(bold*float64(i)/float64(steps), 0)
+		op.GeoM.Concat(geoM)
+		op.GeoM.Translate(-float64(area.Min.X), -float64(area.Min.Y))
+		text.Draw(layer, s, face, op)
+	}
+	op.GeoM, op.ColorScale, op.Blend = geoM, colorScale, blend
+
+	composite := &ebiten.DrawImageOptions{ColorScale: colorScale, Blend: blend}
+	composite.GeoM.Translate(float64(area.Min.X), float64(area.Min.Y))
+	dst.DrawImage(layer, composite)
+}
+
+// syntheticBoldBounds returns the destination pixels that text drawn with op
+// and emboldened by bold pixels can touch: its measured box, aligned as
+// op aligns it, padded for glyphs that overhang their advance and mapped
+// through op.GeoM.
+func syntheticBoldBounds(s string, face text.Face, op *text.DrawOptions, bold float64) image.Rectangle {
+	w, h := text.Measure(s, face, op.LineSpacing)
+	x, y := 0.0, 0.0
+	switch op.PrimaryAlign {
+	case text.AlignCenter:
+		x = -w / 2
+	case text.AlignEnd:
+		x = -w
+	}
+	switch op.SecondaryAlign {
+	case text.AlignCenter:
+		y = -h / 2
+	case text.AlignEnd:
+		y = -h
+	}
+	pad := face.Metrics().HAscent / 2
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, corner := range [4][2]float64{
+		{x - pad, y - pad}, {x + w + bold + pad, y - pad},
+		{x - pad, y + h + pad}, {x + w + bold + pad, y + h + pad},
+	} {
+		cx, cy := op.GeoM.Apply(corner[0], corner[1])
+		minX, minY = math.Min(minX, cx), math.Min(minY, cy)
+		maxX, maxY = math.Max(maxX, cx), math.Max(maxY, cy)
+	}
+	return image.Rect(int(math.Floor(minX)), int(math.Floor(minY)), int(math.Ceil(maxX)), int(math.Ceil(maxY)))
+}
+
+// fontVariation is one font-variation-settings axis value.
+type fontVariation struct {
+	tag   text.Tag
+	value float32
+}
+
+// parseFontVariationSettings parses font-variation-settings: normal or a
+// comma list of "<4-letter axis tag>" <number>.
+func parseFontVariationSettings(value string) []fontVariation {
+	value = strings.TrimSpace(value)
+	if value == "" || strings.EqualFold(value, "normal") {
+		return nil
+	}
+	var variations []fontVariation
+	for _, part := range splitCSSList(value) {
+		fields := strings.Fields(part)
+		if len(fields) != 2 {
+			continue
+		}
+		tag, err := text.ParseTag(strings.Trim(fields[0], `"'`))
+		if err != nil {
+			continue
+		}
+		number, err := strconv.ParseFloat(fields[1], 32)
+		if err != nil {
+			continue
+		}
+		variations = append(variations, fontVariation{tag: tag, value: float32(number)})
+	}
+	return variations
+}
+
+// withWeightAxis puts the weight on the wght axis of a variable face whose
+// @font-face declares a weight range, unless font-variation-settings sets
+// wght itself.
+func withWeightAxis(variations []fontVariation, variant *fontVariant, weight int) []fontVariation {
+	if variant.weightMin == variant.weightMax {
+		return variations
+	}
+	wght := text.MustParseTag("wght")
+	for _, v := range variations {
+		if v.tag == wght {
+			return variations
+		}
+	}
+	clamped := math.Min(math.Max(float64(weight), float64(variant.weightMin)), float64(variant.weightMax))
+	return append([]fontVariation{{tag: wght, value: float32(clamped)}}, variations...)
+}
@@ -0,0 +1,135 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+func TestFontSynthesisAndVariableFaces(t *testing.T) {
+	defaultFace := testTextFace()
+	ui := New(320, 200)
+	ui.DefaultFontFace = defaultFace
+	ui.SetAssetResolver(newMapAssets(map[string][]byte{"regular.ttf": goregular.TTF}))
+	if err := ui.LoadCSS(`
+		@font-face { font-family: Go; src: url(regular.ttf); }
+		@font-face { font-family: Flex; src: url(regular.ttf); font-weight: 100 900; }
+		.bold { font-weight: 700; }
+		.italic { font-style: italic; }
+		.go { font-family: Go; font-size: 18px; }
+		.flex { font-family: Flex; font-size: 18px; }
+		.axes { font-variation-settings: "wdth" 80; }
+		.wght { font-variation-settings: "wght" 300; }
+	`); err != nil {
+		t.Fatalf("LoadCSS: %v", err)
+	}
+	if err := ui.LoadLayout(`<panel id="root">
+		<text id="plain">Hello</text>
+		<text id="bold" class="bold">Hello</text>
+		<text id="italic" class="italic">Hello</text>
+		<text id="goBold" class="go bold">Hello</text>
+		<text id="flexBold" class="flex bold">Hello</text>
+		<text id="flexAxes" class="flex bold axes">Hello</text>
+		<text id="flexWght" class="flex bold wght">Hello</text>
+	</panel>`); err != nil {
+		t.Fatalf("LoadLayout: %v", err)
+	}
+	face := func(id string) text.Face { return ui.GetText(id).FontFace }
+
+	if face("plain") != defaultFace {
+		t.Error("normal text should use the default face unsynthesized")
+	}
+	// Faces without a matching weight or style synthesize it; a variable
+	// face covering the weight does not.
+	for _, tc := range []struct {
+		id      string
+		bold    bool
+		oblique bool
+	}{
+		{"plain", false, false},
+		{"bold", true, false},
+		{"italic", false, true},
+		{"goBold", true, false},
+		{"flexBold", false, false},
+	} {
+		if synth := fontSynthesisOf(face(tc.id)); (synth.bold > 0) != tc.bold || synth.oblique != tc.oblique {
+			t.Errorf("#%s synthesis = %+v, want bold=%v oblique=%v", tc.id, synth, tc.bold, tc.oblique)
+		}
+	}
+
+	plainWidth, _ := text.Measure("Hello", defaultFace, 0)
+	if boldWidth, _ := text.Measure("Hello", face("bold"), 0); boldWidth != plainWidth {
+		t.Errorf("synthetic bold width = %v, want unchanged %v", boldWidth, plainWidth)
+	}
+
+	flexFace, ok := face("flexBold").(*text.GoTextFace)
+	if !ok {
+		t.Fatalf("flexBold FontFace = %T, want *text.GoTextFace", face("flexBold"))
+	}
+	if face("flexAxes") == flexFace || face("flexWght") == flexFace || face("flexAxes") == face("flexWght") {
+		t.Error("different variation settings should use different faces")
+	}
+
+	dst := ebiten.NewImage(120, 40)
+	op := &text.DrawOptions{}
+	op.GeoM.Translate(5, 7)
+	drawText(dst, "Hello", face("bold"), op)
+	drawText(dst, "Hello", face("italic"), op)
+	if x, y := op.GeoM.Apply(0, 0); x != 5 || y != 7 {
+		t.Errorf("drawText left GeoM translated to (%v, %v), want it restored", x, y)
+	}
+}
+
+func TestParseFontVariationSettings(t *testing.T) {
+	settings := parseFontVariationSettings(`"wdth" 80.5, 'slnt' -10, bogus, "toolong" 1`)
+	if len(settings) != 2 || settings[0].tag != text.MustParseTag("wdth") || settings[0].value != 80.5 {
+		t.Fatalf("parseFontVariationSettings = %+v", settings)
+	}
+}
+
+func TestWithWeightAxis(t *testing.T) {
+	variant := &fontVariant{weightMin: 100, weightMax: 900}
+	wght := text.MustParseTag("wght")
+	settings := []fontVariation{{tag: text.MustParseTag("wdth"), value: 80}}
+	if axes := withWeightAxis(settings, variant, 950); len(axes) != 2 || axes[0].tag != wght || axes[0].value != 900 {
+		t.Errorf("withWeightAxis = %+v, want wght clamped to 900 first", axes)
+	}
+	explicit := []fontVariation{{tag: wght, value: 250}}
+	if axes := withWeightAxis(explicit, variant, 700); len(axes) != 1 || axes[0].value != 250 {
+		t.Errorf("withWeightAxis = %+v, want the explicit wght kept", axes)
+	}
+	if axes := withWeightAxis(nil, &fontVariant{weightMin: 400, weightMax: 400}, 700); axes != nil {
+		t.Errorf("withWeightAxis on a static face = %+v, want none", axes)
+	}
+}
+
+func TestUnusedFontChainsAreEvicted(t *testing.T) {
+	ui := New(320, 200)
+	ui.DefaultFontFace = testTextFace()
+	if err := ui.LoadCSS(`#label { font-weight: 700; }`); err != nil {
+		t.Fatalf("LoadCSS: %v", err)
+	}
+	if err := ui.LoadLayout(`<panel id="root"><text id="label">Hello</text></panel>`); err != nil {
+		t.Fatalf("LoadLayout: %v", err)
+	}
+	label := ui.GetText("label")
+	bold := label.FontFace
+	rtl := rtlFace(bold)
+	if fontSynthesisOf(bold).bold <= 0 {
+		t.Fatal("bold text in the default face should synthesize bold")
+	}
+
+	// A chain survives the first pass that stops using it and is evicted,
+	// with its right-to-left copy, by the next.
+	label.Style().FontWeight = "400"
+	ui.setFonts(ui.root)
+	if fontChainFaceOf(bold) == nil {
+		t.Fatal("a chain used in the previous pass should be kept")
+	}
+	ui.setFonts(ui.root)
+	if fontChainFaceOf(bold) != nil || (rtl != nil && fontChainFaceOf(rtl) != nil) || len(ui.fontChains) != 0 {
+		t.Fatalf("unused chain kept after a full pass, %d chains left", len(ui.fontChains))
+	}
+}
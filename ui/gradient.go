@@ -0,0 +1,469 @@
+package ui
+
+import (
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// ============================================================================
+// CSS gradient parsing and drawing
+// ============================================================================
+
+// maxResolvedGradients bounds the per-length copies kept for gradients with
+// pixel stop positions.
+const maxResolvedGradients = 16
+
+// gradientStopSpec is a color stop or color hint as written, before its
+// position is resolved against the gradient line.
+type gradientStopSpec struct {
+	color    color.Color // nil for a color hint
+	position gradientLength
+	hasPos   bool
+}
+
+// gradientLength is a stop position: a fraction of the gradient line, or a
+// length in pixels along it.
+type gradientLength struct {
+	value  float64
+	pixels bool
+}
+
+// parseCSSGradient parses linear-gradient, radial-gradient, conic-gradient
+// and their repeating- forms. Stops take zero, one or two positions; a bare
+// position between two stops is a color hint.
+func parseCSSGradient(value string) *Gradient {
+	tokens := trimCSSWhitespace(tokenizeCSS(strings.TrimSpace(value)))
+	if len(tokens) < 2 || tokens[0].Kind != cssTokenFunction {
+		return nil
+	}
+	args, end := cssFunctionArguments(tokens, 1)
+	if end != len(tokens)-1 {
+		return nil
+	}
+
+	g := &Gradient{}
+	name, repeating := strings.CutPrefix(strings.ToLower(tokens[0].Value), "repeating-")
+	g.Repeating = repeating
+	switch name {
+	case "linear-gradient":
+		g.Type = GradientLinear
+		g.Angle = 90 // default to horizontal
+	case "radial-gradient":
+		g.Type = GradientRadial
+	case "conic-gradient":
+		g.Type = GradientConic
+	default:
+		return nil
+	}
+
+	if len(args) > 0 && len(args[0]) > 0 {
+		first := splitCSSComponents(serializeCSSTokens(args[0]))
+		if len(first) > 0 && parseColor(first[0]) == nil && !isGradientHint(first) {
+			if !g.parseConfig(first) {
+				return nil
+			}
+			args = args[1:]
+		}
+	}
+
+	var specs []gradientStopSpec
+	usesPixels := false
+	for _, arg := range args {
+		components := splitCSSComponents(serializeCSSTokens(arg))
+		if len(components) == 0 {
+			return nil
+		}
+		if len(components) == 1 {
+			if pos, ok := parseGradientPosition(components[0], g.Type); ok {
+				specs = append(specs, gradientStopSpec{position: pos, hasPos: true})
+				usesPixels = usesPixels || pos.pixels
+				continue
+			}
+		}
+		clr := parseColor(components[0])
+		if clr == nil || len(components) > 3 {
+			return nil
+		}
+		if len(components) == 1 {
+			specs = append(specs, gradientStopSpec{color: clr})
+			continue
+		}
+		for _, text := range components[1:] {
+			pos, ok := parseGradientPosition(text, g.Type)
+			if !ok {
+				return nil
+			}
+			specs = append(specs, gradientStopSpec{color: clr, position: pos, hasPos: true})
+			usesPixels = usesPixels || pos.pixels
+		}
+	}
+	specs = dropInvalidColorHints(specs)
+	if len(specs) < 2 {
+		return nil
+	}
+	if usesPixels {
+		if g.Type == GradientConic {
+			return nil
+		}
+		g.stopSpecs = specs
+	}
+	g.ColorStops = resolveGradientStops(specs, 1)
+	return g
+}
+
+// parseConfig applies the leading gradient argument: the direction of a
+// linear gradient, the "at" center of a radial gradient (shape and size
+// keywords are accepted and ignored) or the "from" angle and "at" center of a
+// conic gradient.
+func (g *Gradient) parseConfig(components []string) bool {
+	lower := make([]string, len(components))
+	for i, c := range components {
+		lower[i] = strings.ToLower(c)
+	}
+	if g.Type == GradientLinear {
+		if lower[0] == "to" {
+			angle, ok := gradientSideAngle(lower[1:])
+			g.Angle = angle
+			return ok
+		}
+		angle, ok := parseGradientAngle(lower[0])
+		g.Angle = angle
+		return ok && len(lower) == 1
+	}
+	for i := 0; i < len(lower); i++ {
+		switch {
+		case lower[i] == "at":
+			if i+1 >= len(lower) {
+				return false
+			}
+			g.Center = strings.Join(lower[i+1:], " ")
+			return true
+		case lower[i] == "from" && g.Type == GradientConic:
+			if i+1 >= len(lower) {
+				return false
+			}
+			angle, ok := parseGradientAngle(lower[i+1])
+			if !ok {
+				return false
+			}
+			g.Angle = angle
+			i++
+		case g.Type == GradientRadial:
+			// circle, ellipse, closest-side, farthest-corner, lengths...
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// gradientSideAngle converts "to <side> [<side>]" to an angle. Corners use
+// the diagonal of a square box.
+func gradientSideAngle(sides []string) (float64, bool) {
+	if len(sides) == 0 || len(sides) > 2 {
+		return 0, false
+	}
+	var x, y float64
+	for _, side := range sides {
+		switch side {
+		case "top":
+			y = -1
+		case "bottom":
+			y = 1
+		case "left":
+			x = -1
+		case "right":
+			x = 1
+		default:
+			return 0, false
+		}
+	}
+	if x == 0 && y == 0 {
+		return 0, false
+	}
+	angle := math.Atan2(x, -y) * 180 / math.Pi
+	if angle < 0 {
+		angle += 360
+	}
+	return angle, true
+}
+
+// parseGradientAngle parses an angle in deg, grad, rad or turn (or a unitless
+// zero) to degrees.
+func parseGradientAngle(text string) (float64, bool) {
+	tokens := tokenizeCSS(text)
+	if len(tokens) != 1 {
+		return 0, false
+	}
+	tok := tokens[0]
+	n, err := strconv.ParseFloat(tok.Value, 64)
+	if err != nil {
+		return 0, false
+	}
+	if tok.Kind == cssTokenNumber && n == 0 {
+		return 0, true
+	}
+	if tok.Kind != cssTokenDimension {
+		return 0, false
+	}
+	switch strings.ToLower(tok.Unit) {
+	case "deg":
+		return n, true
+	case "grad":
+		return n * 0.9, true
+	case "rad":
+		return n * 180 / math.Pi, true
+	case "turn":
+		return n * 360, true
+	}
+	return 0, false
+}
+
+// parseGradientPosition parses a stop position. Percentages (and angles for
+// conic gradients) are fractions of the gradient line; lengths are pixels.
+func parseGradientPosition(text string, kind GradientType) (gradientLength, bool) {
+	if strings.HasSuffix(text, "%") {
+		f, err := strconv.ParseFloat(strings.TrimSuffix(text, "%"), 64)
+		return gradientLength{value: f / 100}, err == nil
+	}
+	if kind == GradientConic {
+		angle, ok := parseGradientAngle(strings.ToLower(text))
+		return gradientLength{value: angle / 360}, ok
+	}
+	tokens := tokenizeCSS(text)
+	if len(tokens) != 1 || (tokens[0].Kind != cssTokenNumber && tokens[0].Kind != cssTokenDimension) || !cssTokenIsLength(tokens[0]) {
+		return gradientLength{}, false
+	}
+	if tokens[0].Kind == cssTokenNumber {
+		// Only a unitless zero is a valid length.
+		return gradientLength{}, tokens[0].Value == "0" || strings.Trim(tokens[0].Value, "0.") == ""
+	}
+	return gradientLength{value: parseCSSPixels(text), pixels: true}, true
+}
+
+func isGradientHint(components []string) bool {
+	if len(components) != 1 {
+		return false
+	}
+	_, ok := parseGradientPosition(components[0], GradientLinear)
+	return ok
+}
+
+// dropInvalidColorHints removes hints that do not sit between two color stops.
+func dropInvalidColorHints(specs []gradientStopSpec) []gradientStopSpec {
+	out := specs[:0]
+	for i, spec := range specs {
+		if spec.color == nil {
+			if i == 0 || i == len(specs)-1 || specs[i+1].color == nil || len(out) == 0 || out[len(out)-1].color == nil {
+				continue
+			}
+		}
+		out = append(out, spec)
+	}
+	return out
+}
+
+// resolveGradientStops turns stop specs into positioned stops for a gradient
+// line of the given length in pixels: the first and last stops default to
+// 0 and 1, positions never decrease, and unpositioned stops are spread
+// evenly between their positioned neighbours.
+func resolveGradientStops(specs []gradientStopSpec, length float64) []ColorStop {
+	n := len(specs)
+	stops := make([]ColorStop, n)
+	set := make([]bool, n)
+	for i, spec := range specs {
+		stops[i] = ColorStop{Color: spec.color, Hint: spec.color == nil}
+		if !spec.hasPos {
+			continue
+		}
+		pos := spec.position.value
+		if spec.position.pixels {
+			pos = 0
+			if length > 0 {
+				pos = spec.position.value / length
+			}
+		}
+		stops[i].Position = pos
+		set[i] = true
+	}
+	if !set[0] {
+		stops[0].Position, set[0] = 0, true
+	}
+	if !set[n-1] {
+		stops[n-1].Position, set[n-1] = 1, true
+	}
+	highest := stops[0].Position
+	for i := range stops {
+		if !set[i] {
+			continue
+		}
+		if stops[i].Position < highest {
+			stops[i].Position = highest
+		}
+		highest = stops[i].Position
+	}
+	for i := 1; i < n; {
+		if set[i] {
+			i++
+			continue
+		}
+		j := i
+		for !set[j] {
+			j++
+		}
+		from, to := stops[i-1].Position, stops[j].Position
+		for k := i; k < j; k++ {
+			stops[k].Position = from + (to-from)*float64(k-i+1)/float64(j-i+1)
+		}
+		i = j
+	}
+	return stops
+}
+
+// forLength returns the gradient with pixel stop positions resolved for a
+// gradient line of the given length. Gradients without pixel stops are
+// returned as-is.
+func (g *Gradient) forLength(length float64) *Gradient {
+	if len(g.stopSpecs) == 0 {
+		return g
+	}
+	key := int(math.Round(length))
+	if resolved, ok := g.resolved[key]; ok {
+		return resolved
+	}
+	if g.resolved == nil || len(g.resolved) >= maxResolvedGradients {
+		for _, old := range g.resolved {
+			old.clearGradientStrip()
+		}
+		g.resolved = make(map[int]*Gradient)
+	}
+	resolved := &Gradient{
+		Type:       g.Type,
+		Angle:      g.Angle,
+		ColorStops: resolveGradientStops(g.stopSpecs, float64(key)),
+		Repeating:  g.Repeating,
+		Center:     g.Center,
+	}
+	g.resolved[key] = resolved
+	return resolved
+}
+
+// period returns the start and length of one repetition of the stops, and
+// whether the gradient repeats. Gradients that do not repeat, or whose stops
+// span no distance, use the whole line: 0, 1.
+func (g *Gradient) period() (float64, float64, bool) {
+	if !g.Repeating || len(g.ColorStops) == 0 {
+		return 0, 1, false
+	}
+	first, last := g.ColorStops[0].Position, g.ColorStops[len(g.ColorStops)-1].Position
+	if last-first <= 1e-6 {
+		return 0, 1, false
+	}
+	return first, last - first, true
+}
+
+// periodUniforms adds the repeat uniforms shared by the gradient shaders.
+func (g *Gradient) periodUniforms(uniforms map[string]any) map[string]any {
+	start, span, repeats := g.period()
+	uniforms["Repeat"] = float32(0)
+	if repeats {
+		uniforms["Repeat"] = float32(1)
+	}
+	uniforms["PeriodStart"] = float32(start)
+	uniforms["PeriodLength"] = float32(span)
+	return uniforms
+}
+
+// colorAt returns the color at gradient position t, wrapping repeating
+// gradients.
+func (g *Gradient) colorAt(t float64) color.Color {
+	if start, span, repeats := g.period(); repeats {
+		f := (t - start) / span
+		t = start + span*(f-math.Floor(f))
+	}
+	return interpolateGradient(g.ColorStops, t)
+}
+
+// centerIn returns the gradient center inside r.
+func (g *Gradient) centerIn(r Rect) (float64, float64) {
+	if g.Center == "" {
+		return r.X + r.W/2, r.Y + r.H/2
+	}
+	x, y := backgroundPositionOffset(g.Center, r.W, r.H)
+	return r.X + x, r.Y + y
+}
+
+// DrawConicGradient draws a conic gradient in the given rectangle, sweeping
+// clockwise from the gradient's starting angle around its center.
+func DrawConicGradient(screen *ebiten.Image, r Rect, g *Gradient) {
+	if g == nil || len(g.ColorStops) < 2 {
+		return
+	}
+
+	cx, cy := g.centerIn(r)
+	strip := g.ensureGradientStrip()
+
+	x, y := float32(r.X), float32(r.Y)
+	w, h := float32(math.Max(1, math.Floor(r.W))), float32(math.Max(1, math.Floor(r.H)))
+	sw, sh := float32(strip.Bounds().Dx()), float32(strip.Bounds().Dy())
+	vertices := []ebiten.Vertex{
+		{DstX: x, DstY: y, SrcX: 0, SrcY: 0, ColorR: 1, ColorG: 1, ColorB: 1, ColorA: 1},
+		{DstX: x + w, DstY: y, SrcX: sw, SrcY: 0, ColorR: 1, ColorG: 1, ColorB: 1, ColorA: 1},
+		{DstX: x, DstY: y + h, SrcX: 0, SrcY: sh, ColorR: 1, ColorG: 1, ColorB: 1, ColorA: 1},
+		{DstX: x + w, DstY: y + h, SrcX: sw, SrcY: sh, ColorR: 1, ColorG: 1, ColorB: 1, ColorA: 1},
+	}
+	indices := []uint16{0, 1, 2, 1, 3, 2}
+
+	top := &ebiten.DrawTrianglesShaderOptions{}
+	top.Images[0] = strip
+	top.Uniforms = g.periodUniforms(map[string]any{
+		"CenterX": float32(cx),
+		"CenterY": float32(cy),
+		"From":    float32(g.Angle * math.Pi / 180),
+	})
+	screen.DrawTrianglesShader(vertices, indices, getConicGradientShader(), top)
+}
+
+// drawGradientFill draws any gradient type over r.
+func drawGradientFill(screen *ebiten.Image, r Rect, g *Gradient) {
+	switch g.Type {
+	case GradientRadial:
+		DrawRadialGradient(screen, r, g)
+	case GradientConic:
+		DrawConicGradient(screen, r, g)
+	default:
+		DrawGradient(screen, r, g)
+	}
+}
+
+// buildConicGradientTexture creates a 2D texture for a CSS conic gradient.
+// Used by DrawGradientClipped for UV-mapped tessellated rendering with border-radius.
+func buildConicGradientTexture(g *Gradient, w, h int) *ebiten.Image {
+	if w <= 0 || h <= 0 {
+		return nil
+	}
+
+	img := ebiten.NewImage(w, h)
+	pix := make([]byte, w*h*4)
+	cx, cy := g.centerIn(Rect{W: float64(w), H: float64(h)})
+	from := g.Angle * math.Pi / 180
+
+	for py := 0; py < h; py++ {
+		for px := 0; px < w; px++ {
+			turn := (math.Atan2(float64(px)-cx, cy-float64(py)) - from) / (2 * math.Pi)
+			c := g.colorAt(turn - math.Floor(turn))
+			cr, cg, cb, ca := c.RGBA()
+			off := (py*w + px) * 4
+			pix[off+0] = uint8(cr >> 8)
+			pix[off+1] = uint8(cg >> 8)
+			pix[off+2] = uint8(cb >> 8)
+			pix[off+3] = uint8(ca >> 8)
+		}
+	}
+	img.WritePixels(pix)
+	return img
+}
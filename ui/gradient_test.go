@@ -0,0 +1,58 @@
+package ui
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestParseGradient(t *testing.T) {
+	for _, tc := range []struct {
+		value string
+		check func(*Gradient) bool // nil when the value must not parse
+	}{
+		{"conic-gradient(from 90deg at 25% 75%, red, yellow 0.25turn, blue 180deg, red)", func(g *Gradient) bool {
+			return g.Type == GradientConic && g.Angle == 90 && g.Center == "25% 75%" &&
+				g.ColorStops[1].Position == 0.25 && g.ColorStops[2].Position == 0.5
+		}},
+		{"linear-gradient(to top right, red, blue)", func(g *Gradient) bool { return g.Angle == 45 }},
+		{"radial-gradient(circle at top left, red, blue)", func(g *Gradient) bool {
+			return g.Type == GradientRadial && g.Center == "top left"
+		}},
+		{"linear-gradient(45deg)", nil},
+		{"linear-gradient(red, blue) extra", nil},
+		{"conic-gradient(red 10px, blue)", nil},
+	} {
+		g := ParseGradient(tc.value)
+		switch {
+		case tc.check == nil && g != nil:
+			t.Errorf("%q should not parse, got %+v", tc.value, g)
+		case tc.check != nil && (g == nil || !tc.check(g)):
+			t.Errorf("ParseGradient(%q) = %+v", tc.value, g)
+		}
+	}
+}
+
+func TestGradientColorAt(t *testing.T) {
+	red, blue := color.RGBA{255, 0, 0, 255}, color.RGBA{0, 0, 255, 255}
+	// Pixel stops resolve against the gradient line and repeat every period.
+	stripes := ParseGradient("repeating-linear-gradient(red 0 10px, blue 10px 20px)").forLength(100)
+	if start, span, repeats := stripes.period(); start != 0 || span != 0.2 || !repeats {
+		t.Fatalf("stripe period = %v %v %v, want 0 0.2 true", start, span, repeats)
+	}
+	for _, tc := range []struct {
+		name     string
+		gradient *Gradient
+		t        float64
+		want     color.RGBA
+	}{
+		{"first stripe", stripes, 0.05, red},
+		{"second stripe", stripes, 0.15, blue},
+		{"repeated stripe", stripes, 0.25, red},
+		{"color hint midpoint", ParseGradient("linear-gradient(red, 25%, blue)"), 0.25, color.RGBA{128, 0, 128, 255}},
+		{"premultiplied fade", ParseGradient("linear-gradient(red, transparent)"), 0.5, color.RGBA{128, 0, 0, 128}},
+	} {
+		if got := color.RGBAModel.Convert(tc.gradient.colorAt(tc.t)); got != tc.want {
+			t.Errorf("%s at %v = %v, want %v", tc.name, tc.t, got, tc.want)
+		}
+	}
+}
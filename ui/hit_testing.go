@@ -0,0 +1,160 @@
+package ui
+
+import (
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// ============================================================================
+// Shape-aware hit testing — transforms, border radii, clip-path and
+// pointer-events
+// ============================================================================
+
+// undoAnimationTransform maps a point from the space the widget is drawn
+// into back through its keyframe animation transform.
+func (w *BaseWidget) undoAnimationTransform(x, y float64) (float64, float64, bool) {
+	geoM, hasTransform, _ := w.animationTransform()
+	if !hasTransform {
+		return x, y, true
+	}
+	if !geoM.IsInvertible() {
+		return 0, 0, false
+	}
+	geoM.Invert()
+	x, y = geoM.Apply(x, y)
+	return x, y, true
+}
+
+// undoTransform maps a point back through the widget's 2D CSS transform,
+// built as compositeLayer draws it.
+func (w *BaseWidget) undoTransform(x, y float64, style *Style) (float64, float64, bool) {
+	if style.Transform == "" || style.Transform == "none" {
+		return x, y, true
+	}
+	r := w.computedRect
+	originX, originY := parseCSSTransformOrigin(style.TransformOrigin, r.W, r.H)
+	geoM := parseCSSTransform(style.Transform, r.X+originX, r.Y+originY)
+	if !geoM.IsInvertible() {
+		return 0, 0, false
+	}
+	geoM.Invert()
+	x, y = geoM.Apply(x, y)
+	return x, y, true
+}
+
+// layoutPoint maps a screen point into the widget's layout coordinates as
+// findWidgetAt does: back through every ancestor's transforms and scroll
+// offset, then through the widget's own transforms. ok is false when a
+// transform on the way cannot be inverted.
+func layoutPoint(widget Widget, x, y float64) (float64, float64, bool) {
+	var path []Widget
+	for current := widget; current != nil; current = current.Parent() {
+		path = append(path, current)
+	}
+	for i := len(path) - 1; i >= 0; i-- {
+		bw := baseWidgetOf(path[i])
+		if bw == nil {
+			continue
+		}
+		style := bw.renderStyle(bw.getActiveStyle())
+		lx, ly, ok := bw.undoAnimationTransform(x, y)
+		preserve := false
+		if m, is3D := bw.transform3D(style); is3D {
+			preserve = preserves3D(style)
+			if ok {
+				lx, ly, ok = m.unproject(lx, ly)
+			}
+		} else if ok {
+			lx, ly, ok = bw.undoTransform(lx, ly, style)
+		}
+		if i == 0 {
+			return lx, ly, ok
+		}
+		// Children of a preserve-3d widget project themselves from the
+		// original point.
+		if !preserve {
+			if !ok {
+				return 0, 0, false
+			}
+			x, y = lx, ly
+		}
+		if style.Overflow == "scroll" || style.Overflow == "auto" {
+			scrollX, scrollY := bw.ScrollOffset()
+			x += scrollX
+			y += scrollY
+		}
+	}
+	return x, y, true
+}
+
+// containsPoint reports whether a point in layout coordinates lies in the
+// widget's border box with its rounded corners cut away.
+func (w *BaseWidget) containsPoint(x, y float64, style *Style) bool {
+	r := w.computedRect
+	if !r.Contains(x, y) {
+		return false
+	}
+	radTL, radTR, radBR, radBL := w.getCornerRadii(style)
+	// Radii are clamped to half the smallest side, as DrawRoundedRectPathEx
+	// draws them.
+	limit := min(r.W, r.H) / 2
+	corners := [4]struct{ radius, cx, cy float64 }{
+		{radTL, r.X, r.Y},
+		{radTR, r.X + r.W, r.Y},
+		{radBR, r.X + r.W, r.Y + r.H},
+		{radBL, r.X, r.Y + r.H},
+	}
+	for _, corner := range corners {
+		radius := min(corner.radius, limit)
+		if radius <= 0 {
+			continue
+		}
+		// Centre of the corner arc, inset from the box corner.
+		cx := corner.cx + math.Copysign(radius, r.X+r.W/2-corner.cx)
+		cy := corner.cy + math.Copysign(radius, r.Y+r.H/2-corner.cy)
+		inCornerX := math.Abs(x-corner.cx) < radius
+		inCornerY := math.Abs(y-corner.cy) < radius
+		if inCornerX && inCornerY && math.Hypot(x-cx, y-cy) > radius {
+			return false
+		}
+	}
+	return true
+}
+
+// clipPathContains reports whether a point lies inside a CSS clip-path
+// resolved against the border box r. No clip-path contains every point.
+func clipPathContains(clipPath string, r Rect, x, y float64) bool {
+	path := parseCSSClipPath(clipPath, r)
+	if path == nil {
+		return true
+	}
+	return pathContains(path, x, y)
+}
+
+// pathContains tests a point against a path with the non-zero fill rule.
+// The fill triangles are fans that the stencil counts up or down by their
+// winding, so the point is inside when the signed count is not zero.
+func pathContains(path *vector.Path, x, y float64) bool {
+	vertices, indices := path.AppendVerticesAndIndicesForFilling(nil, nil)
+	winding := 0
+	for i := 0; i+2 < len(indices); i += 3 {
+		a, b, c := vertices[indices[i]], vertices[indices[i+1]], vertices[indices[i+2]]
+		ax, ay := float64(a.DstX), float64(a.DstY)
+		bx, by := float64(b.DstX), float64(b.DstY)
+		cx, cy := float64(c.DstX), float64(c.DstY)
+		area := (bx-ax)*(cy-ay) - (by-ay)*(cx-ax)
+		if area == 0 {
+			continue
+		}
+		d1 := (bx-ax)*(y-ay) - (by-ay)*(x-ax)
+		d2 := (cx-bx)*(y-by) - (cy-by)*(x-bx)
+		d3 := (ax-cx)*(y-cy) - (ay-cy)*(x-cx)
+		if area > 0 && d1 >= 0 && d2 >= 0 && d3 >= 0 {
+			winding++
+		} else if area < 0 && d1 <= 0 && d2 <= 0 && d3 <= 0 {
+			winding--
+		}
+	}
+	return winding != 0
+}
@@ -0,0 +1,388 @@
+package ui
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"unicode/utf8"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/exp/textinput"
+)
+
+// ============================================================================
+// IME composition for editable text
+// ============================================================================
+
+// textComposition is the IME state of an editable widget: the platform
+// field that receives composition events, and the preedit text being
+// composed in place of the selection, drawn inline until it is committed.
+type textComposition struct {
+	field   textinput.Field
+	failed  bool   // the platform reported an error; plain input is used
+	preedit string // text being composed, not yet part of the widget's text
+	caret   int    // caret within preedit, in runes
+}
+
+// imeUpdate is what one poll of the IME produced.
+type imeUpdate struct {
+	handled bool // the IME took this frame's text input
+
+	// committed reports that commit replaces the runes
+	// [replaceStart, replaceEnd) of the text.
+	committed    bool
+	commit       string
+	replaceStart int
+	replaceEnd   int
+
+	preedit      string
+	preeditCaret int
+}
+
+// composing reports whether a preedit is being composed.
+func (c *textComposition) composing() bool {
+	return c.preedit != ""
+}
+
+// set replaces the preedit, with the caret at rune caret within it.
+func (c *textComposition) set(preedit string, caret int) {
+	n := utf8.RuneCountInString(preedit)
+	if caret < 0 || caret > n {
+		caret = n
+	}
+	c.preedit, c.caret = preedit, caret
+}
+
+// compose returns text with the preedit in place of the runes [start, end)
+// and the caret's rune index in the result.
+func (c *textComposition) compose(text string, start, end int) (string, int) {
+	runes := []rune(text)
+	return string(runes[:start]) + c.preedit + string(runes[end:]), start + c.caret
+}
+
+// end drops the preedit and ends the platform's text input session.
+func (c *textComposition) end() {
+	c.preedit, c.caret = "", 0
+	c.field.Blur()
+}
+
+// poll feeds the platform's text input events to the field for text with
+// the rune selection [start, end). The candidate window opens at caret. It
+// reports false where the platform failed, so the caller reads plain
+// characters instead.
+func (c *textComposition) poll(text string, start, end int, caret Rect) (imeUpdate, bool) {
+	if c.failed {
+		return imeUpdate{}, false
+	}
+	startByte, endByte := runeByteOffset(text, start), runeByteOffset(text, end)
+	// Syncing ends the session, so it only happens when the widget's text
+	// or selection changed since the last poll.
+	if s, e := c.field.Selection(); c.field.Text() != text || s != startByte || e != endByte {
+		c.field.SetTextAndSelection(text, startByte, endByte)
+	}
+	c.field.Focus()
+	bounds := image.Rect(int(math.Floor(caret.X)), int(math.Floor(caret.Y)), int(math.Ceil(caret.X+caret.W)), int(math.Ceil(caret.Y+caret.H)))
+	handled, err := c.field.HandleInputWithBounds(bounds)
+	if err != nil {
+		c.failed = true
+		c.end()
+		return imeUpdate{}, false
+	}
+
+	u := imeUpdate{handled: handled}
+	if n := c.field.UncommittedTextLengthInBytes(); n > 0 {
+		s, _ := c.field.Selection()
+		u.preedit = c.field.TextForRendering()[s : s+n]
+		u.preeditCaret = utf8.RuneCountInString(u.preedit)
+		if cs, _, ok := c.field.CompositionSelection(); ok && cs <= len(u.preedit) {
+			u.preeditCaret = utf8.RuneCountInString(u.preedit[:cs])
+		}
+	}
+
+	if newText := c.field.Text(); newText != text {
+		newCaret, _ := c.field.Selection()
+		u.committed = true
+		u.commit, u.replaceStart, u.replaceEnd = committedRange(text, newText, newCaret)
+	}
+	return u, true
+}
+
+// committedRange works out what the IME committed from the field's text
+// before and after, with the caret after the commit at byte newCaret. A
+// commit replaces the selection and may delete text before it, so the text
+// after the caret is what followed the replaced rune range [start, end).
+func committedRange(text, newText string, newCaret int) (commit string, start, end int) {
+	replaceEnd := len(text) - (len(newText) - newCaret)
+	if replaceEnd < 0 || replaceEnd > len(text) {
+		replaceEnd = len(text)
+	}
+	prefix := 0
+	for prefix < replaceEnd && prefix < newCaret && text[prefix] == newText[prefix] {
+		prefix++
+	}
+	for prefix > 0 && prefix < len(text) && !utf8.RuneStart(text[prefix]) {
+		prefix--
+	}
+	return newText[prefix:newCaret], utf8.RuneCountInString(text[:prefix]), utf8.RuneCountInString(text[:replaceEnd])
+}
+
+// runeByteOffset returns the byte offset of a rune index in s.
+func runeByteOffset(s string, index int) int {
+	for offset := range s {
+		if index == 0 {
+			return offset
+		}
+		index--
+	}
+	return len(s)
+}
+
+// drawCompositionUnderline underlines the runes [start, end) of a laid-out
+// line whose origin is at x, with the underline's top at y.
+func drawCompositionUnderline(dst *ebiten.Image, layout *textLayout, start, end int, x, y float64, clr color.Color) {
+	for _, rect := range layout.SelectionRects(start, end) {
+		DrawRoundedRectPath(dst, Rect{X: x + rect.X, Y: y, W: rect.W, H: 1}, 0, clr)
+	}
+}
+
+// TextInput composition
+
+// selectionOrCaret returns the selection in order, or the caret twice.
+func (ti *TextInput) selectionOrCaret() (int, int) {
+	ti.clampIndices()
+	if ti.SelectStart == ti.SelectEnd {
+		return ti.CursorPos, ti.CursorPos
+	}
+	if ti.SelectStart > ti.SelectEnd {
+		return ti.SelectEnd, ti.SelectStart
+	}
+	return ti.SelectStart, ti.SelectEnd
+}
+
+// handleIME polls the IME, applying what it committed and showing what it
+// is composing, and reports whether it took this frame's text input.
+// Password inputs take plain characters, so the preedit cannot reveal them.
+func (ti *TextInput) handleIME() bool {
+	if ti.Password {
+		return false
+	}
+	start, end := ti.selectionOrCaret()
+	u, ok := ti.composition.poll(ti.Text, start, end, ti.caretRect())
+	if !ok {
+		return false
+	}
+	if u.committed {
+		ti.SelectStart, ti.SelectEnd = u.replaceStart, u.replaceEnd
+		ti.CursorPos = u.replaceEnd
+		ti.commitComposition(u.commit)
+	}
+	ti.setComposition(u.preedit, u.preeditCaret)
+	return u.handled
+}
+
+// setComposition shows preedit as the text being composed in place of the
+// selection, with the caret at rune caret within it. An empty preedit
+// cancels the composition.
+func (ti *TextInput) setComposition(preedit string, caret int) {
+	if ti.Password {
+		return
+	}
+	changed := preedit != ti.composition.preedit || caret != ti.composition.caret
+	ti.composition.set(preedit, caret)
+	if preedit != "" {
+		ti.cursorBlink = 0
+		ti.cursorVisible = true
+	}
+	if changed {
+		ti.revealCursor()
+	}
+}
+
+// commitComposition ends the composition and inserts the committed text in
+// place of the selection. A single character is typed, merging into the
+// undo step of the characters typed before it; longer commits are undone
+// on their own.
+func (ti *TextInput) commitComposition(s string) {
+	ti.composition.set("", 0)
+	if utf8.RuneCountInString(s) == 1 {
+		r, _ := utf8.DecodeRuneInString(s)
+		ti.insertChar(r)
+		return
+	}
+	defer ti.trackEdit(editOther)()
+	if s == "" {
+		ti.deleteSelection()
+	}
+	for _, r := range s {
+		ti.insertChar(r)
+	}
+}
+
+// endComposition commits the preedit, as when the input loses focus or is
+// clicked while composing, and ends the IME session.
+func (ti *TextInput) endComposition() {
+	if ti.composition.composing() {
+		ti.commitComposition(ti.composition.preedit)
+	}
+	ti.composition.end()
+}
+
+// caretIndex returns the caret's rune index in the displayed text, which
+// holds the preedit while composing.
+func (ti *TextInput) caretIndex() int {
+	if !ti.composition.composing() {
+		return ti.CursorPos
+	}
+	start, end := ti.selectionOrCaret()
+	_, caret := ti.composition.compose(ti.Text, start, end)
+	return caret
+}
+
+// caretRect returns the caret's rectangle, where the IME candidate window
+// opens.
+func (ti *TextInput) caretRect() Rect {
+	if ti.FontFace == nil {
+		r := ti.ContentRect()
+		return Rect{X: r.X, Y: r.Y, W: caretWidth, H: r.H}
+	}
+	style := ti.getActiveStyle()
+	return ti.caretRectIn(ti.ContentRect(), ti.displayLayout(style), style)
+}
+
+// caretRectIn returns the caret's rectangle in an input drawn in r.
+func (ti *TextInput) caretRectIn(r Rect, layout *textLayout, style *Style) Rect {
+	_, caretX := layout.CaretPosition(ti.caretIndex())
+	return Rect{
+		X: ti.textOriginX(r, layout.width, style) + caretX,
+		Y: r.Y + 4,
+		W: caretWidth,
+		H: r.H - 8,
+	}
+}
+
+// TextArea composition
+
+// selectionOrCaret returns the selection in order, or the caret twice.
+func (ta *TextArea) selectionOrCaret() (int, int) {
+	ta.clampCursorPos()
+	runeLen := utf8.RuneCountInString(ta.Text)
+	start, end := ta.SelectStart, ta.SelectEnd
+	if start > end {
+		start, end = end, start
+	}
+	if start == end || start < 0 || end > runeLen {
+		return ta.CursorPos, ta.CursorPos
+	}
+	return start, end
+}
+
+// handleIME polls the IME, applying what it committed and showing what it
+// is composing, and reports whether it took this frame's text input.
+func (ta *TextArea) handleIME() bool {
+	start, end := ta.selectionOrCaret()
+	u, ok := ta.composition.poll(ta.Text, start, end, ta.caretRect())
+	if !ok {
+		return false
+	}
+	if u.committed {
+		ta.SelectStart, ta.SelectEnd = u.replaceStart, u.replaceEnd
+		ta.CursorPos = u.replaceEnd
+		ta.commitComposition(u.commit)
+	}
+	ta.setComposition(u.preedit, u.preeditCaret)
+	return u.handled
+}
+
+// setComposition shows preedit as the text being composed in place of the
+// selection, with the caret at rune caret within it. An empty preedit
+// cancels the composition.
+func (ta *TextArea) setComposition(preedit string, caret int) {
+	changed := preedit != ta.composition.preedit || caret != ta.composition.caret
+	ta.composition.set(preedit, caret)
+	if preedit != "" {
+		ta.cursorBlink = 0
+		ta.cursorVisible = true
+	}
+	if changed {
+		ta.revealCursor()
+	}
+}
+
+// commitComposition ends the composition and inserts the committed text in
+// place of the selection. A single character is typed, merging into the
+// undo step of the characters typed before it; longer commits are undone
+// on their own.
+func (ta *TextArea) commitComposition(s string) {
+	ta.composition.set("", 0)
+	ta.updateCursorLineCol()
+	if utf8.RuneCountInString(s) == 1 {
+		r, _ := utf8.DecodeRuneInString(s)
+		ta.insertChar(r)
+		return
+	}
+	defer ta.trackEdit(editOther)()
+	if s == "" {
+		ta.deleteSelection()
+	}
+	for _, r := range s {
+		ta.insertChar(r)
+	}
+}
+
+// endComposition commits the preedit, as when the text area loses focus or
+// is clicked while composing, and ends the IME session.
+func (ta *TextArea) endComposition() {
+	if ta.composition.composing() {
+		ta.commitComposition(ta.composition.preedit)
+	}
+	ta.composition.end()
+}
+
+// displayText returns the text as drawn, which holds the preedit in place of
+// the selection while composing, and the caret's rune index in it.
+func (ta *TextArea) displayText() (string, int) {
+	if !ta.composition.composing() {
+		return ta.Text, ta.CursorPos
+	}
+	start, end := ta.selectionOrCaret()
+	return ta.composition.compose(ta.Text, start, end)
+}
+
+// displayLines returns the displayed text's lines and the caret's line and
+// column in them.
+func (ta *TextArea) displayLines() ([]string, int, int) {
+	if !ta.composition.composing() {
+		return ta.lines, ta.CursorLine, ta.CursorCol
+	}
+	text, caret := ta.displayText()
+	lines := splitLines(text)
+	line, col := lineColAt(lines, caret)
+	return lines, line, col
+}
+
+// caretRect returns the caret's rectangle, where the IME candidate window
+// opens.
+func (ta *TextArea) caretRect() Rect {
+	r := ta.ContentRect()
+	if ta.FontFace == nil {
+		return Rect{X: r.X, Y: r.Y, W: caretWidth, H: r.H}
+	}
+	return ta.caretRectIn(r, ta.ensureLineLayouts(ta.getActiveStyle()), ta.getActiveStyle())
+}
+
+// caretRectIn returns the caret's rectangle in a text area drawn in r.
+func (ta *TextArea) caretRectIn(r Rect, layouts []*textLayout, style *Style) Rect {
+	_, line, col := ta.displayLines()
+	lineHeight := ta.lineHeight()
+	x := r.X
+	if line < len(layouts) {
+		_, caretX := layouts[line].CaretPosition(col)
+		x = ta.lineOriginX(r, layouts[line], style) + caretX
+	}
+	return Rect{
+		X: x,
+		Y: r.Y + float64(line)*lineHeight - ta.ScrollY + lineHeight*0.2,
+		W: caretWidth,
+		H: lineHeight * 0.8,
+	}
+}
@@ -0,0 +1,97 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+func TestCommittedRange(t *testing.T) {
+	tests := []struct {
+		name          string
+		text, newText string
+		newCaret      int
+		commit        string
+		start, end    int
+	}{
+		{name: "insert at caret", text: "abc", newText: "a日本bc", newCaret: 7, commit: "日本", start: 1, end: 1},
+		{name: "replace selection", text: "abcd", newText: "aXd", newCaret: 2, commit: "X", start: 1, end: 3},
+		{name: "delete before caret", text: "日本x", newText: "日本語", newCaret: 9, commit: "語", start: 2, end: 3},
+		{name: "after multibyte prefix", text: "かな", newText: "かか", newCaret: 6, commit: "か", start: 1, end: 2},
+	}
+	for _, tt := range tests {
+		commit, start, end := committedRange(tt.text, tt.newText, tt.newCaret)
+		if commit != tt.commit || start != tt.start || end != tt.end {
+			t.Errorf("%s: committedRange() = %q [%d:%d], want %q [%d:%d]", tt.name, commit, start, end, tt.commit, tt.start, tt.end)
+		}
+	}
+}
+
+func TestTextInputCompositionShowsPreeditAndCommits(t *testing.T) {
+	ui := newTextEditingUI(300, 60)
+	ti := ui.GetTextInput("input")
+	x, y := inputPointAt(ti, 6)
+	ui.SimulateClick(x, y)
+
+	rect := ui.SimulateComposition("にほ", 2)
+	style := ti.getActiveStyle()
+	layout := ti.displayLayout(style)
+	if ti.Text != "alpha beta gamma" || layout.text != "alpha にほbeta gamma" {
+		t.Fatalf("composing: Text = %q, displayed %q", ti.Text, layout.text)
+	}
+	_, caretX := layout.CaretPosition(8)
+	if want := ti.textOriginX(ti.ContentRect(), layout.width, style) + caretX; rect.X != want || rect.H <= 0 {
+		t.Fatalf("candidate window rect = %+v, want it at the caret after the preedit (x %v)", rect, want)
+	}
+
+	ui.SimulateKeyPress(ebiten.KeyBackspace, false, false)
+	if ti.Text != "alpha beta gamma" {
+		t.Fatalf("Backspace while composing edited the text to %q", ti.Text)
+	}
+
+	ui.SimulateCompositionCommit("日本")
+	if ti.Text != "alpha 日本beta gamma" || ti.CursorPos != 8 || ti.composition.composing() {
+		t.Fatalf("after commit Text = %q, CursorPos = %d, composing = %v", ti.Text, ti.CursorPos, ti.composition.composing())
+	}
+	if !ti.Undo() || ti.Text != "alpha beta gamma" {
+		t.Fatalf("Undo() after commit left %q, want the commit undone at once", ti.Text)
+	}
+}
+
+func TestTextInputCompositionReplacesSelectionAndCommitsOnBlur(t *testing.T) {
+	ui := newTextEditingUI(300, 60)
+	ti := ui.GetTextInput("input")
+	x, y := inputPointAt(ti, 7)
+	ui.SimulateClick(x+1, y)
+	ui.SimulateClick(x+1, y)
+
+	ui.SimulateComposition("ベ", 1)
+	if got := ti.displayLayout(ti.getActiveStyle()).text; got != "alpha ベ gamma" {
+		t.Fatalf("displayed %q, want the preedit in place of the selected word", got)
+	}
+	ui.Blur()
+	if ti.Text != "alpha ベ gamma" || ti.composition.composing() {
+		t.Fatalf("after blur Text = %q, want the preedit committed", ti.Text)
+	}
+}
+
+func TestTextAreaCompositionCommit(t *testing.T) {
+	ui := newTextEditingUI(300, 80)
+	ta := ui.GetTextArea("area")
+	r := ta.ContentRect()
+	lineHeight := ta.lineHeight()
+	ui.SimulateClick(r.X, r.Y+lineHeight*1.5)
+
+	rect := ui.SimulateComposition("한", 1)
+	if lines, line, col := ta.displayLines(); lines[1] != "한line two" || line != 1 || col != 1 {
+		t.Fatalf("composing: line %d col %d of %q", line, col, lines)
+	}
+	if rect.Y < r.Y+lineHeight || rect.Y >= r.Y+lineHeight*2 {
+		t.Fatalf("candidate window rect = %+v, want it on the second line", rect)
+	}
+
+	ui.SimulateCompositionCommit("한국어")
+	if ta.lines[1] != "한국어line two" || ta.CursorLine != 1 || ta.CursorCol != 3 {
+		t.Fatalf("after commit line = %q, caret at line %d col %d", ta.lines[1], ta.CursorLine, ta.CursorCol)
+	}
+}
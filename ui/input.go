@@ -1,7 +1,9 @@
 package ui
 
 import (
+	"image"
 	"image/color"
+	"math"
 	"strings"
 	"unicode/utf8"
 
@@ -22,6 +24,23 @@ func init() {
 	}
 }
 
+// writeClipboardText copies text to the system clipboard, if available.
+func writeClipboardText(s string) {
+	if clipboardAvailable {
+		clipboard.Write(clipboard.FmtText, []byte(s))
+	}
+}
+
+// caretWidth is the width of the text caret in inputs.
+const caretWidth = 2
+
+// clipImage returns the part of dst inside r, so text scrolled past an
+// input's content box is not drawn over its padding and border.
+func clipImage(dst *ebiten.Image, r Rect) *ebiten.Image {
+	bounds := image.Rect(int(math.Floor(r.X)), int(math.Floor(r.Y)), int(math.Ceil(r.X+r.W)), int(math.Ceil(r.Y+r.H)))
+	return dst.SubImage(bounds).(*ebiten.Image)
+}
+
 // ============================================================================
 // TextInput Widget - Single-line text input
 // ============================================================================
@@ -50,6 +69,7 @@ type TextInput struct {
 	MaxLength int
 	ReadOnly  bool
 	Password  bool // Mask characters
+	UndoLimit int  // undo steps kept, 0 for 100, negative for none
 
 	// Events
 	OnChange func(text string)
@@ -64,6 +84,15 @@ type TextInput struct {
 	repeatKey       ebiten.Key
 	repeatStartTime float64
 	repeatNextTime  float64
+	history         editHistory
+	dragging        bool // a pointer drag is extending the selection
+	composition     textComposition
+
+	// Cached layout of the displayed text
+	layout          *textLayout
+	layoutText      string
+	layoutFace      text.Face
+	layoutDirection bidiDirection
 }
 
 // NewTextInput creates a new text input widget
@@ -81,8 +110,12 @@ func (ti *TextInput) SetText(s string) {
 	if ti.MaxLength > 0 && utf8.RuneCountInString(s) > ti.MaxLength {
 		s = string([]rune(s)[:ti.MaxLength])
 	}
+	if s != ti.Text {
+		ti.history.clear()
+	}
 	ti.Text = s
 	ti.clampIndices()
+	ti.revealCursor()
 }
 
 func (ti *TextInput) clampIndices() {
@@ -134,8 +167,7 @@ func (ti *TextInput) copySelection() {
 		return
 	}
 
-	selected := string(runes[start:end])
-	clipboard.Write(clipboard.FmtText, []byte(selected))
+	writeClipboardText(string(runes[start:end]))
 }
 
 // pasteFromClipboard pastes text from clipboard at cursor position
@@ -143,6 +175,7 @@ func (ti *TextInput) pasteFromClipboard() {
 	if !clipboardAvailable {
 		return
 	}
+	defer ti.trackEdit(editOther)()
 
 	data := clipboard.Read(clipboard.FmtText)
 	if len(data) == 0 {
@@ -161,6 +194,7 @@ func (ti *TextInput) pasteFromClipboard() {
 
 // insertString inserts a string at the cursor position
 func (ti *TextInput) insertString(s string) {
+	defer ti.trackEdit(editOther)()
 	ti.clampIndices()
 
 	runes := []rune(s)
@@ -189,6 +223,7 @@ func (ti *TextInput) insertString(s string) {
 	newRunes = append(newRunes, textRunes[ti.CursorPos:]...)
 	ti.Text = string(newRunes)
 	ti.CursorPos += len(runes)
+	ti.revealCursor()
 
 	if ti.OnChange != nil {
 		ti.OnChange(ti.Text)
@@ -206,6 +241,7 @@ func (ti *TextInput) Focus() {
 
 // Blur removes focus from this input
 func (ti *TextInput) Blur() {
+	ti.endComposition()
 	ti.Focused = false
 	ti.state = StateNormal
 	ti.SelectStart = 0
@@ -221,30 +257,56 @@ func (ti *TextInput) HandleInput() {
 		return
 	}
 
-	// Handle text input
-	inputChars := ebiten.AppendInputChars(nil)
-	for _, char := range inputChars {
-		ti.insertChar(char)
+	// Handle text input, through the IME where the platform has one. Keys
+	// pressed while composing belong to the IME.
+	handled := ti.handleIME()
+	if !handled {
+		inputChars := ebiten.AppendInputChars(nil)
+		for _, char := range inputChars {
+			ti.insertChar(char)
+		}
+	}
+	if handled || ti.composition.composing() {
+		ti.updateCursorBlink()
+		return
 	}
 
-	// Handle key presses
+	// Handle key presses; Ctrl works by words
+	shift := ebiten.IsKeyPressed(ebiten.KeyShift)
+	control := ebiten.IsKeyPressed(ebiten.KeyControl)
 	if inpututil.IsKeyJustPressed(ebiten.KeyBackspace) {
-		ti.handleBackspace()
+		if control {
+			ti.deleteWord(-1)
+		} else {
+			ti.handleBackspace()
+		}
 	}
 	if inpututil.IsKeyJustPressed(ebiten.KeyDelete) {
-		ti.handleDelete()
+		if control {
+			ti.deleteWord(1)
+		} else {
+			ti.handleDelete()
+		}
 	}
 	if inpututil.IsKeyJustPressed(ebiten.KeyLeft) {
-		ti.moveCursor(-1, ebiten.IsKeyPressed(ebiten.KeyShift))
+		if control {
+			ti.moveWord(arrowDelta(-1, ti.isRTL()), shift)
+		} else {
+			ti.moveCursor(arrowDelta(-1, ti.isRTL()), shift)
+		}
 	}
 	if inpututil.IsKeyJustPressed(ebiten.KeyRight) {
-		ti.moveCursor(1, ebiten.IsKeyPressed(ebiten.KeyShift))
+		if control {
+			ti.moveWord(arrowDelta(1, ti.isRTL()), shift)
+		} else {
+			ti.moveCursor(arrowDelta(1, ti.isRTL()), shift)
+		}
 	}
 	if inpututil.IsKeyJustPressed(ebiten.KeyHome) {
-		ti.CursorPos = 0
+		ti.moveCursorTo(0, shift)
 	}
 	if inpututil.IsKeyJustPressed(ebiten.KeyEnd) {
-		ti.CursorPos = utf8.RuneCountInString(ti.Text)
+		ti.moveCursorTo(utf8.RuneCountInString(ti.Text), shift)
 	}
 	if inpututil.IsKeyJustPressed(ebiten.KeyEnter) || inpututil.IsKeyJustPressed(ebiten.KeyNumpadEnter) {
 		if ti.OnSubmit != nil {
@@ -252,6 +314,20 @@ func (ti *TextInput) HandleInput() {
 		}
 	}
 
+	// Ctrl+Z: Undo, Ctrl+Y or Ctrl+Shift+Z: Redo
+	if ebiten.IsKeyPressed(ebiten.KeyControl) && inpututil.IsKeyJustPressed(ebiten.KeyZ) {
+		if ebiten.IsKeyPressed(ebiten.KeyShift) {
+			ti.Redo()
+		} else {
+			ti.Undo()
+		}
+		return
+	}
+	if ebiten.IsKeyPressed(ebiten.KeyControl) && inpututil.IsKeyJustPressed(ebiten.KeyY) {
+		ti.Redo()
+		return
+	}
+
 	// Ctrl+A: Select all
 	if ebiten.IsKeyPressed(ebiten.KeyControl) && inpututil.IsKeyJustPressed(ebiten.KeyA) {
 		ti.SelectStart = 0
@@ -279,7 +355,11 @@ func (ti *TextInput) HandleInput() {
 		return
 	}
 
-	// Update cursor blink
+	ti.updateCursorBlink()
+}
+
+// updateCursorBlink advances the caret blink by one frame.
+func (ti *TextInput) updateCursorBlink() {
 	ti.cursorBlink += 1.0 / 60.0
 	if ti.cursorBlink >= 0.5 {
 		ti.cursorBlink = 0
@@ -288,6 +368,7 @@ func (ti *TextInput) HandleInput() {
 }
 
 func (ti *TextInput) insertChar(char rune) {
+	defer ti.trackEdit(editTyping)()
 	if ti.MaxLength > 0 && utf8.RuneCountInString(ti.Text) >= ti.MaxLength {
 		return
 	}
@@ -306,6 +387,7 @@ func (ti *TextInput) insertChar(char rune) {
 	newRunes = append(newRunes, runes[ti.CursorPos:]...)
 	ti.Text = string(newRunes)
 	ti.CursorPos++
+	ti.revealCursor()
 
 	if ti.OnChange != nil {
 		ti.OnChange(ti.Text)
@@ -313,6 +395,7 @@ func (ti *TextInput) insertChar(char rune) {
 }
 
 func (ti *TextInput) handleBackspace() {
+	defer ti.trackEdit(editBackspace)()
 	ti.clampIndices()
 
 	if ti.SelectStart != ti.SelectEnd {
@@ -325,6 +408,7 @@ func (ti *TextInput) handleBackspace() {
 		prev := prevGraphemeBoundary(ti.Text, ti.CursorPos)
 		ti.Text = string(append(runes[:prev], runes[ti.CursorPos:]...))
 		ti.CursorPos = prev
+		ti.revealCursor()
 
 		if ti.OnChange != nil {
 			ti.OnChange(ti.Text)
@@ -333,6 +417,7 @@ func (ti *TextInput) handleBackspace() {
 }
 
 func (ti *TextInput) handleDelete() {
+	defer ti.trackEdit(editDelete)()
 	ti.clampIndices()
 
 	if ti.SelectStart != ti.SelectEnd {
@@ -344,6 +429,7 @@ func (ti *TextInput) handleDelete() {
 	if ti.CursorPos < len(runes) {
 		next := nextGraphemeBoundary(ti.Text, ti.CursorPos)
 		ti.Text = string(append(runes[:ti.CursorPos], runes[next:]...))
+		ti.revealCursor()
 
 		if ti.OnChange != nil {
 			ti.OnChange(ti.Text)
@@ -352,6 +438,7 @@ func (ti *TextInput) handleDelete() {
 }
 
 func (ti *TextInput) deleteSelection() {
+	defer ti.trackEdit(editOther)()
 	ti.clampIndices()
 
 	if ti.SelectStart == ti.SelectEnd {
@@ -377,6 +464,7 @@ func (ti *TextInput) deleteSelection() {
 	ti.CursorPos = start
 	ti.SelectStart = 0
 	ti.SelectEnd = 0
+	ti.revealCursor()
 
 	if ti.OnChange != nil {
 		ti.OnChange(ti.Text)
@@ -396,37 +484,160 @@ func (ti *TextInput) moveCursor(delta int, selecting bool) {
 			newPos = nextGraphemeBoundary(ti.Text, newPos)
 		}
 	}
+	ti.moveCursorTo(newPos, selecting)
+}
 
+// moveCursorTo moves the caret to a rune index, extending the selection from
+// the old caret when selecting and clearing it otherwise.
+func (ti *TextInput) moveCursorTo(pos int, selecting bool) {
 	if selecting {
 		if ti.SelectStart == ti.SelectEnd {
 			ti.SelectStart = ti.CursorPos
 		}
-		ti.SelectEnd = newPos
+		ti.SelectEnd = pos
 	} else {
 		ti.SelectStart = 0
 		ti.SelectEnd = 0
 	}
 
-	ti.CursorPos = newPos
+	ti.CursorPos = pos
 	ti.cursorBlink = 0
 	ti.cursorVisible = true
+	ti.revealCursor()
+}
+
+// wordBoundary returns where a word jump from the caret lands, forward for
+// a positive dir. Password inputs jump to the ends, so the masked text does
+// not give away its words.
+func (ti *TextInput) wordBoundary(dir int) int {
+	ti.clampIndices()
+	switch {
+	case dir < 0 && ti.Password:
+		return 0
+	case dir < 0:
+		return prevWordStart(ti.Text, ti.CursorPos)
+	case ti.Password:
+		return utf8.RuneCountInString(ti.Text)
+	default:
+		return nextWordEnd(ti.Text, ti.CursorPos)
+	}
+}
+
+// moveWord moves the caret a word forward for a positive dir, backward
+// otherwise (Ctrl+Right and Ctrl+Left).
+func (ti *TextInput) moveWord(dir int, selecting bool) {
+	ti.moveCursorTo(ti.wordBoundary(dir), selecting)
+}
+
+// deleteWord deletes from the caret to the word boundary in dir, or the
+// selection if there is one (Ctrl+Backspace and Ctrl+Delete).
+func (ti *TextInput) deleteWord(dir int) {
+	defer ti.trackEdit(editOther)()
+	if ti.SelectStart == ti.SelectEnd {
+		ti.SelectStart, ti.SelectEnd = ti.CursorPos, ti.wordBoundary(dir)
+	}
+	ti.deleteSelection()
+}
+
+// HandlePointerDown places the caret at the pointer and clears the
+// selection.
+func (ti *TextInput) HandlePointerDown(x, y float64) {
+	ti.handlePointerPress(x, y, 1, false)
 }
 
-func (ti *TextInput) HandlePointerDown(x, _ float64) {
+// handlePointerPress handles the clicks-th click in a row at x, y: a click
+// places the caret and starts a drag selection, or extends the selection
+// with shift; a double click selects a word and a triple click all text.
+func (ti *TextInput) handlePointerPress(x, _ float64, clicks int, shift bool) {
 	if ti.FontFace == nil {
 		return
 	}
-	r := ti.ContentRect()
-	displayText := ti.Text
-	if ti.Password {
-		displayText = strings.Repeat("●", utf8.RuneCountInString(ti.Text))
+	if ti.composition.composing() {
+		ti.endComposition()
+	}
+	caret, char := ti.pointerIndex(x)
+	switch {
+	case clicks == 2 && !ti.Password:
+		ti.SelectStart, ti.SelectEnd = wordRangeAt(ti.Text, char)
+		ti.CursorPos = ti.SelectEnd
+	case clicks >= 2:
+		ti.SelectStart, ti.SelectEnd = 0, utf8.RuneCountInString(ti.Text)
+		ti.CursorPos = ti.SelectEnd
+	default:
+		ti.moveCursorTo(caret, shift)
+		if !shift {
+			ti.SelectStart, ti.SelectEnd = caret, caret
+		}
+		ti.dragging = true
 	}
-	localX := x - r.X + ti.scrollOffset
-	ti.CursorPos = caretRuneIndexForSingleLine(ti.FontFace, displayText, localX)
-	ti.SelectStart = 0
-	ti.SelectEnd = 0
 	ti.cursorBlink = 0
 	ti.cursorVisible = true
+	ti.revealCursor()
+}
+
+// HandlePointerDrag extends the selection to the pointer while a press that
+// started in the input is dragged. Past the input's edges the text scrolls,
+// a little more on every call for as long as the pointer stays outside.
+func (ti *TextInput) HandlePointerDrag(x, _ float64) {
+	if !ti.dragging || ti.FontFace == nil {
+		return
+	}
+	caret, _ := ti.pointerIndex(x)
+	ti.SelectEnd = caret
+	ti.CursorPos = caret
+	ti.revealCursor()
+}
+
+// HandlePointerUp ends a drag selection.
+func (ti *TextInput) HandlePointerUp() {
+	ti.dragging = false
+}
+
+// pointerIndex returns the caret index nearest an absolute x and the index
+// of the character under it, the caret's outside the text.
+func (ti *TextInput) pointerIndex(x float64) (int, int) {
+	style := ti.getActiveStyle()
+	layout := ti.displayLayout(style)
+	localX := x - ti.textOriginX(ti.ContentRect(), layout.width, style)
+	caret := layout.CaretRuneIndexAt(localX, 0)
+	if hit, ok := layout.HitTest(localX, 0); ok {
+		return caret, hit.RuneStart
+	}
+	return caret, caret
+}
+
+// revealCursor scrolls the text to keep the caret in view. It is called
+// wherever the caret or text changes, never while drawing.
+func (ti *TextInput) revealCursor() {
+	r := ti.ContentRect()
+	if ti.FontFace == nil || r.W <= 0 {
+		return
+	}
+	style := ti.getActiveStyle()
+	ti.scrollToCursor(r, ti.displayLayout(style), style)
+}
+
+// scrollToCursor scrolls the text horizontally to keep the caret inside r.
+func (ti *TextInput) scrollToCursor(r Rect, layout *textLayout, style *Style) {
+	overflow := layout.width + caretWidth - r.W
+	if overflow <= 0 {
+		ti.scrollOffset = 0
+		return
+	}
+	lo, hi := 0.0, overflow
+	if isRTLStyle(style) {
+		lo, hi = -overflow, 0
+	}
+	_, caretX := layout.CaretPosition(ti.caretIndex())
+	x := ti.textOriginX(r, layout.width, style) + ti.scrollOffset + caretX
+	scroll := ti.scrollOffset
+	if x-scroll < r.X {
+		scroll = x - r.X
+	}
+	if x-scroll > r.X+r.W-caretWidth {
+		scroll = x - (r.X + r.W - caretWidth)
+	}
+	ti.scrollOffset = clamp(scroll, lo, hi)
 }
 
 // Draw renders the text input
@@ -444,10 +655,10 @@ func (ti *TextInput) Draw(screen *ebiten.Image) {
 	}
 	animGeoM, hasAnimTransform, animOpacity := ti.animationTransform()
 	opacity *= animOpacity
-	hasTransform := style.Transform != "" && style.Transform != "none"
+	hasTransform := ti.hasTransform(style)
 	hasFilter := style.parsedFilter != nil
 	hasClipPath := style.ClipPath != "" && style.ClipPath != "none"
-	needsOffscreen := opacity < 1 || hasTransform || hasFilter || hasAnimTransform || hasClipPath
+	needsOffscreen := opacity < 1 || hasTransform || hasFilter || hasAnimTransform || hasClipPath || hasCompositeEffects(style)
 
 	drawContent := func(target *ebiten.Image, contentRect Rect, contentStyle *Style) {
 		ti.drawContentOnly(target, contentRect, contentStyle)
@@ -475,49 +686,35 @@ func (ti *TextInput) drawTextInputContent(screen *ebiten.Image, widgetRect Rect,
 		style.Padding.Left+bw,
 	)
 
-	// Determine display text
-	displayText := ti.Text
-	if ti.Password {
-		displayText = strings.Repeat("●", utf8.RuneCountInString(ti.Text))
-	}
-	displayRunes := []rune(displayText)
 	ti.clampIndices()
+	layout := ti.displayLayout(style)
 
 	// Draw Placeholder if empty
-	if displayText == "" && ti.Placeholder != "" && !ti.Focused {
+	if layout.text == "" && ti.Placeholder != "" && !ti.Focused {
 		metrics := ti.FontFace.Metrics()
 		emHeight := metrics.HAscent + metrics.HDescent
 		y := r.Y + (r.H-emHeight)/2
 		op := &text.DrawOptions{}
-		op.GeoM.Translate(r.X, y)
+		op.GeoM.Translate(ti.textOriginX(r, ti.measureTextWidth(ti.Placeholder), style), y)
 		op.ColorScale.ScaleWithColor(ti.PlaceholderColor)
-		text.Draw(screen, ti.Placeholder, ti.FontFace, op)
+		drawTextDirection(screen, ti.Placeholder, ti.FontFace, op, styleDirection(style))
 		return
 	}
-
-	// Draw selection highlight
-	if ti.Focused && ti.SelectStart != ti.SelectEnd {
-		start, end := ti.SelectStart, ti.SelectEnd
-		if start > end {
-			start, end = end, start
-		}
-		if start > len(displayRunes) {
-			start = len(displayRunes)
-		}
-		if end > len(displayRunes) {
-			end = len(displayRunes)
-		}
-
-		startX := ti.measureTextWidth(string(displayRunes[:start]))
-		endX := ti.measureTextWidth(string(displayRunes[:end]))
-
-		selRect := Rect{
-			X: r.X + startX - ti.scrollOffset,
-			Y: r.Y + 2,
-			W: endX - startX,
-			H: r.H - 4,
+	originX := ti.textOriginX(r, layout.width, style)
+	screen = clipImage(screen, r)
+
+	// Draw selection highlight; a preedit takes the selection's place
+	composing := ti.composition.composing()
+	if ti.Focused && ti.SelectStart != ti.SelectEnd && !composing {
+		for _, rect := range layout.SelectionRects(ti.SelectStart, ti.SelectEnd) {
+			selRect := Rect{
+				X: originX + rect.X,
+				Y: r.Y + 2,
+				W: rect.W,
+				H: r.H - 4,
+			}
+			DrawRoundedRectPath(screen, selRect, 2, ti.SelectionColor)
 		}
-		DrawRoundedRectPath(screen, selRect, 2, ti.SelectionColor)
 	}
 
 	// Draw text
@@ -531,25 +728,76 @@ func (ti *TextInput) drawTextInputContent(screen *ebiten.Image, widgetRect Rect,
 	y := r.Y + (r.H-emHeight)/2
 
 	op := &text.DrawOptions{}
-	op.GeoM.Translate(r.X-ti.scrollOffset, y)
+	op.GeoM.Translate(originX, y)
 	op.ColorScale.ScaleWithColor(textColor)
-	text.Draw(screen, displayText, ti.FontFace, op)
+	for _, line := range layout.lines {
+		layout.drawLine(screen, line, op)
+		op.GeoM.Translate(0, layout.lineHeight)
+	}
+
+	// Underline the preedit
+	if composing {
+		start, _ := ti.selectionOrCaret()
+		end := start + utf8.RuneCountInString(ti.composition.preedit)
+		drawCompositionUnderline(screen, layout, start, end, originX, y+emHeight, textColor)
+	}
 
 	// Draw cursor
 	if ti.Focused && ti.cursorVisible {
-		cursorPos := ti.CursorPos
-		if cursorPos > len(displayRunes) {
-			cursorPos = len(displayRunes)
-		}
-		cursorX := r.X + ti.measureTextWidth(string(displayRunes[:cursorPos])) - ti.scrollOffset
+		DrawRoundedRectPath(screen, ti.caretRectIn(r, layout, style), 1, ti.CursorColor)
+	}
+}
+
+// displayLayout returns the single-line layout of the displayed text, which
+// places the caret and selection in bidirectional text. While composing the
+// preedit is in place of the selection.
+func (ti *TextInput) displayLayout(style *Style) *textLayout {
+	displayText := ti.Text
+	if ti.Password {
+		displayText = strings.Repeat("●", utf8.RuneCountInString(ti.Text))
+	} else if ti.composition.composing() {
+		start, end := ti.selectionOrCaret()
+		displayText, _ = ti.composition.compose(ti.Text, start, end)
+	}
+	direction := styleDirection(style)
+	if ti.layout != nil && ti.layoutText == displayText && ti.layoutFace == ti.FontFace && ti.layoutDirection == direction {
+		return ti.layout
+	}
+	ti.layout = newTextLayout(displayText, ti.FontFace, textLayoutOptions{
+		WhiteSpace: textWhiteSpacePreWrap,
+		LineHeight: measureLineHeight(ti.FontFace),
+		Direction:  direction,
+	})
+	ti.layoutText = displayText
+	ti.layoutFace = ti.FontFace
+	ti.layoutDirection = direction
+	return ti.layout
+}
 
-		DrawRoundedRectPath(screen, Rect{
-			X: cursorX,
-			Y: r.Y + 4,
-			W: 2,
-			H: r.H - 8,
-		}, 1, ti.CursorColor)
+// textOriginX returns the left edge of text of the given width. Inputs align
+// text to their start edge, the right one in right-to-left inputs.
+func (ti *TextInput) textOriginX(r Rect, width float64, style *Style) float64 {
+	if isRTLStyle(style) {
+		return r.X + r.W - width - ti.scrollOffset
 	}
+	return r.X - ti.scrollOffset
+}
+
+// isRTL reports whether the input's text is a right-to-left paragraph.
+func (ti *TextInput) isRTL() bool {
+	if ti.FontFace == nil {
+		return isRTLStyle(ti.getActiveStyle())
+	}
+	return ti.displayLayout(ti.getActiveStyle()).paragraphRTL(0)
+}
+
+// arrowDelta converts a Left (-1) or Right (+1) arrow key into a logical
+// caret step; in right-to-left paragraphs Left moves forward.
+func arrowDelta(delta int, rtl bool) int {
+	if rtl {
+		return -delta
+	}
+	return delta
 }
 
 func (ti *TextInput) measureTextWidth(s string) float64 {
@@ -588,6 +836,7 @@ type TextArea struct {
 	// Behavior
 	MaxLength int
 	ReadOnly  bool
+	UndoLimit int // undo steps kept, 0 for 100, negative for none
 
 	// Events
 	OnChange func(text string)
@@ -600,6 +849,15 @@ type TextArea struct {
 	lines         []string
 	cursorBlink   float64
 	cursorVisible bool
+	history       editHistory
+	dragging      bool // a pointer drag is extending the selection
+	composition   textComposition
+
+	// Cached layouts of lines
+	lineLayouts     []*textLayout
+	layoutText      string
+	layoutFace      text.Face
+	layoutDirection bidiDirection
 }
 
 // NewTextArea creates a new text area widget
@@ -608,6 +866,7 @@ func NewTextArea(id string) *TextArea {
 		BaseWidget:       NewBaseWidget(id, "textarea"),
 		PlaceholderColor: color.RGBA{128, 128, 128, 255},
 		CursorColor:      color.White,
+		SelectionColor:   color.RGBA{100, 149, 237, 128},
 		lines:            []string{""},
 	}
 }
@@ -617,10 +876,14 @@ func (ta *TextArea) SetText(s string) {
 	if ta.MaxLength > 0 && utf8.RuneCountInString(s) > ta.MaxLength {
 		s = string([]rune(s)[:ta.MaxLength])
 	}
+	if s != ta.Text {
+		ta.history.clear()
+	}
 	ta.Text = s
 	ta.clampCursorPos()
 	ta.updateLines()
 	ta.updateCursorLineCol()
+	ta.revealCursor()
 }
 
 func (ta *TextArea) clampCursorPos() {
@@ -666,6 +929,7 @@ func (ta *TextArea) Focus() {
 
 // Blur removes focus
 func (ta *TextArea) Blur() {
+	ta.endComposition()
 	ta.Focused = false
 	ta.state = StateNormal
 	ta.SelectStart = 0
@@ -696,8 +960,7 @@ func (ta *TextArea) copySelection() {
 		return
 	}
 
-	selected := string(runes[start:end])
-	clipboard.Write(clipboard.FmtText, []byte(selected))
+	writeClipboardText(string(runes[start:end]))
 }
 
 // pasteFromClipboard pastes text from clipboard at cursor position
@@ -705,6 +968,7 @@ func (ta *TextArea) pasteFromClipboard() {
 	if !clipboardAvailable {
 		return
 	}
+	defer ta.trackEdit(editOther)()
 
 	data := clipboard.Read(clipboard.FmtText)
 	if len(data) == 0 {
@@ -723,6 +987,7 @@ func (ta *TextArea) pasteFromClipboard() {
 
 // insertString inserts a string at the cursor position
 func (ta *TextArea) insertString(s string) {
+	defer ta.trackEdit(editOther)()
 	ta.clampCursorPos()
 
 	runes := []rune(s)
@@ -753,6 +1018,7 @@ func (ta *TextArea) insertString(s string) {
 	ta.CursorPos += len(runes)
 	ta.updateLines()
 	ta.updateCursorLineCol()
+	ta.revealCursor()
 
 	if ta.OnChange != nil {
 		ta.OnChange(ta.Text)
@@ -761,6 +1027,7 @@ func (ta *TextArea) insertString(s string) {
 
 // deleteSelection deletes selected text
 func (ta *TextArea) deleteSelection() {
+	defer ta.trackEdit(editOther)()
 	if ta.SelectStart == ta.SelectEnd {
 		return
 	}
@@ -787,6 +1054,7 @@ func (ta *TextArea) deleteSelection() {
 	ta.SelectEnd = 0
 	ta.updateLines()
 	ta.updateCursorLineCol()
+	ta.revealCursor()
 
 	if ta.OnChange != nil {
 		ta.OnChange(ta.Text)
@@ -799,33 +1067,80 @@ func (ta *TextArea) HandleInput() {
 		return
 	}
 
-	// Handle text input
-	inputChars := ebiten.AppendInputChars(nil)
-	for _, char := range inputChars {
-		ta.insertChar(char)
+	// Handle text input, through the IME where the platform has one. Keys
+	// pressed while composing belong to the IME.
+	handled := ta.handleIME()
+	if !handled {
+		inputChars := ebiten.AppendInputChars(nil)
+		for _, char := range inputChars {
+			ta.insertChar(char)
+		}
+	}
+	if handled || ta.composition.composing() {
+		ta.updateCursorBlink()
+		return
 	}
 
-	// Handle key presses
+	// Handle key presses; Ctrl works by words, or the whole text for
+	// Home and End
+	shift := ebiten.IsKeyPressed(ebiten.KeyShift)
+	control := ebiten.IsKeyPressed(ebiten.KeyControl)
 	if inpututil.IsKeyJustPressed(ebiten.KeyBackspace) {
-		ta.handleBackspace()
+		if control {
+			ta.deleteWord(-1)
+		} else {
+			ta.handleBackspace()
+		}
 	}
 	if inpututil.IsKeyJustPressed(ebiten.KeyDelete) {
-		ta.handleDelete()
+		if control {
+			ta.deleteWord(1)
+		} else {
+			ta.handleDelete()
+		}
 	}
 	if inpututil.IsKeyJustPressed(ebiten.KeyEnter) || inpututil.IsKeyJustPressed(ebiten.KeyNumpadEnter) {
 		ta.insertChar('\n')
 	}
 	if inpututil.IsKeyJustPressed(ebiten.KeyLeft) {
-		ta.moveCursorHorizontal(-1)
+		if control {
+			ta.moveWord(arrowDelta(-1, ta.isLineRTL(ta.CursorLine)), shift)
+		} else {
+			ta.moveCursorHorizontal(arrowDelta(-1, ta.isLineRTL(ta.CursorLine)), shift)
+		}
 	}
 	if inpututil.IsKeyJustPressed(ebiten.KeyRight) {
-		ta.moveCursorHorizontal(1)
+		if control {
+			ta.moveWord(arrowDelta(1, ta.isLineRTL(ta.CursorLine)), shift)
+		} else {
+			ta.moveCursorHorizontal(arrowDelta(1, ta.isLineRTL(ta.CursorLine)), shift)
+		}
 	}
 	if inpututil.IsKeyJustPressed(ebiten.KeyUp) {
-		ta.moveCursorVertical(-1)
+		ta.moveCursorVertical(-1, shift)
 	}
 	if inpututil.IsKeyJustPressed(ebiten.KeyDown) {
-		ta.moveCursorVertical(1)
+		ta.moveCursorVertical(1, shift)
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyHome) {
+		ta.moveToLineEdge(false, control, shift)
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyEnd) {
+		ta.moveToLineEdge(true, control, shift)
+	}
+
+	// Ctrl+Z: Undo, Ctrl+Y or Ctrl+Shift+Z: Redo
+	if ebiten.IsKeyPressed(ebiten.KeyControl) && inpututil.IsKeyJustPressed(ebiten.KeyZ) {
+		if ebiten.IsKeyPressed(ebiten.KeyShift) {
+			ta.Redo()
+		} else {
+			ta.Undo()
+		}
+		return
+	}
+	if ebiten.IsKeyPressed(ebiten.KeyControl) && inpututil.IsKeyJustPressed(ebiten.KeyY) {
+		ta.Redo()
+		return
 	}
 
 	// Ctrl+A: Select all
@@ -855,7 +1170,11 @@ func (ta *TextArea) HandleInput() {
 		return
 	}
 
-	// Update cursor blink
+	ta.updateCursorBlink()
+}
+
+// updateCursorBlink advances the caret blink by one frame.
+func (ta *TextArea) updateCursorBlink() {
 	ta.cursorBlink += 1.0 / 60.0
 	if ta.cursorBlink >= 0.5 {
 		ta.cursorBlink = 0
@@ -864,6 +1183,7 @@ func (ta *TextArea) HandleInput() {
 }
 
 func (ta *TextArea) insertChar(char rune) {
+	defer ta.trackEdit(editTyping)()
 	if ta.MaxLength > 0 && utf8.RuneCountInString(ta.Text) >= ta.MaxLength {
 		return
 	}
@@ -884,6 +1204,7 @@ func (ta *TextArea) insertChar(char rune) {
 	ta.CursorPos++
 	ta.updateLines()
 	ta.updateCursorLineCol()
+	ta.revealCursor()
 
 	if ta.OnChange != nil {
 		ta.OnChange(ta.Text)
@@ -891,6 +1212,7 @@ func (ta *TextArea) insertChar(char rune) {
 }
 
 func (ta *TextArea) handleBackspace() {
+	defer ta.trackEdit(editBackspace)()
 	ta.clampCursorPos()
 
 	// Delete selection if any
@@ -906,6 +1228,7 @@ func (ta *TextArea) handleBackspace() {
 		ta.CursorPos = prev
 		ta.updateLines()
 		ta.updateCursorLineCol()
+		ta.revealCursor()
 
 		if ta.OnChange != nil {
 			ta.OnChange(ta.Text)
@@ -914,6 +1237,7 @@ func (ta *TextArea) handleBackspace() {
 }
 
 func (ta *TextArea) handleDelete() {
+	defer ta.trackEdit(editDelete)()
 	ta.clampCursorPos()
 
 	// Delete selection if any
@@ -928,6 +1252,7 @@ func (ta *TextArea) handleDelete() {
 		ta.Text = string(append(runes[:ta.CursorPos], runes[next:]...))
 		ta.updateLines()
 		ta.updateCursorLineCol()
+		ta.revealCursor()
 
 		if ta.OnChange != nil {
 			ta.OnChange(ta.Text)
@@ -935,7 +1260,7 @@ func (ta *TextArea) handleDelete() {
 	}
 }
 
-func (ta *TextArea) moveCursorHorizontal(delta int) {
+func (ta *TextArea) moveCursorHorizontal(delta int, selecting bool) {
 	ta.clampCursorPos()
 
 	newPos := ta.CursorPos
@@ -948,13 +1273,10 @@ func (ta *TextArea) moveCursorHorizontal(delta int) {
 			newPos = nextGraphemeBoundary(ta.Text, newPos)
 		}
 	}
-	ta.CursorPos = newPos
-	ta.updateCursorLineCol()
-	ta.cursorBlink = 0
-	ta.cursorVisible = true
+	ta.moveCursorTo(newPos, selecting)
 }
 
-func (ta *TextArea) moveCursorVertical(delta int) {
+func (ta *TextArea) moveCursorVertical(delta int, selecting bool) {
 	newLine := ta.CursorLine + delta
 	if newLine < 0 {
 		newLine = 0
@@ -963,33 +1285,104 @@ func (ta *TextArea) moveCursorVertical(delta int) {
 		newLine = len(ta.lines) - 1
 	}
 
-	ta.CursorLine = newLine
-	lineRuneLen := utf8.RuneCountInString(ta.lines[newLine])
-	if ta.CursorCol > lineRuneLen {
-		ta.CursorCol = lineRuneLen
+	col := ta.CursorCol
+	if lineRuneLen := utf8.RuneCountInString(ta.lines[newLine]); col > lineRuneLen {
+		col = lineRuneLen
+	}
+	col = snapRuneIndexToBoundary(ta.lines[newLine], col)
+	ta.moveCursorTo(ta.lineStart(newLine)+col, selecting)
+}
+
+// moveCursorTo moves the caret to a rune index, extending the selection from
+// the old caret when selecting and clearing it otherwise.
+func (ta *TextArea) moveCursorTo(pos int, selecting bool) {
+	if selecting {
+		if ta.SelectStart == ta.SelectEnd {
+			ta.SelectStart = ta.CursorPos
+		}
+		ta.SelectEnd = pos
+	} else {
+		ta.SelectStart = 0
+		ta.SelectEnd = 0
 	}
-	ta.CursorCol = snapRuneIndexToBoundary(ta.lines[newLine], ta.CursorCol)
 
-	ta.updateCursorPosFromLineCol()
+	ta.CursorPos = pos
+	ta.updateCursorLineCol()
 	ta.cursorBlink = 0
 	ta.cursorVisible = true
+	ta.revealCursor()
+}
+
+// moveToLineEdge moves the caret to the start or end of its line (Home and
+// End), or of the text with whole (Ctrl+Home and Ctrl+End).
+func (ta *TextArea) moveToLineEdge(end, whole, selecting bool) {
+	ta.updateCursorLineCol()
+	switch {
+	case whole && end:
+		ta.moveCursorTo(utf8.RuneCountInString(ta.Text), selecting)
+	case whole:
+		ta.moveCursorTo(0, selecting)
+	case end:
+		ta.moveCursorTo(ta.lineStart(ta.CursorLine)+utf8.RuneCountInString(ta.lines[ta.CursorLine]), selecting)
+	default:
+		ta.moveCursorTo(ta.lineStart(ta.CursorLine), selecting)
+	}
+}
+
+// moveWord moves the caret a word forward for a positive dir, backward
+// otherwise (Ctrl+Right and Ctrl+Left).
+func (ta *TextArea) moveWord(dir int, selecting bool) {
+	ta.clampCursorPos()
+	if dir < 0 {
+		ta.moveCursorTo(prevWordStart(ta.Text, ta.CursorPos), selecting)
+	} else {
+		ta.moveCursorTo(nextWordEnd(ta.Text, ta.CursorPos), selecting)
+	}
+}
+
+// deleteWord deletes from the caret to the word boundary in dir, or the
+// selection if there is one (Ctrl+Backspace and Ctrl+Delete).
+func (ta *TextArea) deleteWord(dir int) {
+	defer ta.trackEdit(editOther)()
+	ta.clampCursorPos()
+	if ta.SelectStart == ta.SelectEnd {
+		ta.SelectStart = ta.CursorPos
+		if dir < 0 {
+			ta.SelectEnd = prevWordStart(ta.Text, ta.CursorPos)
+		} else {
+			ta.SelectEnd = nextWordEnd(ta.Text, ta.CursorPos)
+		}
+	}
+	ta.deleteSelection()
+}
+
+// lineStart returns the rune index where a line starts in the text.
+func (ta *TextArea) lineStart(line int) int {
+	pos := 0
+	for i := 0; i < line && i < len(ta.lines); i++ {
+		pos += utf8.RuneCountInString(ta.lines[i]) + 1
+	}
+	return pos
 }
 
 func (ta *TextArea) updateCursorLineCol() {
 	ta.clampCursorPos()
+	ta.CursorLine, ta.CursorCol = lineColAt(ta.lines, ta.CursorPos)
+}
 
+// lineColAt returns the line and column of a rune index in text split into
+// lines.
+func lineColAt(lines []string, index int) (int, int) {
 	pos := 0
-	for i, line := range ta.lines {
+	for i, line := range lines {
 		lineLen := len([]rune(line))
-		if ta.CursorPos <= pos+lineLen {
-			ta.CursorLine = i
-			ta.CursorCol = ta.CursorPos - pos
-			return
+		if index <= pos+lineLen {
+			return i, index - pos
 		}
 		pos += lineLen + 1 // +1 for newline
 	}
-	ta.CursorLine = len(ta.lines) - 1
-	ta.CursorCol = len([]rune(ta.lines[ta.CursorLine]))
+	last := len(lines) - 1
+	return last, len([]rune(lines[last]))
 }
 
 func (ta *TextArea) updateCursorPosFromLineCol() {
@@ -1009,32 +1402,126 @@ func (ta *TextArea) updateCursorPosFromLineCol() {
 	ta.clampCursorPos()
 }
 
+// HandlePointerDown places the caret at the pointer and clears the
+// selection.
 func (ta *TextArea) HandlePointerDown(x, y float64) {
+	ta.handlePointerPress(x, y, 1, false)
+}
+
+// handlePointerPress handles the clicks-th click in a row at x, y: a click
+// places the caret and starts a drag selection, or extends the selection
+// with shift; a double click selects a word and a triple click a line.
+func (ta *TextArea) handlePointerPress(x, y float64, clicks int, shift bool) {
 	if ta.FontFace == nil || len(ta.lines) == 0 {
 		return
 	}
+	if ta.composition.composing() {
+		ta.endComposition()
+	}
+	caret, char := ta.pointerIndex(x, y)
+	switch clicks {
+	case 2:
+		ta.SelectStart, ta.SelectEnd = wordRangeAt(ta.Text, char)
+		ta.CursorPos = ta.SelectEnd
+		ta.updateCursorLineCol()
+	case 3:
+		ta.CursorPos = caret
+		ta.updateCursorLineCol()
+		ta.SelectStart = ta.lineStart(ta.CursorLine)
+		ta.SelectEnd = ta.SelectStart + utf8.RuneCountInString(ta.lines[ta.CursorLine])
+		ta.CursorPos = ta.SelectEnd
+		ta.updateCursorLineCol()
+	default:
+		ta.moveCursorTo(caret, shift)
+		if !shift {
+			ta.SelectStart, ta.SelectEnd = caret, caret
+		}
+		ta.dragging = true
+	}
+	ta.cursorBlink = 0
+	ta.cursorVisible = true
+	ta.revealCursor()
+}
+
+// HandlePointerDrag extends the selection to the pointer while a press that
+// started in the text area is dragged. Above or below it the text scrolls,
+// a little more on every call for as long as the pointer stays outside.
+func (ta *TextArea) HandlePointerDrag(x, y float64) {
+	if !ta.dragging || ta.FontFace == nil || len(ta.lines) == 0 {
+		return
+	}
+	caret, _ := ta.pointerIndex(x, y)
+	ta.SelectEnd = caret
+	ta.CursorPos = caret
+	ta.updateCursorLineCol()
+	ta.revealCursor()
+}
+
+// HandlePointerUp ends a drag selection.
+func (ta *TextArea) HandlePointerUp() {
+	ta.dragging = false
+}
+
+// pointerIndex returns the caret index nearest an absolute point and the
+// index of the character under it, the caret's outside the text. Points
+// above or below the lines fall on the first or last line.
+func (ta *TextArea) pointerIndex(x, y float64) (int, int) {
 	r := ta.ContentRect()
-	_, lineH := text.Measure("Ag", ta.FontFace, 0)
-	lineHeight := lineH * 1.2
+	lineHeight := ta.lineHeight()
 	localY := y - r.Y + ta.ScrollY
 	lineIndex := 0
-	if lineHeight > 0 {
+	if lineHeight > 0 && localY > 0 {
 		lineIndex = int(localY / lineHeight)
 	}
-	if lineIndex < 0 {
-		lineIndex = 0
-	}
 	if lineIndex >= len(ta.lines) {
 		lineIndex = len(ta.lines) - 1
 	}
 
-	ta.CursorLine = lineIndex
-	ta.CursorCol = caretRuneIndexForSingleLine(ta.FontFace, ta.lines[lineIndex], x-r.X)
-	ta.updateCursorPosFromLineCol()
-	ta.SelectStart = 0
-	ta.SelectEnd = 0
-	ta.cursorBlink = 0
-	ta.cursorVisible = true
+	style := ta.getActiveStyle()
+	layout := ta.ensureLineLayouts(style)[lineIndex]
+	localX := x - ta.lineOriginX(r, layout, style)
+	start := ta.lineStart(lineIndex)
+	caret := start + layout.CaretRuneIndexAt(localX, 0)
+	if hit, ok := layout.HitTest(localX, 0); ok {
+		return caret, start + hit.RuneStart
+	}
+	return caret, caret
+}
+
+// lineHeight returns the distance between the text area's lines.
+func (ta *TextArea) lineHeight() float64 {
+	_, lineH := text.Measure("Ag", ta.FontFace, 0)
+	return lineH * 1.2
+}
+
+// revealCursor scrolls the lines to keep the caret in view. It is called
+// wherever the caret or text changes, never while drawing.
+func (ta *TextArea) revealCursor() {
+	r := ta.ContentRect()
+	if ta.FontFace == nil || r.H <= 0 {
+		return
+	}
+	ta.scrollToCursor(r)
+}
+
+// scrollToCursor updates MaxScrollY for the content rect r and scrolls
+// ScrollY to keep the caret's line inside it.
+func (ta *TextArea) scrollToCursor(r Rect) {
+	lines, line, _ := ta.displayLines()
+	lineHeight := ta.lineHeight()
+	ta.MaxScrollY = float64(len(lines))*lineHeight - r.H
+	if ta.MaxScrollY < 0 {
+		ta.MaxScrollY = 0
+	}
+	top := float64(line) * lineHeight
+	scroll := ta.ScrollY
+	if top < scroll {
+		scroll = top
+	}
+	if top+lineHeight > scroll+r.H {
+		scroll = top + lineHeight - r.H
+	}
+	ta.ScrollY = clamp(scroll, 0, ta.MaxScrollY)
 }
 
 // Draw renders the text area
@@ -1052,10 +1539,10 @@ func (ta *TextArea) Draw(screen *ebiten.Image) {
 	}
 	animGeoM, hasAnimTransform, animOpacity := ta.animationTransform()
 	opacity *= animOpacity
-	hasTransform := style.Transform != "" && style.Transform != "none"
+	hasTransform := ta.hasTransform(style)
 	hasFilter := style.parsedFilter != nil
 	hasClipPath := style.ClipPath != "" && style.ClipPath != "none"
-	needsOffscreen := opacity < 1 || hasTransform || hasFilter || hasAnimTransform || hasClipPath
+	needsOffscreen := opacity < 1 || hasTransform || hasFilter || hasAnimTransform || hasClipPath || hasCompositeEffects(style)
 
 	drawContent := func(target *ebiten.Image, contentRect Rect, contentStyle *Style) {
 		ta.drawContentOnly(target, contentRect, contentStyle)
@@ -1085,10 +1572,14 @@ func (ta *TextArea) drawTextAreaContent(screen *ebiten.Image, widgetRect Rect, s
 
 	// Draw placeholder if empty
 	if ta.Text == "" && ta.Placeholder != "" && !ta.Focused {
+		x := r.X
+		if isRTLStyle(style) {
+			x = r.X + r.W - text.Advance(ta.Placeholder, ta.FontFace)
+		}
 		op := &text.DrawOptions{}
-		op.GeoM.Translate(r.X, r.Y)
+		op.GeoM.Translate(x, r.Y)
 		op.ColorScale.ScaleWithColor(ta.PlaceholderColor)
-		text.Draw(screen, ta.Placeholder, ta.FontFace, op)
+		drawTextDirection(screen, ta.Placeholder, ta.FontFace, op, styleDirection(style))
 		return
 	}
 
@@ -1098,40 +1589,108 @@ func (ta *TextArea) drawTextAreaContent(screen *ebiten.Image, widgetRect Rect, s
 		textColor = color.White
 	}
 
-	_, lineH := text.Measure("Ag", ta.FontFace, 0)
-	lineHeight := lineH * 1.2
+	lineHeight := ta.lineHeight()
+	layouts := ta.ensureLineLayouts(style)
+	screen = clipImage(screen, r)
 
-	for i, line := range ta.lines {
+	// A preedit takes the selection's place
+	lines, _, _ := ta.displayLines()
+	composing := ta.composition.composing()
+	selStart, selEnd := ta.SelectStart, ta.SelectEnd
+	if selStart > selEnd {
+		selStart, selEnd = selEnd, selStart
+	}
+	lineStart := 0
+	for i, layout := range layouts {
+		lineLen := utf8.RuneCountInString(lines[i])
 		y := r.Y + float64(i)*lineHeight - ta.ScrollY
-		if y < r.Y-lineHeight || y > r.Y+r.H+lineHeight {
-			continue // Skip lines outside visible area
+		visible := y >= r.Y-lineHeight && y <= r.Y+r.H+lineHeight // Skip lines outside visible area
+		if visible && ta.Focused && selStart < selEnd && ta.SelectionColor != nil && !composing {
+			from, to := selStart-lineStart, selEnd-lineStart
+			if from < 0 {
+				from = 0
+			}
+			if to > lineLen {
+				to = lineLen
+			}
+			for _, rect := range layout.SelectionRects(from, to) {
+				rect.X += ta.lineOriginX(r, layout, style)
+				rect.Y = y
+				rect.H = lineHeight
+				DrawRoundedRectPath(screen, rect, 2, ta.SelectionColor)
+			}
+		}
+		lineStart += lineLen + 1
+		if !visible || len(layout.lines) == 0 {
+			continue
 		}
 
 		op := &text.DrawOptions{}
-		op.GeoM.Translate(r.X, y)
+		op.GeoM.Translate(ta.lineOriginX(r, layout, style), y)
 		op.ColorScale.ScaleWithColor(textColor)
-		text.Draw(screen, line, ta.FontFace, op)
+		layout.drawLine(screen, layout.lines[0], op)
+	}
+
+	// Underline the preedit
+	if composing {
+		start, _ := ta.selectionOrCaret()
+		line, col := lineColAt(lines, start)
+		layout := layouts[line]
+		metrics := ta.FontFace.Metrics()
+		y := r.Y + float64(line)*lineHeight - ta.ScrollY + metrics.HAscent + metrics.HDescent
+		end := col + utf8.RuneCountInString(ta.composition.preedit)
+		drawCompositionUnderline(screen, layout, col, end, ta.lineOriginX(r, layout, style), y, textColor)
 	}
 
 	// Draw cursor
 	if ta.Focused && ta.cursorVisible {
-		cursorX := r.X
-		if ta.CursorLine < len(ta.lines) {
-			lineText := ta.lines[ta.CursorLine]
-			if ta.CursorCol <= len([]rune(lineText)) {
-				w, _ := text.Measure(string([]rune(lineText)[:ta.CursorCol]), ta.FontFace, 0)
-				cursorX += w
-			}
-		}
-		cursorY := r.Y + float64(ta.CursorLine)*lineHeight - ta.ScrollY + lineHeight*0.2
+		DrawRoundedRectPath(screen, ta.caretRectIn(r, layouts, style), 1, ta.CursorColor)
+	}
+}
+
+// ensureLineLayouts returns the layouts of the text area's displayed lines,
+// one paragraph each.
+func (ta *TextArea) ensureLineLayouts(style *Style) []*textLayout {
+	direction := styleDirection(style)
+	text, _ := ta.displayText()
+	lines := ta.lines
+	if ta.composition.composing() {
+		lines = splitLines(text)
+	}
+	if ta.lineLayouts != nil && ta.layoutText == text && ta.layoutFace == ta.FontFace && ta.layoutDirection == direction && len(ta.lineLayouts) == len(lines) {
+		return ta.lineLayouts
+	}
+	ta.lineLayouts = ta.lineLayouts[:0]
+	for _, line := range lines {
+		ta.lineLayouts = append(ta.lineLayouts, newTextLayout(line, ta.FontFace, textLayoutOptions{
+			WhiteSpace: textWhiteSpacePreWrap,
+			LineHeight: measureLineHeight(ta.FontFace),
+			Direction:  direction,
+		}))
+	}
+	ta.layoutText = text
+	ta.layoutFace = ta.FontFace
+	ta.layoutDirection = direction
+	return ta.lineLayouts
+}
+
+// lineOriginX returns the left edge of a line, which starts at the right
+// edge in right-to-left text areas.
+func (ta *TextArea) lineOriginX(r Rect, layout *textLayout, style *Style) float64 {
+	if isRTLStyle(style) {
+		return r.X + r.W - layout.width
+	}
+	return r.X
+}
 
-		DrawRoundedRectPath(screen, Rect{
-			X: cursorX,
-			Y: cursorY,
-			W: 2,
-			H: lineHeight * 0.8,
-		}, 1, ta.CursorColor)
+// isLineRTL reports whether a line of the text area is a right-to-left
+// paragraph.
+func (ta *TextArea) isLineRTL(line int) bool {
+	style := ta.getActiveStyle()
+	if ta.FontFace == nil || line < 0 || line >= len(ta.lines) {
+		return isRTLStyle(style)
 	}
+	return ta.ensureLineLayouts(style)[line].paragraphRTL(0)
 }
 
 // resetInputForFrame resets input state for the current frame
@@ -77,7 +77,8 @@ func (le *LayoutEngine) Layout(root Widget, containerWidth, containerHeight floa
 
 // layoutChildren arranges children within a parent widget
 func (le *LayoutEngine) layoutChildren(parent Widget) {
-	allChildren := visibleLayoutChildren(parent.Children())
+	le.layoutOutsideListMarker(parent)
+	allChildren := visibleLayoutChildren(boxChildren(parent))
 	if len(allChildren) == 0 {
 		updateOverflowContentSize(parent)
 		return
@@ -379,6 +380,11 @@ func (le *LayoutEngine) layoutChildren(parent Widget) {
 		}
 		childRect.W = math.Round(childRect.W)
 		childRect.H = math.Round(childRect.H)
+		if isRTLStyle(style) {
+			// Right-to-left containers mirror the inline axis.
+			centred := direction == LayoutColumn && style.Align == AlignCenter
+			childRect.X = math.Round(mirrorRTLX(childRect, childStyle.Margin, availX, availW, centred))
+		}
 		child.SetComputedRect(childRect)
 
 		// Recursively layout grandchildren
@@ -416,7 +422,7 @@ func updateOverflowContentSize(parent Widget) {
 	content := bw.ContentRect()
 	maxRight := content.X + content.W
 	maxBottom := content.Y + content.H
-	for _, child := range parent.Children() {
+	for _, child := range boxChildren(parent) {
 		rect := child.ComputedRect()
 		if rect.X+rect.W > maxRight {
 			maxRight = rect.X + rect.W
@@ -591,6 +597,10 @@ func (le *LayoutEngine) layoutWrappedChildren(parent Widget, children []Widget,
 				cursor += between
 			}
 			rect = constrainedRect(rect, childStyle)
+			if isRTLStyle(style) {
+				centred := direction == LayoutColumn && style.Align == AlignCenter
+				rect.X = mirrorRTLX(rect, childStyle.Margin, avail.X, avail.W, centred)
+			}
 			rect.X = math.Round(rect.X)
 			rect.Y = math.Round(rect.Y)
 			rect.W = math.Round(rect.W)
@@ -1105,3 +1105,54 @@ func TestDrawWidget(t *testing.T) {
 		DrawWidget(nil, nil)
 	})
 }
+
+func TestShapeAwareHitTestingAndPointerEvents(t *testing.T) {
+	ui := New(400, 300)
+	if err := ui.LoadCSS(`
+		#root { width: 400px; height: 300px; }
+		#round { position: absolute; left: 0; top: 0; width: 40px; height: 40px; border-radius: 20px; }
+		#rotated { position: absolute; left: 100px; top: 0; width: 100px; height: 100px; transform: rotate(45deg); }
+		#clipped { position: absolute; left: 250px; top: 0; width: 100px; height: 100px; clip-path: circle(50%); }
+		#target { position: absolute; left: 0; top: 150px; width: 100px; height: 100px; }
+		#overlay { position: absolute; left: 0; top: 150px; width: 200px; height: 100px; pointer-events: none; }
+		#badge { position: absolute; left: 150px; top: 0; width: 20px; height: 20px; pointer-events: auto; }
+	`); err != nil {
+		t.Fatalf("LoadCSS: %v", err)
+	}
+	if err := ui.LoadLayout(`<panel id="root">
+		<button id="round">o</button>
+		<panel id="rotated"><panel id="inner"/></panel>
+		<panel id="clipped"/>
+		<button id="target">go</button>
+		<panel id="overlay"><panel id="decor"/><panel id="badge"/></panel>
+	</panel>`); err != nil {
+		t.Fatalf("LoadLayout: %v", err)
+	}
+	root := ui.GetPanel("root")
+
+	cases := []struct {
+		name string
+		x, y float64
+		want string
+	}{
+		{"round centre", 20, 20, "round"},
+		{"round corner", 3, 3, "root"},
+		{"rotated centre", 150, 50, "rotated"},
+		{"rotated layout corner", 102, 2, "root"},
+		{"rotated tip beyond layout box", 150, -15, "rotated"},
+		{"clip-path centre", 300, 50, "clipped"},
+		{"clip-path corner", 253, 3, "root"},
+		{"through decorative overlay", 50, 200, "target"},
+		{"overlay child with pointer-events auto", 155, 155, "badge"},
+		{"overlay without a target beneath", 180, 220, "root"},
+	}
+	for _, tc := range cases {
+		got := ui.findWidgetAt(root, tc.x, tc.y)
+		if got == nil || got.ID() != tc.want {
+			t.Errorf("%s: hit at (%v, %v) = %v, want %s", tc.name, tc.x, tc.y, got, tc.want)
+		}
+	}
+	if ui.GetPanel("decor").Style().PointerEvents != "none" {
+		t.Error("pointer-events should be inherited")
+	}
+}
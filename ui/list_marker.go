@@ -0,0 +1,352 @@
+package ui
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// ============================================================================
+// CSS counters and list markers
+// ============================================================================
+
+// cssCounterState tracks the CSS counters in scope during a document-order
+// walk. Each name maps to a stack of nested counter instances; scopes records
+// the instances created so a parent can drop its descendants' counters once
+// its subtree has been walked.
+type cssCounterState struct {
+	stacks map[string][]int
+	scopes []string
+}
+
+func newCSSCounterState() *cssCounterState {
+	return &cssCounterState{stacks: make(map[string][]int)}
+}
+
+// cssCounterChange is one "name [int]" entry of counter-reset or
+// counter-increment.
+type cssCounterChange struct {
+	name  string
+	value int
+}
+
+// parseCounterList parses a counter-reset/counter-increment value. Names
+// without an integer take the property's default value.
+func parseCounterList(value string, defaultValue int) []cssCounterChange {
+	value = strings.TrimSpace(value)
+	if value == "" || strings.EqualFold(value, "none") {
+		return nil
+	}
+	var changes []cssCounterChange
+	for _, tok := range tokenizeCSS(value) {
+		switch tok.Kind {
+		case cssTokenIdent:
+			changes = append(changes, cssCounterChange{name: tok.Value, value: defaultValue})
+		case cssTokenNumber:
+			if len(changes) == 0 {
+				continue
+			}
+			if n, err := strconv.Atoi(tok.Value); err == nil {
+				changes[len(changes)-1].value = n
+			}
+		}
+	}
+	return changes
+}
+
+func hasCounterChange(changes []cssCounterChange, name string) bool {
+	for _, change := range changes {
+		if change.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// isListItem reports whether a widget generates a list-item marker: <li>
+// elements and anything styled display: list-item.
+func isListItem(bw *BaseWidget, style *Style) bool {
+	return bw.semanticType == "li" || style.Display == "list-item"
+}
+
+// applyWidget applies a widget's counter-reset and counter-increment,
+// including the implicit list-item counter of ul/ol and li. siblings is the
+// mark taken before the widget's parent walked its children: a reset replaces
+// a same-named counter created by a preceding sibling instead of nesting.
+func (c *cssCounterState) applyWidget(bw *BaseWidget, style *Style, siblings int) {
+	resets := parseCounterList(style.CounterReset, 0)
+	if (bw.semanticType == "ul" || bw.semanticType == "ol") && !hasCounterChange(resets, "list-item") {
+		start := 0
+		if n, err := strconv.Atoi(strings.TrimSpace(bw.Attr("start"))); err == nil {
+			start = n - 1
+		}
+		resets = append(resets, cssCounterChange{name: "list-item", value: start})
+	}
+	for _, reset := range resets {
+		if c.createdSince(reset.name, siblings) {
+			stack := c.stacks[reset.name]
+			stack[len(stack)-1] = reset.value
+			continue
+		}
+		c.stacks[reset.name] = append(c.stacks[reset.name], reset.value)
+		c.scopes = append(c.scopes, reset.name)
+	}
+
+	increments := parseCounterList(style.CounterIncrement, 1)
+	if isListItem(bw, style) && !hasCounterChange(increments, "list-item") {
+		if n, err := strconv.Atoi(strings.TrimSpace(bw.Attr("value"))); err == nil {
+			c.set("list-item", n)
+		} else {
+			increments = append(increments, cssCounterChange{name: "list-item", value: 1})
+		}
+	}
+	for _, increment := range increments {
+		stack := c.stacks[increment.name]
+		if len(stack) == 0 {
+			c.stacks[increment.name] = []int{increment.value}
+			c.scopes = append(c.scopes, increment.name)
+			continue
+		}
+		stack[len(stack)-1] += increment.value
+	}
+}
+
+func (c *cssCounterState) set(name string, value int) {
+	stack := c.stacks[name]
+	if len(stack) == 0 {
+		c.stacks[name] = []int{value}
+		c.scopes = append(c.scopes, name)
+		return
+	}
+	stack[len(stack)-1] = value
+}
+
+// values returns the nested values of a counter, outermost first.
+func (c *cssCounterState) values(name string) []int {
+	return append([]int(nil), c.stacks[name]...)
+}
+
+// clone returns an independent copy of the counters in scope.
+func (c *cssCounterState) clone() *cssCounterState {
+	stacks := make(map[string][]int, len(c.stacks))
+	for name, stack := range c.stacks {
+		stacks[name] = append([]int(nil), stack...)
+	}
+	return &cssCounterState{stacks: stacks, scopes: append([]string(nil), c.scopes...)}
+}
+
+func (c *cssCounterState) mark() int { return len(c.scopes) }
+
+func (c *cssCounterState) createdSince(name string, mark int) bool {
+	for _, scoped := range c.scopes[mark:] {
+		if scoped == name {
+			return true
+		}
+	}
+	return false
+}
+
+// restore drops every counter instance created since mark.
+func (c *cssCounterState) restore(mark int) {
+	for len(c.scopes) > mark {
+		name := c.scopes[len(c.scopes)-1]
+		c.scopes = c.scopes[:len(c.scopes)-1]
+		stack := c.stacks[name]
+		c.stacks[name] = stack[:len(stack)-1]
+	}
+}
+
+// formatCounterValue renders a counter in a list-style-type counter style.
+// Unknown styles fall back to decimal.
+func formatCounterValue(value int, styleType string) string {
+	switch strings.ToLower(strings.TrimSpace(styleType)) {
+	case "none":
+		return ""
+	case "disc":
+		return "•"
+	case "circle":
+		return "◦"
+	case "square":
+		return "▪"
+	case "decimal-leading-zero":
+		if value >= 0 && value < 10 {
+			return "0" + strconv.Itoa(value)
+		}
+	case "lower-alpha", "lower-latin":
+		if value > 0 {
+			return alphabeticCounter(value, "abcdefghijklmnopqrstuvwxyz")
+		}
+	case "upper-alpha", "upper-latin":
+		if value > 0 {
+			return alphabeticCounter(value, "ABCDEFGHIJKLMNOPQRSTUVWXYZ")
+		}
+	case "lower-greek":
+		if value > 0 {
+			return alphabeticCounter(value, "αβγδεζηθικλμνξοπρστυφχψω")
+		}
+	case "lower-roman":
+		if value > 0 && value < 4000 {
+			return strings.ToLower(romanCounter(value))
+		}
+	case "upper-roman":
+		if value > 0 && value < 4000 {
+			return romanCounter(value)
+		}
+	}
+	return strconv.Itoa(value)
+}
+
+// alphabeticCounter renders 1-based bijective numbering: a..z, aa, ab, ...
+func alphabeticCounter(value int, symbols string) string {
+	runes := []rune(symbols)
+	var out []rune
+	for value > 0 {
+		value--
+		out = append([]rune{runes[value%len(runes)]}, out...)
+		value /= len(runes)
+	}
+	return string(out)
+}
+
+func romanCounter(value int) string {
+	numerals := []struct {
+		value  int
+		symbol string
+	}{
+		{1000, "M"}, {900, "CM"}, {500, "D"}, {400, "CD"},
+		{100, "C"}, {90, "XC"}, {50, "L"}, {40, "XL"},
+		{10, "X"}, {9, "IX"}, {5, "V"}, {4, "IV"}, {1, "I"},
+	}
+	var sb strings.Builder
+	for _, numeral := range numerals {
+		for value >= numeral.value {
+			sb.WriteString(numeral.symbol)
+			value -= numeral.value
+		}
+	}
+	return sb.String()
+}
+
+// listMarkerText returns the default ::marker text for a list-style-type:
+// a bullet symbol, "N. " for counter styles, or a quoted string verbatim.
+func listMarkerText(styleType string, value int) string {
+	styleType = strings.TrimSpace(styleType)
+	if styleType == "" {
+		styleType = "disc"
+	}
+	if tokens := tokenizeCSS(styleType); len(tokens) == 1 && tokens[0].Kind == cssTokenString {
+		return tokens[0].Value
+	}
+	switch strings.ToLower(styleType) {
+	case "none":
+		return ""
+	case "disc", "circle", "square":
+		return formatCounterValue(value, styleType) + " "
+	}
+	return formatCounterValue(value, styleType) + ". "
+}
+
+// cssURLValue extracts the target of a url(...) value.
+func cssURLValue(value string) (string, bool) {
+	tokens := trimCSSWhitespace(tokenizeCSS(value))
+	if len(tokens) == 1 && tokens[0].Kind == cssTokenURL {
+		return tokens[0].Value, true
+	}
+	if len(tokens) >= 3 && tokens[0].Kind == cssTokenFunction && strings.EqualFold(tokens[0].Value, "url") {
+		args, _ := cssFunctionArguments(tokens, 1)
+		if len(args) == 1 {
+			if arg := trimCSSWhitespace(args[0]); len(arg) == 1 && arg[0].Kind == cssTokenString {
+				return arg[0].Value, true
+			}
+		}
+	}
+	return "", false
+}
+
+// applyCSSListStyleShorthand expands list-style into its longhands. Each
+// component is recognized by shape: url(...) is the image, inside/outside the
+// position and anything else the type.
+func applyCSSListStyleShorthand(style *Style, value string) {
+	for _, component := range splitCSSComponents(value) {
+		if _, ok := cssURLValue(component); ok {
+			style.ListStyleImage = component
+			continue
+		}
+		switch strings.ToLower(component) {
+		case "inside", "outside":
+			style.ListStylePosition = strings.ToLower(component)
+		default:
+			style.ListStyleType = component
+		}
+	}
+}
+
+// buildListMarker creates, updates or removes the ::marker box of a list
+// item. ::marker content overrides the default text, and list-style-image
+// replaces the text with its image when the image loads.
+func (ui *UI) buildListMarker(host Widget, bw *BaseWidget, style *Style, counters *cssCounterState) {
+	if !isListItem(bw, style) {
+		bw.listMarker = nil
+		return
+	}
+	pseudo := style.MarkerStyle
+	if pseudo != nil && pseudo.Display == "none" {
+		bw.listMarker = nil
+		return
+	}
+
+	content := ""
+	img := ui.listStyleImage(style.ListStyleImage)
+	if pseudo != nil && strings.TrimSpace(pseudo.Content) != "" {
+		var ok bool
+		content, ok = ui.resolveGeneratedContent(host, pseudo.Content, counters)
+		if !ok {
+			bw.listMarker = nil
+			return
+		}
+		img = nil
+	} else if img == nil {
+		value := 0
+		if values := counters.values("list-item"); len(values) > 0 {
+			value = values[len(values)-1]
+		}
+		content = listMarkerText(style.ListStyleType, value)
+		if content == "" {
+			bw.listMarker = nil
+			return
+		}
+	}
+
+	bw.listMarker = ui.styleGeneratedBox(host, bw.listMarker, "::marker", content, pseudo, style)
+	bw.listMarker.image = img
+	bw.listMarkerOutside = !strings.EqualFold(style.ListStylePosition, "inside")
+}
+
+func (ui *UI) listStyleImage(value string) *ebiten.Image {
+	name, ok := cssURLValue(value)
+	if !ok {
+		return nil
+	}
+	img, _ := ui.LoadImage(name)
+	return img
+}
+
+// layoutOutsideListMarker hangs an outside marker to the left of its list
+// item's border box, aligned with the first line of content.
+func (le *LayoutEngine) layoutOutsideListMarker(parent Widget) {
+	bw := baseWidgetOf(parent)
+	if bw == nil || bw.listMarker == nil || !bw.listMarkerOutside {
+		return
+	}
+	marker := bw.listMarker
+	w, h := preferredOuterSize(marker)
+	content := bw.ContentRect()
+	marker.SetComputedRect(Rect{
+		X: math.Round(bw.computedRect.X - w),
+		Y: math.Round(content.Y),
+		W: math.Round(w),
+		H: math.Round(h),
+	})
+}
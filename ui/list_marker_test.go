@@ -0,0 +1,93 @@
+package ui
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestCSSListMarkers(t *testing.T) {
+	ui := New(320, 240)
+	ui.DefaultFontFace = testTextFace()
+	if err := ui.LoadCSS(`
+		#roman { list-style: upper-roman inside; }
+		#roman li::marker { color: #00ff00; }
+	`); err != nil {
+		t.Fatalf("LoadCSS list markers: %v", err)
+	}
+	if err := ui.LoadLayout(`<panel id="root">
+		<ol id="steps"><li id="one">A</li><li id="two">B</li><li id="five" value="5">C</li><li id="six">D</li></ol>
+		<ul id="bullets"><li id="dot">E</li></ul>
+		<ol id="roman" start="3"><li id="three">F</li></ol>
+	</panel>`); err != nil {
+		t.Fatalf("LoadLayout list markers: %v", err)
+	}
+
+	// Outside markers hang left of their item; inside markers sit in it.
+	for _, tc := range []struct {
+		id, content string
+		inside      bool
+	}{
+		{"one", "1. ", false},
+		{"two", "2. ", false},
+		{"five", "5. ", false},
+		{"six", "6. ", false},
+		{"dot", "• ", false},
+		{"three", "III. ", true},
+	} {
+		item := ui.GetPanel(tc.id)
+		marker := item.PseudoElement("marker")
+		if marker == nil || marker.Content != tc.content {
+			t.Errorf("%s marker = %+v, want %q", tc.id, marker, tc.content)
+			continue
+		}
+		box, itemBox := marker.ComputedRect(), item.ComputedRect()
+		if tc.inside && box.X < itemBox.X {
+			t.Errorf("inside marker %+v should sit inside its item %+v", box, itemBox)
+		}
+		if !tc.inside && box.X+box.W > itemBox.X+0.5 {
+			t.Errorf("outside marker %+v should hang left of its item %+v", box, itemBox)
+		}
+	}
+	marker := ui.GetPanel("three").PseudoElement("marker")
+	if got := color.RGBAModel.Convert(marker.Style().TextColor).(color.RGBA); got.G != 0xff || got.R != 0 {
+		t.Errorf("::marker color = %#v, want green", got)
+	}
+}
+
+func TestCSSCountersInGeneratedContent(t *testing.T) {
+	ui := New(320, 240)
+	ui.DefaultFontFace = testTextFace()
+	if err := ui.LoadCSS(`
+		.doc { counter-reset: section; }
+		.section { counter-increment: section; counter-reset: sub; }
+		.section::before { content: "§" counter(section, lower-alpha) " "; }
+		.sub { counter-increment: sub 2; }
+		.sub::before { content: counters(section, ".") "-" counter(sub); }
+		.doc::after { content: "total " counter(section); }
+	`); err != nil {
+		t.Fatalf("LoadCSS counters: %v", err)
+	}
+	if err := ui.LoadLayout(`<panel id="doc" class="doc">
+		<panel id="s1" class="section"><panel id="s1a" class="sub"/><panel id="s1b" class="sub"/></panel>
+		<panel id="s2" class="section"><panel id="s2a" class="sub"/></panel>
+	</panel>`); err != nil {
+		t.Fatalf("LoadLayout counters: %v", err)
+	}
+
+	// ::after sees the counters incremented by the host's children.
+	for _, tc := range []struct {
+		id, pseudo, content string
+	}{
+		{"s1", "before", "§a "},
+		{"s2", "before", "§b "},
+		{"s1a", "before", "1-2"},
+		{"s1b", "before", "1-4"},
+		{"s2a", "before", "2-2"},
+		{"doc", "after", "total 2"},
+	} {
+		box := ui.GetPanel(tc.id).PseudoElement(tc.pseudo)
+		if box == nil || box.Content != tc.content {
+			t.Errorf("%s ::%s = %+v, want %q", tc.id, tc.pseudo, box, tc.content)
+		}
+	}
+}
@@ -0,0 +1,345 @@
+package ui
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// cssConditionKind identifies the at-rule that guards a conditional CSS rule.
+type cssConditionKind int
+
+const (
+	cssConditionMedia cssConditionKind = iota
+	cssConditionContainer
+)
+
+// cssRuleCondition is a single @media or @container prelude attached to the
+// rules nested inside it.
+type cssRuleCondition struct {
+	Kind  cssConditionKind
+	Name  string // container name for @container, empty for any container
+	Query string
+}
+
+var (
+	cssMediaAndPattern      = regexp.MustCompile(`\s+and\s+`)
+	cssMinMaxFeaturePattern = regexp.MustCompile(`^\(\s*(min|max)-(width|height)\s*:\s*([0-9.]+)px\s*\)$`)
+	cssRangeFeaturePattern  = regexp.MustCompile(`^\(\s*(width|height)\s*(>=|<=|>|<|=)\s*([0-9.]+)px\s*\)$`)
+	cssOrientationPattern   = regexp.MustCompile(`^\(\s*orientation\s*:\s*(landscape|portrait)\s*\)$`)
+)
+
+// parseCSSRuleCondition recognises an @media or @container block prelude.
+func parseCSSRuleCondition(prelude string) (cssRuleCondition, bool) {
+	prelude = strings.TrimSpace(prelude)
+	lower := strings.ToLower(prelude)
+	switch {
+	case strings.HasPrefix(lower, "@media"):
+		return cssRuleCondition{
+			Kind:  cssConditionMedia,
+			Query: strings.TrimSpace(prelude[len("@media"):]),
+		}, true
+	case strings.HasPrefix(lower, "@container"):
+		query := strings.TrimSpace(prelude[len("@container"):])
+		name := ""
+		if query != "" && !strings.HasPrefix(query, "(") {
+			fields := strings.Fields(query)
+			name = fields[0]
+			query = strings.TrimSpace(query[len(name):])
+		}
+		return cssRuleCondition{Kind: cssConditionContainer, Name: name, Query: query}, true
+	}
+	return cssRuleCondition{}, false
+}
+
+func appendCSSRuleCondition(conditions []cssRuleCondition, condition cssRuleCondition) []cssRuleCondition {
+	out := make([]cssRuleCondition, 0, len(conditions)+1)
+	out = append(out, conditions...)
+	return append(out, condition)
+}
+
+func (ui *UI) matchesCSSMediaCondition(condition string) bool {
+	for _, query := range splitCSSMediaQueryList(condition) {
+		if ui.matchesCSSMediaQuery(query) {
+			return true
+		}
+	}
+	return false
+}
+
+func (ui *UI) matchesCSSMediaQuery(query string) bool {
+	query = strings.TrimSpace(strings.ToLower(query))
+	if query == "" {
+		return false
+	}
+
+	parts := cssMediaAndPattern.Split(query, -1)
+	hasFeature := false
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			return false
+		}
+		if i == 0 && !strings.HasPrefix(part, "(") {
+			switch part {
+			case "all", "screen":
+				continue
+			default:
+				return false
+			}
+		}
+		hasFeature = true
+		if !ui.matchesCSSMediaFeature(part) {
+			return false
+		}
+	}
+	return hasFeature || parts[0] == "all" || parts[0] == "screen"
+}
+
+func (ui *UI) matchesCSSMediaFeature(feature string) bool {
+	return matchesCSSSizeFeature(feature, ui.width, ui.height, true)
+}
+
+// matchesCSSSizeFeature evaluates a single parenthesised size feature against
+// a box. allowHeight is false for inline-size containers, which only expose
+// their width to queries.
+func matchesCSSSizeFeature(feature string, width, height float64, allowHeight bool) bool {
+	if match := cssMinMaxFeaturePattern.FindStringSubmatch(feature); match != nil {
+		value, err := strconv.ParseFloat(match[3], 64)
+		if err != nil {
+			return false
+		}
+		if match[2] == "height" && !allowHeight {
+			return false
+		}
+		actual := width
+		if match[2] == "height" {
+			actual = height
+		}
+		switch match[1] {
+		case "min":
+			return actual >= value
+		case "max":
+			return actual <= value
+		}
+	}
+
+	if match := cssRangeFeaturePattern.FindStringSubmatch(feature); match != nil {
+		value, err := strconv.ParseFloat(match[3], 64)
+		if err != nil {
+			return false
+		}
+		if match[1] == "height" && !allowHeight {
+			return false
+		}
+		actual := width
+		if match[1] == "height" {
+			actual = height
+		}
+		switch match[2] {
+		case ">=":
+			return actual >= value
+		case "<=":
+			return actual <= value
+		case ">":
+			return actual > value
+		case "<":
+			return actual < value
+		case "=":
+			return actual == value
+		}
+	}
+
+	if match := cssOrientationPattern.FindStringSubmatch(feature); match != nil {
+		if !allowHeight {
+			return false
+		}
+		orientation := "portrait"
+		if width >= height {
+			orientation = "landscape"
+		}
+		return orientation == match[1]
+	}
+
+	return false
+}
+
+func splitCSSMediaQueryList(condition string) []string {
+	var queries []string
+	start := 0
+	depth := 0
+	for i := 0; i < len(condition); i++ {
+		switch condition[i] {
+		case '(':
+			depth++
+		case ')':
+			if depth > 0 {
+				depth--
+			}
+		case ',':
+			if depth == 0 {
+				queries = append(queries, condition[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(queries, condition[start:])
+}
+
+// ruleConditionsMatch reports whether every @media/@container condition
+// guarding a rule holds for a widget with the given ancestors.
+func (ui *UI) ruleConditionsMatch(conditions []cssRuleCondition, ancestors []Widget) bool {
+	for _, condition := range conditions {
+		switch condition.Kind {
+		case cssConditionMedia:
+			if !ui.matchesCSSMediaCondition(condition.Query) {
+				return false
+			}
+		case cssConditionContainer:
+			container := nearestQueryContainer(ancestors, condition.Name)
+			if container == nil || !matchesCSSContainerQuery(container, condition.Query) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// nearestQueryContainer returns the closest ancestor that establishes a query
+// container, optionally restricted to a container-name.
+func nearestQueryContainer(ancestors []Widget, name string) Widget {
+	for i := len(ancestors) - 1; i >= 0; i-- {
+		style := ancestors[i].Style()
+		if style == nil || !isQueryContainer(style) {
+			continue
+		}
+		if name == "" || containerHasName(style, name) {
+			return ancestors[i]
+		}
+	}
+	return nil
+}
+
+func isQueryContainer(style *Style) bool {
+	switch strings.ToLower(strings.TrimSpace(style.ContainerType)) {
+	case "size", "inline-size":
+		return true
+	default:
+		return false
+	}
+}
+
+func containerHasName(style *Style, name string) bool {
+	for _, candidate := range strings.Fields(style.ContainerName) {
+		if candidate == name {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesCSSContainerQuery evaluates a container query against the
+// container's content box from the most recent layout pass.
+func matchesCSSContainerQuery(container Widget, query string) bool {
+	query = strings.TrimSpace(strings.ToLower(query))
+	if query == "" {
+		return true
+	}
+	rect := container.ComputedRect()
+	if bw := baseWidgetOf(container); bw != nil {
+		rect = bw.ContentRect()
+	}
+	allowHeight := strings.ToLower(strings.TrimSpace(container.Style().ContainerType)) == "size"
+	for _, part := range cssMediaAndPattern.Split(query, -1) {
+		if !matchesCSSSizeFeature(strings.TrimSpace(part), rect.W, rect.H, allowHeight) {
+			return false
+		}
+	}
+	return true
+}
+
+// refreshConditionalStyles re-runs the cascade when a viewport resize or a
+// layout pass changes the outcome of any @media or @container condition.
+// Only one restyle pass is made per layout so container queries that resize
+// their own container cannot oscillate.
+func (ui *UI) refreshConditionalStyles() {
+	if ui.root == nil || !ui.styleEngine.hasConditionalRules() {
+		return
+	}
+	signature := ui.evaluateConditionalSignature()
+	if signature == ui.conditionalSignature {
+		return
+	}
+	ui.restyleFromCascadeBase(ui.root)
+	ui.reapplyStyles(ui.root)
+	ui.inheritCSSProperties(ui.root, nil)
+	ui.setFonts(ui.root)
+	ui.loadImageAssets(ui.root)
+	ui.generatePseudoElements(ui.root)
+	ui.layoutEngine.Layout(ui.root, ui.width, ui.height)
+	ui.conditionalSignature = ui.evaluateConditionalSignature()
+}
+
+// evaluateConditionalSignature encodes the current result of every media
+// condition and every container query against every query container.
+func (ui *UI) evaluateConditionalSignature() string {
+	var sb strings.Builder
+	var containerQueries []string
+	seen := make(map[string]bool)
+	for _, rule := range ui.styleEngine.rules {
+		for _, condition := range rule.Conditions {
+			switch condition.Kind {
+			case cssConditionMedia:
+				if ui.matchesCSSMediaCondition(condition.Query) {
+					sb.WriteByte('1')
+				} else {
+					sb.WriteByte('0')
+				}
+			case cssConditionContainer:
+				if !seen[condition.Query] {
+					seen[condition.Query] = true
+					containerQueries = append(containerQueries, condition.Query)
+				}
+			}
+		}
+	}
+	if len(containerQueries) == 0 {
+		return sb.String()
+	}
+	sb.WriteByte('|')
+	var walk func(Widget)
+	walk = func(widget Widget) {
+		if widget == nil {
+			return
+		}
+		if style := widget.Style(); style != nil && isQueryContainer(style) {
+			for _, query := range containerQueries {
+				if matchesCSSContainerQuery(widget, query) {
+					sb.WriteByte('1')
+				} else {
+					sb.WriteByte('0')
+				}
+			}
+		}
+		for _, child := range widget.Children() {
+			walk(child)
+		}
+	}
+	walk(ui.root)
+	return sb.String()
+}
+
+// restyleFromCascadeBase restores each widget to the style it had before the
+// stylesheet cascade was first applied so rules that stopped matching drop out.
+// Properties set from code are reapplied by the cascade that follows.
+func (ui *UI) restyleFromCascadeBase(widget Widget) {
+	if widget == nil {
+		return
+	}
+	if bw := baseWidgetOf(widget); bw != nil && bw.cascadeBase != nil {
+		widget.SetStyle(bw.cascadeBase.Clone())
+	}
+	for _, child := range widget.Children() {
+		ui.restyleFromCascadeBase(child)
+	}
+}
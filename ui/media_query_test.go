@@ -0,0 +1,140 @@
+package ui
+
+import "testing"
+
+func TestUIResizeReevaluatesMediaQueries(t *testing.T) {
+	ui := New(320, 240)
+	if err := ui.LoadCSS(`
+		.card { height: 20px; }
+		@media (min-width: 300px) {
+			.card { width: 120px; }
+		}
+		@media (max-width: 200px) {
+			.card { gap: 9px; }
+		}
+	`); err != nil {
+		t.Fatalf("LoadCSS media: %v", err)
+	}
+	if err := ui.LoadLayout(`<panel id="root"><panel id="card" class="card"></panel></panel>`); err != nil {
+		t.Fatalf("LoadLayout() error = %v", err)
+	}
+	if style := ui.GetPanel("card").Style(); style.Width != 120 || style.GapSet {
+		t.Fatalf("wide style width=%v gapSet=%v, want 120 false", style.Width, style.GapSet)
+	}
+
+	ui.Resize(180, 240)
+	narrow := ui.GetPanel("card").Style()
+	if narrow.WidthSet {
+		t.Fatal("min-width rule should drop out after resizing below the breakpoint")
+	}
+	if narrow.Gap != 9 || !narrow.GapSet {
+		t.Fatalf("narrow gap = %v set=%v, want 9 true", narrow.Gap, narrow.GapSet)
+	}
+	if narrow.Height != 20 {
+		t.Fatalf("unconditional height = %v, want 20", narrow.Height)
+	}
+
+	ui.Resize(320, 240)
+	if style := ui.GetPanel("card").Style(); style.Width != 120 || style.GapSet {
+		t.Fatalf("restored style width=%v gapSet=%v, want 120 false", style.Width, style.GapSet)
+	}
+}
+
+func TestUIResizeAppliesMediaQueriesForIDRules(t *testing.T) {
+	ui := New(320, 240)
+	if err := ui.LoadCSS(`
+		#card { width: 120px; }
+		@media (max-width: 200px) {
+			#card { width: 60px; }
+		}
+	`); err != nil {
+		t.Fatalf("LoadCSS media: %v", err)
+	}
+	if err := ui.LoadLayout(`<panel id="root"><panel id="card"></panel></panel>`); err != nil {
+		t.Fatalf("LoadLayout() error = %v", err)
+	}
+	for _, tc := range []struct {
+		width float64
+		want  float64
+	}{
+		{320, 120},
+		{180, 60},
+		{320, 120},
+	} {
+		ui.Resize(tc.width, 240)
+		if got := ui.GetPanel("card").Style().Width; got != tc.want {
+			t.Errorf("viewport %v: #card width = %v, want %v", tc.width, got, tc.want)
+		}
+	}
+}
+
+func TestStyleChangesFromCodeSurviveBreakpoints(t *testing.T) {
+	ui := New(320, 240)
+	if err := ui.LoadCSS(`
+		.card { width: 100px; height: 20px; }
+		@media (max-width: 200px) {
+			.card { height: 10px; }
+		}
+	`); err != nil {
+		t.Fatalf("LoadCSS media: %v", err)
+	}
+	if err := ui.LoadLayout(`<panel id="root"><panel id="card" class="card"></panel></panel>`); err != nil {
+		t.Fatalf("LoadLayout() error = %v", err)
+	}
+	if !ui.SetStyleProperty("card", "width", "60px") || !ui.SetStyleProperty("card", "opacity", "0.5") {
+		t.Fatal("SetStyleProperty should accept width and opacity")
+	}
+	card := ui.GetPanel("card")
+
+	ui.Resize(180, 240)
+	if style := card.Style(); style.Width != 60 || style.Opacity != 0.5 || style.Height != 10 {
+		t.Fatalf("narrow style width=%v opacity=%v height=%v, want 60 0.5 10", style.Width, style.Opacity, style.Height)
+	}
+	ui.Resize(320, 240)
+	if style := card.Style(); style.Width != 60 || style.Opacity != 0.5 || style.Height != 20 {
+		t.Fatalf("wide style width=%v opacity=%v height=%v, want 60 0.5 20", style.Width, style.Opacity, style.Height)
+	}
+}
+
+func TestUIContainerQueriesUseNamedAncestorSize(t *testing.T) {
+	ui := New(600, 400)
+	if err := ui.LoadCSS(`
+		.dock { container: dock / inline-size; }
+		.narrow { width: 150px; }
+		.wide { width: 400px; }
+		@container dock (max-width: 200px) {
+			.panel { gap: 4px; }
+		}
+		@container dock (width > 300px) {
+			.panel { gap: 16px; }
+		}
+		@container other (min-width: 1px) {
+			.panel { height: 99px; }
+		}
+	`); err != nil {
+		t.Fatalf("LoadCSS container: %v", err)
+	}
+	if err := ui.LoadLayout(`<panel id="root" direction="row">
+		<panel id="left" class="dock narrow"><panel id="a" class="panel"></panel></panel>
+		<panel id="right" class="dock wide"><panel id="b" class="panel"></panel></panel>
+	</panel>`); err != nil {
+		t.Fatalf("LoadLayout() error = %v", err)
+	}
+
+	// Rules for the unknown "other" container never apply.
+	for _, tc := range []struct {
+		id      string
+		wantGap float64
+	}{
+		{"a", 4},
+		{"b", 16},
+	} {
+		style := ui.GetPanel(tc.id).Style()
+		if style.Gap != tc.wantGap || !style.GapSet {
+			t.Errorf("#%s gap = %v set=%v, want %v true", tc.id, style.Gap, style.GapSet, tc.wantGap)
+		}
+		if style.HeightSet {
+			t.Errorf("#%s height set by a query on an unknown container", tc.id)
+		}
+	}
+}
@@ -82,12 +82,14 @@ func (ns *NineSlice) slice() {
 	ns.bottomRight = subImage(ns.image, innerRight, innerBottom, w, h)
 }
 
-// subImage extracts a sub-image
+// subImage extracts a sub-image; coordinates are relative to the image's
+// bounds, which for atlas frames do not start at the origin.
 func subImage(img *ebiten.Image, x0, y0, x1, y1 int) *ebiten.Image {
 	if x1 <= x0 || y1 <= y0 {
 		return nil
 	}
-	return img.SubImage(image.Rect(x0, y0, x1, y1)).(*ebiten.Image)
+	min := img.Bounds().Min
+	return img.SubImage(image.Rect(x0, y0, x1, y1).Add(min)).(*ebiten.Image)
 }
 
 // Draw draws the 9-slice at the target rectangle
@@ -18,6 +18,7 @@ type XMLNode struct {
 	ID       string     `xml:"id,attr"`
 	Class    string     `xml:"class,attr"`
 	Text     string     `xml:",chardata"`
+	InnerXML string     `xml:",innerxml"`
 	Children []XMLNode  `xml:",any"`
 	Attrs    []xml.Attr `xml:",any,attr"`
 }
@@ -163,8 +164,12 @@ func (f *WidgetFactory) CreateFromXML(node *XMLNode) Widget {
 	f.applyStyleBindings(widget, node)
 	f.applyCommandBindings(widget, node)
 
-	// Create children
-	for _, childNode := range node.Children {
+	// Create children; the tags inside rich text are markup, not widgets
+	children := node.Children
+	if _, ok := widget.(*Text); ok && richTextNode(node) {
+		children = nil
+	}
+	for _, childNode := range children {
 		// Skip text-only nodes
 		if childNode.XMLName.Local == "" {
 			continue
@@ -312,11 +317,25 @@ func applySemanticLayoutDefaults(widget Widget, tag string) {
 		if tag == "th" && style.FontWeight == "" {
 			style.FontWeight = "bold"
 		}
+	case "ul", "ol":
+		if style.ListStyleType == "" {
+			style.ListStyleType = "disc"
+			if tag == "ol" {
+				style.ListStyleType = "decimal"
+			}
+		}
+		// Leave room for the outside markers of the list items.
+		if !style.PaddingSet && style.Padding.Left == 0 {
+			style.Padding.Left = 24
+		}
 	}
 }
 
 func (f *WidgetFactory) applyWidgetMetadata(widget Widget, node *XMLNode) {
 	if bw := baseWidgetOf(widget); bw != nil {
+		for _, attr := range node.Attrs {
+			bw.SetAttr(attr.Name.Local, attr.Value)
+		}
 		if tabindex := node.GetAttr("tabindex"); tabindex != "" {
 			if value, err := strconv.Atoi(tabindex); err == nil {
 				bw.SetTabIndex(value)
@@ -410,6 +429,8 @@ func baseWidgetOf(widget Widget) *BaseWidget {
 		return w.BaseWidget
 	case *Image:
 		return w.BaseWidget
+	case *Sprite:
+		return w.BaseWidget
 	case *ProgressBar:
 		return w.BaseWidget
 	case *Slider:
@@ -440,6 +461,8 @@ func baseWidgetOf(widget Widget) *BaseWidget {
 		return w.BaseWidget
 	case *Toast:
 		return w.BaseWidget
+	case *generatedBox:
+		return w.BaseWidget
 	default:
 		return nil
 	}
@@ -493,6 +516,13 @@ func (f *WidgetFactory) applyBindingAttributes(widget Widget, node *XMLNode) {
 	if key := node.GetFirstAttr("bind-options", "data-bind-options"); key != "" {
 		f.bindOptions(key, widget, node)
 	}
+	if key := node.GetFirstAttr("bind-playing", "data-bind-playing"); key != "" {
+		if sprite, ok := widget.(*Sprite); ok {
+			f.bindExpressionAttr(key, widget, "bind-playing", func(value interface{}) {
+				sprite.SetPlaying(bindingTruthy(value))
+			})
+		}
+	}
 }
 
 func (f *WidgetFactory) bindTextLike(key string, widget Widget) {
@@ -500,7 +530,7 @@ func (f *WidgetFactory) bindTextLike(key string, widget Widget) {
 		textValue := bindingString(value)
 		switch w := widget.(type) {
 		case *Text:
-			w.SetContent(textValue)
+			w.setContentValue(textValue)
 		case *Button:
 			w.Label = textValue
 		case *Checkbox:
@@ -885,7 +915,7 @@ func (f *WidgetFactory) applyTemplateBindings(widget Widget, node *XMLNode) {
 		textValue := renderBindingExpressionTemplate(template, f.bindings)
 		switch w := widget.(type) {
 		case *Text:
-			w.SetContent(textValue)
+			w.setContentValue(textValue)
 		case *Button:
 			w.Label = textValue
 		case *Checkbox:
@@ -915,6 +945,9 @@ func (f *WidgetFactory) applyTemplateBindings(widget Widget, node *XMLNode) {
 
 func (f *WidgetFactory) templateSource(widget Widget, node *XMLNode) string {
 	textValue := strings.TrimSpace(node.Text)
+	if _, ok := widget.(*Text); ok && richTextNode(node) {
+		textValue = strings.TrimSpace(node.InnerXML)
+	}
 	if textValue == "" {
 		textValue = node.GetFirstAttr("content", "label", "text", "message")
 	}
@@ -931,7 +964,11 @@ func (f *WidgetFactory) applyAttributeBindings(widget Widget, node *XMLNode) {
 			continue
 		}
 		attrName := normalizeBindingName(name)
+		rawName := strings.ToLower(name)
 		f.bindExpression(attr.Value, widget, func(value interface{}) {
+			if bw := baseWidgetOf(widget); bw != nil {
+				bw.SetAttr(rawName, bindingString(value))
+			}
 			f.applyBoundAttribute(widget, attrName, value)
 		})
 	}
@@ -1063,7 +1100,18 @@ func (f *WidgetFactory) applyStyleBindings(widget Widget, node *XMLNode) {
 }
 
 func (f *WidgetFactory) applyBoundStyle(widget Widget, name string, value interface{}) {
-	style := widget.Style()
+	setBoundStyleValue(widget.Style(), name, value)
+	// Record the binding so it survives restyles, such as after a
+	// breakpoint change.
+	if bw := baseWidgetOf(widget); bw != nil {
+		bw.setStyleOverride(name, func(style *Style) { setBoundStyleValue(style, name, value) })
+	}
+	if f.onLayoutChanged != nil {
+		f.onLayoutChanged()
+	}
+}
+
+func setBoundStyleValue(style *Style, name string, value interface{}) {
 	text := bindingString(value)
 	switch name {
 	case "color":
@@ -1090,12 +1138,23 @@ func (f *WidgetFactory) applyBoundStyle(widget Widget, name string, value interf
 	case "filter":
 		style.Filter = text
 		style.parsedFilter = ParseFilter(text)
+	case "backgroundshader":
+		style.BackgroundShader = text
+		style.parsedBackgroundShader = ParseBackgroundShader(text)
 	case "animation":
 		style.Animation = text
 		style.parsedAnimation = ParseAnimationDeclaration(text)
-	}
-	if f.onLayoutChanged != nil {
-		f.onLayoutChanged()
+	default:
+		// bind-style-uniform-<name> sets a shader uniform. The map is
+		// replaced rather than written so cloned styles stay independent.
+		if uniform, ok := strings.CutPrefix(name, "uniform"); ok && uniform != "" {
+			uniforms := make(map[string]string, len(style.ShaderUniforms)+1)
+			for key, value := range style.ShaderUniforms {
+				uniforms[key] = value
+			}
+			uniforms[shaderUniformKey(uniform)] = text
+			style.ShaderUniforms = uniforms
+		}
 	}
 }
 
@@ -1118,6 +1177,8 @@ func (f *WidgetFactory) applyCommandBindings(widget Widget, node *XMLNode) {
 			if bw := baseWidgetOf(widget); bw != nil && bw.SemanticType() == "form" {
 				f.formReset[widget] = attr.Value
 			}
+		case "onanimationend":
+			f.bindAnimationEndCommand(widget, attr.Value)
 		}
 	}
 }
@@ -1194,6 +1255,18 @@ func (f *WidgetFactory) bindSubmitCommand(widget Widget, name string) {
 	}
 }
 
+func (f *WidgetFactory) bindAnimationEndCommand(widget Widget, name string) {
+	if sprite, ok := widget.(*Sprite); ok {
+		original := sprite.OnAnimationEnd
+		sprite.OnAnimationEnd = func() {
+			if original != nil {
+				original()
+			}
+			f.runCommand(name, widget)
+		}
+	}
+}
+
 func (f *WidgetFactory) runCommand(name string, widget Widget) {
 	if f.commands == nil {
 		return
@@ -1271,6 +1344,7 @@ func renderRepeatTemplate(template *XMLNode, item interface{}, index int) XMLNod
 	node.ID = renderRepeatString(node.ID, item, index)
 	node.Class = renderRepeatString(node.Class, item, index)
 	node.Text = renderRepeatString(node.Text, item, index)
+	node.InnerXML = renderRepeatString(node.InnerXML, item, index)
 	node.Attrs = renderRepeatAttrs(node.Attrs, item, index)
 
 	if len(template.Children) > 0 {
@@ -1508,6 +1582,15 @@ func (f *WidgetFactory) createWidget(node *XMLNode) Widget {
 		return NewButton(node.ID, label)
 
 	case "text", "label", "span", "p", "legend", "h1", "h2", "h3", "h4", "h5", "h6":
+		if richTextNode(node) {
+			txt := NewText(node.ID, "")
+			txt.markup = true
+			txt.SetMarkup(f.templateSource(txt, node))
+			txt.OnLinkClick(func(command string) {
+				f.runCommand(command, txt)
+			})
+			return txt
+		}
 		content := strings.TrimSpace(node.Text)
 		if content == "" {
 			content = node.GetAttr("content")
@@ -1517,6 +1600,11 @@ func (f *WidgetFactory) createWidget(node *XMLNode) Widget {
 	case "image", "img":
 		return NewImage(node.ID)
 
+	case "sprite":
+		sprite := NewSprite(node.ID)
+		applySpriteAttributes(sprite, node)
+		return sprite
+
 	case "progressbar", "progress":
 		pb := NewProgressBar(node.ID)
 		if val := node.GetAttrFloat("value"); val > 0 {
@@ -1753,6 +1841,8 @@ func (f *WidgetFactory) applyInlineStyles(widget Widget, node *XMLNode) {
 			style.HeightSet = true
 		case "direction", "layout":
 			style.Direction = LayoutDirection(attr.Value)
+		case "dir":
+			style.TextDirection = strings.ToLower(attr.Value)
 		case "align":
 			style.Align = Alignment(attr.Value)
 		case "justify":
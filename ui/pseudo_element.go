@@ -0,0 +1,359 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// ============================================================================
+// ::before / ::after generated content
+// ============================================================================
+
+// splitTerminalPseudoElement strips a trailing ::before, ::after or ::marker
+// (or the legacy single-colon form of the first two) from a selector.
+func splitTerminalPseudoElement(selector string) (string, string, bool) {
+	trimmed := strings.TrimSpace(selector)
+	lower := strings.ToLower(trimmed)
+	for _, element := range []string{"before", "after", "marker"} {
+		for _, prefix := range []string{"::", ":"} {
+			suffix := prefix + element
+			if !strings.HasSuffix(lower, suffix) || (element == "marker" && prefix == ":") {
+				continue
+			}
+			base := strings.TrimSpace(trimmed[:len(trimmed)-len(suffix)])
+			if base == "" || strings.HasSuffix(base, ":") {
+				return selector, "", false
+			}
+			return base, element, true
+		}
+	}
+	return selector, "", false
+}
+
+// hasPseudoElementRules reports whether a style declares ::before, ::after
+// or ::marker boxes directly or through one of its state styles.
+func hasPseudoElementRules(style *Style) bool {
+	if style == nil {
+		return false
+	}
+	if style.BeforeStyle != nil || style.AfterStyle != nil || style.MarkerStyle != nil {
+		return true
+	}
+	for _, state := range []*Style{style.HoverStyle, style.ActiveStyle, style.DisabledStyle, style.FocusStyle} {
+		if state != nil && (state.BeforeStyle != nil || state.AfterStyle != nil || state.MarkerStyle != nil) {
+			return true
+		}
+	}
+	return false
+}
+
+// generatedBox is the anonymous box of a ::before or ::after pseudo-element.
+// It lays out and draws like Text, but still paints its box when the content
+// is the empty string, which is common for purely decorative shapes.
+type generatedBox struct {
+	*Text
+	image *ebiten.Image // list-style-image marker
+}
+
+func newGeneratedBox(kind, content string) *generatedBox {
+	box := &generatedBox{Text: NewText("", content)}
+	box.widgetType = kind
+	return box
+}
+
+// IntrinsicWidth returns the image width for image markers, else the text width.
+func (g *generatedBox) IntrinsicWidth() float64 {
+	if g.image != nil {
+		return float64(g.image.Bounds().Dx())
+	}
+	return g.Text.IntrinsicWidth()
+}
+
+// IntrinsicHeight returns the image height for image markers, else the text height.
+func (g *generatedBox) IntrinsicHeight() float64 {
+	if g.image != nil {
+		return float64(g.image.Bounds().Dy())
+	}
+	return g.Text.IntrinsicHeight()
+}
+
+// Draw renders the generated box.
+func (g *generatedBox) Draw(screen *ebiten.Image) {
+	if !g.visible {
+		return
+	}
+	if g.image != nil {
+		g.BaseWidget.Draw(screen)
+		r := g.computedRect
+		bounds := g.image.Bounds()
+		if bounds.Dx() == 0 || bounds.Dy() == 0 {
+			return
+		}
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Scale(r.W/float64(bounds.Dx()), r.H/float64(bounds.Dy()))
+		op.GeoM.Translate(r.X, r.Y)
+		screen.DrawImage(g.image, op)
+		return
+	}
+	if g.Content == "" {
+		g.BaseWidget.Draw(screen)
+		return
+	}
+	g.Text.Draw(screen)
+}
+
+// boxChildren returns a widget's children wrapped by its generated ::before
+// and ::after boxes, in box-tree order. An inside list marker comes first;
+// outside markers hang beside the box and are laid out separately.
+func boxChildren(widget Widget) []Widget {
+	bw := baseWidgetOf(widget)
+	if bw == nil {
+		return widget.Children()
+	}
+	return bw.boxChildren()
+}
+
+func (w *BaseWidget) boxChildren() []Widget {
+	insideMarker := w.listMarker != nil && !w.listMarkerOutside
+	if w.pseudoBefore == nil && w.pseudoAfter == nil && !insideMarker {
+		return w.children
+	}
+	children := make([]Widget, 0, len(w.children)+3)
+	if insideMarker {
+		children = append(children, w.listMarker)
+	}
+	if w.pseudoBefore != nil {
+		children = append(children, w.pseudoBefore)
+	}
+	children = append(children, w.children...)
+	if w.pseudoAfter != nil {
+		children = append(children, w.pseudoAfter)
+	}
+	return children
+}
+
+// PseudoElement returns the generated box for "before", "after" or
+// "marker", or nil when the widget has no such box.
+func (w *BaseWidget) PseudoElement(name string) *Text {
+	var box *generatedBox
+	switch strings.TrimLeft(strings.ToLower(name), ":") {
+	case "before":
+		box = w.pseudoBefore
+	case "after":
+		box = w.pseudoAfter
+	case "marker":
+		box = w.listMarker
+	}
+	if box == nil {
+		return nil
+	}
+	return box.Text
+}
+
+// generatePseudoElements rebuilds the ::before/::after and list marker boxes
+// of every widget from its active style, evaluating CSS counters in document
+// order, and records the hosts whose boxes depend on state.
+func (ui *UI) generatePseudoElements(root Widget) {
+	ui.pseudoHosts = ui.pseudoHosts[:0]
+	ui.generateWidgetBoxes(root, newCSSCounterState(), 0)
+}
+
+func (ui *UI) generateWidgetBoxes(widget Widget, counters *cssCounterState, siblings int) {
+	if widget == nil {
+		return
+	}
+	bw := baseWidgetOf(widget)
+	var active *Style
+	if bw != nil {
+		active = bw.getActiveStyle()
+		counters.applyWidget(bw, active, siblings)
+		bw.pseudoCounters, bw.pseudoAfterCounters = nil, nil
+		if hasPseudoElementRules(bw.style) {
+			ui.pseudoHosts = append(ui.pseudoHosts, widget)
+			bw.pseudoCounters = counters.clone()
+		}
+		bw.pseudoBefore = ui.buildPseudoElement(widget, bw.pseudoBefore, "::before", active.BeforeStyle, active, counters)
+		ui.buildListMarker(widget, bw, active, counters)
+		bw.pseudoState = bw.state
+	}
+	mark := counters.mark()
+	for _, child := range widget.Children() {
+		ui.generateWidgetBoxes(child, counters, mark)
+	}
+	if bw != nil {
+		// ::after follows the children in document order, so it sees their
+		// counter increments.
+		bw.pseudoAfter = ui.buildPseudoElement(widget, bw.pseudoAfter, "::after", active.AfterStyle, active, counters)
+		if bw.pseudoCounters != nil {
+			bw.pseudoAfterCounters = counters.clone()
+		}
+	}
+	counters.restore(mark)
+}
+
+// buildPseudoElement creates or updates one anonymous text box. Boxes are
+// only generated when content resolves to something other than none/normal.
+func (ui *UI) buildPseudoElement(host Widget, box *generatedBox, kind string, pseudo, hostStyle *Style, counters *cssCounterState) *generatedBox {
+	if pseudo == nil || pseudo.Display == "none" {
+		return nil
+	}
+	content, ok := ui.resolveGeneratedContent(host, pseudo.Content, counters)
+	if !ok {
+		return nil
+	}
+	return ui.styleGeneratedBox(host, box, kind, content, pseudo, hostStyle)
+}
+
+// styleGeneratedBox creates or reuses a generated box and gives it the
+// pseudo-element style on top of the host's inherited text properties.
+func (ui *UI) styleGeneratedBox(host Widget, box *generatedBox, kind, content string, pseudo, hostStyle *Style) *generatedBox {
+	if box == nil {
+		box = newGeneratedBox(kind, content)
+		box.SetParent(host)
+	} else {
+		box.SetContent(content)
+	}
+	style := &Style{}
+	if pseudo != nil {
+		style = pseudo.Clone()
+		style.BeforeStyle = nil
+		style.AfterStyle = nil
+		style.MarkerStyle = nil
+	}
+	box.SetStyle(style)
+	ui.resolveStyleImages(style)
+	ui.inheritCSSProperties(box, hostStyle)
+	if face := ui.resolveFontFace(box.Style()); face != nil {
+		box.FontFace = face
+	}
+	box.image = nil
+	box.invalidateLayout()
+	return box
+}
+
+// refreshPseudoElementStates regenerates boxes whose host changed state since
+// they were built (e.g. button:hover::after). Each such host is rebuilt and
+// relaid out on its own; the whole tree is laid out again only when a change
+// can reach outside the host.
+func (ui *UI) refreshPseudoElementStates() {
+	for _, host := range ui.pseudoHosts {
+		if bw := baseWidgetOf(host); bw != nil && bw.pseudoState != bw.state && !ui.refreshHostBoxes(host, bw) {
+			ui.Layout()
+			return
+		}
+	}
+}
+
+// refreshHostBoxes rebuilds a host's generated boxes for its current state
+// from the counters recorded by the last full generation and lays out the
+// host's children in place. It reports false when the state change alters
+// counters, display or the host's own size, which need a full layout.
+func (ui *UI) refreshHostBoxes(host Widget, bw *BaseWidget) bool {
+	previous, active := bw.styleForState(bw.pseudoState), bw.getActiveStyle()
+	if bw.pseudoCounters == nil || bw.pseudoAfterCounters == nil ||
+		previous.Display != active.Display ||
+		previous.CounterReset != active.CounterReset ||
+		previous.CounterIncrement != active.CounterIncrement {
+		return false
+	}
+	fixedSize := active.Width > 0 && active.Height > 0
+	w, h := host.IntrinsicWidth(), host.IntrinsicHeight()
+	bw.pseudoBefore = ui.buildPseudoElement(host, bw.pseudoBefore, "::before", active.BeforeStyle, active, bw.pseudoCounters)
+	ui.buildListMarker(host, bw, active, bw.pseudoCounters)
+	bw.pseudoAfter = ui.buildPseudoElement(host, bw.pseudoAfter, "::after", active.AfterStyle, active, bw.pseudoAfterCounters)
+	bw.pseudoState = bw.state
+	if !fixedSize && (host.IntrinsicWidth() != w || host.IntrinsicHeight() != h) {
+		return false
+	}
+	ui.layoutEngine.layoutChildren(host)
+	return true
+}
+
+// resolveGeneratedContent evaluates a CSS content value for a host widget.
+// It reports false for none/normal or an empty value, which generate no box.
+func (ui *UI) resolveGeneratedContent(host Widget, value string, counters *cssCounterState) (string, bool) {
+	value = strings.TrimSpace(value)
+	switch strings.ToLower(value) {
+	case "", "none", "normal":
+		return "", false
+	}
+	tokens := tokenizeCSS(value)
+	var sb strings.Builder
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		switch tok.Kind {
+		case cssTokenString:
+			sb.WriteString(tok.Value)
+		case cssTokenIdent:
+			switch strings.ToLower(tok.Value) {
+			case "open-quote":
+				sb.WriteString("“")
+			case "close-quote":
+				sb.WriteString("”")
+			}
+		case cssTokenFunction:
+			args, end := cssFunctionArguments(tokens, i+1)
+			sb.WriteString(evaluateContentFunction(host, strings.ToLower(tok.Value), args, counters))
+			i = end
+		}
+	}
+	return sb.String(), true
+}
+
+// cssFunctionArguments collects the comma-separated arguments of a function
+// whose body starts at tokens[start], returning the index of its ')' token.
+func cssFunctionArguments(tokens []cssToken, start int) ([][]cssToken, int) {
+	depth := 0
+	end := len(tokens) - 1
+	for i := start; i < len(tokens); i++ {
+		switch tokens[i].Kind {
+		case cssTokenFunction, cssTokenOpenParen:
+			depth++
+		case cssTokenCloseParen:
+			if depth == 0 {
+				end = i
+				return splitCSSTokensOnComma(tokens[start:i]), end
+			}
+			depth--
+		}
+	}
+	return splitCSSTokensOnComma(tokens[start:]), end
+}
+
+func evaluateContentFunction(host Widget, name string, args [][]cssToken, counters *cssCounterState) string {
+	argText := func(index int) string {
+		if index >= len(args) || len(args[index]) == 0 {
+			return ""
+		}
+		tok := args[index][0]
+		if tok.Kind == cssTokenString || tok.Kind == cssTokenIdent {
+			return tok.Value
+		}
+		return serializeCSSTokens(args[index])
+	}
+	switch name {
+	case "attr":
+		if bw := baseWidgetOf(host); bw != nil {
+			return bw.Attr(argText(0))
+		}
+	case "counter":
+		values := counters.values(argText(0))
+		value := 0
+		if len(values) > 0 {
+			value = values[len(values)-1]
+		}
+		return formatCounterValue(value, argText(1))
+	case "counters":
+		values := counters.values(argText(0))
+		if len(values) == 0 {
+			values = []int{0}
+		}
+		parts := make([]string, len(values))
+		for i, value := range values {
+			parts[i] = formatCounterValue(value, argText(2))
+		}
+		return strings.Join(parts, argText(1))
+	}
+	return ""
+}
@@ -0,0 +1,124 @@
+package ui
+
+import (
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestCSSPseudoElementsGenerateContentAroundChildren(t *testing.T) {
+	ui := New(320, 200)
+	ui.DefaultFontFace = testTextFace()
+	if err := ui.LoadCSS(`
+		.item { flex-direction: row; gap: 4px; }
+		.item::before { content: "• "; color: #ff0000; }
+		.item::after { content: "(" attr(data-count) ")"; }
+		.empty::after { content: none; }
+	`); err != nil {
+		t.Fatalf("LoadCSS pseudo-elements: %v", err)
+	}
+	if err := ui.LoadLayout(`<panel id="root"><panel id="item" class="item" data-count="3"><text id="label">Inbox</text></panel><panel id="quiet" class="item empty"></panel></panel>`); err != nil {
+		t.Fatalf("LoadLayout pseudo-elements: %v", err)
+	}
+
+	item := ui.GetPanel("item")
+	before, after := item.PseudoElement("::before"), item.PseudoElement("after")
+	if before == nil || before.Content != "• " {
+		t.Fatalf("::before box = %+v, want content \"• \"", before)
+	}
+	if got := color.RGBAModel.Convert(before.Style().TextColor).(color.RGBA); got.R != 0xff || got.G != 0 {
+		t.Errorf("::before color = %#v, want red", got)
+	}
+	if after == nil || after.Content != "(3)" {
+		t.Fatalf("::after box = %+v, want content (3) from attr()", after)
+	}
+	if n := len(item.Children()); n != 1 {
+		t.Errorf("generated boxes must not appear in Children(): %d", n)
+	}
+	label := ui.GetText("label").ComputedRect()
+	if before.ComputedRect().X >= label.X || after.ComputedRect().X <= label.X {
+		t.Errorf("generated boxes should wrap the label: before=%+v label=%+v after=%+v", before.ComputedRect(), label, after.ComputedRect())
+	}
+	if quiet := ui.GetPanel("quiet").PseudoElement("after"); quiet != nil {
+		t.Errorf("later content: none should suppress ::after, got %q", quiet.Content)
+	}
+}
+
+func TestCSSPseudoElementStateVariants(t *testing.T) {
+	ui := New(320, 200)
+	ui.DefaultFontFace = testTextFace()
+	if err := ui.LoadCSS(`
+		#go { width: 80px; height: 30px; }
+		button:hover::after { content: "→"; position: absolute; right: 4px; }
+	`); err != nil {
+		t.Fatalf("LoadCSS hover pseudo-element: %v", err)
+	}
+	if err := ui.LoadLayout(`<panel id="root"><button id="go">Go</button></panel>`); err != nil {
+		t.Fatalf("LoadLayout hover pseudo-element: %v", err)
+	}
+
+	button := ui.GetButton("go")
+	if button.PseudoElement("after") != nil {
+		t.Fatal("::after should only exist while hovered")
+	}
+	r := button.ComputedRect()
+	ui.SimulatePointerMove(r.X+r.W/2, r.Y+r.H/2)
+	after := button.PseudoElement("after")
+	if after == nil || after.Content != "→" {
+		t.Fatalf("hover ::after = %+v, want arrow", after)
+	}
+	if box := after.ComputedRect(); math.Abs(box.X+box.W-(r.X+r.W-4)) > 1 {
+		t.Errorf("hover ::after rect = %+v, want right edge at %v", box, r.X+r.W-4)
+	}
+	ui.SimulatePointerMove(r.X+r.W+100, r.Y+r.H+100)
+	if button.PseudoElement("after") != nil {
+		t.Fatal("::after should be removed when hover ends")
+	}
+}
+
+func TestPseudoElementStateChangeRelayout(t *testing.T) {
+	// A fixed-size button's absolutely positioned ::after relays out only
+	// the button; an auto-sized tag's ::before widens it and relays out the
+	// tree, pushing the trailing sibling right.
+	for _, tc := range []struct {
+		host, pseudo string
+		treeLayout   bool
+	}{
+		{"go", "after", false},
+		{"tag", "before", true},
+	} {
+		ui := New(320, 200)
+		ui.DefaultFontFace = testTextFace()
+		if err := ui.LoadCSS(`
+			#root { flex-direction: row; }
+			.cta { width: 80px; height: 30px; }
+			.cta:hover::after { content: "→"; position: absolute; right: 4px; }
+			.tag { min-width: 10px; height: 20px; }
+			.tag:hover::before { content: "NEW"; }
+			#next { width: 20px; height: 20px; }
+		`); err != nil {
+			t.Fatalf("LoadCSS: %v", err)
+		}
+		if err := ui.LoadLayout(`<panel id="root"><button id="go" class="cta">Go</button><panel id="tag" class="tag"/><panel id="next"/></panel>`); err != nil {
+			t.Fatalf("LoadLayout: %v", err)
+		}
+
+		host := baseWidgetOf(ui.GetWidget(tc.host))
+		r := host.ComputedRect()
+		next := ui.GetPanel("next")
+		nextX := next.ComputedRect().X
+		// A layout of the whole tree moves the sibling back into place.
+		next.SetComputedRect(Rect{X: -1, Y: -1})
+		ui.SimulatePointerMove(r.X+r.W/2, r.Y+r.H/2)
+		if host.PseudoElement(tc.pseudo) == nil {
+			t.Fatalf("#%s: hover ::%s should be generated", tc.host, tc.pseudo)
+		}
+		got := next.ComputedRect()
+		switch {
+		case tc.treeLayout && got.X <= nextX:
+			t.Errorf("#%s: sibling x = %v, want it pushed right of %v by the wider host", tc.host, got.X, nextX)
+		case !tc.treeLayout && (got.X != -1 || got.Y != -1):
+			t.Errorf("#%s: sibling rect = %+v, want it untouched by a host-only relayout", tc.host, got)
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package ui
+
+import (
+	"html"
+	"image/color"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+)
+
+// ============================================================================
+// Rich text markup
+// ============================================================================
+
+// TextSpan is a styled rune range of a Text's content, produced by
+// Text.SetMarkup. Spans are sorted and never overlap; nested tags are
+// flattened into one span per distinct combination.
+type TextSpan struct {
+	Start   int         // first rune, inclusive
+	End     int         // last rune, exclusive
+	Bold    bool        // <b>
+	Italic  bool        // <i>
+	Color   color.Color // <color>, nil keeps the text color
+	Size    float64     // <size> in pixels, 0 keeps the font size
+	Icon    string      // <icon> image name; the span holds one U+FFFC per icon
+	Command string      // <link> command dispatched when the span is clicked
+
+	face text.Face     // resolved by the UI, nil draws with the widget face
+	icon *ebiten.Image // resolved by the UI
+}
+
+// textIconRune stands in the content for an inline icon.
+const textIconRune = '\uFFFC'
+
+// textMarkupTags are the tag names parseTextMarkup recognizes; anything else
+// in angle or square brackets is kept as text.
+var textMarkupTags = map[string]bool{
+	"b": true, "i": true, "color": true, "size": true, "icon": true, "link": true,
+}
+
+var textMarkupTagPattern = regexp.MustCompile(`^(/?)([a-zA-Z]+)\s*(=\s*\S+?|(?:\s+[a-zA-Z-]+\s*=\s*(?:"[^"]*"|'[^']*'|[^\s/]+))*)\s*(/?)$`)
+
+var textMarkupAttrPattern = regexp.MustCompile(`([a-zA-Z-]+)\s*=\s*("[^"]*"|'[^']*'|[^\s/]+)`)
+
+// textMarkupTag is one parsed markup tag.
+type textMarkupTag struct {
+	name      string
+	value     string // the "=value" shorthand or the tag's main attribute
+	closing   bool
+	selfClose bool
+}
+
+// parseTextMarkup parses inline markup into plain content and its spans.
+// Tags are written either as XML elements or BBCode:
+//
+//	<b>bold</b> <i>italic</i> <color value="#f80">orange</color>
+//	<size value="20">big</size> <icon name="coin"/> <link command="shop">buy</link>
+//	[b]bold[/b] [color=#f80]orange[/color] [size=20]big[/size] [icon=coin] [link=shop]buy[/link]
+//
+// Text between tags is HTML-unescaped, unknown tags are kept as text and
+// unmatched closing tags are ignored; tags left open end with the content.
+func parseTextMarkup(markup string) (string, []TextSpan) {
+	var content strings.Builder
+	var spans []TextSpan
+	var stack []textMarkupTag
+	runes := 0
+
+	appendText := func(s string) {
+		s = html.UnescapeString(s)
+		if s == "" {
+			return
+		}
+		n := len([]rune(s))
+		content.WriteString(s)
+		spans = appendTextSpan(spans, markupSpan(stack), runes, runes+n)
+		runes += n
+	}
+
+	textStart := 0
+	for i := 0; i < len(markup); i++ {
+		open := markup[i]
+		if open != '<' && open != '[' {
+			continue
+		}
+		closeDelim := byte('>')
+		if open == '[' {
+			closeDelim = ']'
+		}
+		end := strings.IndexByte(markup[i+1:], closeDelim)
+		if end < 0 {
+			break
+		}
+		tag, ok := parseTextMarkupTag(markup[i+1 : i+1+end])
+		if !ok {
+			continue
+		}
+		appendText(markup[textStart:i])
+		i += end + 1
+		textStart = i + 1
+
+		switch {
+		case tag.closing:
+			for j := len(stack) - 1; j >= 0; j-- {
+				if stack[j].name == tag.name {
+					stack = append(stack[:j], stack[j+1:]...)
+					break
+				}
+			}
+		case tag.name == "icon":
+			icon := markupSpan(append(stack, tag))
+			icon.Start, icon.End = runes, runes+1
+			content.WriteRune(textIconRune)
+			spans = append(spans, icon)
+			runes++
+		case !tag.selfClose:
+			stack = append(stack, tag)
+		}
+	}
+	appendText(markup[textStart:])
+	return content.String(), spans
+}
+
+// parseTextMarkupTag parses the inside of a tag's brackets.
+func parseTextMarkupTag(inner string) (textMarkupTag, bool) {
+	m := textMarkupTagPattern.FindStringSubmatch(strings.TrimSpace(inner))
+	if m == nil {
+		return textMarkupTag{}, false
+	}
+	tag := textMarkupTag{
+		name:      strings.ToLower(m[2]),
+		closing:   m[1] == "/",
+		selfClose: m[4] == "/",
+	}
+	if !textMarkupTags[tag.name] {
+		return textMarkupTag{}, false
+	}
+	if value, ok := strings.CutPrefix(m[3], "="); ok {
+		tag.value = unquoteMarkupValue(strings.TrimSpace(value))
+		return tag, true
+	}
+	for _, attr := range textMarkupAttrPattern.FindAllStringSubmatch(m[3], -1) {
+		switch strings.ToLower(attr[1]) {
+		case "value", "color", "size", "name", "src", "command":
+			tag.value = html.UnescapeString(unquoteMarkupValue(attr[2]))
+		}
+	}
+	return tag, true
+}
+
+func unquoteMarkupValue(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// markupSpan folds the open tags into a span style, inner tags winning.
+func markupSpan(stack []textMarkupTag) TextSpan {
+	var span TextSpan
+	for _, tag := range stack {
+		switch tag.name {
+		case "b":
+			span.Bold = true
+		case "i":
+			span.Italic = true
+		case "color":
+			span.Color = parseColor(tag.value)
+		case "size":
+			if size, err := strconv.ParseFloat(strings.TrimSuffix(tag.value, "px"), 64); err == nil && size > 0 {
+				span.Size = size
+			}
+		case "icon":
+			span.Icon = tag.value
+		case "link":
+			span.Command = tag.value
+		}
+	}
+	return span
+}
+
+// appendTextSpan adds a styled range, extending the previous span when the
+// style continues it. Unstyled ranges add nothing.
+func appendTextSpan(spans []TextSpan, span TextSpan, start, end int) []TextSpan {
+	if span == (TextSpan{}) {
+		return spans
+	}
+	if n := len(spans); n > 0 && spans[n-1].End == start && spans[n-1].Icon == "" {
+		last := spans[n-1]
+		last.Start, last.End = span.Start, span.End
+		if last == span {
+			spans[n-1].End = end
+			return spans
+		}
+	}
+	span.Start, span.End = start, end
+	return append(spans, span)
+}
+
+// SetMarkup sets the content from inline rich text markup (see
+// TextSpan for the tags) and keeps the markup's styled spans.
+func (t *Text) SetMarkup(markup string) {
+	t.Content, t.Spans = parseTextMarkup(markup)
+	t.ClearSelection()
+	if t.resolveSpans != nil {
+		t.resolveSpans(t)
+	}
+	t.invalidateLayout()
+}
+
+// setContentValue sets bound content as markup or plain text.
+func (t *Text) setContentValue(value string) {
+	if t.markup {
+		t.SetMarkup(value)
+		return
+	}
+	t.SetContent(value)
+}
+
+// OnLinkClick registers a handler for clicks on <link> spans, called with
+// the link's command. Texts loaded from XML run the UI command of that name.
+func (t *Text) OnLinkClick(handler func(command string)) {
+	t.onLinkClick = handler
+}
+
+// LinkAt returns the command of the link span at the given absolute
+// coordinates.
+func (t *Text) LinkAt(x, y float64) (string, bool) {
+	hit, ok := t.HitTest(x, y)
+	if !ok || hit.Span == nil || hit.Span.Command == "" {
+		return "", false
+	}
+	return hit.Span.Command, true
+}
+
+// HandleClick dispatches a click at the given absolute coordinates to the
+// link under it, if any. Clicks that select text follow no link.
+func (t *Text) HandleClick(x, y float64) {
+	if t.HasSelection() {
+		return
+	}
+	if command, ok := t.LinkAt(x, y); ok && t.onLinkClick != nil {
+		t.onLinkClick(command)
+	}
+}
+
+// resolveTextSpans resolves the faces and icon images of a Text's spans from
+// its style: bold, italic and sized spans get the face the style would get
+// with that font weight, style or size.
+func (ui *UI) resolveTextSpans(t *Text) {
+	style := t.Style()
+	changed := false
+	for i := range t.Spans {
+		span := &t.Spans[i]
+		var face text.Face
+		if span.Bold || span.Italic || span.Size > 0 {
+			spanStyle := &Style{
+				FontFamily:            style.FontFamily,
+				FontSize:              style.FontSize,
+				FontWeight:            style.FontWeight,
+				FontStyle:             style.FontStyle,
+				FontVariationSettings: style.FontVariationSettings,
+			}
+			if span.Bold {
+				spanStyle.FontWeight = "bold"
+			}
+			if span.Italic {
+				spanStyle.FontStyle = "italic"
+			}
+			if span.Size > 0 {
+				spanStyle.FontSize = span.Size
+			}
+			face = ui.resolveFontFace(spanStyle)
+		}
+		var icon *ebiten.Image
+		if span.Icon != "" {
+			icon, _ = ui.LoadImage(span.Icon)
+		}
+		if face != span.face || icon != span.icon {
+			span.face, span.icon = face, icon
+			changed = true
+		}
+	}
+	if changed {
+		t.invalidateLayout()
+	}
+}
+
+// richTextNode reports whether an XML text element holds markup: it sets
+// markup="true" or contains rich text tags, which then are not widgets.
+func richTextNode(node *XMLNode) bool {
+	if node.GetAttrBool("markup") {
+		return true
+	}
+	for _, child := range node.Children {
+		if textMarkupTags[strings.ToLower(child.XMLName.Local)] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,144 @@
+package ui
+
+import (
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+)
+
+func TestParseTextMarkup(t *testing.T) {
+	red := color.RGBA{255, 0, 0, 255}
+	tests := []struct {
+		name    string
+		markup  string
+		content string
+		spans   []TextSpan
+	}{
+		{
+			name:    "xml and bbcode tags",
+			markup:  "Press <b>Start</b> or [color=#ff0000]quit[/color]",
+			content: "Press Start or quit",
+			spans: []TextSpan{
+				{Start: 6, End: 11, Bold: true},
+				{Start: 15, End: 19, Color: red},
+			},
+		},
+		{
+			name:    "nested tags flatten",
+			markup:  "<b>a<i>b</i>c</b>d",
+			content: "abcd",
+			spans: []TextSpan{
+				{Start: 0, End: 1, Bold: true},
+				{Start: 1, End: 2, Bold: true, Italic: true},
+				{Start: 2, End: 3, Bold: true},
+			},
+		},
+		{
+			name:    "icons and links",
+			markup:  `x<icon name="coin"/>[link=shop]buy[/link][size=20]!`,
+			content: "x\uFFFCbuy!",
+			spans: []TextSpan{
+				{Start: 1, End: 2, Icon: "coin"},
+				{Start: 2, End: 5, Command: "shop"},
+				{Start: 5, End: 6, Size: 20},
+			},
+		},
+		{
+			name:    "unknown tags and entities stay text",
+			markup:  "a <u>b</u> [1] &lt;b&gt;",
+			content: "a <u>b</u> [1] <b>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, spans := parseTextMarkup(tt.markup)
+			if content != tt.content {
+				t.Fatalf("content = %q, want %q", content, tt.content)
+			}
+			if len(spans) != len(tt.spans) {
+				t.Fatalf("spans = %+v, want %+v", spans, tt.spans)
+			}
+			for i := range spans {
+				if spans[i] != tt.spans[i] {
+					t.Errorf("span %d = %+v, want %+v", i, spans[i], tt.spans[i])
+				}
+			}
+		})
+	}
+}
+
+func TestTextLayoutRichSpansShareBaseline(t *testing.T) {
+	face := testShapedFace(t)
+	big := &text.GoTextFace{Source: face.(*text.GoTextFace).Source, Size: 32}
+	layout := newTextLayout("ab  cd", face, textLayoutOptions{
+		WhiteSpace: textWhiteSpaceNormal,
+		LineHeight: measureLineHeight(face),
+		Spans:      []TextSpan{{Start: 4, End: 6, Size: 32, face: big}},
+	})
+
+	if layout.text != "ab cd" {
+		t.Fatalf("text = %q, want collapsed whitespace", layout.text)
+	}
+	if span := layout.spans[0]; span.Start != 3 || span.End != 5 {
+		t.Fatalf("span = [%d:%d], want [3:5] after collapsing", span.Start, span.End)
+	}
+	line := layout.lines[0]
+	if len(line.Runs) != 2 || line.Runs[1].Span != 0 {
+		t.Fatalf("Runs = %+v, want a plain run and a span run", line.Runs)
+	}
+	if line.Height <= layout.lineHeight {
+		t.Fatalf("line height = %v, want more than %v for the larger span", line.Height, layout.lineHeight)
+	}
+	baseline := line.Runs[0].Y + face.Metrics().HAscent
+	if bigBaseline := line.Runs[1].Y + big.Metrics().HAscent; math.Abs(baseline-bigBaseline) > 1e-9 {
+		t.Fatalf("baselines = %v and %v, want them shared", baseline, bigBaseline)
+	}
+
+	c := layout.clusters[3]
+	hit, ok := layout.HitTest(c.X+c.Width/2, line.Height/2)
+	if !ok || hit.Span == nil || hit.Span.Size != 32 {
+		t.Fatalf("HitTest over the span = %+v, %v; want the span", hit, ok)
+	}
+}
+
+func TestRichTextLinkFromXMLRunsCommand(t *testing.T) {
+	ui := New(320, 120)
+	ui.DefaultFontFace = testTextFace()
+	var ran Widget
+	ui.RegisterCommand("shop", func(w Widget) { ran = w })
+	if err := ui.LoadLayout(`<panel id="root">
+		<text id="offer">Buy <link command="shop">now</link>, <b>cheap</b></text>
+	</panel>`); err != nil {
+		t.Fatalf("LoadLayout() error = %v", err)
+	}
+
+	txt := ui.GetText("offer")
+	if txt.Content != "Buy now, cheap" {
+		t.Fatalf("Content = %q, want markup stripped", txt.Content)
+	}
+	if len(txt.Children()) != 0 {
+		t.Fatalf("markup tags became %d child widgets", len(txt.Children()))
+	}
+	if len(txt.Spans) != 2 || txt.Spans[0].Command != "shop" || !txt.Spans[1].Bold {
+		t.Fatalf("Spans = %+v, want a link and a bold span", txt.Spans)
+	}
+
+	style := txt.Style()
+	r := txt.ContentRect()
+	layout := txt.ensureLayout(r.W, style)
+	line := layout.lines[0]
+	cluster := layout.clusters[5]
+	x := txt.lineOriginX(r, line.Width, style) + cluster.X + cluster.Width/2
+	y := txt.textStartY(r, layout, style) + line.Y + line.Height/2
+
+	if command, ok := txt.LinkAt(x, y); !ok || command != "shop" {
+		t.Fatalf("LinkAt() = %q, %v; want shop", command, ok)
+	}
+	ui.SimulateClick(x, y)
+	if ran != txt {
+		t.Fatal("clicking the link should run its command with the text widget")
+	}
+}
@@ -19,6 +19,9 @@ var linearGradientShaderSrc []byte
 //go:embed shaders/gradient_radial.kage
 var radialGradientShaderSrc []byte
 
+//go:embed shaders/gradient_conic.kage
+var conicGradientShaderSrc []byte
+
 //go:embed shaders/box_shadow.kage
 var boxShadowShaderSrc []byte
 
@@ -53,11 +56,17 @@ func getLinearGradientShader() *ebiten.Shader {
 // t = x / (width-1) in the gradient.  The result uses premultiplied alpha as
 // required by Ebitengine's rendering pipeline.
 func buildGradientStrip(stops []ColorStop, width int) *ebiten.Image {
+	return buildGradientStripSpan(stops, width, 0, 1)
+}
+
+// buildGradientStripSpan is buildGradientStrip over the gradient positions
+// start..start+span, used for one period of a repeating gradient.
+func buildGradientStripSpan(stops []ColorStop, width int, start, span float64) *ebiten.Image {
 	img := ebiten.NewImage(width, 1)
 	pix := make([]byte, width*4)
 
 	for x := 0; x < width; x++ {
-		t := float64(x) / float64(width-1)
+		t := start + span*float64(x)/float64(width-1)
 		c := interpolateGradient(stops, t)
 
 		// Convert to premultiplied RGBA bytes for WritePixels.
@@ -77,7 +86,8 @@ func buildGradientStrip(stops []ColorStop, width int) *ebiten.Image {
 // single-threaded Ebiten game loop.
 func (g *Gradient) ensureGradientStrip() *ebiten.Image {
 	if g.strip == nil {
-		g.strip = buildGradientStrip(g.ColorStops, gradientStripWidth)
+		start, span, _ := g.period()
+		g.strip = buildGradientStripSpan(g.ColorStops, gradientStripWidth, start, span)
 	}
 	return g.strip
 }
@@ -91,8 +101,6 @@ func (g *Gradient) clearGradientStrip() {
 	}
 }
 
-// --- Box shadow shader (singleton, lazy-compiled) ---------------------------
-
 // --- Radial gradient shader (singleton, lazy-compiled) -----------------------
 
 var (
@@ -113,6 +121,26 @@ func getRadialGradientShader() *ebiten.Shader {
 	return radialGradientShader
 }
 
+// --- Conic gradient shader (singleton, lazy-compiled) ------------------------
+
+var (
+	conicGradientShader     *ebiten.Shader
+	conicGradientShaderOnce sync.Once
+)
+
+// getConicGradientShader returns the compiled Kage shader for conic gradients.
+// The shader is compiled once on first use and cached for the process lifetime.
+func getConicGradientShader() *ebiten.Shader {
+	conicGradientShaderOnce.Do(func() {
+		s, err := ebiten.NewShader(conicGradientShaderSrc)
+		if err != nil {
+			panic(fmt.Sprintf("ui: failed to compile conic gradient shader: %v", err))
+		}
+		conicGradientShader = s
+	})
+	return conicGradientShader
+}
+
 // --- Box shadow shader (singleton, lazy-compiled) ---------------------------
 
 var (
@@ -156,6 +184,29 @@ func getFilterShader() *ebiten.Shader {
 	return filterShader
 }
 
+// --- CSS mix-blend-mode shader (singleton, lazy-compiled) --------------------
+
+//go:embed shaders/blend.kage
+var blendShaderSrc []byte
+
+var (
+	blendShader     *ebiten.Shader
+	blendShaderOnce sync.Once
+)
+
+// getBlendShader returns the compiled Kage shader for mix-blend-mode.
+// The shader is compiled once on first use and cached for the process lifetime.
+func getBlendShader() *ebiten.Shader {
+	blendShaderOnce.Do(func() {
+		s, err := ebiten.NewShader(blendShaderSrc)
+		if err != nil {
+			panic(fmt.Sprintf("ui: failed to compile blend shader: %v", err))
+		}
+		blendShader = s
+	})
+	return blendShader
+}
+
 // --- CSS backdrop-filter blur shader (singleton, lazy-compiled) ---------------
 
 //go:embed shaders/backdrop_blur.kage
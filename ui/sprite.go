@@ -0,0 +1,472 @@
+package ui
+
+import (
+	"bytes"
+	"image"
+	"image/draw"
+	"image/gif"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// ============================================================================
+// Animated sprites
+// ============================================================================
+
+// defaultSpriteFPS is the playback rate of frames without their own duration.
+const defaultSpriteFPS = 12
+
+// SpriteLoop selects what a sprite does after its last frame.
+type SpriteLoop int
+
+const (
+	SpriteLoopRepeat   SpriteLoop = iota // restart from the first frame
+	SpriteLoopOnce                       // stop on the last frame
+	SpriteLoopPingPong                   // reverse direction at either end
+)
+
+// ParseSpriteLoop parses a loop attribute: loop/repeat/true, once/false or
+// pingpong/alternate.
+func ParseSpriteLoop(value string) (SpriteLoop, bool) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "loop", "repeat", "infinite", "true":
+		return SpriteLoopRepeat, true
+	case "once", "none", "false":
+		return SpriteLoopOnce, true
+	case "pingpong", "ping-pong", "alternate":
+		return SpriteLoopPingPong, true
+	}
+	return SpriteLoopRepeat, false
+}
+
+// SpriteFrame is one frame of a sprite animation.
+type SpriteFrame struct {
+	Image    *ebiten.Image
+	Duration time.Duration // 0 uses the sprite's FPS
+}
+
+// Sprite is an image widget that plays a frame sequence. Frames come from an
+// atlas tag or frame-name prefix (src="atlas:hero/run"), a sprite sheet grid
+// (src plus frame-width/frame-height) or an animated GIF. The current frame
+// is drawn like an Image, so object-fit, CSS transforms, filters and
+// keyframe animations apply to it.
+type Sprite struct {
+	*Image
+	Frames  []SpriteFrame
+	FPS     float64
+	Loop    SpriteLoop
+	Reverse bool // play from the last frame towards the first
+	Playing bool
+
+	// PlayCount stops a repeating sequence after it has played this many
+	// times; 0 repeats forever. Animated GIFs set it from their loop count.
+	PlayCount int
+
+	// OnAnimationEnd is called when a non-repeating sequence reaches its end.
+	OnAnimationEnd func()
+
+	frame      int
+	direction  int
+	elapsed    time.Duration
+	plays      int // completed passes of a repeating sequence
+	ended      bool
+	lastUpdate time.Time
+	framesSrc  string
+}
+
+// NewSprite creates a new sprite widget that starts playing once it has frames.
+func NewSprite(id string) *Sprite {
+	return &Sprite{
+		Image:   &Image{BaseWidget: NewBaseWidget(id, "sprite")},
+		FPS:     defaultSpriteFPS,
+		Playing: true,
+	}
+}
+
+// SetFrames replaces the frame sequence and rewinds to its first frame.
+func (s *Sprite) SetFrames(frames []SpriteFrame) {
+	s.Frames = frames
+	s.Rewind()
+}
+
+// Play starts or resumes playback. A sequence that ran to its end restarts.
+func (s *Sprite) Play() {
+	if s.ended {
+		s.Rewind()
+	}
+	s.Playing = true
+}
+
+// Pause stops playback on the current frame.
+func (s *Sprite) Pause() {
+	s.Playing = false
+}
+
+// Stop pauses playback and rewinds to the first frame.
+func (s *Sprite) Stop() {
+	s.Playing = false
+	s.Rewind()
+}
+
+// SetPlaying plays or pauses the sprite.
+func (s *Sprite) SetPlaying(playing bool) {
+	if playing {
+		s.Play()
+	} else {
+		s.Pause()
+	}
+}
+
+// Rewind returns to the first frame of the current direction.
+func (s *Sprite) Rewind() {
+	s.direction = 1
+	s.frame = 0
+	if s.Reverse {
+		s.direction = -1
+		s.frame = max0(len(s.Frames) - 1)
+	}
+	s.elapsed = 0
+	s.plays = 0
+	s.ended = false
+}
+
+// Frame returns the index of the frame currently shown.
+func (s *Sprite) Frame() int {
+	return s.frame
+}
+
+// SetFrame shows the frame at index, clamped to the sequence.
+func (s *Sprite) SetFrame(index int) {
+	if index >= len(s.Frames) {
+		index = len(s.Frames) - 1
+	}
+	s.frame = max0(index)
+	s.elapsed = 0
+}
+
+// CurrentImage returns the image of the frame currently shown, or nil.
+func (s *Sprite) CurrentImage() *ebiten.Image {
+	if s.frame < 0 || s.frame >= len(s.Frames) {
+		return nil
+	}
+	return s.Frames[s.frame].Image
+}
+
+// Update advances playback by the wall-clock time since the previous Update.
+// UI.Update calls it for every sprite in the tree.
+func (s *Sprite) Update() {
+	now := time.Now()
+	if !s.lastUpdate.IsZero() {
+		s.Advance(now.Sub(s.lastUpdate))
+	}
+	s.lastUpdate = now
+}
+
+// Advance moves playback forward by dt, stepping over as many frames as the
+// elapsed time covers.
+func (s *Sprite) Advance(dt time.Duration) {
+	if !s.Playing || len(s.Frames) == 0 || dt <= 0 {
+		return
+	}
+	if s.direction == 0 {
+		s.Rewind()
+	}
+	s.elapsed += dt
+	for s.Playing {
+		d := s.frameDuration(s.frame)
+		if s.elapsed < d {
+			break
+		}
+		s.elapsed -= d
+		s.step()
+	}
+}
+
+func (s *Sprite) frameDuration(index int) time.Duration {
+	if d := s.Frames[index].Duration; d > 0 {
+		return d
+	}
+	fps := s.FPS
+	if fps <= 0 {
+		fps = defaultSpriteFPS
+	}
+	return time.Duration(float64(time.Second) / fps)
+}
+
+func (s *Sprite) step() {
+	next := s.frame + s.direction
+	if next >= 0 && next < len(s.Frames) {
+		s.frame = next
+		return
+	}
+	switch s.Loop {
+	case SpriteLoopPingPong:
+		if len(s.Frames) > 1 {
+			s.direction = -s.direction
+			s.frame += s.direction
+		}
+	case SpriteLoopOnce:
+		s.end()
+	default:
+		if s.plays++; s.PlayCount > 0 && s.plays >= s.PlayCount {
+			s.end()
+			return
+		}
+		s.frame = 0
+		if s.direction < 0 {
+			s.frame = len(s.Frames) - 1
+		}
+	}
+}
+
+// end stops playback on the last frame shown and reports the end.
+func (s *Sprite) end() {
+	s.Playing = false
+	s.ended = true
+	s.elapsed = 0
+	if s.OnAnimationEnd != nil {
+		s.OnAnimationEnd()
+	}
+}
+
+// Draw renders the current frame
+func (s *Sprite) Draw(screen *ebiten.Image) {
+	if !s.visible {
+		return
+	}
+	s.Source = s.CurrentImage()
+	s.Image.Draw(screen)
+}
+
+// updateSprites advances every sprite in a widget tree.
+func (ui *UI) updateSprites(widget Widget) {
+	if widget == nil {
+		return
+	}
+	if sprite, ok := widget.(*Sprite); ok {
+		sprite.Update()
+	}
+	for _, child := range widget.Children() {
+		ui.updateSprites(child)
+	}
+}
+
+// loadSpriteFrames resolves the src of a <sprite> into frames. It runs again
+// only when src changes, so frames set in code are kept.
+func (ui *UI) loadSpriteFrames(s *Sprite) {
+	src := s.Attr("src")
+	if src == "" || src == s.framesSrc {
+		return
+	}
+	s.framesSrc = src
+	var frames []SpriteFrame
+	if ref, ok := strings.CutPrefix(src, atlasPrefix); ok {
+		var direction string
+		frames, direction = ui.atlasSequence(ref)
+		switch direction {
+		case "reverse":
+			s.Reverse = true
+		case "pingpong":
+			if s.Attr("loop") == "" {
+				s.Loop = SpriteLoopPingPong
+			}
+		}
+	} else if fw, fh := attrInt(s.Attr("frame-width")), attrInt(s.Attr("frame-height")); fw > 0 || fh > 0 {
+		if sheet, err := ui.LoadImage(src); err == nil {
+			frames = spriteSheetFrames(sheet, fw, fh, attrInt(s.Attr("frame-count")))
+		}
+	} else {
+		var plays int
+		frames, plays = ui.decodeSpriteAsset(src)
+		if s.Attr("loop") == "" {
+			if plays == 1 {
+				s.Loop = SpriteLoopOnce
+			} else {
+				s.PlayCount = plays
+			}
+		}
+	}
+	s.SetFrames(frames)
+}
+
+func attrInt(value string) int {
+	n, _ := strconv.Atoi(strings.TrimSpace(value))
+	return n
+}
+
+// atlasSequence returns the frames of an atlas tag, or of the frames whose
+// names start with ref in file order, together with the tag direction.
+// "name/tag" selects one atlas like atlasFrame does.
+func (ui *UI) atlasSequence(ref string) ([]SpriteFrame, string) {
+	find := func(atlas *Atlas, name string) ([]SpriteFrame, string) {
+		if tag, ok := atlas.Tag(name); ok {
+			var frames []SpriteFrame
+			for i := tag.From; i <= tag.To && i < len(atlas.Order); i++ {
+				frames = appendAtlasFrame(frames, atlas, atlas.Order[i])
+			}
+			return frames, tag.Direction
+		}
+		var frames []SpriteFrame
+		for _, frameName := range atlas.Order {
+			if strings.HasPrefix(frameName, name) {
+				frames = appendAtlasFrame(frames, atlas, frameName)
+			}
+		}
+		return frames, ""
+	}
+	for _, name := range ui.atlasOrder {
+		if atlas := ui.atlases[name]; atlas != nil {
+			if frames, direction := find(atlas, ref); len(frames) > 0 {
+				return frames, direction
+			}
+		}
+	}
+	if atlasName, name, ok := strings.Cut(ref, "/"); ok {
+		if atlas := ui.atlases[atlasName]; atlas != nil {
+			return find(atlas, name)
+		}
+	}
+	return nil, ""
+}
+
+func appendAtlasFrame(frames []SpriteFrame, atlas *Atlas, name string) []SpriteFrame {
+	img := atlas.FrameImage(name)
+	if img == nil {
+		return frames
+	}
+	return append(frames, SpriteFrame{Image: img, Duration: atlas.Frames[name].Duration})
+}
+
+// spriteSheetFrames cuts a sheet into a row-major grid of frames. A missing
+// frame size spans the whole sheet; count limits the number of frames.
+func spriteSheetFrames(sheet *ebiten.Image, frameW, frameH, count int) []SpriteFrame {
+	bounds := sheet.Bounds()
+	if frameW <= 0 {
+		frameW = bounds.Dx()
+	}
+	if frameH <= 0 {
+		frameH = bounds.Dy()
+	}
+	var frames []SpriteFrame
+	for y := bounds.Min.Y; y+frameH <= bounds.Max.Y; y += frameH {
+		for x := bounds.Min.X; x+frameW <= bounds.Max.X; x += frameW {
+			if count > 0 && len(frames) >= count {
+				return frames
+			}
+			cell := image.Rect(x, y, x+frameW, y+frameH)
+			frames = append(frames, SpriteFrame{Image: sheet.SubImage(cell).(*ebiten.Image)})
+		}
+	}
+	return frames
+}
+
+// decodeSpriteAsset loads an animated GIF as frames, together with the
+// number of times the GIF asks to be played, 0 for forever. Other images
+// become a single frame.
+func (ui *UI) decodeSpriteAsset(name string) ([]SpriteFrame, int) {
+	rc, err := ui.openAsset(name)
+	if err != nil {
+		return nil, 0
+	}
+	data, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		return nil, 0
+	}
+	if bytes.HasPrefix(data, []byte("GIF8")) {
+		if anim, err := gif.DecodeAll(bytes.NewReader(data)); err == nil {
+			return gifFrames(anim), gifPlayCount(anim.LoopCount)
+		}
+	}
+	img, err := ui.LoadImage(name)
+	if err != nil {
+		return nil, 0
+	}
+	return []SpriteFrame{{Image: img}}, 0
+}
+
+// gifPlayCount converts a GIF loop count, where 0 loops forever, -1 plays
+// once and n repeats the animation n more times, into a play count.
+func gifPlayCount(loopCount int) int {
+	switch {
+	case loopCount < 0:
+		return 1
+	case loopCount == 0:
+		return 0
+	default:
+		return loopCount + 1
+	}
+}
+
+// gifFrames converts a GIF's composited frames to sprite frames, with the
+// delays converted from hundredths of a second.
+func gifFrames(anim *gif.GIF) []SpriteFrame {
+	canvases := compositeGIFFrames(anim)
+	frames := make([]SpriteFrame, 0, len(canvases))
+	for i, canvas := range canvases {
+		var delay time.Duration
+		if i < len(anim.Delay) {
+			delay = time.Duration(anim.Delay[i]) * 10 * time.Millisecond
+		}
+		frames = append(frames, SpriteFrame{Image: ebiten.NewImageFromImage(canvas), Duration: delay})
+	}
+	return frames
+}
+
+// compositeGIFFrames draws each GIF frame onto a full-size canvas, honouring
+// the previous frames' disposal methods, and returns a copy of the canvas
+// as it stands after each frame.
+func compositeGIFFrames(anim *gif.GIF) []*image.RGBA {
+	w, h := anim.Config.Width, anim.Config.Height
+	if w <= 0 || h <= 0 {
+		var bounds image.Rectangle
+		for _, frame := range anim.Image {
+			bounds = bounds.Union(frame.Bounds())
+		}
+		w, h = bounds.Max.X, bounds.Max.Y
+	}
+	canvas := image.NewRGBA(image.Rect(0, 0, w, h))
+	frames := make([]*image.RGBA, 0, len(anim.Image))
+	for i, frame := range anim.Image {
+		var disposal byte
+		if i < len(anim.Disposal) {
+			disposal = anim.Disposal[i]
+		}
+		var previous *image.RGBA
+		if disposal == gif.DisposalPrevious {
+			previous = image.NewRGBA(canvas.Bounds())
+			copy(previous.Pix, canvas.Pix)
+		}
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+		composited := image.NewRGBA(canvas.Bounds())
+		copy(composited.Pix, canvas.Pix)
+		frames = append(frames, composited)
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			canvas = previous
+		}
+	}
+	return frames
+}
+
+// applySpriteAttributes reads the playback attributes of a <sprite>.
+func applySpriteAttributes(s *Sprite, node *XMLNode) {
+	if fps := node.GetAttrFloat("fps"); fps > 0 {
+		s.FPS = fps
+	}
+	if loop, ok := ParseSpriteLoop(node.GetAttr("loop")); ok {
+		s.Loop = loop
+	}
+	if node.GetAttr("direction") == "reverse" {
+		s.Reverse = true
+	}
+	if autoplay := node.GetAttr("autoplay"); autoplay != "" {
+		s.Playing = node.GetAttrBool("autoplay")
+	}
+}
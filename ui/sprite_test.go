@@ -0,0 +1,175 @@
+package ui
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"testing"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// newTestGIF builds an 8x4 GIF of two 4x4 frames, each setting its top-left
+// pixel red, shown for 50ms each.
+func newTestGIF(loopCount int, disposal byte) *gif.GIF {
+	palette := color.Palette{color.Transparent, color.RGBA{255, 0, 0, 255}}
+	anim := &gif.GIF{LoopCount: loopCount, Config: image.Config{Width: 8, Height: 4}}
+	for i := 0; i < 2; i++ {
+		frame := image.NewPaletted(image.Rect(i*4, 0, i*4+4, 4), palette)
+		frame.SetColorIndex(i*4, 0, 1)
+		anim.Image = append(anim.Image, frame)
+		anim.Delay = append(anim.Delay, 5)
+		anim.Disposal = append(anim.Disposal, disposal)
+	}
+	return anim
+}
+
+func encodeTestGIF(t *testing.T, anim *gif.GIF) []byte {
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, anim); err != nil {
+		t.Fatalf("gif.EncodeAll: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestSpritePlaysAtlasTag(t *testing.T) {
+	ui := New(320, 200)
+	atlas, err := NewAtlas(ebiten.NewImage(64, 16), []byte(`{
+		"frames": [
+			{"filename": "hero 0.png", "frame": {"x": 0, "y": 0, "w": 16, "h": 16}, "duration": 100},
+			{"filename": "hero 1.png", "frame": {"x": 16, "y": 0, "w": 16, "h": 16}, "duration": 200},
+			{"filename": "hero 2.png", "frame": {"x": 32, "y": 0, "w": 16, "h": 16}, "duration": 100}
+		],
+		"meta": {"frameTags": [{"name": "run", "from": 0, "to": 2, "direction": "pingpong"}]}
+	}`))
+	if err != nil {
+		t.Fatalf("NewAtlas: %v", err)
+	}
+	ui.RegisterAtlas("hero", atlas)
+	ui.Bind("walking", true)
+	if err := ui.LoadLayout(`<panel id="root"><sprite id="run" src="atlas:hero/run" bind-playing="walking"/></panel>`); err != nil {
+		t.Fatalf("LoadLayout: %v", err)
+	}
+
+	run := ui.GetSprite("run")
+	if run == nil || len(run.Frames) != 3 || run.Frames[1].Duration != 200*time.Millisecond {
+		t.Fatalf("atlas sprite = %+v", run)
+	}
+	if run.Loop != SpriteLoopPingPong {
+		t.Fatalf("atlas sprite loop = %v, want the tag's pingpong direction", run.Loop)
+	}
+	// Frames follow the tag durations and reverse at the end; unbinding
+	// playing pauses on the current frame.
+	for _, step := range []struct {
+		advance time.Duration
+		walking bool
+		frame   int
+	}{
+		{300 * time.Millisecond, true, 2},
+		{100 * time.Millisecond, true, 1},
+		{time.Second, false, 1},
+	} {
+		ui.Bind("walking", step.walking)
+		run.Advance(step.advance)
+		if run.Frame() != step.frame || run.Playing != step.walking {
+			t.Fatalf("after %v with walking=%v: frame=%d playing=%v, want frame %d", step.advance, step.walking, run.Frame(), run.Playing, step.frame)
+		}
+	}
+}
+
+func TestSpriteSheetPlaysOnce(t *testing.T) {
+	ui := New(320, 200)
+	ui.SetAssetResolver(newMapAssets(map[string][]byte{"sheet.png": testPNG(t, 64, 32)}))
+	ended := 0
+	ui.RegisterCommand("landed", func(Widget) { ended++ })
+	if err := ui.LoadLayout(`<panel id="root">
+		<sprite id="sheet" src="sheet.png" frame-width="16" frame-height="16" frame-count="6" fps="10" loop="once" onAnimationEnd="landed"/>
+	</panel>`); err != nil {
+		t.Fatalf("LoadLayout: %v", err)
+	}
+
+	sheet := ui.GetSprite("sheet")
+	if sheet == nil || len(sheet.Frames) != 6 || sheet.Frames[5].Image.Bounds() != image.Rect(16, 16, 32, 32) {
+		t.Fatalf("sheet sprite = %+v", sheet)
+	}
+	sheet.Advance(550 * time.Millisecond)
+	if sheet.Frame() != 5 || !sheet.Playing || ended != 0 {
+		t.Fatalf("sheet sprite frame = %d playing=%v", sheet.Frame(), sheet.Playing)
+	}
+	sheet.Advance(100 * time.Millisecond)
+	if sheet.Frame() != 5 || sheet.Playing || ended != 1 {
+		t.Fatalf("loop=once should stop on the last frame and run onAnimationEnd, got frame=%d playing=%v ended=%d", sheet.Frame(), sheet.Playing, ended)
+	}
+	sheet.Play()
+	if sheet.Frame() != 0 || !sheet.Playing {
+		t.Fatal("Play after the end should restart the sequence")
+	}
+}
+
+func TestSpritePlaysGIF(t *testing.T) {
+	ui := New(320, 200)
+	ui.SetAssetResolver(newMapAssets(map[string][]byte{
+		"spin.gif":  encodeTestGIF(t, newTestGIF(-1, gif.DisposalNone)),
+		"twice.gif": encodeTestGIF(t, newTestGIF(1, gif.DisposalNone)),
+	}))
+	if err := ui.LoadLayout(`<panel id="root"><sprite id="spin" src="spin.gif"/><sprite id="twice" src="twice.gif"/></panel>`); err != nil {
+		t.Fatalf("LoadLayout: %v", err)
+	}
+
+	spin := ui.GetSprite("spin")
+	if spin == nil || len(spin.Frames) != 2 || spin.Loop != SpriteLoopOnce || spin.Frames[0].Duration != 50*time.Millisecond {
+		t.Fatalf("gif sprite = %+v", spin)
+	}
+	if b := spin.Frames[1].Image.Bounds(); b.Dx() != 8 || b.Dy() != 4 {
+		t.Errorf("gif frames should be composited at full size, got %v", b)
+	}
+	spin.Style().Opacity = 0.5
+	ui.Draw(ebiten.NewImage(320, 200))
+	if spin.Source != spin.Frames[0].Image {
+		t.Error("Draw should show the current frame")
+	}
+
+	twice := ui.GetSprite("twice")
+	if twice.Loop != SpriteLoopRepeat || twice.PlayCount != 2 {
+		t.Fatalf("loop count 1 = loop %v PlayCount %d, want repeat twice", twice.Loop, twice.PlayCount)
+	}
+	twice.Advance(150 * time.Millisecond)
+	if !twice.Playing || twice.Frame() != 1 {
+		t.Fatalf("after the first pass playing=%v frame=%d, want the second pass on frame 1", twice.Playing, twice.Frame())
+	}
+	twice.Advance(100 * time.Millisecond)
+	if twice.Playing || twice.Frame() != 1 {
+		t.Fatalf("after two passes playing=%v frame=%d, want stopped on the last frame", twice.Playing, twice.Frame())
+	}
+}
+
+func TestCompositeGIFFramesHonoursDisposal(t *testing.T) {
+	red := color.RGBA{255, 0, 0, 255}
+	for _, tc := range []struct {
+		name     string
+		disposal byte
+		keeps    bool
+	}{
+		{"none", gif.DisposalNone, true},
+		{"background", gif.DisposalBackground, false},
+		{"previous", gif.DisposalPrevious, false},
+	} {
+		frames := compositeGIFFrames(newTestGIF(0, tc.disposal))
+		if len(frames) != 2 || frames[0].RGBAAt(0, 0) != red || frames[1].RGBAAt(4, 0) != red {
+			t.Fatalf("%s: frames should show their own pixels", tc.name)
+		}
+		if kept := frames[1].RGBAAt(0, 0) == red; kept != tc.keeps {
+			t.Errorf("%s: frame 2 keeps frame 1's pixel = %v, want %v", tc.name, kept, tc.keeps)
+		}
+	}
+}
+
+func TestGIFPlayCount(t *testing.T) {
+	for loopCount, want := range map[int]int{-1: 1, 0: 0, 1: 2, 4: 5} {
+		if got := gifPlayCount(loopCount); got != want {
+			t.Errorf("gifPlayCount(%d) = %d, want %d", loopCount, got, want)
+		}
+	}
+}
@@ -37,8 +37,10 @@ type KeyframeStyle struct {
 
 // StyleEngine manages and applies styles
 type StyleEngine struct {
-	styles map[string]*Style
-	rules  []styleRuleRecord
+	styles      map[string]*Style
+	rules       []styleRuleRecord
+	diagnostics []CSSDiagnostic
+	fontFaces   []cssFontFace // @font-face rules of the most recent CSS load
 }
 
 type styleRuleRecord struct {
@@ -47,12 +49,14 @@ type styleRuleRecord struct {
 	Specificity int
 	Order       int
 	Important   bool
+	Conditions  []cssRuleCondition
 }
 
 type cssParsedRule struct {
-	Selector  string
-	Style     *Style
-	Important bool
+	Selector   string
+	Style      *Style
+	Important  bool
+	Conditions []cssRuleCondition
 }
 
 // NewStyleEngine creates a new style engine
@@ -146,129 +150,140 @@ func (se *StyleEngine) loadKeyframes(raw json.RawMessage) error {
 	return nil
 }
 
-// LoadCSS loads a small CSS subset: simple selector blocks plus literal @keyframes blocks.
+// LoadCSS loads a CSS stylesheet: selector rule blocks, @keyframes, and
+// @media/@container groups. @font-face rules are compiled but only loaded by
+// UI.LoadCSS, which has the asset resolver. Problems are recorded as
+// source-located diagnostics; error-level problems abort the load and are
+// returned.
 func (se *StyleEngine) LoadCSS(css string) error {
-	animations, err := parseCSSKeyframes(css)
+	return se.loadCSS(css, "", nil)
+}
+
+// LoadCSSFile loads a CSS stylesheet from disk, naming the file in diagnostics.
+func (se *StyleEngine) LoadCSSFile(filename string) error {
+	data, err := os.ReadFile(filename)
 	if err != nil {
+		return fmt.Errorf("failed to read style file: %w", err)
+	}
+	return se.loadCSS(string(data), filename, nil)
+}
+
+// Diagnostics returns the warnings and errors recorded by the most recent CSS load.
+func (se *StyleEngine) Diagnostics() []CSSDiagnostic {
+	result := make([]CSSDiagnostic, len(se.diagnostics))
+	copy(result, se.diagnostics)
+	return result
+}
+
+// loadCSS parses and compiles a stylesheet. A non-nil substitute rewrites
+// declaration values that contain var() before they are compiled.
+func (se *StyleEngine) loadCSS(css, file string, substitute func(string) string) error {
+	sheet, diagnostics := parseCSSStylesheet(css, file)
+	if substitute != nil {
+		substituteCSSValues(sheet.Rules, substitute)
+	}
+	compiler := &cssCompiler{
+		cssReporter: cssReporter{file: file, diagnostics: &diagnostics},
+		keyframes:   make(map[string]map[string]KeyframeStyle),
+	}
+	compiler.compileRules(sheet.Rules, nil)
+	se.diagnostics = diagnostics
+	if err := cssDiagnosticsError(diagnostics); err != nil {
 		return err
 	}
-	for name, frames := range animations {
-		anim := animationFromKeyframeStyles(name, frames)
+	se.fontFaces = compiler.fontFaces
+	for _, name := range compiler.keyframeOrder {
+		anim := animationFromKeyframeStyles(name, compiler.keyframes[name])
 		if anim != nil {
 			RegisterAnimation(name, anim)
 		}
 	}
-	rules, err := parseCSSStyleRules(css)
-	if err != nil {
-		return err
-	}
-	for _, rule := range rules {
+	for _, rule := range compiler.rules {
+		if len(rule.Conditions) > 0 {
+			se.addConditionalStyle(rule.Selector, rule.Style, rule.Important, rule.Conditions)
+			continue
+		}
 		se.addStyle(rule.Selector, rule.Style, rule.Important)
 	}
 	return nil
 }
 
-func parseCSSKeyframes(css string) (map[string]map[string]KeyframeStyle, error) {
-	result := make(map[string]map[string]KeyframeStyle)
-	remaining := stripCSSComments(css)
-	for {
-		idx := strings.Index(remaining, "@keyframes")
-		if idx < 0 {
-			break
-		}
-		remaining = remaining[idx+len("@keyframes"):]
-		remaining = strings.TrimSpace(remaining)
-		nameEnd := strings.IndexAny(remaining, " \t\r\n{")
-		if nameEnd <= 0 {
-			return nil, fmt.Errorf("failed to parse @keyframes: missing animation name")
-		}
-		name := strings.TrimSpace(remaining[:nameEnd])
-		remaining = strings.TrimSpace(remaining[nameEnd:])
-		if !strings.HasPrefix(remaining, "{") {
-			return nil, fmt.Errorf("failed to parse @keyframes %q: missing block", name)
-		}
-		body, rest, ok := consumeCSSBlock(remaining)
-		if !ok {
-			return nil, fmt.Errorf("failed to parse @keyframes %q: unclosed block", name)
-		}
-		frames, err := parseCSSKeyframeBody(name, body)
-		if err != nil {
-			return nil, err
-		}
-		result[name] = frames
-		remaining = rest
-	}
-	if len(result) == 0 && strings.Contains(css, "@keyframes") {
-		return nil, fmt.Errorf("failed to parse @keyframes")
-	}
-	return result, nil
+// cssCompiler turns a parsed stylesheet into style rules and keyframes.
+type cssCompiler struct {
+	cssReporter
+	rules         []cssParsedRule
+	keyframes     map[string]map[string]KeyframeStyle
+	keyframeOrder []string
+	fontFaces     []cssFontFace
 }
 
-func parseCSSStyleRules(css string) ([]cssParsedRule, error) {
-	remaining := stripCSSAtKeyframes(stripCSSComments(css))
-	rules := make([]cssParsedRule, 0)
-	for strings.TrimSpace(remaining) != "" {
-		open := strings.Index(remaining, "{")
-		if open < 0 {
-			if strings.TrimSpace(remaining) == "" {
-				break
-			}
-			return nil, fmt.Errorf("failed to parse CSS rule: missing block")
-		}
-		selectorText := strings.TrimSpace(remaining[:open])
-		if selectorText == "" {
-			return nil, fmt.Errorf("failed to parse CSS rule: empty selector")
-		}
-		block, rest, ok := consumeCSSBlock(remaining[open:])
-		if !ok {
-			return nil, fmt.Errorf("failed to parse CSS rule %q: unclosed block", selectorText)
-		}
-		normalStyle, importantStyle := stylesFromCSSDeclarations(block)
-		for _, selector := range splitCSSSelectorList(selectorText) {
-			selector = strings.TrimSpace(selector)
-			if selector != "" {
-				if normalStyle != nil {
-					rules = append(rules, cssParsedRule{Selector: selector, Style: normalStyle.Clone()})
-				}
-				if importantStyle != nil {
-					rules = append(rules, cssParsedRule{Selector: selector, Style: importantStyle.Clone(), Important: true})
-				}
+func (c *cssCompiler) compileRules(rules []*cssRule, conditions []cssRuleCondition) {
+	for _, rule := range rules {
+		switch rule.AtKeyword {
+		case "":
+			c.compileStyleRule(rule, conditions)
+		case "media", "container":
+			condition, _ := parseCSSRuleCondition("@" + rule.AtKeyword + " " + rule.Prelude)
+			if !rule.HasBlock {
+				c.report(CSSError, rule.Line, rule.Column, "@%s: missing block", rule.AtKeyword)
+				continue
 			}
+			c.compileRules(rule.Rules, appendCSSRuleCondition(conditions, condition))
+		case "keyframes":
+			c.compileKeyframes(rule)
+		case "font-face":
+			c.compileFontFace(rule)
+		default:
+			c.report(CSSWarning, rule.Line, rule.Column, "unsupported at-rule @%s", rule.AtKeyword)
 		}
-		remaining = strings.TrimSpace(rest)
 	}
-	return rules, nil
 }
 
-func styleFromCSSDeclarations(block string) *Style {
-	style, important := stylesFromCSSDeclarations(block)
-	if style != nil {
-		return style
+func (c *cssCompiler) compileStyleRule(rule *cssRule, conditions []cssRuleCondition) {
+	var selectors []string
+	for _, part := range splitCSSTokensOnComma(rule.PreludeToks) {
+		selector := serializeCSSTokens(part)
+		if selector == "" {
+			c.report(CSSError, rule.Line, rule.Column, "empty selector in list %q", rule.Prelude)
+			return
+		}
+		selectors = append(selectors, selector)
 	}
-	if important != nil {
-		return important
+	normalStyle, importantStyle := c.stylesFromDeclarations(rule.Declarations)
+	for _, selector := range selectors {
+		if normalStyle != nil {
+			c.rules = append(c.rules, cssParsedRule{Selector: selector, Style: normalStyle.Clone(), Conditions: conditions})
+		}
+		if importantStyle != nil {
+			c.rules = append(c.rules, cssParsedRule{Selector: selector, Style: importantStyle.Clone(), Important: true, Conditions: conditions})
+		}
 	}
-	return &Style{}
 }
 
-func stylesFromCSSDeclarations(block string) (*Style, *Style) {
+func (c *cssCompiler) stylesFromDeclarations(declarations []cssDeclaration) (*Style, *Style) {
 	normalStyle := &Style{}
 	importantStyle := &Style{}
 	hasNormal := false
 	hasImportant := false
-	for _, declaration := range splitCSSDeclarations(block) {
-		name, value, ok := strings.Cut(declaration, ":")
-		if !ok {
+	for _, decl := range declarations {
+		if strings.HasPrefix(decl.Name, "--") {
+			// Custom properties are resolved by CSSVariables before parsing.
+			continue
+		}
+		if problem := cssDeclarationValueProblem(decl); problem != "" {
+			c.report(CSSWarning, decl.Line, decl.Column, "%s", problem)
 			continue
 		}
-		cleanValue, important := parseCSSImportantValue(value)
-		if important {
-			applyCSSDeclaration(importantStyle, strings.ToLower(strings.TrimSpace(name)), cleanValue)
+		target := normalStyle
+		if decl.Important {
+			target = importantStyle
 			hasImportant = true
 		} else {
-			applyCSSDeclaration(normalStyle, strings.ToLower(strings.TrimSpace(name)), cleanValue)
 			hasNormal = true
 		}
+		if !applyCSSDeclaration(target, decl.Name, decl.Value) {
+			c.report(CSSWarning, decl.Line, decl.Column, "unknown property %q", decl.Name)
+		}
 	}
 	if !hasNormal {
 		normalStyle = nil
@@ -279,7 +294,45 @@ func stylesFromCSSDeclarations(block string) (*Style, *Style) {
 	return normalStyle, importantStyle
 }
 
-func applyCSSDeclaration(style *Style, prop, value string) {
+func (c *cssCompiler) compileKeyframes(rule *cssRule) {
+	name := rule.Prelude
+	if name == "" || strings.ContainsAny(name, " \t") {
+		c.report(CSSError, rule.Line, rule.Column, "@keyframes: missing or invalid animation name")
+		return
+	}
+	if !rule.HasBlock {
+		c.report(CSSError, rule.Line, rule.Column, "@keyframes %q: missing block", name)
+		return
+	}
+	frames := make(map[string]KeyframeStyle)
+	for _, frameRule := range rule.Rules {
+		if frameRule.AtKeyword != "" {
+			c.report(CSSError, frameRule.Line, frameRule.Column, "@keyframes %q: unexpected @%s", name, frameRule.AtKeyword)
+			return
+		}
+		frame := c.keyframeStyleFromDeclarations(frameRule.Declarations)
+		for _, part := range splitCSSTokensOnComma(frameRule.PreludeToks) {
+			label := serializeCSSTokens(part)
+			if _, ok := parseKeyframePercent(label); !ok {
+				c.report(CSSError, frameRule.Line, frameRule.Column, "@keyframes %q: invalid selector %q", name, label)
+				return
+			}
+			frames[label] = frame
+		}
+	}
+	if len(frames) == 0 {
+		c.report(CSSError, rule.Line, rule.Column, "@keyframes %q: no frames", name)
+		return
+	}
+	if _, exists := c.keyframes[name]; !exists {
+		c.keyframeOrder = append(c.keyframeOrder, name)
+	}
+	c.keyframes[name] = frames
+}
+
+// applyCSSDeclaration sets one CSS property on style and reports whether the
+// property is recognised.
+func applyCSSDeclaration(style *Style, prop, value string) bool {
 	switch prop {
 	case "display":
 		style.Display = value
@@ -333,8 +386,45 @@ func applyCSSDeclaration(style *Style, prop, value string) {
 		spacing := cssBoxSpacing(value)
 		style.Margin = Margin(spacing)
 		style.MarginSet = true
-	case "background", "background-color":
+	case "padding-inline":
+		if parts := strings.Fields(value); len(parts) > 0 {
+			style.PaddingInlineStart = parseCSSPixels(parts[0])
+			style.PaddingInlineEnd = parseCSSPixels(parts[len(parts)-1])
+			style.PaddingInlineStartSet = true
+			style.PaddingInlineEndSet = true
+		}
+	case "padding-inline-start":
+		style.PaddingInlineStart = parseCSSPixels(value)
+		style.PaddingInlineStartSet = true
+	case "padding-inline-end":
+		style.PaddingInlineEnd = parseCSSPixels(value)
+		style.PaddingInlineEndSet = true
+	case "background":
+		if cssBackgroundHasLayers(value) {
+			applyCSSBackgroundShorthand(style, value)
+		} else {
+			style.Background = value
+		}
+	case "background-color":
 		style.Background = value
+	case "background-image":
+		style.BackgroundImage = value
+	case "background-size":
+		style.BackgroundSize = strings.ToLower(value)
+	case "background-position":
+		style.BackgroundPosition = strings.ToLower(value)
+	case "background-repeat":
+		style.BackgroundRepeat = strings.ToLower(value)
+	case "border-image":
+		applyCSSBorderImageShorthand(style, value)
+	case "border-image-source":
+		style.BorderImage = value
+	case "border-image-slice":
+		style.BorderImageSlice = strings.ToLower(value)
+	case "border-image-width":
+		style.BorderImageWidth = strings.ToLower(value)
+	case "border-image-repeat":
+		style.BorderImageRepeat = strings.ToLower(value)
 	case "color":
 		style.Color = value
 	case "border":
@@ -342,16 +432,66 @@ func applyCSSDeclaration(style *Style, prop, value string) {
 	case "border-color":
 		style.Border = value
 	case "border-width":
-		style.BorderWidth = parseCSSPixels(value)
+		style.BorderWidth = parseCSSBorderWidth(value)
 		style.BorderWidthSet = true
+	case "border-style":
+		applyCSSBorderStyleDeclaration(style, value)
+	case "border-top", "border-right", "border-bottom", "border-left":
+		applyCSSBorderSideDeclaration(style, strings.TrimPrefix(prop, "border-"), value)
+	case "border-top-width", "border-right-width", "border-bottom-width", "border-left-width":
+		width, set, _, _ := cssBorderSideFields(style, strings.TrimSuffix(strings.TrimPrefix(prop, "border-"), "-width"))
+		*width = parseCSSBorderWidth(value)
+		*set = true
+	case "border-top-color", "border-right-color", "border-bottom-color", "border-left-color":
+		_, _, clr, _ := cssBorderSideFields(style, strings.TrimSuffix(strings.TrimPrefix(prop, "border-"), "-color"))
+		*clr = value
+	case "border-top-style", "border-right-style", "border-bottom-style", "border-left-style":
+		_, _, _, lineStyle := cssBorderSideFields(style, strings.TrimSuffix(strings.TrimPrefix(prop, "border-"), "-style"))
+		*lineStyle = strings.ToLower(value)
+	case "outline":
+		style.Outline = value
+		style.parsedOutline = nil
+	case "outline-offset":
+		style.OutlineOffset = parseCSSPixels(value)
+		style.OutlineOffsetSet = true
 	case "border-radius":
-		style.BorderRadius = parseCSSPixels(value)
+		style.BorderRadius = parseCSSBorderRadius(value)
 		style.BorderRadiusSet = true
 	case "font-size":
-		style.FontSize = parseCSSPixels(value)
+		style.FontSize, style.fontSizeScale = parseCSSFontSize(value)
 		style.FontSizeSet = true
 	case "font-weight":
 		style.FontWeight = value
+	case "font-style":
+		style.FontStyle = value
+	case "font-variation-settings":
+		style.FontVariationSettings = value
+	case "direction":
+		style.TextDirection = strings.ToLower(value)
+	case "text-align":
+		style.TextAlign = strings.ToLower(value)
+	case "text-overflow":
+		style.TextOverflow = strings.ToLower(value)
+	case "text-transform":
+		style.TextTransform = strings.ToLower(value)
+	case "white-space":
+		style.WhiteSpace = strings.ToLower(value)
+	case "word-break":
+		style.WordBreak = strings.ToLower(value)
+	case "overflow-wrap", "word-wrap":
+		style.OverflowWrap = strings.ToLower(value)
+	case "tab-size":
+		style.TabSize, _ = strconv.ParseFloat(value, 64)
+	case "line-clamp", "-webkit-line-clamp":
+		style.LineClamp, _ = strconv.Atoi(value)
+	case "text-decoration":
+		applyTextDecorationShorthand(style, value)
+	case "text-decoration-line":
+		style.TextDecoration = strings.ToLower(value)
+	case "text-decoration-color":
+		style.TextDecorationColor = value
+	case "text-decoration-thickness":
+		style.TextDecorationThickness = parseCSSPixels(value)
 	case "line-height":
 		style.LineHeight = parseCSSPixels(value)
 		style.LineHeightSet = true
@@ -359,7 +499,7 @@ func applyCSSDeclaration(style *Style, prop, value string) {
 		style.LetterSpacing = parseCSSPixels(value)
 		style.LetterSpacingSet = true
 	case "opacity":
-		style.Opacity, _ = strconv.ParseFloat(value, 64)
+		style.Opacity = parseFilterAmount(value)
 		style.OpacitySet = true
 	case "box-shadow":
 		style.BoxShadow = value
@@ -373,12 +513,45 @@ func applyCSSDeclaration(style *Style, prop, value string) {
 		style.Filter = value
 	case "backdrop-filter":
 		style.BackdropFilter = value
+	case "background-shader":
+		style.BackgroundShader = value
+	case "ripple":
+		style.Ripple = value
+	case "skeleton":
+		style.Skeleton = value
 	case "transform":
 		style.Transform = value
 	case "transform-origin":
 		style.TransformOrigin = value
+	case "perspective":
+		style.Perspective = 0
+		if !strings.EqualFold(value, "none") {
+			style.Perspective = parseCSSPixels(value)
+		}
+	case "perspective-origin":
+		style.PerspectiveOrigin = value
+	case "transform-style":
+		style.TransformStyle = strings.ToLower(value)
+	case "backface-visibility":
+		style.BackfaceVisibility = strings.ToLower(value)
 	case "clip-path":
 		style.ClipPath = value
+	case "pointer-events":
+		style.PointerEvents = strings.ToLower(value)
+	case "user-select", "-webkit-user-select":
+		style.UserSelect = strings.ToLower(value)
+	case "mix-blend-mode":
+		style.MixBlendMode = strings.ToLower(value)
+	case "mask":
+		applyCSSMaskShorthand(style, value)
+	case "mask-image":
+		style.MaskImage = value
+	case "mask-size":
+		style.MaskSize = strings.ToLower(value)
+	case "mask-position":
+		style.MaskPosition = strings.ToLower(value)
+	case "mask-repeat":
+		style.MaskRepeat = strings.ToLower(value)
 	case "overflow":
 		style.Overflow = value
 	case "overflow-x":
@@ -404,7 +577,34 @@ func applyCSSDeclaration(style *Style, prop, value string) {
 		style.ZIndexSet = true
 	case "visibility":
 		style.Visibility = value
+	case "content":
+		style.Content = value
+	case "counter-reset":
+		style.CounterReset = value
+	case "counter-increment":
+		style.CounterIncrement = value
+	case "list-style-type":
+		style.ListStyleType = value
+	case "list-style-position":
+		style.ListStylePosition = value
+	case "list-style-image":
+		style.ListStyleImage = value
+	case "list-style":
+		applyCSSListStyleShorthand(style, value)
+	case "container-type":
+		style.ContainerType = value
+	case "container-name":
+		style.ContainerName = value
+	case "container":
+		name, kind, ok := strings.Cut(value, "/")
+		style.ContainerName = strings.TrimSpace(name)
+		if ok {
+			style.ContainerType = strings.TrimSpace(kind)
+		}
+	default:
+		return false
 	}
+	return true
 }
 
 func cssJustify(value string) Justify {
@@ -467,17 +667,160 @@ func cssBoxSpacing(value string) cssSpacing {
 func applyCSSBorderDeclaration(style *Style, value string) {
 	parts := strings.Fields(value)
 	for _, part := range parts {
-		if strings.HasSuffix(part, "px") || isCSSNumeric(part) {
-			style.BorderWidth = parseCSSPixels(part)
+		if strings.HasSuffix(part, "px") || isCSSNumeric(part) || isBorderWidthKeyword(part) {
+			style.BorderWidth = parseCSSBorderWidth(part)
 			style.BorderWidthSet = true
 			continue
 		}
+		if isBorderLineStyle(part) {
+			style.BorderStyle = strings.ToLower(part)
+			continue
+		}
 		if strings.HasPrefix(part, "#") || strings.HasPrefix(part, "rgb") || isNamedColor(part) {
 			style.Border = part
 		}
 	}
 }
 
+// cssBorderLineStyles are the accepted border-style/outline-style keywords.
+var cssBorderLineStyles = []string{"none", "hidden", "solid", "dashed", "dotted", "double", "groove", "ridge", "inset", "outset"}
+
+func isBorderLineStyle(value string) bool {
+	return cssKeywordAllowed(cssBorderLineStyles, value)
+}
+
+// parseCSSBorderRadius returns the single corner radius a border-radius
+// value resolves to: the first horizontal radius. Per-corner and elliptical
+// (after '/') radii are accepted but not drawn.
+func parseCSSBorderRadius(value string) float64 {
+	horizontal, _, _ := strings.Cut(value, "/")
+	if components := splitCSSComponents(horizontal); len(components) > 0 {
+		return parseCSSPixels(components[0])
+	}
+	return 0
+}
+
+// cssBorderWidthKeywords are the named border widths.
+var cssBorderWidthKeywords = []string{"thin", "medium", "thick"}
+
+func isBorderWidthKeyword(value string) bool {
+	return cssKeywordAllowed(cssBorderWidthKeywords, value)
+}
+
+// parseCSSBorderWidth parses a border width in pixels, resolving thin, medium
+// and thick to 1, 3 and 5px.
+func parseCSSBorderWidth(value string) float64 {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "thin":
+		return 1
+	case "medium":
+		return 3
+	case "thick":
+		return 5
+	}
+	return parseCSSPixels(value)
+}
+
+// cssFontSizeKeywords are the absolute and relative font-size keywords.
+var cssFontSizeKeywords = []string{
+	"xx-small", "x-small", "small", "medium", "large", "x-large", "xx-large", "xxx-large",
+	"larger", "smaller",
+}
+
+// cssFontSizeScaleStep is the ratio larger and smaller scale the parent
+// font size by.
+const cssFontSizeScaleStep = 1.2
+
+// parseCSSFontSize parses a font-size into pixels, or for sizes relative to
+// the parent (larger, smaller, em and %) into a scale of the inherited size.
+func parseCSSFontSize(value string) (px, scale float64) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	switch value {
+	case "xx-small":
+		return 9, 0
+	case "x-small":
+		return 10, 0
+	case "small":
+		return 13, 0
+	case "medium":
+		return 16, 0
+	case "large":
+		return 18, 0
+	case "x-large":
+		return 24, 0
+	case "xx-large":
+		return 32, 0
+	case "xxx-large":
+		return 48, 0
+	case "larger":
+		return 0, cssFontSizeScaleStep
+	case "smaller":
+		return 0, 1 / cssFontSizeScaleStep
+	}
+	if strings.HasSuffix(value, "%") {
+		return 0, parseFilterAmount(value)
+	}
+	if strings.HasSuffix(value, "em") && !strings.HasSuffix(value, "rem") {
+		f, _ := strconv.ParseFloat(strings.TrimSuffix(value, "em"), 64)
+		return 0, f
+	}
+	return parseCSSPixels(value), 0
+}
+
+// cssBorderSideFields returns the width, width-set flag, color string and line
+// style fields of one border side ("top", "right", "bottom" or "left").
+func cssBorderSideFields(style *Style, side string) (*float64, *bool, *string, *string) {
+	switch side {
+	case "top":
+		return &style.BorderTopWidth, &style.BorderTopWidthSet, &style.BorderTop, &style.BorderTopStyle
+	case "right":
+		return &style.BorderRightWidth, &style.BorderRightWidthSet, &style.BorderRight, &style.BorderRightStyle
+	case "bottom":
+		return &style.BorderBottomWidth, &style.BorderBottomWidthSet, &style.BorderBottom, &style.BorderBottomStyle
+	default:
+		return &style.BorderLeftWidth, &style.BorderLeftWidthSet, &style.BorderLeft, &style.BorderLeftStyle
+	}
+}
+
+// applyCSSBorderStyleDeclaration expands border-style with the usual one to
+// four value box shorthand. A single value sets BorderStyle for every side.
+func applyCSSBorderStyleDeclaration(style *Style, value string) {
+	values := strings.Fields(strings.ToLower(value))
+	switch len(values) {
+	case 0:
+		return
+	case 1:
+		style.BorderStyle = values[0]
+		style.BorderTopStyle, style.BorderRightStyle, style.BorderBottomStyle, style.BorderLeftStyle = "", "", "", ""
+		return
+	}
+	top, right, bottom, left := values[0], values[1], values[0], values[1]
+	if len(values) >= 3 {
+		bottom = values[2]
+	}
+	if len(values) >= 4 {
+		left = values[3]
+	}
+	style.BorderTopStyle, style.BorderRightStyle, style.BorderBottomStyle, style.BorderLeftStyle = top, right, bottom, left
+}
+
+// applyCSSBorderSideDeclaration applies a border-top/right/bottom/left
+// shorthand: "width style color" in any order.
+func applyCSSBorderSideDeclaration(style *Style, side, value string) {
+	width, set, clr, lineStyle := cssBorderSideFields(style, side)
+	for _, part := range splitCSSComponents(value) {
+		switch {
+		case isBorderLineStyle(part):
+			*lineStyle = strings.ToLower(part)
+		case strings.HasSuffix(part, "px") || isCSSNumeric(part) || isBorderWidthKeyword(part):
+			*width = parseCSSBorderWidth(part)
+			*set = true
+		default:
+			*clr = part
+		}
+	}
+}
+
 func isCSSNumeric(value string) bool {
 	_, err := strconv.ParseFloat(value, 64)
 	return err == nil
@@ -492,52 +835,13 @@ func isNamedColor(value string) bool {
 	}
 }
 
-func parseCSSKeyframeBody(name, body string) (map[string]KeyframeStyle, error) {
-	frames := make(map[string]KeyframeStyle)
-	remaining := strings.TrimSpace(body)
-	for remaining != "" {
-		open := strings.Index(remaining, "{")
-		if open < 0 {
-			if strings.TrimSpace(remaining) == "" {
-				break
-			}
-			return nil, fmt.Errorf("failed to parse @keyframes %q: malformed selector", name)
-		}
-		selector := strings.TrimSpace(remaining[:open])
-		if selector == "" {
-			return nil, fmt.Errorf("failed to parse @keyframes %q: empty selector", name)
-		}
-		block, rest, ok := consumeCSSBlock(remaining[open:])
-		if !ok {
-			return nil, fmt.Errorf("failed to parse @keyframes %q: unclosed keyframe block", name)
-		}
-		for _, part := range strings.Split(selector, ",") {
-			label := strings.TrimSpace(part)
-			if _, ok := parseKeyframePercent(label); !ok {
-				return nil, fmt.Errorf("failed to parse @keyframes %q: invalid selector %q", name, label)
-			}
-			frames[label] = keyframeStyleFromCSSDeclarations(block)
-		}
-		remaining = strings.TrimSpace(rest)
-	}
-	if len(frames) == 0 {
-		return nil, fmt.Errorf("failed to parse @keyframes %q: no frames", name)
-	}
-	return frames, nil
-}
-
-func keyframeStyleFromCSSDeclarations(block string) KeyframeStyle {
+func (c *cssCompiler) keyframeStyleFromDeclarations(declarations []cssDeclaration) KeyframeStyle {
 	var frame KeyframeStyle
-	for _, declaration := range splitCSSDeclarations(block) {
-		name, value, ok := strings.Cut(declaration, ":")
-		if !ok {
-			continue
-		}
-		prop := strings.ToLower(strings.TrimSpace(name))
-		text, _ := parseCSSImportantValue(value)
-		switch prop {
+	for _, decl := range declarations {
+		text := decl.Value
+		switch decl.Name {
 		case "opacity":
-			frame.Opacity, _ = strconv.ParseFloat(text, 64)
+			frame.Opacity = parseFilterAmount(text)
 		case "transform":
 			frame.Transform = text
 		case "width":
@@ -552,142 +856,11 @@ func keyframeStyleFromCSSDeclarations(block string) KeyframeStyle {
 			frame.BoxShadowBlur = parseCSSPixels(text)
 		case "box-shadow-spread":
 			frame.BoxShadowSpread = parseCSSPixels(text)
-		}
-	}
-	return frame
-}
-
-func splitCSSDeclarations(block string) []string {
-	return splitCSSListLike(block, ';')
-}
-
-func splitCSSSelectorList(selectorText string) []string {
-	return splitCSSListLike(selectorText, ',')
-}
-
-func splitCSSListLike(s string, separator rune) []string {
-	parts := make([]string, 0)
-	var current strings.Builder
-	depth := 0
-	var quote rune
-	escaped := false
-	for _, r := range s {
-		if quote != 0 {
-			current.WriteRune(r)
-			if escaped {
-				escaped = false
-				continue
-			}
-			if r == '\\' {
-				escaped = true
-				continue
-			}
-			if r == quote {
-				quote = 0
-			}
-			continue
-		}
-		switch r {
-		case '\'', '"':
-			quote = r
-			current.WriteRune(r)
-		case '(':
-			depth++
-			current.WriteRune(r)
-		case ')':
-			if depth > 0 {
-				depth--
-			}
-			current.WriteRune(r)
 		default:
-			if r == separator && depth == 0 {
-				if part := strings.TrimSpace(current.String()); part != "" {
-					parts = append(parts, part)
-				}
-				current.Reset()
-				continue
-			}
-			current.WriteRune(r)
-		}
-	}
-	if part := strings.TrimSpace(current.String()); part != "" {
-		parts = append(parts, part)
-	}
-	return parts
-}
-
-func parseCSSImportantValue(value string) (string, bool) {
-	value = strings.TrimSpace(value)
-	lower := strings.ToLower(value)
-	if !strings.HasSuffix(lower, "!important") {
-		return value, false
-	}
-	before := strings.TrimSpace(value[:len(value)-len("!important")])
-	return before, true
-}
-
-func stripCSSComments(css string) string {
-	for {
-		start := strings.Index(css, "/*")
-		if start < 0 {
-			return css
-		}
-		end := strings.Index(css[start+2:], "*/")
-		if end < 0 {
-			return css[:start]
+			c.report(CSSWarning, decl.Line, decl.Column, "property %q is not animatable in @keyframes", decl.Name)
 		}
-		css = css[:start] + css[start+2+end+2:]
 	}
-}
-
-func stripCSSAtKeyframes(css string) string {
-	var out strings.Builder
-	remaining := css
-	for {
-		idx := strings.Index(remaining, "@keyframes")
-		if idx < 0 {
-			out.WriteString(remaining)
-			return out.String()
-		}
-		out.WriteString(remaining[:idx])
-		after := strings.TrimSpace(remaining[idx+len("@keyframes"):])
-		nameEnd := strings.IndexAny(after, " \t\r\n{")
-		if nameEnd < 0 {
-			return out.String()
-		}
-		after = strings.TrimSpace(after[nameEnd:])
-		if !strings.HasPrefix(after, "{") {
-			return out.String()
-		}
-		_, rest, ok := consumeCSSBlock(after)
-		if !ok {
-			return out.String()
-		}
-		remaining = rest
-	}
-}
-
-func consumeCSSBlock(s string) (body, rest string, ok bool) {
-	s = strings.TrimSpace(s)
-	if !strings.HasPrefix(s, "{") {
-		return "", s, false
-	}
-	depth := 0
-	for i, r := range s {
-		switch r {
-		case '{':
-			depth++
-			if depth == 1 {
-				continue
-			}
-		case '}':
-			depth--
-			if depth == 0 {
-				return s[1:i], s[i+1:], true
-			}
-		}
-	}
-	return "", s, false
+	return frame
 }
 
 func animationFromKeyframeStyles(name string, frames map[string]KeyframeStyle) *Animation {
@@ -976,6 +1149,15 @@ func (se *StyleEngine) detectExplicitFields(style *Style, rawJSON json.RawMessag
 	if focusRaw, ok := rawFields["focus"]; ok && style.FocusStyle != nil {
 		se.detectExplicitFields(style.FocusStyle, focusRaw)
 	}
+	if beforeRaw, ok := rawFields["before"]; ok && style.BeforeStyle != nil {
+		se.detectExplicitFields(style.BeforeStyle, beforeRaw)
+	}
+	if afterRaw, ok := rawFields["after"]; ok && style.AfterStyle != nil {
+		se.detectExplicitFields(style.AfterStyle, afterRaw)
+	}
+	if markerRaw, ok := rawFields["marker"]; ok && style.MarkerStyle != nil {
+		se.detectExplicitFields(style.MarkerStyle, markerRaw)
+	}
 }
 
 // parseStyleColors recursively parses color strings in a style
@@ -987,10 +1169,8 @@ func (se *StyleEngine) parseStyleColors(style *Style) {
 	// Parse main colors
 	if style.Background != "" {
 		// Check if it's a gradient
-		if strings.HasPrefix(style.Background, "linear-gradient") {
+		if isCSSGradient(style.Background) {
 			style.parsedGradient = ParseGradient(style.Background)
-		} else if strings.HasPrefix(style.Background, "radial-gradient") {
-			style.parsedGradient = parseRadialGradientCSS(style.Background)
 		} else {
 			style.BackgroundColor = parseColor(style.Background)
 		}
@@ -1046,6 +1226,19 @@ func (se *StyleEngine) parseStyleColors(style *Style) {
 		style.parsedBackdropFilter = ParseBackdropFilter(style.BackdropFilter)
 	}
 
+	// Parse background-shader
+	if style.BackgroundShader != "" {
+		style.parsedBackgroundShader = ParseBackgroundShader(style.BackgroundShader)
+	}
+
+	// Parse ripple and skeleton
+	if style.Ripple != "" {
+		style.parsedRipple = parseRipple(style.Ripple)
+	}
+	if style.Skeleton != "" {
+		style.parsedSkeleton = parseSkeleton(style.Skeleton)
+	}
+
 	// Parse state styles
 	if style.HoverStyle != nil {
 		se.parseStyleColors(style.HoverStyle)
@@ -1059,6 +1252,17 @@ func (se *StyleEngine) parseStyleColors(style *Style) {
 	if style.FocusStyle != nil {
 		se.parseStyleColors(style.FocusStyle)
 	}
+
+	// Parse pseudo-element styles
+	if style.BeforeStyle != nil {
+		se.parseStyleColors(style.BeforeStyle)
+	}
+	if style.AfterStyle != nil {
+		se.parseStyleColors(style.AfterStyle)
+	}
+	if style.MarkerStyle != nil {
+		se.parseStyleColors(style.MarkerStyle)
+	}
 }
 
 // LoadFromString loads styles from a JSON string
@@ -1102,7 +1306,49 @@ func (se *StyleEngine) addStyle(selector string, style *Style, important bool) {
 	})
 }
 
+// addConditionalStyle records a rule guarded by @media or @container
+// conditions. Conditional rules are only reachable through the ordered rule
+// pass so they can be re-evaluated when the viewport or containers resize.
+func (se *StyleEngine) addConditionalStyle(selector string, style *Style, important bool, conditions []cssRuleCondition) {
+	se.parseStyleColors(style)
+	specificity := complexSelectorSpecificity(selector)
+	selector, style = styleForTerminalPseudoSelector(selector, style)
+	se.rules = append(se.rules, styleRuleRecord{
+		Selector:    selector,
+		Style:       style.Clone(),
+		Specificity: specificity,
+		Order:       len(se.rules),
+		Important:   important,
+		Conditions:  conditions,
+	})
+}
+
+// hasConditionalRules reports whether any loaded rule depends on @media or
+// @container conditions.
+func (se *StyleEngine) hasConditionalRules() bool {
+	for _, rule := range se.rules {
+		if len(rule.Conditions) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
 func styleForTerminalPseudoSelector(selector string, style *Style) (string, *Style) {
+	if base, element, ok := splitTerminalPseudoElement(selector); ok {
+		elementStyle := &Style{}
+		switch element {
+		case "before":
+			elementStyle.BeforeStyle = style.Clone()
+		case "after":
+			elementStyle.AfterStyle = style.Clone()
+		case "marker":
+			elementStyle.MarkerStyle = style.Clone()
+		}
+		// A state before the pseudo-element (button:hover::after) nests the
+		// pseudo-element style inside the state style.
+		return styleForTerminalPseudoSelector(base, elementStyle)
+	}
 	base, pseudo, ok := splitTerminalStatePseudo(selector)
 	if !ok {
 		return selector, style
@@ -1720,7 +1966,8 @@ func parseColorValue(s string) uint8 {
 
 // ParseFilter parses a CSS filter string.
 // Supported functions: blur(), brightness(), contrast(), grayscale(), sepia(),
-// saturate(), hue-rotate(), invert()
+// saturate(), hue-rotate(), invert() and shader(name, uniform=value, ...)
+// for shaders registered with UI.RegisterShader.
 // Example: "blur(5px) brightness(1.2) contrast(0.8)"
 func ParseFilter(s string) *Filter {
 	s = strings.TrimSpace(s)
@@ -1743,7 +1990,7 @@ func ParseFilter(s string) *Filter {
 		}
 		funcName := strings.TrimSpace(remaining[:parenIdx])
 
-		closeIdx := strings.Index(remaining, ")")
+		closeIdx := closingParen(remaining, parenIdx)
 		if closeIdx < 0 {
 			break
 		}
@@ -1767,14 +2014,38 @@ func ParseFilter(s string) *Filter {
 			f.HueRotate = parseFilterAngle(arg)
 		case "invert":
 			f.Invert = parseFilterAmount(arg)
+		case "shader":
+			if effect := parseShaderArgs(arg); effect != nil {
+				f.Shaders = append(f.Shaders, *effect)
+			}
 		}
 	}
 
 	return f
 }
 
-// ParseBackdropFilter parses a CSS backdrop-filter string.
-// Currently supports: blur(Npx), brightness(), saturate()
+// closingParen returns the index of the parenthesis closing the one at
+// open, or -1 when it is unbalanced.
+func closingParen(s string, open int) int {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// ParseBackdropFilter parses a CSS backdrop-filter string into its filter
+// functions, kept in declaration order: blur(), brightness(), contrast(),
+// saturate(), grayscale(), sepia(), hue-rotate() and invert(). The Blur,
+// Brightness and Saturate fields hold the last value of those functions.
 func ParseBackdropFilter(s string) *BackdropFilter {
 	s = strings.TrimSpace(s)
 	if s == "" || s == "none" {
@@ -1794,23 +2065,37 @@ func ParseBackdropFilter(s string) *BackdropFilter {
 		if parenIdx < 0 {
 			break
 		}
-		funcName := strings.TrimSpace(remaining[:parenIdx])
+		funcName := strings.ToLower(strings.TrimSpace(remaining[:parenIdx]))
 
-		closeIdx := strings.Index(remaining, ")")
+		closeIdx := closingParen(remaining, parenIdx)
 		if closeIdx < 0 {
 			break
 		}
 		arg := strings.TrimSpace(remaining[parenIdx+1 : closeIdx])
 		remaining = remaining[closeIdx+1:]
 
-		switch strings.ToLower(funcName) {
+		step := FilterStep{Name: funcName}
+		switch funcName {
 		case "blur":
-			bf.Blur = parsePixelValue(arg)
-		case "brightness":
-			bf.Brightness = parseFilterAmount(arg)
-		case "saturate":
-			bf.Saturate = parseFilterAmount(arg)
+			step.Amount = parsePixelValue(arg)
+			bf.Blur = step.Amount
+		case "hue-rotate":
+			step.Amount = parseFilterAngle(arg)
+		case "brightness", "contrast", "saturate", "grayscale", "sepia", "invert":
+			step.Amount = 1
+			if arg != "" {
+				step.Amount = parseFilterAmount(arg)
+			}
+			switch funcName {
+			case "brightness":
+				bf.Brightness = step.Amount
+			case "saturate":
+				bf.Saturate = step.Amount
+			}
+		default:
+			continue
 		}
+		bf.Steps = append(bf.Steps, step)
 	}
 
 	return bf
@@ -1829,94 +2114,36 @@ func parseFilterAmount(s string) float64 {
 	return f
 }
 
-// parseFilterAngle parses a filter angle argument.
-// Accepts: "90deg", "1.57rad"
+// parseFilterAngle parses a filter angle argument in degrees.
+// Accepts: "90deg", "1.57rad", "100grad", "0.25turn", "0"
 func parseFilterAngle(s string) float64 {
-	s = strings.TrimSpace(s)
-	if strings.HasSuffix(s, "rad") {
-		s = strings.TrimSuffix(s, "rad")
-		f, _ := strconv.ParseFloat(s, 64)
-		return f
-	}
-	s = strings.TrimSuffix(s, "deg")
-	f, _ := strconv.ParseFloat(s, 64)
-	return f // degrees (will be converted to radians by the shader caller)
-}
-
-// ============================================================================
-// Radial Gradient CSS Parsing
-// ============================================================================
-
-// parseRadialGradientCSS parses a CSS radial-gradient string.
-// Example: "radial-gradient(circle, #ff0000, #0000ff)"
-// Example: "radial-gradient(ellipse, red 0%, blue 100%)"
-func parseRadialGradientCSS(s string) *Gradient {
-	s = strings.TrimSpace(s)
-	s = strings.TrimPrefix(s, "radial-gradient(")
-	s = strings.TrimSuffix(s, ")")
-
-	parts := strings.Split(s, ",")
-	if len(parts) < 2 {
-		return nil
-	}
-
-	g := &Gradient{
-		Type:       GradientRadial,
-		ColorStops: make([]ColorStop, 0),
-	}
-
-	startIdx := 0
-	first := strings.TrimSpace(parts[0])
-	firstLower := strings.ToLower(first)
-
-	// Check if first part is shape keyword (circle, ellipse, etc.)
-	if firstLower == "circle" || firstLower == "ellipse" ||
-		strings.HasPrefix(firstLower, "circle ") || strings.HasPrefix(firstLower, "ellipse ") ||
-		strings.Contains(firstLower, "at ") {
-		startIdx = 1
-	}
-
-	// Parse color stops
-	numColors := len(parts) - startIdx
-	if numColors < 2 {
-		return nil
-	}
-
-	for i := startIdx; i < len(parts); i++ {
-		colorStr := strings.TrimSpace(parts[i])
-		// Check for explicit position like "red 50%"
-		colorAndPos := strings.Fields(colorStr)
-		var clr color.Color
-		stopPos := float64(i-startIdx) / float64(numColors-1)
-
-		if len(colorAndPos) >= 2 {
-			// Last field might be a percentage
-			lastField := colorAndPos[len(colorAndPos)-1]
-			if strings.HasSuffix(lastField, "%") {
-				pct := strings.TrimSuffix(lastField, "%")
-				f, err := strconv.ParseFloat(pct, 64)
-				if err == nil {
-					stopPos = f / 100
-				}
-				clr = parseColor(strings.Join(colorAndPos[:len(colorAndPos)-1], " "))
-			} else {
-				clr = parseColor(colorStr)
+	deg, _ := parseGradientAngle(strings.TrimSpace(s))
+	return deg // degrees (will be converted to radians by the shader caller)
+}
+
+// applyTextDecorationShorthand splits text-decoration into its line
+// keywords, color and thickness. Line styles other than solid are accepted
+// but drawn solid.
+func applyTextDecorationShorthand(style *Style, value string) {
+	var lines []string
+	style.TextDecoration = "none"
+	style.TextDecorationColor = ""
+	style.TextDecorationThickness = 0
+	for _, component := range splitCSSComponents(value) {
+		lower := strings.ToLower(component)
+		switch lower {
+		case "underline", "overline", "line-through":
+			lines = append(lines, lower)
+		case "none", "solid", "double", "dotted", "dashed", "wavy", "auto", "from-font":
+		default:
+			if lower[0] >= '0' && lower[0] <= '9' || lower[0] == '.' {
+				style.TextDecorationThickness = parseCSSPixels(lower)
+			} else if parseColor(lower) != nil {
+				style.TextDecorationColor = component
 			}
-		} else {
-			clr = parseColor(colorStr)
-		}
-
-		if clr != nil {
-			g.ColorStops = append(g.ColorStops, ColorStop{
-				Color:    clr,
-				Position: stopPos,
-			})
 		}
 	}
-
-	if len(g.ColorStops) < 2 {
-		return nil
+	if len(lines) > 0 {
+		style.TextDecoration = strings.Join(lines, " ")
 	}
-
-	return g
 }
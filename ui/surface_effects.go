@@ -0,0 +1,187 @@
+package ui
+
+import (
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// ============================================================================
+// Declarative surface effects — ripple and skeleton shimmer
+// ============================================================================
+
+// rippleSpec is a parsed ripple declaration.
+type rippleSpec struct {
+	color    color.Color
+	duration time.Duration
+}
+
+// skeletonSpec is a parsed skeleton declaration. The highlight band is a
+// horizontal gradient swept across the box once per duration.
+type skeletonSpec struct {
+	duration time.Duration
+	band     *Gradient
+}
+
+const (
+	defaultRippleDuration    = 600 * time.Millisecond
+	defaultShimmerDuration   = 1500 * time.Millisecond
+	defaultShimmerHighlight  = "rgba(255, 255, 255, 0.35)"
+	shimmerBandWidthFraction = 0.6
+)
+
+// parseRipple parses a ripple value: none or "<color> [<duration>]".
+func parseRipple(value string) *rippleSpec {
+	value = strings.TrimSpace(value)
+	if value == "" || strings.EqualFold(value, "none") {
+		return nil
+	}
+	spec := &rippleSpec{duration: defaultRippleDuration}
+	for _, part := range splitCSSComponents(value) {
+		if d, ok := parseEffectDuration(part); ok {
+			spec.duration = d
+		} else if c := parseColor(part); c != nil {
+			spec.color = c
+		}
+	}
+	if spec.color == nil {
+		return nil
+	}
+	return spec
+}
+
+// parseSkeleton parses a skeleton value: none or
+// "shimmer [<highlight color>] [<duration>]".
+func parseSkeleton(value string) *skeletonSpec {
+	parts := splitCSSComponents(value)
+	if len(parts) == 0 || !strings.EqualFold(parts[0], "shimmer") {
+		return nil
+	}
+	spec := &skeletonSpec{duration: defaultShimmerDuration}
+	highlight := defaultShimmerHighlight
+	for _, part := range parts[1:] {
+		if d, ok := parseEffectDuration(part); ok {
+			spec.duration = d
+		} else if parseColor(part) != nil {
+			highlight = part
+		}
+	}
+	spec.band = ParseGradient("linear-gradient(to right, transparent, " + highlight + ", transparent)")
+	if spec.band == nil {
+		return nil
+	}
+	return spec
+}
+
+// parseEffectDuration accepts a positive CSS time such as 400ms or 1.2s.
+func parseEffectDuration(s string) (time.Duration, bool) {
+	s = strings.ToLower(s)
+	if !strings.HasSuffix(s, "s") {
+		return 0, false
+	}
+	number := strings.TrimSuffix(strings.TrimSuffix(s, "s"), "m")
+	if _, err := strconv.ParseFloat(number, 64); err != nil {
+		return 0, false
+	}
+	seconds := parseDuration(s)
+	if seconds <= 0 {
+		return 0, false
+	}
+	return time.Duration(seconds * float64(time.Second)), true
+}
+
+// startRipple starts a ripple on the pressed widget or the nearest ancestor
+// whose style declares one, centred on the press point. The point is given in
+// screen coordinates and mapped into that widget's layout space.
+func (ui *UI) startRipple(pressed Widget, x, y float64) {
+	for current := pressed; current != nil; current = current.Parent() {
+		bw := baseWidgetOf(current)
+		if bw == nil || !bw.Enabled() {
+			continue
+		}
+		style := bw.renderStyle(bw.getActiveStyle())
+		if style.parsedRipple == nil {
+			continue
+		}
+		if lx, ly, ok := layoutPoint(current, x, y); ok {
+			bw.StartRipple(lx, ly)
+		}
+		return
+	}
+}
+
+// StartRipple starts the widget's CSS ripple at a point in layout
+// coordinates, growing to cover the farthest corner of the box. It does
+// nothing when the widget's style declares no ripple.
+func (w *BaseWidget) StartRipple(x, y float64) {
+	spec := w.renderStyle(w.getActiveStyle()).parsedRipple
+	if spec == nil {
+		return
+	}
+	r := w.computedRect
+	localX, localY := x-r.X, y-r.Y
+	maxRadius := math.Max(
+		math.Max(math.Hypot(localX, localY), math.Hypot(r.W-localX, localY)),
+		math.Max(math.Hypot(localX, r.H-localY), math.Hypot(r.W-localX, r.H-localY)),
+	)
+	ripple := NewRippleEffect(localX, localY, maxRadius, spec.color)
+	ripple.Duration = spec.duration
+	w.ripples = append(w.ripples, ripple)
+}
+
+// Ripples returns the widget's running ripples, positioned relative to its
+// top-left corner.
+func (w *BaseWidget) Ripples() []*RippleEffect {
+	return w.ripples
+}
+
+// drawSurfaceEffects draws the skeleton shimmer and running ripples over
+// the background, clipped to the rounded border box. Finished ripples are
+// dropped.
+func (w *BaseWidget) drawSurfaceEffects(screen *ebiten.Image, r Rect, style *Style) {
+	skeleton := style.parsedSkeleton
+	if skeleton == nil {
+		w.shimmer = nil
+	} else if w.shimmer == nil || w.shimmer.Duration != skeleton.duration {
+		w.shimmer = NewShimmerEffect()
+		w.shimmer.Duration = skeleton.duration
+	}
+	if skeleton == nil && len(w.ripples) == 0 {
+		return
+	}
+	iw, ih := int(math.Ceil(r.W)), int(math.Ceil(r.H))
+	if iw <= 0 || ih <= 0 {
+		return
+	}
+
+	localRect := Rect{W: r.W, H: r.H}
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(r.X, r.Y)
+	clipComposite(screen, iw, ih, func(content *ebiten.Image) {
+		if skeleton != nil {
+			band := r.W * shimmerBandWidthFraction
+			x := -band + (r.W+band)*w.shimmer.GetOffset()
+			drawGradientFill(content, Rect{X: x, W: band, H: r.H}, skeleton.band)
+		}
+		active := w.ripples[:0]
+		for _, ripple := range w.ripples {
+			radius, alpha := ripple.Update()
+			if !ripple.IsActive {
+				continue
+			}
+			if radius > 0 {
+				vector.DrawFilledCircle(content, float32(ripple.X), float32(ripple.Y), float32(radius), applyOpacity(ripple.Color, alpha), true)
+			}
+			active = append(active, ripple)
+		}
+		w.ripples = active
+	}, func(mask *ebiten.Image) {
+		radTL, radTR, radBR, radBL := w.getCornerRadii(style)
+		DrawRoundedRectPathEx(mask, localRect, radTL, radTR, radBR, radBL, color.White)
+	}, op)
+}
@@ -0,0 +1,90 @@
+package ui
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+func TestCSSRippleAndSkeleton(t *testing.T) {
+	ui := New(320, 200)
+	if err := ui.LoadCSS(`
+		#root { flex-direction: column; }
+		#cta { width: 120px; height: 40px; border-radius: 8px; ripple: rgba(255, 255, 255, 0.3) 400ms; }
+		#card { width: 200px; height: 80px; ripple: #000; }
+		#label { width: 50px; height: 20px; }
+		#placeholder { width: 160px; height: 16px; border-radius: 4px; background: #ccc; skeleton: shimmer #fff 1.2s; }
+		#plain { width: 10px; height: 10px; skeleton: none; ripple: none; }
+	`); err != nil {
+		t.Fatalf("LoadCSS: %v", err)
+	}
+	if err := ui.LoadLayout(`<panel id="root"><button id="cta">Go</button><panel id="card"><panel id="label"/></panel><panel id="placeholder"/><panel id="plain"/></panel>`); err != nil {
+		t.Fatalf("LoadLayout: %v", err)
+	}
+
+	cta := ui.GetButton("cta")
+	if spec := cta.Style().parsedRipple; spec == nil || spec.duration != 400*time.Millisecond {
+		t.Fatalf("parsed ripple = %+v", spec)
+	}
+	skeleton := ui.GetPanel("placeholder").Style().parsedSkeleton
+	if skeleton == nil || skeleton.duration != 1200*time.Millisecond || skeleton.band == nil {
+		t.Fatalf("parsed skeleton = %+v", skeleton)
+	}
+	if plain := ui.GetPanel("plain").Style(); plain.parsedSkeleton != nil || plain.parsedRipple != nil {
+		t.Fatal("none should disable ripple and skeleton")
+	}
+
+	// A press starts a ripple at the press point that grows to the farthest
+	// corner.
+	r := cta.ComputedRect()
+	ui.SimulatePointerDown(r.X+10, r.Y+10, ebiten.MouseButtonLeft)
+	ui.SimulatePointerUp(r.X+10, r.Y+10, ebiten.MouseButtonLeft)
+	ripples := cta.Ripples()
+	if len(ripples) != 1 || ripples[0].X != 10 || ripples[0].Y != 10 || ripples[0].Duration != 400*time.Millisecond {
+		t.Fatalf("ripples after press = %+v", ripples)
+	}
+	if want := math.Hypot(r.W-10, r.H-10); math.Abs(ripples[0].MaxRadius-want) > 1e-9 {
+		t.Errorf("ripple radius = %v, want the farthest corner %v", ripples[0].MaxRadius, want)
+	}
+
+	// Pressing a child without a ripple ripples its nearest ancestor.
+	label := ui.GetPanel("label")
+	lr := label.ComputedRect()
+	ui.SimulatePointerDown(lr.X+5, lr.Y+5, ebiten.MouseButtonLeft)
+	ui.SimulatePointerUp(lr.X+5, lr.Y+5, ebiten.MouseButtonLeft)
+	if card := ui.GetPanel("card"); len(card.Ripples()) != 1 || len(label.Ripples()) != 0 {
+		t.Errorf("card ripples = %d, label ripples = %d", len(card.Ripples()), len(label.Ripples()))
+	}
+
+	ui.Draw(ebiten.NewImage(320, 200))
+	if ui.GetPanel("placeholder").shimmer == nil {
+		t.Error("drawing a skeleton should start its shimmer clock")
+	}
+}
+
+func TestRippleInScrolledContainerStartsAtPressPoint(t *testing.T) {
+	ui := New(320, 200)
+	if err := ui.LoadCSS(`
+		#list { width: 200px; height: 100px; flex-direction: column; overflow: scroll; }
+		.item { width: 200px; height: 80px; flex-shrink: 0; ripple: #000; }
+	`); err != nil {
+		t.Fatalf("LoadCSS: %v", err)
+	}
+	if err := ui.LoadLayout(`<panel id="root"><panel id="list"><panel id="a" class="item"/><panel id="b" class="item"/><panel id="c" class="item"/></panel></panel>`); err != nil {
+		t.Fatalf("LoadLayout: %v", err)
+	}
+	list, b := ui.GetPanel("list"), ui.GetPanel("b")
+	list.SetScrollOffset(0, 100)
+	if _, y := list.ScrollOffset(); y != 100 {
+		t.Fatalf("scroll offset = %v, want 100", y)
+	}
+	// #b is laid out 80px below the list top and drawn 100px higher.
+	lr, br := list.ComputedRect(), b.ComputedRect()
+	ui.SimulatePointerDown(br.X+10, lr.Y+30, ebiten.MouseButtonLeft)
+	ripples := b.Ripples()
+	if len(ripples) != 1 || ripples[0].X != 10 || ripples[0].Y != 50 {
+		t.Fatalf("ripples on #b = %+v, want one at 10,50", ripples)
+	}
+}
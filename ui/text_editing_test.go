@@ -0,0 +1,235 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+func TestTextInputWordNavigationAndDeletion(t *testing.T) {
+	ti := NewTextInput("input")
+	ti.SetText("hello, wide  world")
+	ti.CursorPos = 18
+
+	simulateTextInputKeyPress(ti, ebiten.KeyLeft, false, true)
+	if ti.CursorPos != 13 {
+		t.Fatalf("Ctrl+Left moved to %d, want the start of %q at 13", ti.CursorPos, "world")
+	}
+	simulateTextInputKeyPress(ti, ebiten.KeyLeft, true, true)
+	if ti.CursorPos != 7 || ti.SelectStart != 13 || ti.SelectEnd != 7 {
+		t.Fatalf("Ctrl+Shift+Left: CursorPos = %d, selection = [%d:%d]", ti.CursorPos, ti.SelectStart, ti.SelectEnd)
+	}
+	simulateTextInputKeyPress(ti, ebiten.KeyBackspace, false, true)
+	if ti.Text != "hello, world" || ti.CursorPos != 7 {
+		t.Fatalf("Ctrl+Backspace with a selection left %q at %d", ti.Text, ti.CursorPos)
+	}
+	simulateTextInputKeyPress(ti, ebiten.KeyBackspace, false, true)
+	if ti.Text != "world" || ti.CursorPos != 0 {
+		t.Fatalf("Ctrl+Backspace left %q at %d, want the previous word and its punctuation gone", ti.Text, ti.CursorPos)
+	}
+	simulateTextInputKeyPress(ti, ebiten.KeyDelete, false, true)
+	if ti.Text != "" {
+		t.Fatalf("Ctrl+Delete left %q", ti.Text)
+	}
+	if !ti.Undo() || ti.Text != "world" {
+		t.Fatalf("Undo() after Ctrl+Delete left %q", ti.Text)
+	}
+
+	simulateTextInputKeyPress(ti, ebiten.KeyEnd, false, false)
+	simulateTextInputKeyPress(ti, ebiten.KeyHome, true, false)
+	if ti.CursorPos != 0 || ti.SelectStart != 5 || ti.SelectEnd != 0 {
+		t.Fatalf("Shift+Home: CursorPos = %d, selection = [%d:%d]", ti.CursorPos, ti.SelectStart, ti.SelectEnd)
+	}
+
+	ti.Password = true
+	ti.SetText("open sesame")
+	ti.CursorPos = 11
+	simulateTextInputKeyPress(ti, ebiten.KeyLeft, false, true)
+	if ti.CursorPos != 0 {
+		t.Fatalf("Ctrl+Left in a password input moved to %d, want 0", ti.CursorPos)
+	}
+}
+
+func TestTextAreaLineEdgesAndWordJumps(t *testing.T) {
+	ta := NewTextArea("area")
+	ta.SetText("one two\nthree four")
+	ta.CursorPos = 10
+	ta.updateCursorLineCol()
+
+	simulateTextAreaKeyPress(ta, ebiten.KeyHome, false, false)
+	if ta.CursorPos != 8 {
+		t.Fatalf("Home moved to %d, want the line start 8", ta.CursorPos)
+	}
+	simulateTextAreaKeyPress(ta, ebiten.KeyEnd, true, false)
+	if ta.SelectStart != 8 || ta.SelectEnd != 18 || ta.CursorLine != 1 {
+		t.Fatalf("Shift+End: selection = [%d:%d], CursorLine = %d", ta.SelectStart, ta.SelectEnd, ta.CursorLine)
+	}
+	simulateTextAreaKeyPress(ta, ebiten.KeyHome, false, true)
+	if ta.CursorPos != 0 || ta.SelectStart != ta.SelectEnd {
+		t.Fatalf("Ctrl+Home: CursorPos = %d, selection = [%d:%d]", ta.CursorPos, ta.SelectStart, ta.SelectEnd)
+	}
+
+	for _, want := range []int{3, 7, 13} {
+		simulateTextAreaKeyPress(ta, ebiten.KeyRight, false, true)
+		if ta.CursorPos != want {
+			t.Fatalf("Ctrl+Right moved to %d, want %d", ta.CursorPos, want)
+		}
+	}
+	simulateTextAreaKeyPress(ta, ebiten.KeyBackspace, false, true)
+	if ta.Text != "one two\n four" || ta.CursorLine != 1 || ta.CursorCol != 0 {
+		t.Fatalf("Ctrl+Backspace left %q at line %d col %d", ta.Text, ta.CursorLine, ta.CursorCol)
+	}
+	simulateTextAreaKeyPress(ta, ebiten.KeyEnd, true, true)
+	if ta.SelectStart != 8 || ta.SelectEnd != 13 {
+		t.Fatalf("Ctrl+Shift+End: selection = [%d:%d], want [8:13]", ta.SelectStart, ta.SelectEnd)
+	}
+}
+
+func newTextEditingUI(inputWidth, areaHeight float64) *UI {
+	ui := New(420, 240)
+	ui.DefaultFontFace = testTextFace()
+
+	root := NewPanel("root")
+	root.SetStyle(&Style{
+		Direction:  LayoutColumn,
+		Width:      420,
+		Height:     240,
+		Padding:    PaddingAll(10),
+		PaddingSet: true,
+		Gap:        8,
+		GapSet:     true,
+	})
+
+	input := NewTextInput("input")
+	input.SetText("alpha beta gamma")
+	input.SetStyle(&Style{Width: inputWidth, Height: 32})
+
+	area := NewTextArea("area")
+	area.SetText("line one\nline two\nline three\nline four\nline five\nline six")
+	area.SetStyle(&Style{Height: areaHeight})
+
+	root.AddChild(input)
+	root.AddChild(area)
+	ui.SetRoot(root)
+	return ui
+}
+
+// inputPointAt returns the absolute position of the caret before a rune of a
+// TextInput, halfway down the input.
+func inputPointAt(ti *TextInput, runeIndex int) (float64, float64) {
+	style := ti.getActiveStyle()
+	r := ti.ContentRect()
+	layout := ti.displayLayout(style)
+	_, x := layout.CaretPosition(runeIndex)
+	return ti.textOriginX(r, layout.width, style) + x, r.Y + r.H/2
+}
+
+func TestTextInputPointerSelection(t *testing.T) {
+	ui := newTextEditingUI(300, 60)
+	ti := ui.GetTextInput("input")
+
+	x, y := inputPointAt(ti, 6)
+	ui.SimulateClick(x, y)
+	if ti.CursorPos != 6 || ti.SelectStart != ti.SelectEnd {
+		t.Fatalf("click: CursorPos = %d, selection = [%d:%d]", ti.CursorPos, ti.SelectStart, ti.SelectEnd)
+	}
+	x, y = inputPointAt(ti, 10)
+	ui.SimulateShiftClick(x, y)
+	if ti.SelectStart != 6 || ti.SelectEnd != 10 {
+		t.Fatalf("Shift+click selected [%d:%d], want [6:10]", ti.SelectStart, ti.SelectEnd)
+	}
+
+	x, y = inputPointAt(ti, 12)
+	ui.SimulateClick(x+1, y)
+	ui.SimulateClick(x+1, y)
+	if ti.SelectStart != 11 || ti.SelectEnd != 16 {
+		t.Fatalf("double click selected [%d:%d], want %q at [11:16]", ti.SelectStart, ti.SelectEnd, "gamma")
+	}
+	ui.SimulateClick(x+1, y)
+	if ti.SelectStart != 0 || ti.SelectEnd != 16 {
+		t.Fatalf("triple click selected [%d:%d], want everything", ti.SelectStart, ti.SelectEnd)
+	}
+
+	startX, startY := inputPointAt(ti, 0)
+	endX, endY := inputPointAt(ti, 5)
+	ui.SimulatePointerDown(startX, startY, ebiten.MouseButtonLeft)
+	ui.SimulatePointerMove(endX, endY)
+	ui.SimulatePointerUp(endX, endY, ebiten.MouseButtonLeft)
+	if ti.SelectStart != 0 || ti.SelectEnd != 5 {
+		t.Fatalf("drag selected [%d:%d], want [0:5]", ti.SelectStart, ti.SelectEnd)
+	}
+	ui.SimulatePointerMove(endX+40, endY)
+	if ti.SelectEnd != 5 {
+		t.Fatal("moving after release should not extend the selection")
+	}
+}
+
+func TestTextInputDragPastEdgeAutoScrolls(t *testing.T) {
+	ui := newTextEditingUI(40, 60)
+	ti := ui.GetTextInput("input")
+	r := ti.ContentRect()
+
+	x, y := inputPointAt(ti, 0)
+	ui.SimulatePointerDown(x, y, ebiten.MouseButtonLeft)
+	ui.SimulatePointerMove(r.X+r.W+20, y)
+	if ti.scrollOffset <= 0 || ti.SelectEnd == 0 || ti.SelectEnd == 16 {
+		t.Fatalf("first drag past the edge: scrollOffset = %v, SelectEnd = %d", ti.scrollOffset, ti.SelectEnd)
+	}
+	for i := 0; i < 40; i++ {
+		ui.SimulatePointerMove(r.X+r.W+20, y)
+	}
+	if ti.SelectEnd != 16 {
+		t.Fatalf("holding the pointer past the edge selected up to %d, want the end", ti.SelectEnd)
+	}
+	ui.SimulatePointerUp(r.X+r.W+20, y, ebiten.MouseButtonLeft)
+}
+
+func TestTextAreaTripleClickAndDragAutoScroll(t *testing.T) {
+	ui := newTextEditingUI(300, 40)
+	ta := ui.GetTextArea("area")
+	r := ta.ContentRect()
+	lineHeight := ta.lineHeight()
+
+	x, y := r.X+2, r.Y+lineHeight*1.5
+	for i := 0; i < 3; i++ {
+		ui.SimulateClick(x, y)
+	}
+	if ta.SelectStart != 9 || ta.SelectEnd != 17 {
+		t.Fatalf("triple click selected [%d:%d], want %q at [9:17]", ta.SelectStart, ta.SelectEnd, "line two")
+	}
+
+	ui.SimulatePointerDown(r.X, r.Y+1, ebiten.MouseButtonLeft)
+	for i := 0; i < 10; i++ {
+		ui.SimulatePointerMove(r.X+r.W, r.Y+r.H+lineHeight)
+	}
+	ui.SimulatePointerUp(r.X+r.W, r.Y+r.H+lineHeight, ebiten.MouseButtonLeft)
+	if ta.CursorLine != 5 || ta.SelectStart != 0 || ta.SelectEnd != len([]rune(ta.Text)) {
+		t.Fatalf("drag below: CursorLine = %d, selection = [%d:%d]", ta.CursorLine, ta.SelectStart, ta.SelectEnd)
+	}
+	if ta.ScrollY <= 0 || ta.ScrollY < ta.MaxScrollY-0.5 {
+		t.Fatalf("ScrollY = %v, want it scrolled to MaxScrollY %v", ta.ScrollY, ta.MaxScrollY)
+	}
+}
+
+func TestTextAreaDrawKeepsScrollAwayFromCaret(t *testing.T) {
+	ui := newTextEditingUI(300, 40)
+	ta := ui.GetTextArea("area")
+	r := ta.ContentRect()
+	ui.SimulateClick(r.X+2, r.Y+2)
+	if ta.CursorLine != 0 || ta.MaxScrollY <= 0 {
+		t.Fatalf("click: CursorLine = %d, MaxScrollY = %v", ta.CursorLine, ta.MaxScrollY)
+	}
+
+	ta.ScrollY = ta.MaxScrollY
+	screen := ebiten.NewImage(420, 240)
+	defer screen.Deallocate()
+	ui.Draw(screen)
+	if ta.ScrollY != ta.MaxScrollY {
+		t.Fatalf("Draw moved ScrollY to %v, want the scrolled position %v kept", ta.ScrollY, ta.MaxScrollY)
+	}
+
+	simulateTextAreaKeyPress(ta, ebiten.KeyRight, false, false)
+	if ta.ScrollY != 0 {
+		t.Fatalf("moving the caret left ScrollY at %v, want it back on the caret's line", ta.ScrollY)
+	}
+}
@@ -2,27 +2,74 @@ package ui
 
 import (
 	"math"
+	"sort"
 	"strings"
 	"unicode"
 	"unicode/utf8"
 
+	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/text/v2"
 	"github.com/rivo/uniseg"
+	"golang.org/x/text/unicode/bidi"
 )
 
 type textWhiteSpaceMode string
 
 const (
 	textWhiteSpaceNormal  textWhiteSpaceMode = "normal"
+	textWhiteSpaceNowrap  textWhiteSpaceMode = "nowrap"
+	textWhiteSpacePre     textWhiteSpaceMode = "pre"
 	textWhiteSpacePreWrap textWhiteSpaceMode = "pre-wrap"
+	textWhiteSpacePreLine textWhiteSpaceMode = "pre-line"
 )
 
+// textWhiteSpaceFromStyle returns the white-space mode of a style, normal
+// when unset or unknown.
+func textWhiteSpaceFromStyle(style *Style) textWhiteSpaceMode {
+	switch mode := textWhiteSpaceMode(style.WhiteSpace); mode {
+	case textWhiteSpaceNowrap, textWhiteSpacePre, textWhiteSpacePreWrap, textWhiteSpacePreLine:
+		return mode
+	}
+	return textWhiteSpaceNormal
+}
+
+// preservesSpaces reports whether the mode keeps spaces and tabs as written.
+func (m textWhiteSpaceMode) preservesSpaces() bool {
+	return m == textWhiteSpacePre || m == textWhiteSpacePreWrap
+}
+
+// wraps reports whether the mode lets lines wrap at the available width.
+func (m textWhiteSpaceMode) wraps() bool {
+	return m != textWhiteSpaceNowrap && m != textWhiteSpacePre
+}
+
 type textLayoutOptions struct {
 	MaxWidth               float64
 	Wrap                   bool
 	WhiteSpace             textWhiteSpaceMode
 	LineHeight             float64
 	TrimTrailingWhitespace bool
+	Direction              bidiDirection
+	Spans                  []TextSpan // rich text runs by rune index of the content
+	TabSize                float64    // tab stop spacing in spaces, 0 leaves tabs unexpanded
+	WordBreak              string     // normal, break-all, keep-all, break-word
+	OverflowWrap           string     // normal keeps long words whole; unset breaks them
+	MaxLines               int        // lines past this are dropped, the last ellipsized
+}
+
+// sameLayout reports whether two sets of options lay text out alike. Spans
+// are not compared; changing them invalidates a widget's layout.
+func (o textLayoutOptions) sameLayout(other textLayoutOptions) bool {
+	return o.MaxWidth == other.MaxWidth &&
+		o.Wrap == other.Wrap &&
+		o.WhiteSpace == other.WhiteSpace &&
+		o.LineHeight == other.LineHeight &&
+		o.TrimTrailingWhitespace == other.TrimTrailingWhitespace &&
+		o.Direction == other.Direction &&
+		o.TabSize == other.TabSize &&
+		o.WordBreak == other.WordBreak &&
+		o.OverflowWrap == other.OverflowWrap &&
+		o.MaxLines == other.MaxLines
 }
 
 type TextHit struct {
@@ -32,6 +79,7 @@ type TextHit struct {
 	RuneStart    int
 	RuneEnd      int
 	Rect         Rect
+	Span         *TextSpan // rich text span under the point, nil for plain text
 }
 
 type textLayoutCluster struct {
@@ -45,6 +93,8 @@ type textLayoutCluster struct {
 	X          float64
 	Width      float64
 	Line       int
+	Level      uint8 // bidi embedding level, odd for right-to-left
+	Span       int   // rich text span index, -1 for plain text
 }
 
 type textLayoutLine struct {
@@ -54,6 +104,23 @@ type textLayoutLine struct {
 	RuneStart    int
 	RuneEnd      int
 	Width        float64
+	Y            float64         // top of the line
+	Height       float64         // grows past the line height for larger rich text
+	RTL          bool            // right-to-left paragraph
+	Runs         []textLayoutRun // visual runs of bidirectional or rich lines, left to right
+}
+
+// textLayoutRun is a single-direction, single-style run of a bidirectional
+// or rich line. Its clusters are in logical order.
+type textLayoutRun struct {
+	Text         string
+	StartCluster int
+	EndCluster   int
+	RTL          bool
+	Span         int // rich text span index, -1 for plain text
+	X            float64
+	Y            float64 // offset of the run's em box from the line's plain em box
+	Width        float64
 }
 
 type textLayout struct {
@@ -64,6 +131,10 @@ type textLayout struct {
 	lines      []textLayoutLine
 	width      float64
 	height     float64
+	bidi       *bidiText // nil for text that needs no reordering
+	direction  bidiDirection
+	spans      []TextSpan // nil for plain text
+	tabWidth   float64    // tab stop spacing, 0 when tabs are not expanded
 }
 
 type graphemeBoundary struct {
@@ -76,8 +147,15 @@ type graphemeBoundary struct {
 
 func newTextLayout(content string, face text.Face, opts textLayoutOptions) *textLayout {
 	layout := &textLayout{
-		text: normalizeLayoutText(content, opts.WhiteSpace),
-		face: face,
+		face:      face,
+		direction: opts.Direction,
+	}
+	if len(opts.Spans) > 0 {
+		var index []int
+		layout.text, index = normalizeLayoutTextIndex(content, opts.WhiteSpace)
+		layout.spans = remapTextSpans(opts.Spans, index)
+	} else {
+		layout.text = normalizeLayoutText(content, opts.WhiteSpace)
 	}
 	if opts.LineHeight > 0 {
 		layout.lineHeight = opts.LineHeight
@@ -88,8 +166,24 @@ func newTextLayout(content string, face text.Face, opts textLayoutOptions) *text
 	if layout.lineHeight <= 0 {
 		layout.lineHeight = measureLineHeight(face)
 	}
+	if opts.TabSize > 0 && strings.Contains(layout.text, "\t") {
+		layout.tabWidth = opts.TabSize * text.Advance(" ", face)
+	}
 	layout.buildClusters()
+	if needsBidi(layout.text, opts.Direction) {
+		layout.bidi = resolveBidi([]rune(layout.text), opts.Direction)
+		for i := range layout.clusters {
+			layout.clusters[i].Level = layout.bidi.levels[layout.clusters[i].RuneStart]
+		}
+	}
 	layout.buildLines(opts)
+	if opts.MaxLines > 0 && len(layout.lines) > opts.MaxLines {
+		maxWidth := opts.MaxWidth
+		if !opts.Wrap || maxWidth <= 0 {
+			maxWidth = math.Inf(1)
+		}
+		layout.clampLines(opts.MaxLines, maxWidth)
+	}
 	return layout
 }
 
@@ -104,20 +198,35 @@ func (tl *textLayout) buildClusters() {
 	runePos := 0
 	var prefix strings.Builder
 	var prevWidth float64
+	span := -1
 
 	for len(rest) > 0 {
 		cluster, next, boundaries, nextState := uniseg.StepString(rest, state)
 		runeCount := utf8.RuneCountInString(cluster)
 
+		// Each span is measured on its own, in its own face.
+		if clusterSpan := tl.spanIndexAt(runePos); clusterSpan != span {
+			span = clusterSpan
+			prefix.Reset()
+			prevWidth = 0
+		}
+
 		advance := 0.0
-		if cluster != "\n" {
+		switch {
+		case cluster == "\n":
+			prefix.Reset()
+			prevWidth = 0
+		case cluster == "\t" && tl.tabWidth > 0:
+			// Tabs advance to the next stop, found once lines are known.
+			prefix.Reset()
+			prevWidth = 0
+		case span >= 0 && tl.spans[span].Icon != "":
+			advance = tl.iconWidth(span)
+		default:
 			prefix.WriteString(cluster)
-			width, _ := text.Measure(prefix.String(), tl.face, 0)
+			width, _ := text.Measure(prefix.String(), tl.spanFace(span), 0)
 			advance = width - prevWidth
 			prevWidth = width
-		} else {
-			prefix.Reset()
-			prevWidth = 0
 		}
 
 		tl.clusters = append(tl.clusters, textLayoutCluster{
@@ -129,6 +238,7 @@ func (tl *textLayout) buildClusters() {
 			BreakAfter: boundaries & uniseg.MaskLine,
 			Advance:    advance,
 			Line:       -1,
+			Span:       span,
 		})
 
 		bytePos += len(cluster)
@@ -148,11 +258,16 @@ func (tl *textLayout) buildLines(opts textLayoutOptions) {
 		maxWidth = math.Inf(1)
 	}
 
+	// overflow-wrap: normal lets a word with no break opportunity overflow
+	// the line instead of breaking it between clusters.
+	keepLongWords := opts.OverflowWrap == "normal" && opts.WordBreak != "break-word"
+
 	lineStart := 0
 	lineRuneStart := 0
 	currentWidth := 0.0
 	lastBreak := -1
 	endedWithHardBreak := false
+	y := 0.0
 
 	emitLine := func(start, end int, runeStart int) {
 		if start < 0 {
@@ -173,31 +288,13 @@ func (tl *textLayout) buildLines(opts textLayoutOptions) {
 			StartCluster: start,
 			EndCluster:   visibleEnd,
 			RuneStart:    runeStart,
-			RuneEnd:      runeStart,
-		}
-
-		if visibleEnd > start {
-			var builder strings.Builder
-			var prefix strings.Builder
-			prevWidth := 0.0
-			for i := start; i < visibleEnd; i++ {
-				cluster := &tl.clusters[i]
-				builder.WriteString(cluster.Text)
-				prefix.WriteString(cluster.Text)
-				width, _ := text.Measure(prefix.String(), tl.face, 0)
-				cluster.X = prevWidth
-				cluster.Width = width - prevWidth
-				cluster.Line = len(tl.lines)
-				prevWidth = width
-			}
-			line.Text = builder.String()
-			line.Width = prevWidth
-			line.RuneEnd = tl.clusters[visibleEnd-1].RuneEnd
+			Y:            y,
 		}
-
+		tl.layoutLine(&line, len(tl.lines))
 		if line.Width > tl.width {
 			tl.width = line.Width
 		}
+		y += line.Height
 		tl.lines = append(tl.lines, line)
 	}
 
@@ -216,11 +313,16 @@ func (tl *textLayout) buildLines(opts textLayoutOptions) {
 		}
 
 		endedWithHardBreak = false
-		nextWidth := currentWidth + cluster.Advance
-		fits := nextWidth <= maxWidth || math.IsInf(maxWidth, 1) || i == lineStart
+		advance := cluster.Advance
+		if cluster.Text == "\t" && tl.tabWidth > 0 {
+			advance = tl.tabAdvance(currentWidth)
+		}
+		nextWidth := currentWidth + advance
+		fits := nextWidth <= maxWidth || math.IsInf(maxWidth, 1) || i == lineStart ||
+			(keepLongWords && lastBreak < lineStart)
 		if fits {
 			currentWidth = nextWidth
-			if cluster.BreakAfter != uniseg.LineDontBreak {
+			if tl.canBreakAfter(i, opts.WordBreak) {
 				lastBreak = i
 			}
 			i++
@@ -252,14 +354,408 @@ func (tl *textLayout) buildLines(opts textLayoutOptions) {
 		emitLine(lineStart, lineStart, lineRuneStart)
 	}
 
-	tl.height = float64(len(tl.lines)) * tl.lineHeight
+	tl.height = y
+}
+
+// layoutLine measures the clusters [StartCluster, EndCluster) of a line and
+// places them as the line at the given index.
+func (tl *textLayout) layoutLine(line *textLayoutLine, index int) {
+	start, end := line.StartCluster, line.EndCluster
+	line.Text, line.Width, line.RuneEnd, line.Runs = "", 0, line.RuneStart, nil
+	line.Height = tl.lineHeight
+	if end <= start {
+		if tl.bidi != nil {
+			line.RTL = tl.paragraphRTL(line.RuneStart)
+		}
+		return
+	}
+	for i := start; i < end; i++ {
+		tl.clusters[i].Line = index
+	}
+	line.Text = tl.text[tl.clusters[start].ByteStart:tl.clusters[end-1].ByteEnd]
+	line.RuneEnd = tl.clusters[end-1].RuneEnd
+	if tl.bidi != nil || tl.spans != nil || tl.tabWidth > 0 {
+		tl.layoutRunLine(line)
+		if tl.spans != nil {
+			tl.fitRichLine(line)
+		}
+		return
+	}
+
+	var prefix strings.Builder
+	prevWidth := 0.0
+	for i := start; i < end; i++ {
+		cluster := &tl.clusters[i]
+		prefix.WriteString(cluster.Text)
+		width, _ := text.Measure(prefix.String(), tl.face, 0)
+		cluster.X = prevWidth
+		cluster.Width = width - prevWidth
+		prevWidth = width
+	}
+	line.Width = prevWidth
+}
+
+// canBreakAfter reports whether a line may wrap after a cluster under the
+// word-break rule: break-all allows it between any two clusters and
+// keep-all keeps adjacent letters and numbers together.
+func (tl *textLayout) canBreakAfter(i int, wordBreak string) bool {
+	breakAfter := tl.clusters[i].BreakAfter != uniseg.LineDontBreak
+	switch wordBreak {
+	case "break-all":
+		return true
+	case "keep-all":
+		if breakAfter && i+1 < len(tl.clusters) {
+			return !isWordCluster(tl.clusters[i].Text) || !isWordCluster(tl.clusters[i+1].Text)
+		}
+	}
+	return breakAfter
+}
+
+// tabAdvance returns the advance of a tab starting x from the line start,
+// up to the next tab stop.
+func (tl *textLayout) tabAdvance(x float64) float64 {
+	return (math.Floor(x/tl.tabWidth)+1)*tl.tabWidth - x
+}
+
+// clampLines drops the lines past maxLines and ends the last line kept with
+// an ellipsis, giving up its trailing clusters until it fits maxWidth.
+func (tl *textLayout) clampLines(maxLines int, maxWidth float64) {
+	const ellipsis = "..."
+	for i := range tl.clusters {
+		if tl.clusters[i].Line >= maxLines {
+			tl.clusters[i].Line = -1
+		}
+	}
+	tl.lines = tl.lines[:maxLines]
+	line := &tl.lines[maxLines-1]
+	ellipsisWidth := text.Advance(ellipsis, tl.face)
+	for line.EndCluster > line.StartCluster {
+		last := tl.clusters[line.EndCluster-1]
+		if !isWhitespaceCluster(last.Text) && line.Width+ellipsisWidth <= maxWidth {
+			break
+		}
+		tl.clusters[line.EndCluster-1].Line = -1
+		line.EndCluster--
+		tl.layoutLine(line, maxLines-1)
+	}
+
+	if len(line.Runs) == 0 {
+		line.Text += ellipsis
+	} else {
+		run := textLayoutRun{
+			Text:         ellipsis,
+			StartCluster: line.EndCluster,
+			EndCluster:   line.EndCluster,
+			Span:         -1,
+			X:            line.Width,
+			Width:        ellipsisWidth,
+		}
+		if line.RTL {
+			// The ellipsis ends a right-to-left line on its left.
+			run.X = 0
+			for i := range line.Runs {
+				line.Runs[i].X += ellipsisWidth
+			}
+			for i := line.StartCluster; i < line.EndCluster; i++ {
+				tl.clusters[i].X += ellipsisWidth
+			}
+			line.Runs = append([]textLayoutRun{run}, line.Runs...)
+		} else {
+			line.Runs = append(line.Runs, run)
+		}
+		if tl.spans != nil {
+			tl.fitRichLine(line)
+		}
+	}
+	line.Width += ellipsisWidth
+
+	tl.width = 0
+	for _, l := range tl.lines {
+		tl.width = max(tl.width, l.Width)
+	}
+	tl.height = line.Y + line.Height
+}
+
+// layoutRunLine splits a bidirectional or rich line into visual runs of one
+// direction and span and places its clusters left to right. Clusters of
+// right-to-left runs are measured with the right-to-left face, from the
+// run's right edge.
+func (tl *textLayout) layoutRunLine(line *textLayoutLine) {
+	start, end := line.StartCluster, line.EndCluster
+	levels := make([]uint8, end-start)
+	if tl.bidi != nil {
+		paragraph := tl.bidi.paragraphs[tl.clusters[start].RuneStart]
+		line.RTL = paragraph%2 == 1
+		classes := make([]bidi.Class, end-start)
+		for i := start; i < end; i++ {
+			classes[i-start] = tl.bidi.classes[tl.clusters[i].RuneStart]
+			levels[i-start] = tl.clusters[i].Level
+		}
+		resetBidiWhitespace(classes, levels, paragraph)
+	}
+
+	x := 0.0
+	for _, visual := range tl.splitRuns(bidiVisualRuns(levels), start) {
+		run := textLayoutRun{
+			StartCluster: start + visual.start,
+			EndCluster:   start + visual.end,
+			RTL:          visual.rtl(),
+			Span:         tl.clusters[start+visual.start].Span,
+			X:            x,
+		}
+		face := tl.spanFace(run.Span)
+		if run.RTL {
+			if f := rtlFace(face); f != nil {
+				face = f
+			}
+		}
+		icon := run.Span >= 0 && tl.spans[run.Span].Icon != ""
+		tab := tl.isTabRun(run)
+		var builder strings.Builder
+		advances := make([]float64, 0, run.EndCluster-run.StartCluster)
+		total := 0.0
+		for i := run.StartCluster; i < run.EndCluster; i++ {
+			builder.WriteString(tl.clusters[i].Text)
+			switch {
+			case tab:
+				advances = append(advances, tl.tabAdvance(x))
+			case icon:
+				total += tl.clusters[i].Advance
+				advances = append(advances, total)
+			default:
+				advances = append(advances, text.Advance(builder.String(), face))
+			}
+		}
+		run.Text = builder.String()
+		run.Width = advances[len(advances)-1]
+
+		prev := 0.0
+		for i := run.StartCluster; i < run.EndCluster; i++ {
+			cluster := &tl.clusters[i]
+			advance := advances[i-run.StartCluster]
+			cluster.Level = levels[i-start]
+			cluster.Width = advance - prev
+			if run.RTL {
+				cluster.X = x + run.Width - advance
+			} else {
+				cluster.X = x + prev
+			}
+			prev = advance
+		}
+		line.Runs = append(line.Runs, run)
+		x += run.Width
+	}
+	line.Width = x
+}
+
+// splitRuns splits visual runs where the rich text span changes and around
+// expanded tabs, which take a run each. The pieces of a right-to-left run are
+// placed in reverse.
+func (tl *textLayout) splitRuns(runs []bidiRun, start int) []bidiRun {
+	if tl.spans == nil && tl.tabWidth == 0 {
+		return runs
+	}
+	tab := func(i int) bool {
+		return tl.tabWidth > 0 && tl.clusters[i].Text == "\t"
+	}
+	var split []bidiRun
+	for _, run := range runs {
+		var pieces []bidiRun
+		pieceStart := run.start
+		for i := run.start + 1; i <= run.end; i++ {
+			if i == run.end || tl.clusters[start+i].Span != tl.clusters[start+pieceStart].Span ||
+				tab(start+i) || tab(start+i-1) {
+				pieces = append(pieces, bidiRun{start: pieceStart, end: i, level: run.level})
+				pieceStart = i
+			}
+		}
+		if run.rtl() {
+			for i, j := 0, len(pieces)-1; i < j; i, j = i+1, j-1 {
+				pieces[i], pieces[j] = pieces[j], pieces[i]
+			}
+		}
+		split = append(split, pieces...)
+	}
+	return split
+}
+
+// isTabRun reports whether a run holds an expanded tab.
+func (tl *textLayout) isTabRun(run textLayoutRun) bool {
+	return tl.tabWidth > 0 && run.EndCluster == run.StartCluster+1 && tl.clusters[run.StartCluster].Text == "\t"
+}
+
+// fitRichLine grows a line holding larger rich text so all its runs share a
+// baseline, and offsets each run's em box onto that baseline.
+func (tl *textLayout) fitRichLine(line *textLayoutLine) {
+	metrics := tl.face.Metrics()
+	halfLeading := (tl.lineHeight - metrics.HAscent - metrics.HDescent) / 2
+	ascent, descent := metrics.HAscent, metrics.HDescent
+	for _, run := range line.Runs {
+		m := tl.spanFace(run.Span).Metrics()
+		ascent = max(ascent, m.HAscent)
+		descent = max(descent, m.HDescent)
+	}
+	baseline := halfLeading + ascent
+	line.Height = max(tl.lineHeight, baseline+descent+halfLeading)
+	for i := range line.Runs {
+		run := &line.Runs[i]
+		run.Y = baseline - tl.spanFace(run.Span).Metrics().HAscent - halfLeading
+	}
+}
+
+// spanIndexAt returns the index of the rich text span holding a rune, or -1.
+func (tl *textLayout) spanIndexAt(runeIndex int) int {
+	for i, span := range tl.spans {
+		if runeIndex >= span.Start && runeIndex < span.End {
+			return i
+		}
+	}
+	return -1
+}
+
+// spanFace returns the face of a rich text span, or the layout face.
+func (tl *textLayout) spanFace(span int) text.Face {
+	if span >= 0 && tl.spans[span].face != nil {
+		return tl.spans[span].face
+	}
+	return tl.face
+}
+
+// iconWidth returns the advance of an inline icon, scaled to the em height
+// of its span's face. Icons whose image is missing draw as blank squares.
+func (tl *textLayout) iconWidth(span int) float64 {
+	metrics := tl.spanFace(span).Metrics()
+	height := metrics.HAscent + metrics.HDescent
+	if icon := tl.spans[span].icon; icon != nil && icon.Bounds().Dy() > 0 {
+		return height * float64(icon.Bounds().Dx()) / float64(icon.Bounds().Dy())
+	}
+	return height
+}
+
+// paragraphRTL reports whether the paragraph holding a rune is right to left.
+func (tl *textLayout) paragraphRTL(runeIndex int) bool {
+	if tl.bidi != nil && runeIndex < len(tl.bidi.paragraphs) {
+		return tl.bidi.paragraphs[runeIndex]%2 == 1
+	}
+	return tl.direction == bidiRTL
+}
+
+// drawLine draws a laid-out line with its left edge at the origin of op,
+// which sits on the em box top of the layout face. Rich text runs draw in
+// their own face and color; links are underlined and icons drawn inline.
+func (tl *textLayout) drawLine(dst *ebiten.Image, line textLayoutLine, op *text.DrawOptions) {
+	tl.drawLineRuns(dst, line, op, false)
+}
+
+// drawLineShadow draws a line like drawLine for a text shadow: every run
+// takes the color of op and icons are left out.
+func (tl *textLayout) drawLineShadow(dst *ebiten.Image, line textLayoutLine, op *text.DrawOptions) {
+	tl.drawLineRuns(dst, line, op, true)
+}
+
+func (tl *textLayout) drawLineRuns(dst *ebiten.Image, line textLayoutLine, op *text.DrawOptions, shadow bool) {
+	if len(line.Runs) == 0 {
+		drawText(dst, line.Text, tl.face, op)
+		return
+	}
+	origin := op.GeoM
+	colorScale := op.ColorScale
+	for _, run := range line.Runs {
+		if tl.isTabRun(run) {
+			continue
+		}
+		op.GeoM.Reset()
+		op.GeoM.Translate(run.X, run.Y)
+		op.GeoM.Concat(origin)
+		op.ColorScale = colorScale
+		face := tl.spanFace(run.Span)
+		if run.Span < 0 {
+			drawTextRun(dst, run.Text, face, op, run.RTL)
+			continue
+		}
+		span := &tl.spans[run.Span]
+		if span.Color != nil && !shadow {
+			op.ColorScale.Reset()
+			op.ColorScale.ScaleWithColor(span.Color)
+			op.ColorScale.ScaleAlpha(colorScale.A())
+		}
+		if span.Icon != "" {
+			if !shadow {
+				tl.drawIcons(dst, run, span, face, op)
+			}
+		} else {
+			drawTextRun(dst, run.Text, face, op, run.RTL)
+		}
+		if span.Command != "" {
+			drawTextDecoration(dst, run.Width, face, op, "underline", 0)
+		}
+	}
+	op.GeoM = origin
+	op.ColorScale = colorScale
+}
+
+// drawIcons draws the inline icons of a run over its em box, keeping only
+// the alpha of the text color.
+func (tl *textLayout) drawIcons(dst *ebiten.Image, run textLayoutRun, span *TextSpan, face text.Face, op *text.DrawOptions) {
+	if span.icon == nil {
+		return
+	}
+	metrics := face.Metrics()
+	height := metrics.HAscent + metrics.HDescent
+	bounds := span.icon.Bounds()
+	for i := run.StartCluster; i < run.EndCluster; i++ {
+		cluster := tl.clusters[i]
+		iconOp := &ebiten.DrawImageOptions{Filter: ebiten.FilterLinear}
+		iconOp.GeoM.Scale(cluster.Width/float64(bounds.Dx()), height/float64(bounds.Dy()))
+		iconOp.GeoM.Translate(cluster.X-run.X, 0)
+		iconOp.GeoM.Concat(op.GeoM)
+		iconOp.ColorScale.ScaleAlpha(op.ColorScale.A())
+		dst.DrawImage(span.icon, iconOp)
+	}
+}
+
+// drawLineDecoration draws a text decoration line across a laid-out line,
+// placed like drawLine, on the baseline its runs share.
+func (tl *textLayout) drawLineDecoration(dst *ebiten.Image, line textLayoutLine, op *text.DrawOptions, kind string, thickness float64) {
+	origin := op.GeoM
+	if len(line.Runs) > 0 {
+		run := line.Runs[0]
+		op.GeoM.Reset()
+		op.GeoM.Translate(0, run.Y+tl.spanFace(run.Span).Metrics().HAscent-tl.face.Metrics().HAscent)
+		op.GeoM.Concat(origin)
+	}
+	drawTextDecoration(dst, line.Width, tl.face, op, kind, thickness)
+	op.GeoM = origin
+}
+
+// drawTextDecoration draws an underline, overline or line-through across a
+// run of the given width in the color of op. A thickness of 0 takes one
+// scaled to the face.
+func drawTextDecoration(dst *ebiten.Image, width float64, face text.Face, op *text.DrawOptions, kind string, thickness float64) {
+	metrics := face.Metrics()
+	if thickness <= 0 {
+		thickness = math.Max(1, math.Round(metrics.HAscent/12))
+	}
+	y := metrics.HAscent + thickness
+	switch kind {
+	case "overline":
+		y = 0
+	case "line-through":
+		y = metrics.HAscent*0.65 - thickness/2
+	}
+	lineOp := &ebiten.DrawImageOptions{}
+	lineOp.GeoM.Scale(width, thickness)
+	lineOp.GeoM.Translate(0, y)
+	lineOp.GeoM.Concat(op.GeoM)
+	lineOp.ColorScale = op.ColorScale
+	dst.DrawImage(whiteImage, lineOp)
 }
 
 func (tl *textLayout) HitTest(x, y float64) (TextHit, bool) {
 	if len(tl.lines) == 0 || tl.lineHeight <= 0 {
 		return TextHit{}, false
 	}
-	lineIndex := clampLineIndex(int(math.Floor(y/tl.lineHeight)), len(tl.lines))
+	lineIndex := tl.lineIndexAt(y)
 	if lineIndex < 0 {
 		return TextHit{}, false
 	}
@@ -269,32 +765,60 @@ func (tl *textLayout) HitTest(x, y float64) (TextHit, bool) {
 		if x < cluster.X || x > cluster.X+cluster.Width {
 			continue
 		}
-		return TextHit{
-			LineIndex:    lineIndex,
-			ClusterIndex: i,
-			Text:         cluster.Text,
-			RuneStart:    cluster.RuneStart,
-			RuneEnd:      cluster.RuneEnd,
-			Rect: Rect{
-				X: cluster.X,
-				Y: float64(lineIndex) * tl.lineHeight,
-				W: cluster.Width,
-				H: tl.lineHeight,
-			},
-		}, true
+		return tl.clusterHit(lineIndex, i), true
 	}
 	return TextHit{}, false
 }
 
+// clusterHit describes a cluster as a hit, relative to the layout origin.
+func (tl *textLayout) clusterHit(lineIndex, clusterIndex int) TextHit {
+	line := tl.lines[lineIndex]
+	cluster := tl.clusters[clusterIndex]
+	hit := TextHit{
+		LineIndex:    lineIndex,
+		ClusterIndex: clusterIndex,
+		Text:         cluster.Text,
+		RuneStart:    cluster.RuneStart,
+		RuneEnd:      cluster.RuneEnd,
+		Rect: Rect{
+			X: cluster.X,
+			Y: line.Y,
+			W: cluster.Width,
+			H: line.Height,
+		},
+	}
+	if cluster.Span >= 0 {
+		hit.Span = &tl.spans[cluster.Span]
+	}
+	return hit
+}
+
+// lineIndexAt returns the line at a y offset from the layout top, clamped to
+// the first and last lines, or -1 without lines.
+func (tl *textLayout) lineIndexAt(y float64) int {
+	if len(tl.lines) == 0 {
+		return -1
+	}
+	for i, line := range tl.lines {
+		if y < line.Y+line.Height {
+			return i
+		}
+	}
+	return len(tl.lines) - 1
+}
+
 func (tl *textLayout) CaretRuneIndexAt(x, y float64) int {
 	if len(tl.lines) == 0 {
 		return 0
 	}
-	lineIndex := clampLineIndex(int(math.Floor(y/tl.lineHeight)), len(tl.lines))
+	lineIndex := tl.lineIndexAt(y)
 	if lineIndex < 0 {
 		return 0
 	}
 	line := tl.lines[lineIndex]
+	if tl.bidi != nil {
+		return tl.bidiCaretRuneIndexAt(line, x)
+	}
 	if x <= 0 {
 		return line.RuneStart
 	}
@@ -311,49 +835,184 @@ func (tl *textLayout) CaretRuneIndexAt(x, y float64) int {
 	return line.RuneEnd
 }
 
-func clampLineIndex(index, lineCount int) int {
-	if lineCount == 0 {
-		return -1
+// bidiCaretRuneIndexAt returns the caret index nearest x on a bidirectional
+// line, where right-to-left clusters start on their right edge. Points
+// before or after the line map to its logical start or end.
+func (tl *textLayout) bidiCaretRuneIndexAt(line textLayoutLine, x float64) int {
+	if x <= 0 || x >= line.Width {
+		if (x <= 0) == line.RTL {
+			return line.RuneEnd
+		}
+		return line.RuneStart
 	}
-	if index < 0 {
-		return 0
+	for i := line.StartCluster; i < line.EndCluster; i++ {
+		cluster := tl.clusters[i]
+		if x >= cluster.X && x <= cluster.X+cluster.Width {
+			return clusterEdgeRune(cluster, x > cluster.X+cluster.Width/2)
+		}
 	}
-	if index >= lineCount {
-		return lineCount - 1
+	return line.RuneEnd
+}
+
+// clusterEdgeRune returns the caret index at the left or right edge of a
+// cluster.
+func clusterEdgeRune(cluster textLayoutCluster, right bool) int {
+	if (cluster.Level%2 == 1) == right {
+		return cluster.RuneStart
 	}
-	return index
+	return cluster.RuneEnd
 }
 
-func normalizeLayoutText(s string, whiteSpace textWhiteSpaceMode) string {
-	s = strings.ReplaceAll(s, "\r\n", "\n")
-	s = strings.ReplaceAll(s, "\r", "\n")
-	s = strings.ReplaceAll(s, "\f", "\n")
-	if whiteSpace == textWhiteSpacePreWrap {
-		return s
+// CaretPosition returns the line and x offset of the caret before the rune
+// at runeIndex: the leading edge of the cluster starting there, else the
+// trailing edge of the cluster ending there. Right-to-left clusters lead on
+// their right edge.
+func (tl *textLayout) CaretPosition(runeIndex int) (int, float64) {
+	for lineIndex, line := range tl.lines {
+		if lineIndex < len(tl.lines)-1 && runeIndex >= tl.lines[lineIndex+1].RuneStart {
+			continue
+		}
+		for i := line.StartCluster; i < line.EndCluster; i++ {
+			if cluster := tl.clusters[i]; cluster.RuneStart == runeIndex {
+				if cluster.Level%2 == 1 {
+					return lineIndex, cluster.X + cluster.Width
+				}
+				return lineIndex, cluster.X
+			}
+		}
+		for i := line.StartCluster; i < line.EndCluster; i++ {
+			if cluster := tl.clusters[i]; cluster.RuneEnd == runeIndex {
+				if cluster.Level%2 == 1 {
+					return lineIndex, cluster.X
+				}
+				return lineIndex, cluster.X + cluster.Width
+			}
+		}
+		if (runeIndex <= line.RuneStart) == line.RTL {
+			return lineIndex, line.Width
+		}
+		return lineIndex, 0
 	}
+	return 0, 0
+}
 
-	parts := strings.Split(s, "\n")
-	for i, part := range parts {
-		parts[i] = collapseInlineWhitespace(part)
+// SelectionRects returns the highlight rectangles of the runes in
+// [start, end), relative to the layout origin. Adjacent clusters merge, so a
+// selection spans one rectangle per line unless bidi reordering splits it.
+func (tl *textLayout) SelectionRects(start, end int) []Rect {
+	if start > end {
+		start, end = end, start
+	}
+	var rects []Rect
+	for _, line := range tl.lines {
+		var lineRects []Rect
+		for i := line.StartCluster; i < line.EndCluster; i++ {
+			cluster := tl.clusters[i]
+			if cluster.RuneStart < start || cluster.RuneEnd > end {
+				continue
+			}
+			lineRects = append(lineRects, Rect{
+				X: cluster.X,
+				Y: line.Y,
+				W: cluster.Width,
+				H: line.Height,
+			})
+		}
+		sort.Slice(lineRects, func(i, j int) bool { return lineRects[i].X < lineRects[j].X })
+		for i, rect := range lineRects {
+			if i > 0 {
+				last := &rects[len(rects)-1]
+				if math.Abs(last.X+last.W-rect.X) < 0.5 {
+					last.W = rect.X + rect.W - last.X
+					continue
+				}
+			}
+			rects = append(rects, rect)
+		}
 	}
-	return strings.Join(parts, "\n")
+	return rects
 }
 
-func collapseInlineWhitespace(s string) string {
-	var out []rune
-	seenSpace := false
-	for _, r := range s {
-		if unicode.IsSpace(r) {
-			seenSpace = true
-			continue
+func normalizeLayoutText(s string, whiteSpace textWhiteSpaceMode) string {
+	normalized, _ := normalizeLayoutTextIndex(s, whiteSpace)
+	return normalized
+}
+
+// normalizeLayoutTextIndex turns CR LF, CR and FF into LF and, unless
+// whiteSpace is pre or pre-wrap, collapses each line's whitespace runs to single
+// spaces and trims them at both line ends. It also maps every rune index of
+// s, and its end, to the matching index of the result; dropped whitespace
+// maps to where the next kept rune lands.
+func normalizeLayoutTextIndex(s string, whiteSpace textWhiteSpaceMode) (string, []int) {
+	runes := []rune(s)
+	index := make([]int, len(runes)+1)
+	out := make([]rune, 0, len(runes))
+	pendingSpace := false
+	for i, r := range runes {
+		index[i] = len(out)
+		switch {
+		case r == '\r' && i+1 < len(runes) && runes[i+1] == '\n':
+		case r == '\r' || r == '\f' || r == '\n':
+			out = append(out, '\n')
+			pendingSpace = false
+		case !whiteSpace.preservesSpaces() && unicode.IsSpace(r):
+			pendingSpace = true
+		default:
+			if pendingSpace && len(out) > 0 && out[len(out)-1] != '\n' {
+				out = append(out, ' ')
+				index[i] = len(out)
+			}
+			pendingSpace = false
+			out = append(out, r)
+		}
+	}
+	index[len(runes)] = len(out)
+	return string(out), index
+}
+
+// remapTextSpans moves span rune ranges through a normalization index,
+// dropping spans left empty.
+func remapTextSpans(spans []TextSpan, index []int) []TextSpan {
+	remapped := make([]TextSpan, 0, len(spans))
+	at := func(runeIndex int) int {
+		if runeIndex < 0 {
+			return index[0]
+		}
+		if runeIndex >= len(index) {
+			return index[len(index)-1]
 		}
-		if seenSpace && len(out) > 0 {
-			out = append(out, ' ')
+		return index[runeIndex]
+	}
+	for _, span := range spans {
+		span.Start = at(span.Start)
+		span.End = at(span.End)
+		if span.End > span.Start {
+			remapped = append(remapped, span)
 		}
-		out = append(out, r)
-		seenSpace = false
 	}
-	return string(out)
+	return remapped
+}
+
+// applyTextTransform applies a text-transform keyword rune by rune, so rune
+// indices into the content stay valid.
+func applyTextTransform(s, transform string) string {
+	switch transform {
+	case "uppercase":
+		return strings.Map(unicode.ToUpper, s)
+	case "lowercase":
+		return strings.Map(unicode.ToLower, s)
+	case "capitalize":
+		wordStart := true
+		return strings.Map(func(r rune) rune {
+			word := unicode.IsLetter(r) || unicode.IsNumber(r) || r == '\''
+			if word && wordStart {
+				r = unicode.ToTitle(r)
+			}
+			wordStart = !word
+			return r
+		}, s)
+	}
+	return s
 }
 
 func measureLineHeight(face text.Face) float64 {
@@ -487,16 +1146,66 @@ func runeIndexToClusterOffset(s string, runeIndex int) int {
 	return offset
 }
 
-func caretRuneIndexForSingleLine(face text.Face, s string, x float64) int {
-	if face == nil || s == "" {
-		return 0
+// wordRangeAt returns the rune range of the word holding the rune at index,
+// by Unicode word boundaries. Runs of spaces and punctuation are words of
+// their own; an index at the end of s falls in the last word.
+func wordRangeAt(s string, index int) (int, int) {
+	start := 0
+	rest := s
+	state := -1
+	var word string
+	for len(rest) > 0 {
+		word, rest, state = uniseg.FirstWordInString(rest, state)
+		end := start + utf8.RuneCountInString(word)
+		if index < end || len(rest) == 0 {
+			return start, end
+		}
+		start = end
+	}
+	return 0, 0
+}
+
+// nextWordEnd returns where a word jump forward from index lands: the end of
+// the next word, skipping spaces and punctuation before it, or the end of s.
+func nextWordEnd(s string, index int) int {
+	start := 0
+	rest := s
+	state := -1
+	var word string
+	for len(rest) > 0 {
+		word, rest, state = uniseg.FirstWordInString(rest, state)
+		end := start + utf8.RuneCountInString(word)
+		if end > index && isWordCluster(word) {
+			return end
+		}
+		start = end
+	}
+	return start
+}
+
+// prevWordStart returns where a word jump backward from index lands: the
+// start of the word before it, skipping spaces and punctuation after it, or
+// the start of s.
+func prevWordStart(s string, index int) int {
+	start := 0
+	rest := s
+	state := -1
+	var word string
+	found := 0
+	for len(rest) > 0 && start < index {
+		word, rest, state = uniseg.FirstWordInString(rest, state)
+		if isWordCluster(word) {
+			found = start
+		}
+		start += utf8.RuneCountInString(word)
 	}
-	layout := newTextLayout(s, face, textLayoutOptions{
-		Wrap:       false,
-		WhiteSpace: textWhiteSpacePreWrap,
-		LineHeight: measureLineHeight(face),
-	})
-	return layout.CaretRuneIndexAt(x, 0)
+	return found
+}
+
+// isWordCluster reports whether a cluster starts with a letter or number.
+func isWordCluster(s string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return unicode.IsLetter(r) || unicode.IsNumber(r)
 }
 
 func isWhitespaceCluster(s string) bool {
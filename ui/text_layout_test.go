@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"image/color"
 	"testing"
 
 	"github.com/hajimehoshi/bitmapfont/v4"
@@ -86,13 +87,153 @@ func TestTextAreaHorizontalMovementUsesGraphemeBoundaries(t *testing.T) {
 	ta.SetText("A👨‍👩‍👧‍👦B")
 
 	ta.CursorPos = 1
-	ta.moveCursorHorizontal(1)
+	ta.moveCursorHorizontal(1, false)
 	if ta.CursorPos != 8 {
 		t.Fatalf("CursorPos after moving right = %d, want 8", ta.CursorPos)
 	}
 
-	ta.moveCursorHorizontal(-1)
+	ta.moveCursorHorizontal(-1, false)
 	if ta.CursorPos != 1 {
 		t.Fatalf("CursorPos after moving left = %d, want 1", ta.CursorPos)
 	}
 }
+
+func TestTextLayoutWhiteSpaceModesAndTabs(t *testing.T) {
+	face := testTextFace()
+	space := text.Advance(" ", face)
+
+	pre := newTextLayout("a  b\tc", face, textLayoutOptions{
+		MaxWidth:   space * 2,
+		Wrap:       textWhiteSpacePre.wraps(),
+		WhiteSpace: textWhiteSpacePre,
+		TabSize:    4,
+	})
+	if len(pre.lines) != 1 || pre.text != "a  b\tc" {
+		t.Fatalf("pre lines = %d, text = %q; want one unwrapped line with spaces kept", len(pre.lines), pre.text)
+	}
+	tab := pre.clusters[4]
+	if tab.X != space*4 || tab.Width != space*4 {
+		t.Fatalf("tab at %v width %v, want it to run from the first stop %v to the second", tab.X, tab.Width, space*4)
+	}
+	if c := pre.clusters[5]; c.X != space*8 {
+		t.Fatalf("cluster after the tab at %v, want %v", c.X, space*8)
+	}
+
+	preLine := newTextLayout("a  b\nc", face, textLayoutOptions{WhiteSpace: textWhiteSpacePreLine})
+	if preLine.text != "a b\nc" || len(preLine.lines) != 2 {
+		t.Fatalf("pre-line text = %q with %d lines, want collapsed spaces and kept newline", preLine.text, len(preLine.lines))
+	}
+}
+
+func TestTextLayoutWordBreakRules(t *testing.T) {
+	face := testTextFace()
+	width := text.Advance("abcd", face)
+	lineTexts := func(layout *textLayout) []string {
+		var lines []string
+		for _, line := range layout.lines {
+			lines = append(lines, line.Text)
+		}
+		return lines
+	}
+
+	tests := []struct {
+		name         string
+		wordBreak    string
+		overflowWrap string
+		want         []string
+	}{
+		{name: "long words break when unset", want: []string{"ab", "abcd", "efgh"}},
+		{name: "overflow-wrap normal keeps words whole", overflowWrap: "normal", want: []string{"ab", "abcdefgh"}},
+		{name: "break-all fills lines", wordBreak: "break-all", overflowWrap: "normal", want: []string{"ab a", "bcde", "fgh"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layout := newTextLayout("ab abcdefgh", face, textLayoutOptions{
+				MaxWidth:               width,
+				Wrap:                   true,
+				WhiteSpace:             textWhiteSpaceNormal,
+				TrimTrailingWhitespace: true,
+				WordBreak:              tt.wordBreak,
+				OverflowWrap:           tt.overflowWrap,
+			})
+			got := lineTexts(layout)
+			if len(got) != len(tt.want) {
+				t.Fatalf("lines = %q, want %q", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("lines = %q, want %q", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestTextLayoutLineClampEndsWithEllipsis(t *testing.T) {
+	face := testTextFace()
+	width := text.Advance("one two ", face)
+	layout := newTextLayout("one two three four five", face, textLayoutOptions{
+		MaxWidth:               width,
+		Wrap:                   true,
+		WhiteSpace:             textWhiteSpaceNormal,
+		TrimTrailingWhitespace: true,
+		MaxLines:               2,
+	})
+	if len(layout.lines) != 2 {
+		t.Fatalf("lines = %d, want 2", len(layout.lines))
+	}
+	last := layout.lines[1]
+	if last.Text != "three..." {
+		t.Fatalf("last line = %q, want %q", last.Text, "three...")
+	}
+	if last.Width > width {
+		t.Fatalf("last line width %v exceeds %v", last.Width, width)
+	}
+	if layout.height != 2*layout.lineHeight {
+		t.Fatalf("height = %v, want two lines", layout.height)
+	}
+	for _, c := range layout.clusters[last.EndCluster:] {
+		if c.Line != -1 {
+			t.Fatalf("clamped cluster %q kept line %d", c.Text, c.Line)
+		}
+	}
+}
+
+func TestCSSTextTransformDecorationAndClamp(t *testing.T) {
+	ui := New(200, 200)
+	ui.DefaultFontFace = testTextFace()
+	if err := ui.LoadCSS(`
+		#root { white-space: pre-line; }
+		#title {
+			text-transform: capitalize;
+			text-decoration: underline line-through #f00 2px;
+			-webkit-line-clamp: 1;
+		}
+	`); err != nil {
+		t.Fatalf("LoadCSS() error = %v", err)
+	}
+	if err := ui.LoadLayout(`<panel id="root">
+		<text id="title">hello  world
+second line</text>
+	</panel>`); err != nil {
+		t.Fatalf("LoadLayout() error = %v", err)
+	}
+
+	txt := ui.GetText("title")
+	style := txt.Style()
+	if style.WhiteSpace != "pre-line" {
+		t.Fatalf("WhiteSpace = %q, want it inherited", style.WhiteSpace)
+	}
+	if style.TextDecoration != "underline line-through" || style.TextDecorationColor != "#f00" || style.TextDecorationThickness != 2 {
+		t.Fatalf("decoration = %q %q %v", style.TextDecoration, style.TextDecorationColor, style.TextDecorationThickness)
+	}
+	lines, c := textDecorations(style, color.White)
+	if len(lines) != 2 || c != parseColor("#f00") {
+		t.Fatalf("textDecorations() = %v, %v", lines, c)
+	}
+
+	layout := txt.ensureLayout(200, style)
+	if len(layout.lines) != 1 || layout.lines[0].Text != "Hello World..." {
+		t.Fatalf("lines = %+v, want one capitalized, clamped line", layout.lines)
+	}
+}
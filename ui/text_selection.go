@@ -0,0 +1,261 @@
+package ui
+
+import (
+	"image/color"
+	"math"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// ============================================================================
+// Selectable read-only text
+// ============================================================================
+
+// TextSelection is the selection of read-only text whose style sets
+// user-select: text. SelectStart is where the selection was anchored and
+// SelectEnd where it was extended to, both rune indices of the text as laid
+// out, so SelectEnd may come first.
+type TextSelection struct {
+	SelectStart    int
+	SelectEnd      int
+	SelectionColor color.Color // highlight color, nil for the default
+}
+
+// defaultSelectionColor highlights selected text, as in text inputs.
+var defaultSelectionColor = color.RGBA{100, 149, 237, 128}
+
+// HasSelection reports whether any text is selected.
+func (s *TextSelection) HasSelection() bool {
+	return s.SelectStart != s.SelectEnd
+}
+
+// ClearSelection collapses the selection.
+func (s *TextSelection) ClearSelection() {
+	s.SelectStart, s.SelectEnd = 0, 0
+}
+
+// selectionRange returns the selection in order.
+func (s *TextSelection) selectionRange() (int, int) {
+	if s.SelectStart > s.SelectEnd {
+		return s.SelectEnd, s.SelectStart
+	}
+	return s.SelectStart, s.SelectEnd
+}
+
+func (s *TextSelection) selectionColor() color.Color {
+	if s.SelectionColor != nil {
+		return s.SelectionColor
+	}
+	return defaultSelectionColor
+}
+
+// selectableText is a read-only widget whose laid-out text can be selected
+// with the pointer.
+type selectableText interface {
+	Widget
+	textSelection() *TextSelection
+	selectionLayout() *textLayout
+	// selectionPoint converts absolute coordinates to the line-relative x
+	// and layout y that textLayout hit testing takes.
+	selectionPoint(x, y float64) (float64, float64)
+}
+
+// textSelectable reports whether a widget's text can be selected.
+func textSelectable(w Widget) (selectableText, bool) {
+	st, ok := w.(selectableText)
+	if !ok || w.Style().UserSelect != "text" {
+		return nil, false
+	}
+	return st, true
+}
+
+// selectedLayoutText returns the selected part of a layout's text.
+func selectedLayoutText(layout *textLayout, selection *TextSelection) string {
+	if layout == nil {
+		return ""
+	}
+	start, end := selection.selectionRange()
+	runes := []rune(layout.text)
+	if start < 0 || end > len(runes) {
+		return ""
+	}
+	return string(runes[start:end])
+}
+
+// Multi-click detection: presses on the same widget within the interval and
+// distance count as double and triple clicks.
+const (
+	multiClickInterval = 500 * time.Millisecond
+	multiClickDistance = 4.0
+)
+
+// countClick records a left press and returns whether it is a single,
+// double or triple click.
+func (ui *UI) countClick(w Widget, x, y float64) int {
+	now := time.Now()
+	if w == ui.lastClickWidget && now.Sub(ui.lastClickTime) <= multiClickInterval &&
+		math.Abs(x-ui.lastClickX) <= multiClickDistance && math.Abs(y-ui.lastClickY) <= multiClickDistance {
+		ui.clickCount = ui.clickCount%3 + 1
+	} else {
+		ui.clickCount = 1
+	}
+	ui.lastClickWidget = w
+	ui.lastClickTime = now
+	ui.lastClickX, ui.lastClickY = x, y
+	return ui.clickCount
+}
+
+// beginTextSelection starts selecting the text of a selectable widget
+// pressed at x, y for the clicks-th time in a row: a click places the anchor
+// for a drag, a double click selects a word and a triple click a line.
+// Pressing anywhere else clears the current selection.
+func (ui *UI) beginTextSelection(w Widget, x, y float64, clicks int) {
+	ui.selectingText = false
+	st, ok := textSelectable(w)
+	if ui.selectedText != nil && (!ok || ui.selectedText != st) {
+		ui.selectedText.textSelection().ClearSelection()
+		ui.selectedText = nil
+	}
+	if !ok {
+		return
+	}
+	layout := st.selectionLayout()
+	if layout == nil {
+		return
+	}
+	ui.selectedText = st
+	selection := st.textSelection()
+	localX, localY := st.selectionPoint(x, y)
+	caret := layout.CaretRuneIndexAt(localX, localY)
+
+	switch clicks {
+	case 2:
+		index := caret
+		if hit, ok := layout.HitTest(localX, localY); ok {
+			index = hit.RuneStart
+		}
+		selection.SelectStart, selection.SelectEnd = wordRangeAt(layout.text, index)
+	case 3:
+		if lineIndex := layout.lineIndexAt(localY); lineIndex >= 0 {
+			line := layout.lines[lineIndex]
+			selection.SelectStart, selection.SelectEnd = line.RuneStart, line.RuneEnd
+		}
+	default:
+		selection.SelectStart, selection.SelectEnd = caret, caret
+		ui.selectingText = true
+	}
+}
+
+// dragTextSelection extends a selection being dragged to x, y, in
+// selectable text or the pressed text input or text area. Points past the
+// text's edges select up to its start or end.
+func (ui *UI) dragTextSelection(x, y float64) {
+	switch w := ui.activeWidget.(type) {
+	case *TextInput:
+		w.HandlePointerDrag(x, y)
+	case *TextArea:
+		w.HandlePointerDrag(x, y)
+	}
+	if !ui.selectingText || ui.selectedText == nil {
+		return
+	}
+	layout := ui.selectedText.selectionLayout()
+	if layout == nil {
+		return
+	}
+	localX, localY := ui.selectedText.selectionPoint(x, y)
+	ui.selectedText.textSelection().SelectEnd = layout.CaretRuneIndexAt(localX, localY)
+}
+
+// endTextSelection ends the drag selection when the left button is
+// released.
+func (ui *UI) endTextSelection() {
+	ui.selectingText = false
+	switch w := ui.activeWidget.(type) {
+	case *TextInput:
+		w.HandlePointerUp()
+	case *TextArea:
+		w.HandlePointerUp()
+	}
+}
+
+// copyTextSelection copies the selected read-only text to the clipboard and
+// reports whether there was any.
+func (ui *UI) copyTextSelection() bool {
+	if ui.selectedText == nil || !ui.selectedText.textSelection().HasSelection() {
+		return false
+	}
+	writeClipboardText(selectedLayoutText(ui.selectedText.selectionLayout(), ui.selectedText.textSelection()))
+	return true
+}
+
+// SelectedText returns the text selected in the text widget, if any.
+func (t *Text) SelectedText() string {
+	return selectedLayoutText(t.selectionLayout(), &t.TextSelection)
+}
+
+func (t *Text) textSelection() *TextSelection {
+	return &t.TextSelection
+}
+
+func (t *Text) selectionLayout() *textLayout {
+	return t.ensureLayout(t.ContentRect().W, t.getActiveStyle())
+}
+
+func (t *Text) selectionPoint(x, y float64) (float64, float64) {
+	style := t.getActiveStyle()
+	r := t.ContentRect()
+	layout := t.ensureLayout(r.W, style)
+	if layout == nil || len(layout.lines) == 0 {
+		return x - r.X, y - r.Y
+	}
+	localY := y - t.textStartY(r, layout, style)
+	line := layout.lines[layout.lineIndexAt(localY)]
+	return x - t.lineOriginX(r, line.Width, style), localY
+}
+
+// drawSelection highlights the selected text of a laid-out Text.
+func (t *Text) drawSelection(screen *ebiten.Image, r Rect, layout *textLayout, style *Style, startY float64) {
+	if !t.HasSelection() || style.UserSelect != "text" {
+		return
+	}
+	start, end := t.selectionRange()
+	for _, rect := range layout.SelectionRects(start, end) {
+		line := layout.lines[layout.lineIndexAt(rect.Y)]
+		rect.X += t.lineOriginX(r, line.Width, style)
+		rect.Y += startY
+		DrawRoundedRectPath(screen, rect, 2, t.selectionColor())
+	}
+}
+
+// SelectedText returns the text selected in the button's label, if any.
+func (b *Button) SelectedText() string {
+	return selectedLayoutText(b.selectionLayout(), &b.TextSelection)
+}
+
+func (b *Button) textSelection() *TextSelection {
+	return &b.TextSelection
+}
+
+// selectionLayout lays the label out on one line for hit testing, keeping
+// its spaces so rune indices match the label.
+func (b *Button) selectionLayout() *textLayout {
+	if b.Label == "" || b.FontFace == nil {
+		return nil
+	}
+	label := applyTextTransform(b.Label, b.getActiveStyle().TextTransform)
+	if b.labelLayout == nil || b.labelLayout.text != label || b.labelLayout.face != b.FontFace {
+		b.labelLayout = newTextLayout(label, b.FontFace, textLayoutOptions{WhiteSpace: textWhiteSpacePreWrap})
+	}
+	return b.labelLayout
+}
+
+func (b *Button) selectionPoint(x, y float64) (float64, float64) {
+	layout := b.selectionLayout()
+	if layout == nil {
+		return 0, 0
+	}
+	originX, _ := b.labelOrigin(b.computedRect, b.getActiveStyle(), layout.width)
+	return x - originX, 0
+}
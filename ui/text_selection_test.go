@@ -0,0 +1,106 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+func TestWordRangeAt(t *testing.T) {
+	const sample = "hello, wide world"
+	tests := []struct {
+		index      int
+		start, end int
+	}{
+		{index: 0, start: 0, end: 5},
+		{index: 4, start: 0, end: 5},
+		{index: 5, start: 5, end: 6},
+		{index: 9, start: 7, end: 11},
+		{index: 17, start: 12, end: 17},
+	}
+	for _, tt := range tests {
+		start, end := wordRangeAt(sample, tt.index)
+		if start != tt.start || end != tt.end {
+			t.Errorf("wordRangeAt(%d) = [%d:%d], want [%d:%d]", tt.index, start, end, tt.start, tt.end)
+		}
+	}
+}
+
+// textPointAt returns the absolute position of the caret before a rune of a
+// Text, halfway down its line.
+func textPointAt(txt *Text, runeIndex int) (float64, float64) {
+	style := txt.Style()
+	r := txt.ContentRect()
+	layout := txt.ensureLayout(r.W, style)
+	lineIndex, x := layout.CaretPosition(runeIndex)
+	line := layout.lines[lineIndex]
+	return txt.lineOriginX(r, line.Width, style) + x, txt.textStartY(r, layout, style) + line.Y + line.Height/2
+}
+
+func TestSelectableTextDragAndDoubleClick(t *testing.T) {
+	ui := New(320, 200)
+	ui.DefaultFontFace = testTextFace()
+	if err := ui.LoadCSS(`#copy { user-select: text; }`); err != nil {
+		t.Fatalf("LoadCSS() error = %v", err)
+	}
+	if err := ui.LoadLayout(`<panel id="root">
+		<text id="copy">alpha beta
+gamma delta</text>
+		<text id="plain">not selectable</text>
+	</panel>`); err != nil {
+		t.Fatalf("LoadLayout() error = %v", err)
+	}
+	txt := ui.GetText("copy")
+
+	startX, startY := textPointAt(txt, 0)
+	endX, endY := textPointAt(txt, 19)
+	ui.SimulatePointerDown(startX+1, startY, ebiten.MouseButtonLeft)
+	ui.SimulatePointerMove(endX, endY)
+	ui.SimulatePointerUp(endX, endY, ebiten.MouseButtonLeft)
+	if got, want := txt.SelectedText(), "alpha beta\ngamma de"; got != want {
+		t.Fatalf("drag selected %q, want %q", got, want)
+	}
+	ui.SimulateKeyPress(ebiten.KeyC, false, true)
+
+	wordX, wordY := textPointAt(txt, 7)
+	ui.SimulateClick(wordX+1, wordY)
+	ui.SimulateClick(wordX+1, wordY)
+	if got := txt.SelectedText(); got != "beta" {
+		t.Fatalf("double click selected %q, want %q", got, "beta")
+	}
+
+	plain := ui.GetText("plain")
+	plainX, plainY := textPointAt(plain, 1)
+	ui.SimulatePointerDown(plainX, plainY, ebiten.MouseButtonLeft)
+	ui.SimulatePointerMove(plainX+40, plainY)
+	ui.SimulatePointerUp(plainX+40, plainY, ebiten.MouseButtonLeft)
+	if plain.HasSelection() {
+		t.Fatalf("text without user-select selected %q", plain.SelectedText())
+	}
+	if txt.HasSelection() {
+		t.Fatal("pressing other text should clear the selection")
+	}
+}
+
+func TestSelectableButtonLabelDoubleClickSelectsWord(t *testing.T) {
+	ui := New(320, 200)
+	ui.DefaultFontFace = testTextFace()
+	if err := ui.LoadCSS(`#buy { user-select: text; width: 200px; height: 40px; }`); err != nil {
+		t.Fatalf("LoadCSS() error = %v", err)
+	}
+	if err := ui.LoadLayout(`<panel id="root"><button id="buy">Buy now</button></panel>`); err != nil {
+		t.Fatalf("LoadLayout() error = %v", err)
+	}
+	button := ui.GetButton("buy")
+	layout := button.selectionLayout()
+	r := button.ComputedRect()
+	originX, _ := button.labelOrigin(r, button.Style(), layout.width)
+	cluster := layout.clusters[5]
+	x, y := originX+cluster.X+cluster.Width/2, r.Y+r.H/2
+
+	ui.SimulateClick(x, y)
+	ui.SimulateClick(x, y)
+	if got := button.SelectedText(); got != "now" {
+		t.Fatalf("SelectedText() = %q, want %q", got, "now")
+	}
+}
@@ -0,0 +1,402 @@
+package ui
+
+import (
+	"image"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// ============================================================================
+// 3D transforms — perspective, rotateX/rotateY, translateZ, transform-style
+// and backface-visibility
+// ============================================================================
+
+// mat4 is a row-major 4x4 matrix applied to column vectors, p' = M p.
+type mat4 [16]float64
+
+// projectedGrid is the number of cells per side of the grid a 3D layer is
+// drawn as. DrawTriangles interpolates texture coordinates linearly, so a
+// single quad would bend straight lines under perspective.
+const projectedGrid = 8
+
+func identity4() mat4 {
+	return mat4{1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1}
+}
+
+func (m mat4) mul(n mat4) mat4 {
+	var out mat4
+	for row := 0; row < 4; row++ {
+		for col := 0; col < 4; col++ {
+			var sum float64
+			for k := 0; k < 4; k++ {
+				sum += m[row*4+k] * n[k*4+col]
+			}
+			out[row*4+col] = sum
+		}
+	}
+	return out
+}
+
+func translate4(x, y, z float64) mat4 {
+	m := identity4()
+	m[3], m[7], m[11] = x, y, z
+	return m
+}
+
+func scale4(x, y, z float64) mat4 {
+	m := identity4()
+	m[0], m[5], m[10] = x, y, z
+	return m
+}
+
+// rotate4 rotates by angle radians about the axis (x, y, z), as CSS
+// rotate3d() does; positive angles turn clockwise looking along the axis
+// towards the origin.
+func rotate4(x, y, z, angle float64) mat4 {
+	length := math.Sqrt(x*x + y*y + z*z)
+	if length == 0 {
+		return identity4()
+	}
+	x, y, z = x/length, y/length, z/length
+	s, c := math.Sincos(angle)
+	t := 1 - c
+	return mat4{
+		c + x*x*t, x*y*t - z*s, x*z*t + y*s, 0,
+		y*x*t + z*s, c + y*y*t, y*z*t - x*s, 0,
+		z*x*t - y*s, z*y*t + x*s, c + z*z*t, 0,
+		0, 0, 0, 1,
+	}
+}
+
+// perspective4 places the viewer distance d in front of the z = 0 plane.
+func perspective4(d float64) mat4 {
+	m := identity4()
+	if d > 0 {
+		m[14] = -1 / d
+	}
+	return m
+}
+
+// project maps the point (x, y, 0) and returns its homogeneous x, y and w.
+func (m mat4) project(x, y float64) (float64, float64, float64) {
+	return m[0]*x + m[1]*y + m[3],
+		m[4]*x + m[5]*y + m[7],
+		m[12]*x + m[13]*y + m[15]
+}
+
+// unproject finds the point of the z = 0 plane that m projects to (x, y),
+// inverting the plane's homography. It fails when the plane is seen edge-on
+// or the point lies behind the viewer.
+func (m mat4) unproject(x, y float64) (float64, float64, bool) {
+	a, b, c := m[0], m[1], m[3]
+	d, e, f := m[4], m[5], m[7]
+	g, h, i := m[12], m[13], m[15]
+	det := a*(e*i-f*h) - b*(d*i-f*g) + c*(d*h-e*g)
+	if math.Abs(det) < 1e-12 {
+		return 0, 0, false
+	}
+	lx := (e*i-f*h)*x + (c*h-b*i)*y + (b*f - c*e)
+	ly := (f*g-d*i)*x + (a*i-c*g)*y + (c*d - a*f)
+	lw := (d*h-e*g)*x + (b*g-a*h)*y + (a*e - b*d)
+	if math.Abs(lw) < 1e-12 {
+		return 0, 0, false
+	}
+	lx, ly = lx/lw, ly/lw
+	if _, _, w := m.project(lx, ly); w <= 0 {
+		return 0, 0, false
+	}
+	return lx, ly, true
+}
+
+// facesAway reports whether m turns the front of the rectangle r away from
+// the viewer, by the winding of its projected corners.
+func (m mat4) facesAway(r Rect) bool {
+	corners := [4][2]float64{{r.X, r.Y}, {r.X + r.W, r.Y}, {r.X + r.W, r.Y + r.H}, {r.X, r.Y + r.H}}
+	var area float64
+	var px, py [4]float64
+	for i, corner := range corners {
+		x, y, w := m.project(corner[0], corner[1])
+		if w <= 0 {
+			return true
+		}
+		px[i], py[i] = x/w, y/w
+	}
+	for i := range corners {
+		j := (i + 1) % 4
+		area += px[i]*py[j] - px[j]*py[i]
+	}
+	return area < 0
+}
+
+// parseCSSTransform3D parses a transform list into a matrix about the
+// origin (0, 0), applying the functions right to left as CSS does. is3D
+// reports whether the list uses a 3D function: perspective(), rotateX(),
+// rotateY(), rotate3d(), translateZ(), translate3d(), scaleZ(), scale3d()
+// or matrix3d().
+func parseCSSTransform3D(transform string) (m mat4, is3D bool) {
+	m = identity4()
+	remaining := strings.TrimSpace(transform)
+	if remaining == "" || remaining == "none" {
+		return m, false
+	}
+	for remaining != "" {
+		open := strings.Index(remaining, "(")
+		if open < 0 {
+			break
+		}
+		closeIdx := closingParen(remaining, open)
+		if closeIdx < 0 {
+			break
+		}
+		name := strings.ToLower(strings.TrimSpace(remaining[:open]))
+		args := strings.Split(remaining[open+1:closeIdx], ",")
+		for i := range args {
+			args[i] = strings.TrimSpace(args[i])
+		}
+		remaining = strings.TrimSpace(remaining[closeIdx+1:])
+
+		arg := func(i int, fallback float64, parse func(string) float64) float64 {
+			if i < len(args) && args[i] != "" {
+				return parse(args[i])
+			}
+			return fallback
+		}
+		var step mat4
+		switch name {
+		case "translate":
+			step = translate4(arg(0, 0, parseCSSPixels), arg(1, 0, parseCSSPixels), 0)
+		case "translatex":
+			step = translate4(arg(0, 0, parseCSSPixels), 0, 0)
+		case "translatey":
+			step = translate4(0, arg(0, 0, parseCSSPixels), 0)
+		case "translatez":
+			step = translate4(0, 0, arg(0, 0, parseCSSPixels))
+			is3D = true
+		case "translate3d":
+			step = translate4(arg(0, 0, parseCSSPixels), arg(1, 0, parseCSSPixels), arg(2, 0, parseCSSPixels))
+			is3D = true
+		case "scale":
+			sx := arg(0, 1, parseCSSScaleValue)
+			step = scale4(sx, arg(1, sx, parseCSSScaleValue), 1)
+		case "scalex":
+			step = scale4(arg(0, 1, parseCSSScaleValue), 1, 1)
+		case "scaley":
+			step = scale4(1, arg(0, 1, parseCSSScaleValue), 1)
+		case "scalez":
+			step = scale4(1, 1, arg(0, 1, parseCSSScaleValue))
+			is3D = true
+		case "scale3d":
+			step = scale4(arg(0, 1, parseCSSScaleValue), arg(1, 1, parseCSSScaleValue), arg(2, 1, parseCSSScaleValue))
+			is3D = true
+		case "rotate", "rotatez":
+			step = rotate4(0, 0, 1, arg(0, 0, parseCSSAngle))
+		case "rotatex":
+			step = rotate4(1, 0, 0, arg(0, 0, parseCSSAngle))
+			is3D = true
+		case "rotatey":
+			step = rotate4(0, 1, 0, arg(0, 0, parseCSSAngle))
+			is3D = true
+		case "rotate3d":
+			step = rotate4(arg(0, 0, parseCSSNumber), arg(1, 0, parseCSSNumber), arg(2, 0, parseCSSNumber), arg(3, 0, parseCSSAngle))
+			is3D = true
+		case "skew":
+			step = identity4()
+			step[1] = math.Tan(arg(0, 0, parseCSSAngle))
+			step[4] = math.Tan(arg(1, 0, parseCSSAngle))
+		case "skewx":
+			step = identity4()
+			step[1] = math.Tan(arg(0, 0, parseCSSAngle))
+		case "skewy":
+			step = identity4()
+			step[4] = math.Tan(arg(0, 0, parseCSSAngle))
+		case "perspective":
+			step = perspective4(arg(0, 0, parseCSSPixels))
+			is3D = true
+		case "matrix":
+			if len(args) != 6 {
+				continue
+			}
+			step = identity4()
+			step[0], step[4], step[1], step[5], step[3], step[7] =
+				parseCSSNumber(args[0]), parseCSSNumber(args[1]), parseCSSNumber(args[2]),
+				parseCSSNumber(args[3]), parseCSSNumber(args[4]), parseCSSNumber(args[5])
+		case "matrix3d":
+			if len(args) != 16 {
+				continue
+			}
+			// matrix3d lists its values column by column.
+			for i, value := range args {
+				step[(i%4)*4+i/4] = parseCSSNumber(value)
+			}
+			is3D = true
+		default:
+			continue
+		}
+		m = m.mul(step)
+	}
+	return m, is3D
+}
+
+func parseCSSNumber(s string) float64 {
+	f, _ := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	return f
+}
+
+// preserves3D reports whether a style keeps its children in its 3D
+// rendering context. As in CSS, clipping overflow flattens the context.
+func preserves3D(style *Style) bool {
+	if style.TransformStyle != "preserve-3d" {
+		return false
+	}
+	if style.Overflow != "" && style.Overflow != "visible" {
+		return false
+	}
+	// Properties that composite the box as a group also flatten it.
+	if style.Opacity > 0 && style.Opacity < 1 {
+		return false
+	}
+	hasClipPath := style.ClipPath != "" && style.ClipPath != "none"
+	return style.parsedFilter == nil && !hasClipPath && !hasCompositeEffects(style)
+}
+
+// perspectiveMatrix returns the projection a perspective property applies
+// to the widget's children, centred on its perspective-origin.
+func (w *BaseWidget) perspectiveMatrix(style *Style) mat4 {
+	r := w.computedRect
+	ox, oy := parseCSSTransformOrigin(style.PerspectiveOrigin, r.W, r.H)
+	ox += r.X
+	oy += r.Y
+	return translate4(ox, oy, 0).mul(perspective4(style.Perspective)).mul(translate4(-ox, -oy, 0))
+}
+
+// transform3D returns the matrix from the widget's layout coordinates to the
+// space it is drawn in, and whether the widget needs projecting: when its
+// transform is 3D, its parent sets perspective, or a preserve-3d parent
+// shares its 3D rendering context. A preserve-3d parent draws its children
+// in its own target, so their matrices include the parent's.
+func (w *BaseWidget) transform3D(style *Style) (mat4, bool) {
+	r := w.computedRect
+	own, is3D := parseCSSTransform3D(style.Transform)
+	ox, oy := parseCSSTransformOrigin(style.TransformOrigin, r.W, r.H)
+	ox += r.X
+	oy += r.Y
+	m := translate4(ox, oy, 0).mul(own).mul(translate4(-ox, -oy, 0))
+
+	parent := baseWidgetOf(w.parent)
+	if parent == nil {
+		return m, is3D
+	}
+	parentStyle := parent.renderStyle(parent.getActiveStyle())
+	if parentStyle.Perspective > 0 && style.Transform != "" && style.Transform != "none" {
+		m = parent.perspectiveMatrix(parentStyle).mul(m)
+		is3D = true
+	}
+	if preserves3D(parentStyle) {
+		if context, inContext := parent.transform3D(parentStyle); inContext {
+			m = context.mul(m)
+			is3D = true
+		}
+	}
+	return m, is3D
+}
+
+// hasTransform reports whether the widget draws transformed, by its own
+// transform or by a parent's perspective or 3D rendering context.
+func (w *BaseWidget) hasTransform(style *Style) bool {
+	if style.Transform != "" && style.Transform != "none" {
+		return true
+	}
+	_, is3D := w.transform3D(style)
+	return is3D
+}
+
+// layerBounds returns the area of an offscreen layer to project: the border
+// box grown to hold overflowing descendants and the outline.
+func (w *BaseWidget) layerBounds(r Rect, style *Style) Rect {
+	minX, minY, maxX, maxY := r.X, r.Y, r.X+r.W, r.Y+r.H
+	var grow func(widget Widget)
+	grow = func(widget Widget) {
+		for _, child := range widget.Children() {
+			if !child.Visible() {
+				continue
+			}
+			cr := child.ComputedRect()
+			minX, minY = min(minX, cr.X), min(minY, cr.Y)
+			maxX, maxY = max(maxX, cr.X+cr.W), max(maxY, cr.Y+cr.H)
+			grow(child)
+		}
+	}
+	if !preserves3D(style) {
+		grow(w)
+	}
+	if outline := style.parsedOutline; outline != nil && outline.Width > 0 {
+		extent := outline.Width + max(0, outline.Offset)
+		minX, minY, maxX, maxY = minX-extent, minY-extent, maxX+extent, maxY+extent
+	}
+	return Rect{X: minX, Y: minY, W: maxX - minX, H: maxY - minY}
+}
+
+// drawProjected draws an offscreen layer onto screen through the 3D matrix
+// m as a grid of triangles, then applies opacity and mix-blend-mode. With
+// backface-visibility: hidden a layer turned away from the viewer is not
+// drawn, nor is one that passes behind the viewer.
+func (w *BaseWidget) drawProjected(screen, layer *ebiten.Image, r Rect, m mat4, style *Style, opacity float64, animGeoM ebiten.GeoM, hasAnimTransform bool) {
+	if style.BackfaceVisibility == "hidden" && m.facesAway(r) {
+		return
+	}
+	bounds := layer.Bounds()
+	area := w.layerBounds(r, style)
+	x0, y0 := max(area.X, float64(bounds.Min.X)), max(area.Y, float64(bounds.Min.Y))
+	x1, y1 := min(area.X+area.W, float64(bounds.Max.X)), min(area.Y+area.H, float64(bounds.Max.Y))
+	if x1 <= x0 || y1 <= y0 {
+		return
+	}
+
+	const n = projectedGrid
+	vertices := make([]ebiten.Vertex, 0, (n+1)*(n+1))
+	for j := 0; j <= n; j++ {
+		for i := 0; i <= n; i++ {
+			sx := x0 + (x1-x0)*float64(i)/n
+			sy := y0 + (y1-y0)*float64(j)/n
+			x, y, wv := m.project(sx, sy)
+			if wv <= 1e-6 {
+				return
+			}
+			dx, dy := x/wv, y/wv
+			if hasAnimTransform {
+				dx, dy = animGeoM.Apply(dx, dy)
+			}
+			vertices = append(vertices, ebiten.Vertex{
+				DstX: float32(dx), DstY: float32(dy),
+				SrcX: float32(sx), SrcY: float32(sy),
+				ColorR: 1, ColorG: 1, ColorB: 1, ColorA: 1,
+			})
+		}
+	}
+	indices := make([]uint16, 0, n*n*6)
+	for j := 0; j < n; j++ {
+		for i := 0; i < n; i++ {
+			tl := uint16(j*(n+1) + i)
+			tr, bl := tl+1, tl+n+1
+			indices = append(indices, tl, tr, bl, tr, bl+1, bl)
+		}
+	}
+
+	screenBounds := screen.Bounds()
+	screenW, screenH := screenBounds.Dx(), screenBounds.Dy()
+	projected := globalImagePool.Get(screenW, screenH)
+	projected.DrawTriangles(vertices, indices, layer, &ebiten.DrawTrianglesOptions{
+		Filter:    ebiten.FilterLinear,
+		AntiAlias: true,
+	})
+	op := &ebiten.DrawImageOptions{}
+	if opacity < 1 {
+		op.ColorScale.ScaleAlpha(float32(opacity))
+	}
+	cropped := projected.SubImage(image.Rect(0, 0, screenW, screenH)).(*ebiten.Image)
+	drawBlended(screen, cropped, op, style.MixBlendMode)
+	globalImagePool.Put(projected)
+}
@@ -0,0 +1,68 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+func TestCSS3DTransformsProjectAndHitTest(t *testing.T) {
+	ui := New(400, 300)
+	if err := ui.LoadCSS(`
+		#stage { width: 400px; height: 300px; perspective: 600px; flex-direction: column; }
+		#card { width: 200px; height: 100px; transform-style: preserve-3d; transform: rotateY(180deg); }
+		#front, #back { position: absolute; left: 0; top: 0; width: 200px; height: 100px; backface-visibility: hidden; }
+		#back { transform: rotateY(180deg); }
+		#tilt { width: 200px; height: 100px; transform: rotateX(60deg); }
+		#bad { transform-style: cube; }
+	`); err != nil {
+		t.Fatalf("LoadCSS: %v", err)
+	}
+	if err := ui.LoadLayout(`<panel id="stage"><panel id="card"><panel id="front"/><panel id="back"/></panel><panel id="tilt"/><panel id="bad"/></panel>`); err != nil {
+		t.Fatalf("LoadLayout: %v", err)
+	}
+
+	if s := ui.GetPanel("card").Style(); s.TransformStyle != "preserve-3d" {
+		t.Errorf("transform-style = %q, want preserve-3d", s.TransformStyle)
+	}
+	if p := ui.GetPanel("stage").Style().Perspective; p != 600 {
+		t.Errorf("perspective = %v, want 600", p)
+	}
+	if bad := ui.GetPanel("bad").Style(); bad.TransformStyle != "" {
+		t.Errorf("invalid transform-style should be dropped, got %q", bad.TransformStyle)
+	}
+
+	// A card turned 180deg shows its back face, both to drawing and to hit
+	// testing.
+	front, back := ui.GetPanel("front"), ui.GetPanel("back")
+	frontM, front3D := front.transform3D(front.getActiveStyle())
+	backM, back3D := back.transform3D(back.getActiveStyle())
+	if !front3D || !back3D {
+		t.Fatal("children of a preserve-3d card should be projected through it")
+	}
+	if !frontM.facesAway(front.ComputedRect()) || backM.facesAway(back.ComputedRect()) {
+		t.Fatal("a card turned 180deg should show its back face")
+	}
+	cr := ui.GetPanel("card").ComputedRect()
+	if got := ui.findWidgetAt(ui.root, cr.X+cr.W/2, cr.Y+cr.H/2); got != back {
+		t.Errorf("hit at the card centre = %v, want the visible back face", got)
+	}
+
+	tilt := ui.GetPanel("tilt")
+	tr := tilt.ComputedRect()
+	m, is3D := tilt.transform3D(tilt.getActiveStyle())
+	if !is3D {
+		t.Fatal("rotateX under a parent perspective should be 3D")
+	}
+	// The top edge tilts away from the viewer, so a point just inside the
+	// layout box's top edge falls outside the projected quad.
+	if got := ui.findWidgetAt(ui.root, tr.X+tr.W/2, tr.Y+2); got == tilt {
+		t.Error("points outside the projected quad should miss the tilted panel")
+	}
+	x, y, w := m.project(tr.X+20, tr.Y+tr.H/4)
+	if got := ui.findWidgetAt(ui.root, x/w, y/w); got != tilt {
+		t.Errorf("hit at a projected point = %v, want the tilted panel", got)
+	}
+
+	ui.Draw(ebiten.NewImage(400, 300))
+}
@@ -151,6 +151,12 @@ type Style struct {
 	PaddingSet bool    `json:"-"` // true if padding was explicitly set (allows zero override)
 	MarginSet  bool    `json:"-"` // true if margin was explicitly set (allows zero override)
 
+	// Logical inline padding, resolved to Padding.Left/Right by TextDirection
+	PaddingInlineStart    float64 `json:"paddingInlineStart"`
+	PaddingInlineStartSet bool    `json:"-"`
+	PaddingInlineEnd      float64 `json:"paddingInlineEnd"`
+	PaddingInlineEndSet   bool    `json:"-"`
+
 	// Colors
 	BackgroundColor color.Color `json:"-"`
 	BorderColor     color.Color `json:"-"`
@@ -191,20 +197,49 @@ type Style struct {
 	BorderBottomRightRadius    float64     `json:"borderBottomRightRadius"`
 	BorderBottomRightRadiusSet bool        `json:"-"` // true if borderBottomRightRadius was explicitly set (allows zero override)
 
+	// Border line styles: solid (default), dashed, dotted, double, groove,
+	// ridge, inset, outset, none, hidden. Per-side values override BorderStyle.
+	BorderStyle       string `json:"borderStyle"`
+	BorderTopStyle    string `json:"borderTopStyle"`
+	BorderRightStyle  string `json:"borderRightStyle"`
+	BorderBottomStyle string `json:"borderBottomStyle"`
+	BorderLeftStyle   string `json:"borderLeftStyle"`
+
 	// Text
 	FontSize         float64 `json:"fontSize"`
 	FontSizeSet      bool    `json:"-"` // true if fontSize was explicitly set (allows zero override)
+	fontSizeScale    float64 // relative font-size (larger, smaller, em, %) of the inherited size
 	FontFamily       string  `json:"fontFamily"`
 	FontWeight       string  `json:"fontWeight"`    // normal, bold, 100-900
-	FontStyle        string  `json:"fontStyle"`     // normal, italic
+	FontStyle        string  `json:"fontStyle"`     // normal, italic, oblique
 	TextAlign        string  `json:"textAlign"`     // left, center, right
 	VerticalAlign    string  `json:"verticalAlign"` // top, center, bottom
 	LineHeight       float64 `json:"lineHeight"`
 	LineHeightSet    bool    `json:"-"` // true if lineHeight was explicitly set (allows zero override)
 	LetterSpacing    float64 `json:"letterSpacing"`
-	LetterSpacingSet bool    `json:"-"`            // true if letterSpacing was explicitly set (allows zero override)
-	TextWrap         string  `json:"textWrap"`     // normal, nowrap
-	TextOverflow     string  `json:"textOverflow"` // clip, ellipsis
+	LetterSpacingSet bool    `json:"-"`             // true if letterSpacing was explicitly set (allows zero override)
+	TextWrap         string  `json:"textWrap"`      // normal, nowrap
+	TextOverflow     string  `json:"textOverflow"`  // clip, ellipsis
+	WhiteSpace       string  `json:"whiteSpace"`    // normal, nowrap, pre, pre-wrap, pre-line
+	WordBreak        string  `json:"wordBreak"`     // normal, break-all, keep-all, break-word
+	OverflowWrap     string  `json:"overflowWrap"`  // normal, anywhere, break-word; unset breaks long words
+	TabSize          float64 `json:"tabSize"`       // tab stop spacing in spaces, 0 for 8
+	LineClamp        int     `json:"lineClamp"`     // maximum lines, the last ellipsized; 0 for none
+	TextTransform    string  `json:"textTransform"` // none, uppercase, lowercase, capitalize
+
+	// Text decoration lines: any of underline, overline and line-through, or
+	// none. The color defaults to the text color and the thickness to the
+	// font's.
+	TextDecoration          string  `json:"textDecoration"`
+	TextDecorationColor     string  `json:"textDecorationColor"`
+	TextDecorationThickness float64 `json:"textDecorationThickness"`
+
+	// Variable font axes, e.g. "wght" 650, "wdth" 80
+	FontVariationSettings string `json:"fontVariationSettings"`
+
+	// CSS direction: ltr or rtl. Unset text takes its bidi base direction
+	// from its first strong character but lays out left to right.
+	TextDirection string `json:"textDirection"`
 
 	// Visual Effects
 	Opacity    float64 `json:"opacity"`    // 0-1
@@ -221,23 +256,50 @@ type Style struct {
 	OutlineOffsetSet bool    `json:"-"`             // true if outlineOffset was explicitly set (allows zero override)
 
 	// Filters
-	Filter         string `json:"filter"`         // blur(), brightness(), etc.
+	Filter         string `json:"filter"`         // blur(), brightness(), shader(), etc.
 	BackdropFilter string `json:"backdropFilter"` // for glassmorphism
 
+	// Surface effects
+	Ripple   string `json:"ripple"`   // none or "<color> [<duration>]", an ink ripple from the press point
+	Skeleton string `json:"skeleton"` // none or "shimmer [<color>] [<duration>]", a loading placeholder sweep
+
+	// Custom shaders
+	BackgroundShader string            `json:"backgroundShader"` // name or shader(name, uniform=value, ...)
+	ShaderUniforms   map[string]string `json:"shaderUniforms"`   // bound uniform values, override CSS ones
+
 	// Transform
 	Transform       string `json:"transform"`       // rotate(), scale(), translate()
 	TransformOrigin string `json:"transformOrigin"` // center, top left, etc.
 
+	// 3D transforms
+	Perspective        float64 `json:"perspective"`        // viewer distance for children's 3D transforms; 0 is none
+	PerspectiveOrigin  string  `json:"perspectiveOrigin"`  // like transformOrigin, default center
+	TransformStyle     string  `json:"transformStyle"`     // flat, preserve-3d
+	BackfaceVisibility string  `json:"backfaceVisibility"` // visible, hidden
+
 	// Clip Path
 	ClipPath string `json:"clipPath"` // circle(), polygon(), inset(), path()
 
+	// Hit testing
+	PointerEvents string `json:"pointerEvents"` // auto, none; inherited
+	UserSelect    string `json:"userSelect"`    // auto, none, text; inherited. text makes Text and Button labels selectable
+
+	// Compositing
+	MixBlendMode string `json:"mixBlendMode"` // normal, multiply, screen, overlay, plus-lighter, ...
+	MaskImage    string `json:"maskImage"`    // comma-separated url(...) or gradient alpha masks
+	MaskSize     string `json:"maskSize"`     // like backgroundSize, per mask layer
+	MaskPosition string `json:"maskPosition"` // like backgroundPosition, per mask layer
+	MaskRepeat   string `json:"maskRepeat"`   // like backgroundRepeat, per mask layer
+
 	// 9-Slice Image
 	BackgroundImage    string `json:"backgroundImage"`    // image path
 	BorderImage        string `json:"borderImage"`        // image path for 9-slice
-	BorderImageSlice   string `json:"borderImageSlice"`   // "top right bottom left"
+	BorderImageSlice   string `json:"borderImageSlice"`   // "top right bottom left [fill]", numbers or percentages
+	BorderImageWidth   string `json:"borderImageWidth"`   // multiples of border width, lengths or auto
+	BorderImageRepeat  string `json:"borderImageRepeat"`  // stretch, repeat, round, space (horizontal [vertical])
 	BackgroundSize     string `json:"backgroundSize"`     // cover, contain, or dimensions
 	BackgroundPosition string `json:"backgroundPosition"` // center, top left, etc.
-	BackgroundRepeat   string `json:"backgroundRepeat"`   // repeat, no-repeat
+	BackgroundRepeat   string `json:"backgroundRepeat"`   // repeat, repeat-x, repeat-y, no-repeat, round, space
 
 	// Overflow
 	Overflow  string `json:"overflow"` // visible, hidden, scroll
@@ -261,6 +323,25 @@ type Style struct {
 	Display    string `json:"display"`    // block, flex, none
 	Visibility string `json:"visibility"` // visible, hidden
 
+	// Container queries
+	ContainerType string `json:"containerType"` // normal, size, inline-size
+	ContainerName string `json:"containerName"` // space-separated names matched by @container
+
+	// Generated content (::before / ::after)
+	Content          string `json:"content"`          // CSS content value: strings, attr(), counter(), counters(), none
+	CounterReset     string `json:"counterReset"`     // "name [int]"... or none
+	CounterIncrement string `json:"counterIncrement"` // "name [int]"... or none
+
+	// Lists
+	ListStyleType     string `json:"listStyleType"`     // disc, circle, square, decimal, lower-roman, upper-alpha, "string", none
+	ListStylePosition string `json:"listStylePosition"` // outside, inside
+	ListStyleImage    string `json:"listStyleImage"`    // url(name) of a registered image
+
+	// Pseudo-elements
+	BeforeStyle *Style `json:"before"`
+	AfterStyle  *Style `json:"after"`
+	MarkerStyle *Style `json:"marker"`
+
 	// States
 	HoverStyle    *Style `json:"hover"`
 	ActiveStyle   *Style `json:"active"`
@@ -268,18 +349,24 @@ type Style struct {
 	FocusStyle    *Style `json:"focus"`
 
 	// Parsed values (internal)
-	parsedBoxShadow      *BoxShadow      `json:"-"`
-	parsedBoxShadows     []*BoxShadow    `json:"-"`
-	parsedTextShadow     *TextShadow     `json:"-"`
-	parsedTextShadows    []*TextShadow   `json:"-"`
-	parsedOutline        *Outline        `json:"-"`
-	parsedTransitions    []Transition    `json:"-"`
-	parsed9Slice         *NineSlice      `json:"-"`
-	parsedGradient       *Gradient       `json:"-"`
-	parsedFilter         *Filter         `json:"-"`
-	parsedBackdropFilter *BackdropFilter `json:"-"`
-	parsedTransform      *Transform      `json:"-"`
-	parsedAnimation      *Animation      `json:"-"`
+	parsedBoxShadow        *BoxShadow         `json:"-"`
+	parsedBoxShadows       []*BoxShadow       `json:"-"`
+	parsedTextShadow       *TextShadow        `json:"-"`
+	parsedTextShadows      []*TextShadow      `json:"-"`
+	parsedOutline          *Outline           `json:"-"`
+	parsedTransitions      []Transition       `json:"-"`
+	parsed9Slice           *NineSlice         `json:"-"`
+	parsedBorderImage      *ebiten.Image      `json:"-"`
+	parsedGradient         *Gradient          `json:"-"`
+	parsedFilter           *Filter            `json:"-"`
+	parsedBackdropFilter   *BackdropFilter    `json:"-"`
+	parsedBackgroundShader *ShaderEffect      `json:"-"`
+	parsedRipple           *rippleSpec        `json:"-"`
+	parsedSkeleton         *skeletonSpec      `json:"-"`
+	parsedTransform        *Transform         `json:"-"`
+	parsedAnimation        *Animation         `json:"-"`
+	parsedBackgroundLayers []*backgroundLayer `json:"-"` // top layer first
+	parsedMaskLayers       []*backgroundLayer `json:"-"` // top layer first
 }
 
 // Clone creates a deep copy of the style
@@ -300,6 +387,15 @@ func (s *Style) Clone() *Style {
 	if s.FocusStyle != nil {
 		copy.FocusStyle = s.FocusStyle.Clone()
 	}
+	if s.BeforeStyle != nil {
+		copy.BeforeStyle = s.BeforeStyle.Clone()
+	}
+	if s.AfterStyle != nil {
+		copy.AfterStyle = s.AfterStyle.Clone()
+	}
+	if s.MarkerStyle != nil {
+		copy.MarkerStyle = s.MarkerStyle.Clone()
+	}
 	return &copy
 }
 
@@ -369,6 +465,14 @@ func (s *Style) Merge(other *Style) {
 		s.Padding = other.Padding
 		s.PaddingSet = true
 	}
+	if other.PaddingInlineStartSet {
+		s.PaddingInlineStart = other.PaddingInlineStart
+		s.PaddingInlineStartSet = true
+	}
+	if other.PaddingInlineEndSet {
+		s.PaddingInlineEnd = other.PaddingInlineEnd
+		s.PaddingInlineEndSet = true
+	}
 	if other.MarginSet || other.Margin.Top != 0 || other.Margin.Right != 0 || other.Margin.Bottom != 0 || other.Margin.Left != 0 {
 		s.Margin = other.Margin
 		s.MarginSet = true
@@ -464,11 +568,27 @@ func (s *Style) Merge(other *Style) {
 		s.BorderBottomRightRadius = other.BorderBottomRightRadius
 		s.BorderBottomRightRadiusSet = other.BorderBottomRightRadiusSet
 	}
+	if other.BorderStyle != "" {
+		s.BorderStyle = other.BorderStyle
+	}
+	if other.BorderTopStyle != "" {
+		s.BorderTopStyle = other.BorderTopStyle
+	}
+	if other.BorderRightStyle != "" {
+		s.BorderRightStyle = other.BorderRightStyle
+	}
+	if other.BorderBottomStyle != "" {
+		s.BorderBottomStyle = other.BorderBottomStyle
+	}
+	if other.BorderLeftStyle != "" {
+		s.BorderLeftStyle = other.BorderLeftStyle
+	}
 
 	// Text
 	if other.FontSizeSet || other.FontSize != 0 {
 		s.FontSize = other.FontSize
 		s.FontSizeSet = other.FontSizeSet
+		s.fontSizeScale = other.fontSizeScale
 	}
 	if other.FontFamily != "" {
 		s.FontFamily = other.FontFamily
@@ -486,12 +606,45 @@ func (s *Style) Merge(other *Style) {
 	if other.TextOverflow != "" {
 		s.TextOverflow = other.TextOverflow
 	}
+	if other.WhiteSpace != "" {
+		s.WhiteSpace = other.WhiteSpace
+	}
+	if other.WordBreak != "" {
+		s.WordBreak = other.WordBreak
+	}
+	if other.OverflowWrap != "" {
+		s.OverflowWrap = other.OverflowWrap
+	}
+	if other.TabSize != 0 {
+		s.TabSize = other.TabSize
+	}
+	if other.LineClamp != 0 {
+		s.LineClamp = other.LineClamp
+	}
+	if other.TextTransform != "" {
+		s.TextTransform = other.TextTransform
+	}
+	if other.TextDecoration != "" {
+		s.TextDecoration = other.TextDecoration
+	}
+	if other.TextDecorationColor != "" {
+		s.TextDecorationColor = other.TextDecorationColor
+	}
+	if other.TextDecorationThickness != 0 {
+		s.TextDecorationThickness = other.TextDecorationThickness
+	}
 	if other.FontWeight != "" {
 		s.FontWeight = other.FontWeight
 	}
 	if other.FontStyle != "" {
 		s.FontStyle = other.FontStyle
 	}
+	if other.FontVariationSettings != "" {
+		s.FontVariationSettings = other.FontVariationSettings
+	}
+	if other.TextDirection != "" {
+		s.TextDirection = other.TextDirection
+	}
 	if other.VerticalAlign != "" {
 		s.VerticalAlign = other.VerticalAlign
 	}
@@ -542,6 +695,32 @@ func (s *Style) Merge(other *Style) {
 		s.parsedBackdropFilter = other.parsedBackdropFilter
 	}
 
+	// Surface effects
+	if other.Ripple != "" {
+		s.Ripple = other.Ripple
+		s.parsedRipple = other.parsedRipple
+	}
+	if other.Skeleton != "" {
+		s.Skeleton = other.Skeleton
+		s.parsedSkeleton = other.parsedSkeleton
+	}
+
+	// Custom shaders
+	if other.BackgroundShader != "" {
+		s.BackgroundShader = other.BackgroundShader
+		s.parsedBackgroundShader = other.parsedBackgroundShader
+	}
+	if len(other.ShaderUniforms) > 0 {
+		uniforms := make(map[string]string, len(s.ShaderUniforms)+len(other.ShaderUniforms))
+		for key, value := range s.ShaderUniforms {
+			uniforms[key] = value
+		}
+		for key, value := range other.ShaderUniforms {
+			uniforms[key] = value
+		}
+		s.ShaderUniforms = uniforms
+	}
+
 	// Transform
 	if other.Transform != "" {
 		s.Transform = other.Transform
@@ -550,22 +729,66 @@ func (s *Style) Merge(other *Style) {
 	if other.TransformOrigin != "" {
 		s.TransformOrigin = other.TransformOrigin
 	}
+	if other.Perspective != 0 {
+		s.Perspective = other.Perspective
+	}
+	if other.PerspectiveOrigin != "" {
+		s.PerspectiveOrigin = other.PerspectiveOrigin
+	}
+	if other.TransformStyle != "" {
+		s.TransformStyle = other.TransformStyle
+	}
+	if other.BackfaceVisibility != "" {
+		s.BackfaceVisibility = other.BackfaceVisibility
+	}
 
 	// Clip Path
 	if other.ClipPath != "" {
 		s.ClipPath = other.ClipPath
 	}
+	if other.PointerEvents != "" {
+		s.PointerEvents = other.PointerEvents
+	}
+	if other.UserSelect != "" {
+		s.UserSelect = other.UserSelect
+	}
+
+	// Compositing
+	if other.MixBlendMode != "" {
+		s.MixBlendMode = other.MixBlendMode
+	}
+	if other.MaskImage != "" {
+		s.MaskImage = other.MaskImage
+		s.parsedMaskLayers = other.parsedMaskLayers
+	}
+	if other.MaskSize != "" {
+		s.MaskSize = other.MaskSize
+	}
+	if other.MaskPosition != "" {
+		s.MaskPosition = other.MaskPosition
+	}
+	if other.MaskRepeat != "" {
+		s.MaskRepeat = other.MaskRepeat
+	}
 
 	// 9-Slice
 	if other.BackgroundImage != "" {
 		s.BackgroundImage = other.BackgroundImage
+		s.parsedBackgroundLayers = other.parsedBackgroundLayers
 	}
 	if other.BorderImage != "" {
 		s.BorderImage = other.BorderImage
+		s.parsedBorderImage = other.parsedBorderImage
 	}
 	if other.BorderImageSlice != "" {
 		s.BorderImageSlice = other.BorderImageSlice
 	}
+	if other.BorderImageWidth != "" {
+		s.BorderImageWidth = other.BorderImageWidth
+	}
+	if other.BorderImageRepeat != "" {
+		s.BorderImageRepeat = other.BorderImageRepeat
+	}
 	if other.BackgroundSize != "" {
 		s.BackgroundSize = other.BackgroundSize
 	}
@@ -614,6 +837,56 @@ func (s *Style) Merge(other *Style) {
 		s.Visibility = other.Visibility
 	}
 
+	// Container queries
+	if other.ContainerType != "" {
+		s.ContainerType = other.ContainerType
+	}
+	if other.ContainerName != "" {
+		s.ContainerName = other.ContainerName
+	}
+
+	// Generated content
+	if other.Content != "" {
+		s.Content = other.Content
+	}
+	if other.CounterReset != "" {
+		s.CounterReset = other.CounterReset
+	}
+	if other.CounterIncrement != "" {
+		s.CounterIncrement = other.CounterIncrement
+	}
+
+	// Lists
+	if other.ListStyleType != "" {
+		s.ListStyleType = other.ListStyleType
+	}
+	if other.ListStylePosition != "" {
+		s.ListStylePosition = other.ListStylePosition
+	}
+	if other.ListStyleImage != "" {
+		s.ListStyleImage = other.ListStyleImage
+	}
+
+	// Pseudo-elements
+	if other.BeforeStyle != nil {
+		if s.BeforeStyle == nil {
+			s.BeforeStyle = &Style{}
+		}
+		s.BeforeStyle.Merge(other.BeforeStyle)
+	}
+	if other.AfterStyle != nil {
+		if s.AfterStyle == nil {
+			s.AfterStyle = &Style{}
+		}
+		s.AfterStyle.Merge(other.AfterStyle)
+	}
+	if other.MarkerStyle != nil {
+		if s.MarkerStyle == nil {
+			s.MarkerStyle = &Style{}
+		}
+		s.MarkerStyle.Merge(other.MarkerStyle)
+	}
+
 	// States
 	if other.HoverStyle != nil {
 		if s.HoverStyle == nil {
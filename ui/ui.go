@@ -1,10 +1,13 @@
 package ui
 
 import (
+	"fmt"
+	"os"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 	"unicode/utf8"
 
 	"github.com/hajimehoshi/ebiten/v2"
@@ -25,11 +28,14 @@ type UI struct {
 	DefaultFontFace text.Face              // For GoXFace (bitmap fonts)
 
 	// Font caches (lazily initialised)
-	fontCache       *FontCache // regular weight
-	boldFontCache   *FontCache // bold weight
-	fontFaces       map[string]text.Face
-	fontSources     map[string]*FontCache
-	boldFontSources map[string]*FontCache
+	fontCache     *FontCache // regular weight
+	boldFontCache *FontCache // bold weight
+	fontFaces     map[string]text.Face
+	fontVariants  map[string][]fontVariant          // scalable faces by family
+	fontFiles     map[string]*text.GoTextFaceSource // @font-face sources by asset name
+	fontFallbacks []string                          // UI-wide fallback families
+	fontChains    map[string]*fontChainFace         // MultiFace chains by member faces
+	fontPass      int                               // counts setFonts passes over the tree
 
 	// Dimensions
 	width, height float64
@@ -42,6 +48,15 @@ type UI struct {
 	modalRestore  Widget
 	modalStack    []modalFocusState
 
+	// Read-only text selection and multi-click tracking
+	selectedText    selectableText // widget holding the text selection
+	selectingText   bool           // a drag is extending the selection
+	lastClickWidget Widget
+	lastClickTime   time.Time
+	lastClickX      float64
+	lastClickY      float64
+	clickCount      int
+
 	// Widget lookup cache
 	widgetByID map[string]Widget
 
@@ -55,6 +70,23 @@ type UI struct {
 	viewportWidth  float64
 	viewportHeight float64
 	rootFontSize   float64
+
+	// Last evaluated @media/@container results, used to detect breakpoint changes
+	conditionalSignature string
+
+	// Widgets with ::before/::after rules, checked for state-dependent content
+	pseudoHosts []Widget
+
+	// Images registered or loaded by name; nil marks a failed load
+	images        map[string]*ebiten.Image
+	assetResolver AssetResolver
+
+	// Texture atlases for "atlas:" image references, in registration order
+	atlases    map[string]*Atlas
+	atlasOrder []string
+
+	// Kage shaders for filter: shader() and background-shader, by name
+	shaders map[string]*customShader
 }
 
 type modalFocusState struct {
@@ -67,18 +99,21 @@ func New(width, height float64) *UI {
 	styleEngine := NewStyleEngine()
 	bindings := NewBindingContext()
 	manager := &UI{
-		styleEngine:     styleEngine,
-		layoutEngine:    NewLayoutEngine(),
-		factory:         NewWidgetFactory(styleEngine, bindings),
-		width:           width,
-		height:          height,
-		widgetByID:      make(map[string]Widget),
-		variables:       NewCSSVariables(),
-		bindings:        bindings,
-		rootFontSize:    16, // Default browser root font size
-		fontFaces:       make(map[string]text.Face),
-		fontSources:     make(map[string]*FontCache),
-		boldFontSources: make(map[string]*FontCache),
+		styleEngine:  styleEngine,
+		layoutEngine: NewLayoutEngine(),
+		factory:      NewWidgetFactory(styleEngine, bindings),
+		width:        width,
+		height:       height,
+		widgetByID:   make(map[string]Widget),
+		variables:    NewCSSVariables(),
+		bindings:     bindings,
+		rootFontSize: 16, // Default browser root font size
+		fontFaces:    make(map[string]text.Face),
+		fontVariants: make(map[string][]fontVariant),
+		fontFiles:    make(map[string]*text.GoTextFaceSource),
+		fontChains:   make(map[string]*fontChainFace),
+		images:       make(map[string]*ebiten.Image),
+		shaders:      make(map[string]*customShader),
 	}
 	manager.factory.onTreeChanged = manager.refreshDynamicTree
 	manager.factory.onLayoutChanged = manager.refreshDynamicLayout
@@ -97,22 +132,42 @@ func (ui *UI) RegisterFontFace(family string, face text.Face) {
 	ui.fontFaces[name] = face
 }
 
-// RegisterFontSource registers a scalable Go text font source for a CSS font-family name.
+// RegisterFontSource registers a scalable Go text font source for a CSS
+// font-family name as its normal (400) weight.
 func (ui *UI) RegisterFontSource(family string, source *text.GoTextFaceSource) {
-	name := normalizeFontFamilyName(family)
-	if name == "" || source == nil {
+	if source == nil {
 		return
 	}
-	ui.fontSources[name] = NewFontCache(source)
+	ui.addFontVariant(family, fontVariant{weightMin: 400, weightMax: 400, style: "normal", cache: NewFontCache(source)})
 }
 
-// RegisterBoldFontSource registers a scalable bold source for a CSS font-family name.
+// RegisterBoldFontSource registers a scalable bold (700) source for a CSS
+// font-family name.
 func (ui *UI) RegisterBoldFontSource(family string, source *text.GoTextFaceSource) {
-	name := normalizeFontFamilyName(family)
-	if name == "" || source == nil {
+	if source == nil {
 		return
 	}
-	ui.boldFontSources[name] = NewFontCache(source)
+	ui.addFontVariant(family, fontVariant{weightMin: 700, weightMax: 700, style: "normal", cache: NewFontCache(source)})
+}
+
+// SetFontFallbacks sets the registered families tried, in order, for
+// characters that none of a widget's font-family list can draw, such as CJK,
+// symbol and emoji fonts behind a Latin UI font.
+func (ui *UI) SetFontFallbacks(families ...string) {
+	ui.fontFallbacks = append([]string(nil), families...)
+}
+
+// RegisterImage registers an image under a name that CSS url(...) values and
+// <image src> can reference, taking precedence over the asset resolver.
+func (ui *UI) RegisterImage(name string, img *ebiten.Image) {
+	if name == "" {
+		return
+	}
+	if img == nil {
+		delete(ui.images, name)
+		return
+	}
+	ui.images[name] = img
 }
 
 // SetRoot sets the root widget directly and runs the normal style/font/layout pipeline.
@@ -163,162 +218,50 @@ func (ui *UI) LoadStyles(jsonContent string) error {
 		ui.reapplyStyles(ui.root)
 		ui.inheritCSSProperties(ui.root, nil)
 		ui.setFonts(ui.root)
+		ui.loadImageAssets(ui.root)
 		ui.Layout()
 	}
 
 	return nil
 }
 
-// LoadCSS loads literal CSS subset content such as @keyframes blocks.
+// LoadCSS loads a CSS stylesheet of rule blocks, @keyframes, @media,
+// @container and @font-face rules. Font sources load through the asset
+// resolver. Conditional rules are retained and re-evaluated on Resize and
+// after layout. Syntax errors are returned as a *CSSParseError carrying
+// line/column diagnostics; warnings are available from CSSDiagnostics.
 func (ui *UI) LoadCSS(cssContent string) error {
-	css := ui.variables.Resolve(cssContent)
-	css = ui.expandCSSMediaQueries(css)
-	if err := ui.styleEngine.LoadCSS(css); err != nil {
-		return err
-	}
-	if ui.root != nil {
-		ui.reapplyStyles(ui.root)
-		ui.inheritCSSProperties(ui.root, nil)
-		ui.setFonts(ui.root)
-		ui.Layout()
-	}
-	return nil
+	return ui.loadCSS(cssContent, "")
 }
 
-func (ui *UI) expandCSSMediaQueries(css string) string {
-	var out strings.Builder
-	remaining := css
-	for {
-		idx := strings.Index(remaining, "@media")
-		if idx < 0 {
-			out.WriteString(remaining)
-			break
-		}
-		out.WriteString(remaining[:idx])
-		media := remaining[idx:]
-		open := strings.Index(media, "{")
-		if open < 0 {
-			out.WriteString(media)
-			break
-		}
-		condition := strings.TrimSpace(strings.TrimPrefix(media[:open], "@media"))
-		closeIdx := matchingCSSBlockEnd(media, open)
-		if closeIdx < 0 {
-			out.WriteString(media)
-			break
-		}
-		body := media[open+1 : closeIdx]
-		if ui.matchesCSSMediaCondition(condition) {
-			out.WriteString(body)
-		}
-		remaining = media[closeIdx+1:]
-	}
-	return out.String()
-}
-
-func (ui *UI) matchesCSSMediaCondition(condition string) bool {
-	for _, query := range splitCSSMediaQueryList(condition) {
-		if ui.matchesCSSMediaQuery(query) {
-			return true
-		}
-	}
-	return false
-}
-
-func (ui *UI) matchesCSSMediaQuery(query string) bool {
-	query = strings.TrimSpace(strings.ToLower(query))
-	if query == "" {
-		return false
-	}
-
-	parts := regexp.MustCompile(`\s+and\s+`).Split(query, -1)
-	hasFeature := false
-	for i, part := range parts {
-		part = strings.TrimSpace(part)
-		if part == "" {
-			return false
-		}
-		if i == 0 && !strings.HasPrefix(part, "(") {
-			switch part {
-			case "all", "screen":
-				continue
-			default:
-				return false
-			}
-		}
-		hasFeature = true
-		if !ui.matchesCSSMediaFeature(part) {
-			return false
-		}
+// LoadCSSFile loads a CSS stylesheet from disk. Diagnostics name the file.
+func (ui *UI) LoadCSSFile(filename string) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("failed to read style file: %w", err)
 	}
-	return hasFeature || parts[0] == "all" || parts[0] == "screen"
+	return ui.loadCSS(string(data), filename)
 }
 
-func (ui *UI) matchesCSSMediaFeature(feature string) bool {
-	if match := regexp.MustCompile(`^\(\s*(min|max)-(width|height)\s*:\s*([0-9.]+)px\s*\)$`).FindStringSubmatch(feature); match != nil {
-		value, err := strconv.ParseFloat(match[3], 64)
-		if err != nil {
-			return false
-		}
-		actual := ui.width
-		if match[2] == "height" {
-			actual = ui.height
-		}
-		switch match[1] {
-		case "min":
-			return actual >= value
-		case "max":
-			return actual <= value
-		}
-	}
-
-	if match := regexp.MustCompile(`^\(\s*orientation\s*:\s*(landscape|portrait)\s*\)$`).FindStringSubmatch(feature); match != nil {
-		orientation := "portrait"
-		if ui.width >= ui.height {
-			orientation = "landscape"
-		}
-		return orientation == match[1]
-	}
-
-	return false
+// CSSDiagnostics returns the warnings and errors from the most recent CSS load.
+func (ui *UI) CSSDiagnostics() []CSSDiagnostic {
+	return ui.styleEngine.Diagnostics()
 }
 
-func splitCSSMediaQueryList(condition string) []string {
-	var queries []string
-	start := 0
-	depth := 0
-	for i := 0; i < len(condition); i++ {
-		switch condition[i] {
-		case '(':
-			depth++
-		case ')':
-			if depth > 0 {
-				depth--
-			}
-		case ',':
-			if depth == 0 {
-				queries = append(queries, condition[start:i])
-				start = i + 1
-			}
-		}
+func (ui *UI) loadCSS(cssContent, file string) error {
+	if err := ui.styleEngine.loadCSS(cssContent, file, ui.variables.Resolve); err != nil {
+		return err
 	}
-	return append(queries, condition[start:])
-}
-
-func matchingCSSBlockEnd(s string, open int) int {
-	depth := 0
-	for i := open; i < len(s); i++ {
-		switch s[i] {
-		case '{':
-			depth++
-		case '}':
-			depth--
-			if depth == 0 {
-				return i
-			}
-		}
+	fontDiagnostics := ui.loadFontFaces(ui.styleEngine.fontFaces, file)
+	ui.styleEngine.diagnostics = append(ui.styleEngine.diagnostics, fontDiagnostics...)
+	if ui.root != nil {
+		ui.reapplyStyles(ui.root)
+		ui.inheritCSSProperties(ui.root, nil)
+		ui.setFonts(ui.root)
+		ui.loadImageAssets(ui.root)
+		ui.Layout()
 	}
-	return -1
+	return nil
 }
 
 // LoadStylesFile loads styles from a JSON file
@@ -332,6 +275,7 @@ func (ui *UI) LoadStylesFile(filename string) error {
 		ui.reapplyStyles(ui.root)
 		ui.inheritCSSProperties(ui.root, nil)
 		ui.setFonts(ui.root)
+		ui.loadImageAssets(ui.root)
 		ui.Layout()
 	}
 
@@ -341,11 +285,13 @@ func (ui *UI) LoadStylesFile(filename string) error {
 // Layout recalculates the layout
 func (ui *UI) Layout() {
 	if ui.root != nil {
+		ui.generatePseudoElements(ui.root)
 		ui.layoutEngine.Layout(ui.root, ui.width, ui.height)
+		ui.refreshConditionalStyles()
 	}
 }
 
-// Resize updates the UI dimensions
+// Resize updates the UI dimensions, switching @media breakpoints as needed.
 func (ui *UI) Resize(width, height float64) {
 	ui.width = width
 	ui.height = height
@@ -403,14 +349,16 @@ func (ui *UI) Update() {
 
 	// Handle clicks
 	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		clicks := ui.countClick(hoveredWidget, mouseX, mouseY)
+		shift := ebiten.IsKeyPressed(ebiten.KeyShift)
 		if hoveredWidget != nil {
 			switch w := hoveredWidget.(type) {
 			case *TextInput:
 				ui.setFocusedWidget(hoveredWidget)
-				w.HandlePointerDown(mouseX, mouseY)
+				w.handlePointerPress(mouseX, mouseY, clicks, shift)
 			case *TextArea:
 				ui.setFocusedWidget(hoveredWidget)
-				w.HandlePointerDown(mouseX, mouseY)
+				w.handlePointerPress(mouseX, mouseY, clicks, shift)
 			default:
 				if ui.focusedWidget != nil && ui.focusedWidget != hoveredWidget {
 					ui.Blur()
@@ -418,9 +366,14 @@ func (ui *UI) Update() {
 			}
 			hoveredWidget.SetState(StateActive)
 			ui.activeWidget = hoveredWidget
+			ui.startRipple(hoveredWidget, mouseX, mouseY)
 		} else {
 			ui.Blur()
 		}
+		ui.beginTextSelection(hoveredWidget, mouseX, mouseY, clicks)
+	}
+	if ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
+		ui.dragTextSelection(mouseX, mouseY)
 	}
 
 	if ui.activeWidget != nil && ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
@@ -434,12 +387,15 @@ func (ui *UI) Update() {
 	}
 
 	if inpututil.IsMouseButtonJustReleased(ebiten.MouseButtonLeft) {
+		ui.endTextSelection()
 		if ui.activeWidget != nil {
 			if ui.activeWidget == hoveredWidget {
 				// Click confirmed - handle all widget types
 				switch w := ui.activeWidget.(type) {
 				case *Button:
 					w.HandleClick()
+				case *Text:
+					w.HandleClick(mouseX, mouseY)
 				case *Panel:
 					w.HandleClick()
 				case *Toggle:
@@ -471,22 +427,29 @@ func (ui *UI) Update() {
 	}
 
 	ui.handleRuntimeKeyboard()
+	ui.updateSprites(ui.root)
+	ui.refreshPseudoElementStates()
 }
 
 // SimulatePointerMove updates hover state as if the pointer moved.
 func (ui *UI) SimulatePointerMove(x, y float64) Widget {
-	return ui.handlePointerMove(x, y)
+	hovered := ui.handlePointerMove(x, y)
+	ui.refreshPseudoElementStates()
+	return hovered
 }
 
 // SimulatePointerDown presses a pointer button at the given coordinates.
 func (ui *UI) SimulatePointerDown(x, y float64, button ebiten.MouseButton) Widget {
-	return ui.handlePointerDown(x, y, button)
+	pressed := ui.handlePointerDown(x, y, button, false)
+	ui.refreshPseudoElementStates()
+	return pressed
 }
 
 // SimulatePointerUp releases a pointer button at the given coordinates.
 func (ui *UI) SimulatePointerUp(x, y float64, button ebiten.MouseButton) Widget {
 	hovered := ui.handlePointerMove(x, y)
 	ui.handlePointerUp(x, y, button, hovered)
+	ui.refreshPseudoElementStates()
 	return hovered
 }
 
@@ -497,6 +460,15 @@ func (ui *UI) SimulateClick(x, y float64) Widget {
 	return hovered
 }
 
+// SimulateShiftClick performs a left click with Shift held, which extends
+// the selection of a text input or text area to the clicked point.
+func (ui *UI) SimulateShiftClick(x, y float64) Widget {
+	hovered := ui.handlePointerDown(x, y, ebiten.MouseButtonLeft, true)
+	ui.refreshPseudoElementStates()
+	ui.handlePointerUp(x, y, ebiten.MouseButtonLeft, hovered)
+	return hovered
+}
+
 // SimulateTypeText inserts text into the currently focused text input or text area.
 func (ui *UI) SimulateTypeText(s string) {
 	switch w := ui.focusedWidget.(type) {
@@ -511,6 +483,42 @@ func (ui *UI) SimulateTypeText(s string) {
 	}
 }
 
+// SimulateComposition shows preedit as IME composition text in the focused
+// text input or text area, in place of its selection and with the caret at
+// rune caret within it, as while a CJK input method composes. An empty
+// preedit cancels the composition. It returns the caret's rectangle, where
+// the candidate window opens.
+func (ui *UI) SimulateComposition(preedit string, caret int) Rect {
+	switch w := ui.focusedWidget.(type) {
+	case *TextInput:
+		if !w.ReadOnly {
+			w.setComposition(preedit, caret)
+		}
+		return w.caretRect()
+	case *TextArea:
+		if !w.ReadOnly {
+			w.setComposition(preedit, caret)
+		}
+		return w.caretRect()
+	}
+	return Rect{}
+}
+
+// SimulateCompositionCommit commits text from the input method to the
+// focused text input or text area, replacing the preedit.
+func (ui *UI) SimulateCompositionCommit(text string) {
+	switch w := ui.focusedWidget.(type) {
+	case *TextInput:
+		if !w.ReadOnly {
+			w.commitComposition(text)
+		}
+	case *TextArea:
+		if !w.ReadOnly {
+			w.commitComposition(text)
+		}
+	}
+}
+
 // SimulateKeyPress dispatches a keyboard event to the focused widget.
 func (ui *UI) SimulateKeyPress(key ebiten.Key, shift, control bool) {
 	ui.handleKeyPress(key, shift, control)
@@ -536,6 +544,9 @@ func (ui *UI) handleRuntimeKeyboard() {
 			ui.handleKeyPress(key, shift, control)
 		}
 	}
+	if control && ui.selectedText != nil && inpututil.IsKeyJustPressed(ebiten.KeyC) {
+		ui.handleKeyPress(ebiten.KeyC, shift, control)
+	}
 }
 
 func (ui *UI) handleKeyPress(key ebiten.Key, shift, control bool) {
@@ -555,6 +566,9 @@ func (ui *UI) handleKeyPress(key ebiten.Key, shift, control bool) {
 			return
 		}
 	}
+	if control && key == ebiten.KeyC && ui.copyTextSelection() {
+		return
+	}
 	switch w := ui.focusedWidget.(type) {
 	case *TextInput:
 		simulateTextInputKeyPress(w, key, shift, control)
@@ -707,6 +721,15 @@ func (ui *UI) GetText(id string) *Text {
 	return nil
 }
 
+// GetSprite returns a sprite by ID
+func (ui *UI) GetSprite(id string) *Sprite {
+	w := ui.widgetByID[id]
+	if s, ok := w.(*Sprite); ok {
+		return s
+	}
+	return nil
+}
+
 // QueryByClass returns widgets that have the given CSS class.
 func (ui *UI) QueryByClass(class string) []Widget {
 	return ui.Query(ui.root, "."+class)
@@ -876,6 +899,31 @@ func (ui *UI) ResetForm(id string) {
 	ui.refreshDynamicLayout()
 }
 
+// SetStyleProperty sets one CSS property on a widget by ID from code, like
+// an inline style. Unlike edits to the widget's Style, the value is reapplied
+// after every cascade, so it survives stylesheet reloads and @media or
+// @container changes. It reports whether the widget exists and the property
+// is recognised.
+func (ui *UI) SetStyleProperty(id, prop, value string) bool {
+	bw := baseWidgetOf(ui.GetWidget(id))
+	if bw == nil || bw.style == nil {
+		return false
+	}
+	prop = strings.ToLower(strings.TrimSpace(prop))
+	value = strings.TrimSpace(value)
+	apply := func(style *Style) {
+		applyCSSDeclaration(style, prop, value)
+		ui.styleEngine.parseStyleColors(style)
+	}
+	if !applyCSSDeclaration(bw.style.Clone(), prop, value) {
+		return false
+	}
+	apply(bw.style)
+	bw.setStyleOverride(prop, apply)
+	ui.refreshDynamicLayout()
+	return true
+}
+
 // SetValidationState updates a widget's form validation state by ID.
 func (ui *UI) SetValidationState(id string, state ValidationState) {
 	if bw := baseWidgetOf(ui.GetWidget(id)); bw != nil {
@@ -1357,6 +1405,7 @@ func (ui *UI) refreshDynamicTree() {
 	ui.reapplyStyles(ui.root)
 	ui.inheritCSSProperties(ui.root, nil)
 	ui.setFonts(ui.root)
+	ui.loadImageAssets(ui.root)
 	ui.Layout()
 }
 
@@ -1366,6 +1415,7 @@ func (ui *UI) refreshDynamicLayout() {
 	}
 	ui.inheritCSSProperties(ui.root, nil)
 	ui.setFonts(ui.root)
+	ui.loadImageAssets(ui.root)
 	ui.Layout()
 }
 
@@ -1399,23 +1449,25 @@ func (ui *UI) handlePointerMove(x, y float64) Widget {
 	if txt, ok := hoveredWidget.(*Text); ok {
 		txt.HandlePointerMove(x, y)
 	}
+	ui.dragTextSelection(x, y)
 	return hoveredWidget
 }
 
-func (ui *UI) handlePointerDown(x, y float64, button ebiten.MouseButton) Widget {
+func (ui *UI) handlePointerDown(x, y float64, button ebiten.MouseButton, shift bool) Widget {
 	hoveredWidget := ui.handlePointerMove(x, y)
 	if button != ebiten.MouseButtonLeft {
 		return hoveredWidget
 	}
 
+	clicks := ui.countClick(hoveredWidget, x, y)
 	if hoveredWidget != nil {
 		switch w := hoveredWidget.(type) {
 		case *TextInput:
 			ui.setFocusedWidget(hoveredWidget)
-			w.HandlePointerDown(x, y)
+			w.handlePointerPress(x, y, clicks, shift)
 		case *TextArea:
 			ui.setFocusedWidget(hoveredWidget)
-			w.HandlePointerDown(x, y)
+			w.handlePointerPress(x, y, clicks, shift)
 		default:
 			if ui.focusedWidget != nil && ui.focusedWidget != hoveredWidget {
 				ui.Blur()
@@ -1423,15 +1475,22 @@ func (ui *UI) handlePointerDown(x, y float64, button ebiten.MouseButton) Widget
 		}
 		hoveredWidget.SetState(StateActive)
 		ui.activeWidget = hoveredWidget
+		ui.startRipple(hoveredWidget, x, y)
+		ui.beginTextSelection(hoveredWidget, x, y, clicks)
 		return hoveredWidget
 	}
 
 	ui.Blur()
+	ui.beginTextSelection(nil, x, y, clicks)
 	return nil
 }
 
 func (ui *UI) handlePointerUp(x, y float64, button ebiten.MouseButton, hoveredWidget Widget) {
-	if button != ebiten.MouseButtonLeft || ui.activeWidget == nil {
+	if button != ebiten.MouseButtonLeft {
+		return
+	}
+	ui.endTextSelection()
+	if ui.activeWidget == nil {
 		return
 	}
 	if hoveredWidget == nil {
@@ -1442,6 +1501,8 @@ func (ui *UI) handlePointerUp(x, y float64, button ebiten.MouseButton, hoveredWi
 		switch w := ui.activeWidget.(type) {
 		case *Button:
 			w.HandleClick()
+		case *Text:
+			w.HandleClick(x, y)
 		case *Panel:
 			w.HandleClick()
 		case *Toggle:
@@ -1473,26 +1534,39 @@ func (ui *UI) handlePointerUp(x, y float64, button ebiten.MouseButton, hoveredWi
 }
 
 func simulateTextInputKeyPress(ti *TextInput, key ebiten.Key, shift, control bool) {
+	if ti.composition.composing() {
+		return // keys belong to the input method while it composes
+	}
 	switch {
+	case control && (key == ebiten.KeyY || key == ebiten.KeyZ && shift):
+		ti.Redo()
+	case control && key == ebiten.KeyZ:
+		ti.Undo()
 	case control && key == ebiten.KeyA:
 		ti.SelectStart = 0
 		ti.SelectEnd = utf8.RuneCountInString(ti.Text)
 		ti.CursorPos = ti.SelectEnd
 		ti.clampIndices()
+	case control && key == ebiten.KeyBackspace:
+		ti.deleteWord(-1)
+	case control && key == ebiten.KeyDelete:
+		ti.deleteWord(1)
+	case control && key == ebiten.KeyLeft:
+		ti.moveWord(arrowDelta(-1, ti.isRTL()), shift)
+	case control && key == ebiten.KeyRight:
+		ti.moveWord(arrowDelta(1, ti.isRTL()), shift)
 	case key == ebiten.KeyBackspace:
 		ti.handleBackspace()
 	case key == ebiten.KeyDelete:
 		ti.handleDelete()
 	case key == ebiten.KeyLeft:
-		ti.moveCursor(-1, shift)
+		ti.moveCursor(arrowDelta(-1, ti.isRTL()), shift)
 	case key == ebiten.KeyRight:
-		ti.moveCursor(1, shift)
+		ti.moveCursor(arrowDelta(1, ti.isRTL()), shift)
 	case key == ebiten.KeyHome:
-		ti.CursorPos = 0
-		ti.clampIndices()
+		ti.moveCursorTo(0, shift)
 	case key == ebiten.KeyEnd:
-		ti.CursorPos = utf8.RuneCountInString(ti.Text)
-		ti.clampIndices()
+		ti.moveCursorTo(utf8.RuneCountInString(ti.Text), shift)
 	case key == ebiten.KeyEnter || key == ebiten.KeyNumpadEnter:
 		if ti.OnSubmit != nil {
 			ti.OnSubmit(ti.Text)
@@ -1500,13 +1574,28 @@ func simulateTextInputKeyPress(ti *TextInput, key ebiten.Key, shift, control boo
 	}
 }
 
-func simulateTextAreaKeyPress(ta *TextArea, key ebiten.Key, _ bool, control bool) {
+func simulateTextAreaKeyPress(ta *TextArea, key ebiten.Key, shift, control bool) {
+	if ta.composition.composing() {
+		return // keys belong to the input method while it composes
+	}
 	switch {
+	case control && (key == ebiten.KeyY || key == ebiten.KeyZ && shift):
+		ta.Redo()
+	case control && key == ebiten.KeyZ:
+		ta.Undo()
 	case control && key == ebiten.KeyA:
 		ta.SelectStart = 0
 		ta.SelectEnd = utf8.RuneCountInString(ta.Text)
 		ta.CursorPos = ta.SelectEnd
 		ta.updateCursorLineCol()
+	case control && key == ebiten.KeyBackspace:
+		ta.deleteWord(-1)
+	case control && key == ebiten.KeyDelete:
+		ta.deleteWord(1)
+	case control && key == ebiten.KeyLeft:
+		ta.moveWord(arrowDelta(-1, ta.isLineRTL(ta.CursorLine)), shift)
+	case control && key == ebiten.KeyRight:
+		ta.moveWord(arrowDelta(1, ta.isLineRTL(ta.CursorLine)), shift)
 	case key == ebiten.KeyBackspace:
 		ta.handleBackspace()
 	case key == ebiten.KeyDelete:
@@ -1514,21 +1603,17 @@ func simulateTextAreaKeyPress(ta *TextArea, key ebiten.Key, _ bool, control bool
 	case key == ebiten.KeyEnter || key == ebiten.KeyNumpadEnter:
 		ta.insertChar('\n')
 	case key == ebiten.KeyLeft:
-		ta.moveCursorHorizontal(-1)
+		ta.moveCursorHorizontal(arrowDelta(-1, ta.isLineRTL(ta.CursorLine)), shift)
 	case key == ebiten.KeyRight:
-		ta.moveCursorHorizontal(1)
+		ta.moveCursorHorizontal(arrowDelta(1, ta.isLineRTL(ta.CursorLine)), shift)
 	case key == ebiten.KeyUp:
-		ta.moveCursorVertical(-1)
+		ta.moveCursorVertical(-1, shift)
 	case key == ebiten.KeyDown:
-		ta.moveCursorVertical(1)
+		ta.moveCursorVertical(1, shift)
 	case key == ebiten.KeyHome:
-		ta.CursorCol = 0
-		ta.updateCursorPosFromLineCol()
+		ta.moveToLineEdge(false, control, shift)
 	case key == ebiten.KeyEnd:
-		if ta.CursorLine >= 0 && ta.CursorLine < len(ta.lines) {
-			ta.CursorCol = utf8.RuneCountInString(ta.lines[ta.CursorLine])
-			ta.updateCursorPosFromLineCol()
-		}
+		ta.moveToLineEdge(true, control, shift)
 	}
 }
 
@@ -1545,24 +1630,58 @@ func (ui *UI) buildWidgetCache(widget Widget) {
 	}
 }
 
-// findWidgetAt finds the deepest widget at a given position
+// findWidgetAt finds the deepest widget at a given position. The point is
+// mapped through each widget's transforms and must fall inside its rounded
+// border box and clip-path; pointer-events: none lets it pass through to
+// the widgets beneath while still reaching children that accept events.
 func (ui *UI) findWidgetAt(widget Widget, x, y float64) Widget {
 	if widget == nil || !widget.Visible() {
 		return nil
 	}
 	style := widget.Style()
-	if bw := baseWidgetOf(widget); bw != nil {
-		style = bw.getActiveStyle()
+	// Point in the widget's own layout coordinates. Children of a
+	// preserve-3d widget project themselves, so they are tested against
+	// the original point.
+	localX, localY := x, y
+	childX, childY := x, y
+	hitSelf := true
+	bw := baseWidgetOf(widget)
+	if bw != nil {
+		style = bw.renderStyle(bw.getActiveStyle())
+		lx, ly, ok := bw.undoAnimationTransform(x, y)
+		preserve := false
+		if m, is3D := bw.transform3D(style); is3D {
+			preserve = preserves3D(style)
+			if ok {
+				lx, ly, ok = m.unproject(lx, ly)
+			}
+			hitSelf = ok && (style.BackfaceVisibility != "hidden" || !m.facesAway(bw.computedRect))
+		} else if ok {
+			lx, ly, ok = bw.undoTransform(lx, ly, style)
+		}
+		if !ok && !preserve {
+			return nil
+		}
+		localX, localY = lx, ly
+		if !preserve {
+			childX, childY = lx, ly
+			// clip-path clips the whole offscreen layer, children included.
+			if !clipPathContains(style.ClipPath, bw.computedRect, localX, localY) {
+				return nil
+			}
+		}
 		if style.Overflow == "hidden" || style.Overflow == "scroll" || style.Overflow == "auto" {
-			if !bw.ContentRect().Contains(x, y) {
+			if !bw.ContentRect().Contains(localX, localY) {
 				return nil
 			}
 		}
+		hitSelf = hitSelf && ok && style.PointerEvents != "none" && bw.containsPoint(localX, localY, style)
+	} else {
+		hitSelf = widget.ComputedRect().Contains(x, y)
 	}
 
 	// Check children first in reverse visual z-order.
-	childX, childY := x, y
-	if bw := baseWidgetOf(widget); bw != nil && (style.Overflow == "scroll" || style.Overflow == "auto") {
+	if bw != nil && (style.Overflow == "scroll" || style.Overflow == "auto") {
 		scrollX, scrollY := bw.ScrollOffset()
 		childX += scrollX
 		childY += scrollY
@@ -1575,7 +1694,7 @@ func (ui *UI) findWidgetAt(widget Widget, x, y float64) Widget {
 	}
 
 	// Check this widget
-	if widget.ComputedRect().Contains(x, y) {
+	if hitSelf {
 		return widget
 	}
 
@@ -1608,6 +1727,10 @@ func (ui *UI) setFonts(widget Widget) {
 		return
 	}
 
+	if widget == ui.root {
+		ui.evictFontChains()
+	}
+
 	// Lazily create font caches
 	if ui.fontCache == nil && ui.DefaultFont != nil {
 		ui.fontCache = NewFontCache(ui.DefaultFont)
@@ -1626,6 +1749,8 @@ func (ui *UI) setFonts(widget Widget) {
 			w.FontFace = fontFace
 		case *Text:
 			w.FontFace = fontFace
+			w.resolveSpans = ui.resolveTextSpans
+			ui.resolveTextSpans(w)
 		case *TextInput:
 			w.FontFace = fontFace
 		case *TextArea:
@@ -1655,49 +1780,142 @@ func (ui *UI) setFonts(widget Widget) {
 	}
 }
 
+// defaultFontSize is the font size in pixels of text without a font-size.
+const defaultFontSize = 14.0
+
+// resolveFontFace builds the style's font fallback chain: every registered
+// family of the font-family list in order (or the default font when none is
+// registered), then the UI-wide fallbacks. Each family contributes the face
+// nearest the style's weight and font-style, with font-variation-settings
+// applied. Each character is drawn and measured with the first face in the
+// chain that has a glyph for it. When the primary face is lighter or more
+// upright than asked for, the chain draws with synthetic bold or oblique.
 func (ui *UI) resolveFontFace(style *Style) text.Face {
-	if style == nil {
-		return ui.defaultFontFace(14, false)
-	}
-	fontSize := style.FontSize
-	if fontSize <= 0 {
-		fontSize = 14
+	fontSize := defaultFontSize
+	weight := 400
+	fontStyle := "normal"
+	var families []string
+	var variations []fontVariation
+	if style != nil {
+		if style.FontSize > 0 {
+			fontSize = style.FontSize
+		}
+		weight = cssFontWeight(style.FontWeight)
+		fontStyle = cssFontStyle(style.FontStyle)
+		families = parseFontFamilyList(style.FontFamily)
+		variations = parseFontVariationSettings(style.FontVariationSettings)
 	}
-	isBold := style.FontWeight == "bold" || style.FontWeight == "700" ||
-		style.FontWeight == "800" || style.FontWeight == "900"
 
-	for _, family := range parseFontFamilyList(style.FontFamily) {
-		name := normalizeFontFamilyName(family)
-		if name == "" {
-			continue
-		}
-		if face := ui.fontFaces[name]; face != nil {
-			return face
+	var chain []text.Face
+	var synth fontSynthesis
+	for _, family := range families {
+		face, faceSynth := ui.familyFontFace(family, fontSize, weight, fontStyle, variations)
+		if face != nil && len(chain) == 0 {
+			synth = faceSynth
 		}
-		if isBold {
-			if cache := ui.boldFontSources[name]; cache != nil {
-				return cache.GetFace(fontSize)
-			}
-		}
-		if cache := ui.fontSources[name]; cache != nil {
-			return cache.GetFace(fontSize)
+		chain = appendFontFace(chain, face)
+	}
+	if len(chain) == 0 {
+		var face text.Face
+		face, synth = ui.defaultFontFace(fontSize, weight, fontStyle)
+		chain = appendFontFace(chain, face)
+	}
+	for _, family := range ui.fontFallbacks {
+		face, _ := ui.familyFontFace(family, fontSize, weight, fontStyle, variations)
+		chain = appendFontFace(chain, face)
+	}
+	return ui.fontChain(chain, synth)
+}
+
+// familyFontFace returns the registered face of one family, or nil, with the
+// synthesis it needs for the weight and style. Fixed faces count as normal
+// weight and upright.
+func (ui *UI) familyFontFace(family string, fontSize float64, weight int, fontStyle string, variations []fontVariation) (text.Face, fontSynthesis) {
+	name := normalizeFontFamilyName(family)
+	if name == "" {
+		return nil, fontSynthesis{}
+	}
+	if face := ui.fontFaces[name]; face != nil {
+		return face, needsFontSynthesis(weight, fontStyle, 400, "normal", fontSize)
+	}
+	if variant := matchFontVariant(ui.fontVariants[name], weight, fontStyle); variant != nil {
+		face := variant.cache.variedFace(fontSize, withWeightAxis(variations, variant, weight))
+		return face, needsFontSynthesis(weight, fontStyle, variant.weightMax, variant.style, fontSize)
+	}
+	return nil, fontSynthesis{}
+}
+
+// appendFontFace adds a face to a fallback chain, skipping nil and repeated
+// faces.
+func appendFontFace(chain []text.Face, face text.Face) []text.Face {
+	if face == nil {
+		return chain
+	}
+	for _, existing := range chain {
+		if existing == face {
+			return chain
 		}
 	}
+	return append(chain, face)
+}
 
-	return ui.defaultFontFace(fontSize, isBold)
+// fontChain returns a single face as is and combines several into a
+// text.MultiFace, reused while the member faces stay the same so widget
+// layout caches keyed by face keep hitting. A synthesized chain is always
+// wrapped, even around one face, so drawText can find its synthesis by face.
+func (ui *UI) fontChain(chain []text.Face, synth fontSynthesis) text.Face {
+	switch {
+	case len(chain) == 0:
+		return nil
+	case len(chain) == 1 && synth == (fontSynthesis{}):
+		return chain[0]
+	}
+	var key strings.Builder
+	for _, face := range chain {
+		fmt.Fprintf(&key, "%p;", face)
+	}
+	fmt.Fprintf(&key, "%v", synth)
+	if built := ui.fontChains[key.String()]; built != nil {
+		built.pass = ui.fontPass
+		return built.face
+	}
+	multi, err := text.NewMultiFace(chain...)
+	if err != nil {
+		// Faces with different directions cannot be combined.
+		return chain[0]
+	}
+	built := &fontChainFace{face: multi, synth: synth, members: chain, pass: ui.fontPass}
+	builtFaces.Store(text.Face(multi), built)
+	ui.fontChains[key.String()] = built
+	return multi
 }
 
-func (ui *UI) defaultFontFace(fontSize float64, isBold bool) text.Face {
+// evictFontChains drops the chains that were not resolved during the
+// previous setFonts pass, together with their synthesis and right-to-left
+// copies, and starts a new pass.
+func (ui *UI) evictFontChains() {
+	for key, built := range ui.fontChains {
+		if built.pass < ui.fontPass {
+			built.forget()
+			delete(ui.fontChains, key)
+		}
+	}
+	ui.fontPass++
+}
+
+// defaultFontFace returns the configured default face for a size, weight
+// and style, synthesizing what the default fonts lack.
+func (ui *UI) defaultFontFace(fontSize float64, weight int, fontStyle string) (text.Face, fontSynthesis) {
 	if ui.DefaultFontFace != nil {
-		return ui.DefaultFontFace
+		return ui.DefaultFontFace, needsFontSynthesis(weight, fontStyle, 400, "normal", fontSize)
 	}
-	if isBold && ui.boldFontCache != nil {
-		return ui.boldFontCache.GetFace(fontSize)
+	if weight >= 600 && ui.boldFontCache != nil {
+		return ui.boldFontCache.GetFace(fontSize), needsFontSynthesis(weight, fontStyle, 700, "normal", fontSize)
 	}
 	if ui.fontCache != nil {
-		return ui.fontCache.GetFace(fontSize)
+		return ui.fontCache.GetFace(fontSize), needsFontSynthesis(weight, fontStyle, 400, "normal", fontSize)
 	}
-	return nil
+	return nil, fontSynthesis{}
 }
 
 func parseFontFamilyList(value string) []string {
@@ -1747,6 +1965,9 @@ func (ui *UI) reapplyStylesWithAncestors(widget Widget, ancestors []Widget) {
 	if widget == nil {
 		return
 	}
+	if bw := baseWidgetOf(widget); bw != nil && bw.cascadeBase == nil {
+		bw.cascadeBase = widget.Style().Clone()
+	}
 
 	// 1. Apply by type (e.g., "panel", "svg", "button")
 	ui.styleEngine.ApplyStyle(widget, widget.Type())
@@ -1771,18 +1992,20 @@ func (ui *UI) reapplyStylesWithAncestors(widget Widget, ancestors []Widget) {
 	}
 
 	// 4. Apply source-ordered CSS rules. This pass preserves later wins for
-	// same-specificity rules while the surrounding direct passes keep legacy
-	// type/class/id behavior stable.
+	// same-specificity rules while the direct passes above keep legacy
+	// type/class behavior stable. Every stored style is also an ordered rule,
+	// so ID rules are applied here by specificity, letting a later @media or
+	// @container rule for the same ID override an unconditional one.
 	ui.applyOrderedRuleStyles(widget, ancestors, false)
 
-	// 5. Apply by ID (e.g., "#root", "#header")
-	if widget.ID() != "" {
-		ui.styleEngine.ApplyStyle(widget, "#"+widget.ID())
-	}
-
-	// 6. Apply !important CSS declarations after normal direct styles.
+	// 5. Apply !important CSS declarations after normal direct styles.
 	ui.applyOrderedRuleStyles(widget, ancestors, true)
 
+	// 6. Reapply properties set from code, which act like inline styles.
+	if bw := baseWidgetOf(widget); bw != nil {
+		bw.applyStyleOverrides()
+	}
+
 	// Recursively apply to children
 	childAncestors := append(append([]Widget(nil), ancestors...), widget)
 	for _, child := range widget.Children() {
@@ -1793,6 +2016,9 @@ func (ui *UI) reapplyStylesWithAncestors(widget Widget, ancestors []Widget) {
 func (ui *UI) applyOrderedRuleStyles(widget Widget, ancestors []Widget, important bool) {
 	matches := ui.styleEngine.matchingRules(widget, ancestors, important)
 	for _, rule := range matches {
+		if !ui.ruleConditionsMatch(rule.Conditions, ancestors) {
+			continue
+		}
 		existing := widget.Style()
 		widget.SetStyle(mergeStylesFully(existing, rule.Style))
 	}
@@ -1976,6 +2202,12 @@ func (ui *UI) inheritCSSProperties(widget Widget, parentStyle *Style) {
 		if style.FontStyle == "" && parentStyle.FontStyle != "" {
 			style.FontStyle = parentStyle.FontStyle
 		}
+		if style.FontVariationSettings == "" && parentStyle.FontVariationSettings != "" {
+			style.FontVariationSettings = parentStyle.FontVariationSettings
+		}
+		if style.TextDirection == "" && parentStyle.TextDirection != "" {
+			style.TextDirection = parentStyle.TextDirection
+		}
 
 		// Text layout
 		if style.TextAlign == "" && parentStyle.TextAlign != "" {
@@ -1990,7 +2222,51 @@ func (ui *UI) inheritCSSProperties(widget Widget, parentStyle *Style) {
 		if style.LetterSpacing == 0 && parentStyle.LetterSpacing != 0 {
 			style.LetterSpacing = parentStyle.LetterSpacing
 		}
+		if style.WhiteSpace == "" && parentStyle.WhiteSpace != "" {
+			style.WhiteSpace = parentStyle.WhiteSpace
+		}
+		if style.WordBreak == "" && parentStyle.WordBreak != "" {
+			style.WordBreak = parentStyle.WordBreak
+		}
+		if style.OverflowWrap == "" && parentStyle.OverflowWrap != "" {
+			style.OverflowWrap = parentStyle.OverflowWrap
+		}
+		if style.TabSize == 0 && parentStyle.TabSize != 0 {
+			style.TabSize = parentStyle.TabSize
+		}
+		if style.TextTransform == "" && parentStyle.TextTransform != "" {
+			style.TextTransform = parentStyle.TextTransform
+		}
+
+		// Lists
+		if style.ListStyleType == "" && parentStyle.ListStyleType != "" {
+			style.ListStyleType = parentStyle.ListStyleType
+		}
+		if style.ListStylePosition == "" && parentStyle.ListStylePosition != "" {
+			style.ListStylePosition = parentStyle.ListStylePosition
+		}
+		if style.ListStyleImage == "" && parentStyle.ListStyleImage != "" {
+			style.ListStyleImage = parentStyle.ListStyleImage
+		}
+
+		// Pointer events
+		if style.PointerEvents == "" && parentStyle.PointerEvents != "" {
+			style.PointerEvents = parentStyle.PointerEvents
+		}
+		if style.UserSelect == "" && parentStyle.UserSelect != "" {
+			style.UserSelect = parentStyle.UserSelect
+		}
+	}
+	if style.fontSizeScale != 0 {
+		// Relative sizes scale the parent's computed size, so repeated
+		// passes stay stable.
+		base := defaultFontSize
+		if parentStyle != nil && parentStyle.FontSize > 0 {
+			base = parentStyle.FontSize
+		}
+		style.FontSize = base * style.fontSizeScale
 	}
+	resolveInlinePadding(style)
 
 	for _, child := range widget.Children() {
 		ui.inheritCSSProperties(child, style)
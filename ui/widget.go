@@ -43,6 +43,30 @@ type BaseWidget struct {
 	// 9-slice image for background
 	nineSlice *NineSlice
 
+	// Style before the stylesheet cascade, restored when @media/@container
+	// results change so stale conditional rules drop out.
+	cascadeBase *Style
+	// Style properties set from code (UI.SetStyleProperty, bind-style-*),
+	// reapplied after every cascade
+	styleOverrides []styleOverride
+
+	// Generated ::before/::after boxes and the state they were built for
+	pseudoBefore *generatedBox
+	pseudoAfter  *generatedBox
+	pseudoState  WidgetState
+
+	// Counters in scope at the ::before and ::after boxes of a host whose
+	// boxes depend on state, so they can be rebuilt without a full pass
+	pseudoCounters      *cssCounterState
+	pseudoAfterCounters *cssCounterState
+
+	// Generated ::marker box of a list item; outside markers hang beside it
+	listMarker        *generatedBox
+	listMarkerOutside bool
+
+	// XML attributes, read by attr() in generated content
+	attributes map[string]string
+
 	// Animation state
 	animating            bool
 	animState            *AnimationState
@@ -51,6 +75,13 @@ type BaseWidget struct {
 	// CSS transition state
 	transitionEngine *TransitionEngine
 	compositing      bool
+
+	// Shaders registered on the UI, attached with the widget's assets
+	shaders map[string]*customShader
+
+	// Running CSS ripples and the skeleton shimmer clock
+	ripples []*RippleEffect
+	shimmer *ShimmerEffect
 }
 
 // NewBaseWidget creates a new base widget
@@ -148,9 +179,36 @@ func (w *BaseWidget) Style() *Style { return w.style }
 // SetStyle sets the widget's style
 func (w *BaseWidget) SetStyle(s *Style) { w.style = s }
 
+// styleOverride is one style property set from code.
+type styleOverride struct {
+	name  string
+	apply func(*Style)
+}
+
+// setStyleOverride records a style property set from code, replacing an
+// earlier value of the same property.
+func (w *BaseWidget) setStyleOverride(name string, apply func(*Style)) {
+	for i := range w.styleOverrides {
+		if w.styleOverrides[i].name == name {
+			w.styleOverrides[i].apply = apply
+			return
+		}
+	}
+	w.styleOverrides = append(w.styleOverrides, styleOverride{name: name, apply: apply})
+}
+
+// applyStyleOverrides reapplies the properties set from code on top of the
+// cascaded style.
+func (w *BaseWidget) applyStyleOverrides() {
+	for _, override := range w.styleOverrides {
+		override.apply(w.style)
+	}
+}
+
 // IntrinsicWidth returns the widget's natural width based on content or children
 func (w *BaseWidget) IntrinsicWidth() float64 {
-	if len(w.children) == 0 {
+	children := w.boxChildren()
+	if len(children) == 0 {
 		return 0
 	}
 
@@ -162,9 +220,9 @@ func (w *BaseWidget) IntrinsicWidth() float64 {
 	var width float64
 	if style.Direction == LayoutRow {
 		// Sum of children widths + gaps
-		visibleCount := visibleChildCount(w.children)
+		visibleCount := visibleChildCount(children)
 		visibleIndex := 0
-		for _, child := range w.children {
+		for _, child := range children {
 			if !child.Visible() {
 				continue
 			}
@@ -180,7 +238,7 @@ func (w *BaseWidget) IntrinsicWidth() float64 {
 		}
 	} else {
 		// Max of children widths
-		for _, child := range w.children {
+		for _, child := range children {
 			if !child.Visible() {
 				continue
 			}
@@ -200,7 +258,8 @@ func (w *BaseWidget) IntrinsicWidth() float64 {
 
 // IntrinsicHeight returns the widget's natural height based on content or children
 func (w *BaseWidget) IntrinsicHeight() float64 {
-	if len(w.children) == 0 {
+	children := w.boxChildren()
+	if len(children) == 0 {
 		return 0
 	}
 
@@ -212,7 +271,7 @@ func (w *BaseWidget) IntrinsicHeight() float64 {
 	var height float64
 	if style.Direction == LayoutRow {
 		// Max of children heights
-		for _, child := range w.children {
+		for _, child := range children {
 			if !child.Visible() {
 				continue
 			}
@@ -227,9 +286,9 @@ func (w *BaseWidget) IntrinsicHeight() float64 {
 		}
 	} else {
 		// Sum of children heights + gaps
-		visibleCount := visibleChildCount(w.children)
+		visibleCount := visibleChildCount(children)
 		visibleIndex := 0
-		for _, child := range w.children {
+		for _, child := range children {
 			if !child.Visible() {
 				continue
 			}
@@ -274,6 +333,26 @@ func (w *BaseWidget) SetState(s WidgetState) {
 	w.startStyleTransitions(oldStyle, newStyle)
 }
 
+// Attr returns an XML attribute recorded when the widget was created or
+// bound. id and class are answered from the widget itself.
+func (w *BaseWidget) Attr(name string) string {
+	switch name {
+	case "id":
+		return w.id
+	case "class":
+		return strings.Join(w.classes, " ")
+	}
+	return w.attributes[name]
+}
+
+// SetAttr records an attribute value for attr() lookups.
+func (w *BaseWidget) SetAttr(name, value string) {
+	if w.attributes == nil {
+		w.attributes = make(map[string]string)
+	}
+	w.attributes[name] = value
+}
+
 // Visible returns whether the widget is visible
 func (w *BaseWidget) Visible() bool { return w.visible }
 
@@ -611,10 +690,10 @@ func (w *BaseWidget) Draw(screen *ebiten.Image) {
 	opacity *= animOpacity
 
 	// Determine if we need offscreen compositing
-	hasTransform := style.Transform != "" && style.Transform != "none"
+	hasTransform := w.hasTransform(style)
 	hasFilter := style.parsedFilter != nil
 	hasClipPath := style.ClipPath != "" && style.ClipPath != "none"
-	needsOffscreen := opacity < 1 || hasTransform || hasFilter || hasAnimTransform || hasClipPath
+	needsOffscreen := opacity < 1 || hasTransform || hasFilter || hasAnimTransform || hasClipPath || hasCompositeEffects(style)
 
 	if needsOffscreen {
 		w.drawWithCompositing(screen, r, style, opacity, hasTransform, hasFilter, hasClipPath, animGeoM, hasAnimTransform)
@@ -635,11 +714,7 @@ func (w *BaseWidget) drawDirect(screen *ebiten.Image, r Rect, style *Style) {
 	w.drawBackground(screen, r, style)
 
 	// 3. Border
-	if style.BorderColor != nil && style.BorderWidth > 0 {
-		tl, tr, br, bl := w.getCornerRadii(style)
-		drawRoundedRectStrokeEx(screen, r, tl, tr, br, bl, style.BorderWidth, style.BorderColor)
-	}
-	w.drawIndividualBorders(screen, r, style)
+	w.drawBorder(screen, r, style)
 
 	// 4. Outline
 	w.drawOutline(screen, r, style)
@@ -651,6 +726,13 @@ func (w *BaseWidget) drawDirect(screen *ebiten.Image, r Rect, style *Style) {
 // drawWithCompositing renders to an offscreen buffer, applies transform/filter/opacity,
 // then composites onto the target screen.
 func (w *BaseWidget) drawWithCompositing(screen *ebiten.Image, r Rect, style *Style, opacity float64, hasTransform, hasFilter, hasClipPath bool, animGeoM ebiten.GeoM, hasAnimTransform bool) {
+	// A preserve-3d box projects only itself; its children project
+	// themselves through its matrix instead of flattening into its layer.
+	if _, is3D := w.transform3D(style); is3D && preserves3D(style) {
+		w.drawCustomWithCompositing(screen, r, style, opacity, hasTransform, hasFilter, hasClipPath, animGeoM, hasAnimTransform, w.drawBoxOnly)
+		w.drawChildren(screen, r, style)
+		return
+	}
 	w.drawCustomWithCompositing(screen, r, style, opacity, hasTransform, hasFilter, hasClipPath, animGeoM, hasAnimTransform, w.drawContentOnly)
 }
 
@@ -665,10 +747,17 @@ func (w *BaseWidget) drawCustomWithCompositing(screen *ebiten.Image, r Rect, sty
 	// should not be affected by the widget's own transform or opacity.
 	w.drawBoxShadow(screen, r, style)
 
+	// The backdrop filter reads what is already on screen behind the widget,
+	// which the offscreen buffer does not hold.
+	w.drawBackdropFilter(screen, r, style)
+
 	offscreen := globalImagePool.Get(screenW, screenH)
 
 	// Draw widget content to offscreen at original coordinates
+	compositing := w.compositing
+	w.compositing = true
 	drawContent(offscreen, r, style)
+	w.compositing = compositing
 
 	if hasClipPath {
 		applyCSSClipPath(offscreen, r, style.ClipPath)
@@ -681,6 +770,31 @@ func (w *BaseWidget) drawCustomWithCompositing(screen *ebiten.Image, r Rect, sty
 			globalImagePool.Put(offscreen)
 			offscreen = filtered
 		}
+		shaded := w.applyShaderFilters(offscreen, r, style)
+		if shaded != offscreen {
+			globalImagePool.Put(offscreen)
+			offscreen = shaded
+		}
+	}
+	applyCSSMask(offscreen, r, style)
+
+	w.compositeLayer(screen, offscreen, r, style, opacity, hasTransform, animGeoM, hasAnimTransform)
+	globalImagePool.Put(offscreen)
+}
+
+// compositeLayer draws an offscreen layer onto screen with the widget's
+// transform, opacity and mix-blend-mode. 3D transforms draw it projected.
+func (w *BaseWidget) compositeLayer(screen, offscreen *ebiten.Image, r Rect, style *Style, opacity float64, hasTransform bool, animGeoM ebiten.GeoM, hasAnimTransform bool) {
+	// Crop to the actual screen size because the pooled image may be larger
+	// (power-of-2 bucketing).
+	bounds := screen.Bounds()
+	cropped := offscreen.SubImage(image.Rect(0, 0, bounds.Dx(), bounds.Dy())).(*ebiten.Image)
+
+	if hasTransform {
+		if m, is3D := w.transform3D(style); is3D {
+			w.drawProjected(screen, cropped, r, m, style, opacity, animGeoM, hasAnimTransform)
+			return
+		}
 	}
 
 	// Composite to screen with transform and opacity
@@ -701,11 +815,7 @@ func (w *BaseWidget) drawCustomWithCompositing(screen *ebiten.Image, r Rect, sty
 		op.ColorScale.ScaleAlpha(float32(opacity))
 	}
 
-	// Crop to the actual screen size because the pooled image may be larger
-	// (power-of-2 bucketing).
-	cropped := offscreen.SubImage(image.Rect(0, 0, screenW, screenH)).(*ebiten.Image)
-	screen.DrawImage(cropped, op)
-	globalImagePool.Put(offscreen)
+	drawBlended(screen, cropped, op, style.MixBlendMode)
 }
 
 func (w *BaseWidget) drawFullWidgetWithEffects(screen *ebiten.Image, draw func(*ebiten.Image)) bool {
@@ -721,10 +831,10 @@ func (w *BaseWidget) drawFullWidgetWithEffects(screen *ebiten.Image, draw func(*
 	}
 	animGeoM, hasAnimTransform, animOpacity := w.animationTransform()
 	opacity *= animOpacity
-	hasTransform := style.Transform != "" && style.Transform != "none"
+	hasTransform := w.hasTransform(style)
 	hasFilter := style.parsedFilter != nil
 	hasClipPath := style.ClipPath != "" && style.ClipPath != "none"
-	if opacity >= 1 && !hasTransform && !hasFilter && !hasAnimTransform && !hasClipPath {
+	if opacity >= 1 && !hasTransform && !hasFilter && !hasAnimTransform && !hasClipPath && !hasCompositeEffects(style) {
 		return false
 	}
 
@@ -733,6 +843,7 @@ func (w *BaseWidget) drawFullWidgetWithEffects(screen *ebiten.Image, draw func(*
 	if screenW <= 0 || screenH <= 0 {
 		return true
 	}
+	w.drawBackdropFilter(screen, r, style)
 	offscreen := globalImagePool.Get(screenW, screenH)
 	w.compositing = true
 	draw(offscreen)
@@ -747,23 +858,15 @@ func (w *BaseWidget) drawFullWidgetWithEffects(screen *ebiten.Image, draw func(*
 			globalImagePool.Put(offscreen)
 			offscreen = filtered
 		}
+		shaded := w.applyShaderFilters(offscreen, r, style)
+		if shaded != offscreen {
+			globalImagePool.Put(offscreen)
+			offscreen = shaded
+		}
 	}
+	applyCSSMask(offscreen, r, style)
 
-	op := &ebiten.DrawImageOptions{}
-	if hasTransform {
-		originX, originY := parseCSSTransformOrigin(style.TransformOrigin, r.W, r.H)
-		originX += r.X
-		originY += r.Y
-		op.GeoM = parseCSSTransform(style.Transform, originX, originY)
-	}
-	if hasAnimTransform {
-		op.GeoM.Concat(animGeoM)
-	}
-	if opacity < 1 {
-		op.ColorScale.ScaleAlpha(float32(opacity))
-	}
-	cropped := offscreen.SubImage(image.Rect(0, 0, screenW, screenH)).(*ebiten.Image)
-	screen.DrawImage(cropped, op)
+	w.compositeLayer(screen, offscreen, r, style, opacity, hasTransform, animGeoM, hasAnimTransform)
 	globalImagePool.Put(offscreen)
 	return true
 }
@@ -778,11 +881,7 @@ func (w *BaseWidget) drawContentOnly(screen *ebiten.Image, r Rect, style *Style)
 	w.drawBackground(screen, r, style)
 
 	// Border
-	if style.BorderColor != nil && style.BorderWidth > 0 {
-		tl, tr, br, bl := w.getCornerRadii(style)
-		drawRoundedRectStrokeEx(screen, r, tl, tr, br, bl, style.BorderWidth, style.BorderColor)
-	}
-	w.drawIndividualBorders(screen, r, style)
+	w.drawBorder(screen, r, style)
 
 	// Outline
 	w.drawOutline(screen, r, style)
@@ -791,6 +890,15 @@ func (w *BaseWidget) drawContentOnly(screen *ebiten.Image, r Rect, style *Style)
 	w.drawChildren(screen, r, style)
 }
 
+// drawBoxOnly renders the widget's own box (bg, border, outline) without its
+// children.
+func (w *BaseWidget) drawBoxOnly(screen *ebiten.Image, r Rect, style *Style) {
+	w.drawBackdropFilter(screen, r, style)
+	w.drawBackground(screen, r, style)
+	w.drawBorder(screen, r, style)
+	w.drawOutline(screen, r, style)
+}
+
 // drawBoxShadow draws the box shadow effect, parsing on first use.
 func (w *BaseWidget) drawBoxShadow(screen *ebiten.Image, r Rect, style *Style) {
 	shadows := style.parsedBoxShadows
@@ -811,20 +919,36 @@ func (w *BaseWidget) drawBoxShadow(screen *ebiten.Image, r Rect, style *Style) {
 }
 
 // drawBackdropFilter applies the CSS backdrop-filter effect (glassmorphism).
-// Captures the screen region behind this widget and applies blur/brightness/saturate.
+// Captures the screen region behind this widget, runs its filter functions
+// and clips the result to the rounded border box and clip-path.
 // Must be called before drawBackground so the captured content is uncontaminated.
+// Offscreen compositing draws it onto the real backdrop beforehand.
 func (w *BaseWidget) drawBackdropFilter(screen *ebiten.Image, r Rect, style *Style) {
-	if style.parsedBackdropFilter != nil {
-		ApplyBackdropFilter(screen, r, style.parsedBackdropFilter)
-	} else if style.BackdropFilter != "" {
-		style.parsedBackdropFilter = ParseBackdropFilter(style.BackdropFilter)
-		if style.parsedBackdropFilter != nil {
-			ApplyBackdropFilter(screen, r, style.parsedBackdropFilter)
+	if w.compositing {
+		return
+	}
+	if style.parsedBackdropFilter == nil {
+		if style.BackdropFilter == "" {
+			return
 		}
+		style.parsedBackdropFilter = ParseBackdropFilter(style.BackdropFilter)
 	}
+	applyBackdropFilter(screen, r, style.parsedBackdropFilter, func(buf *ebiten.Image, local Rect) {
+		if tl, tr, br, bl := w.getCornerRadii(style); tl > 0 || tr > 0 || br > 0 || bl > 0 {
+			bounds := buf.Bounds()
+			mask := globalImagePool.Get(bounds.Dx(), bounds.Dy())
+			DrawRoundedRectPathEx(mask, local, tl, tr, br, bl, color.White)
+			buf.DrawImage(mask, &ebiten.DrawImageOptions{Blend: ebiten.BlendDestinationIn})
+			globalImagePool.Put(mask)
+		}
+		if style.ClipPath != "" && style.ClipPath != "none" {
+			applyCSSClipPath(buf, local, style.ClipPath)
+		}
+	})
 }
 
-// drawBackground draws the widget background (9-slice, gradient, or solid colour).
+// drawBackground draws the widget background (9-slice, gradient, or solid colour)
+// and then its background-image layers.
 func (w *BaseWidget) drawBackground(screen *ebiten.Image, r Rect, style *Style) {
 	if w.nineSlice != nil {
 		w.nineSlice.Draw(screen, r.X, r.Y, r.W, r.H, nil)
@@ -832,15 +956,16 @@ func (w *BaseWidget) drawBackground(screen *ebiten.Image, r Rect, style *Style)
 		radTL, radTR, radBR, radBL := w.getCornerRadii(style)
 		if radTL > 0 || radTR > 0 || radBR > 0 || radBL > 0 {
 			drawGradientWithRadius(screen, r, style.parsedGradient, radTL, radTR, radBR, radBL)
-		} else if style.parsedGradient.Type == GradientRadial {
-			DrawRadialGradient(screen, r, style.parsedGradient)
 		} else {
-			DrawGradient(screen, r, style.parsedGradient)
+			drawGradientFill(screen, r, style.parsedGradient)
 		}
 	} else if style.BackgroundColor != nil {
 		radTL, radTR, radBR, radBL := w.getCornerRadii(style)
 		DrawRoundedRectPathEx(screen, r, radTL, radTR, radBR, radBL, style.BackgroundColor)
 	}
+	w.drawBackgroundShader(screen, r, style)
+	w.drawBackgroundLayers(screen, r, style)
+	w.drawSurfaceEffects(screen, r, style)
 }
 
 // drawGradientWithRadius draws a gradient clipped to rounded corners.
@@ -855,11 +980,7 @@ func drawGradientWithRadius(screen *ebiten.Image, r Rect, g *Gradient, radTL, ra
 
 	clipComposite(screen, iw, ih,
 		func(content *ebiten.Image) {
-			if g.Type == GradientRadial {
-				DrawRadialGradient(content, localRect, g)
-			} else {
-				DrawGradient(content, localRect, g)
-			}
+			drawGradientFill(content, localRect, g)
 		},
 		func(mask *ebiten.Image) {
 			DrawRoundedRectPathEx(mask, localRect, radTL, radTR, radBR, radBL, color.White)
@@ -884,6 +1005,10 @@ func (w *BaseWidget) drawOutline(screen *ebiten.Image, r Rect, style *Style) {
 
 // drawChildren renders child widgets with overflow handling.
 func (w *BaseWidget) drawChildren(screen *ebiten.Image, r Rect, style *Style) {
+	// Outside markers hang beside the box, so overflow clipping skips them.
+	if w.listMarker != nil && w.listMarkerOutside {
+		w.listMarker.Draw(screen)
+	}
 	if style.Overflow == "hidden" || style.Overflow == "scroll" || style.Overflow == "auto" {
 		content := w.ContentRect()
 		clipW := int(content.W)
@@ -894,7 +1019,7 @@ func (w *BaseWidget) drawChildren(screen *ebiten.Image, r Rect, style *Style) {
 			if style.Overflow == "scroll" || style.Overflow == "auto" {
 				scrollX, scrollY = w.ScrollOffset()
 			}
-			for _, child := range sortedChildrenByZ(w.children, false) {
+			for _, child := range sortedChildrenByZ(w.boxChildren(), false) {
 				translateWidgetTree(child, -content.X-scrollX, -content.Y-scrollY)
 				DrawWidget(tmpImg, child)
 				translateWidgetTree(child, content.X+scrollX, content.Y+scrollY)
@@ -909,7 +1034,7 @@ func (w *BaseWidget) drawChildren(screen *ebiten.Image, r Rect, style *Style) {
 			globalImagePool.Put(tmpImg)
 		}
 	} else {
-		for _, child := range sortedChildrenByZ(w.children, false) {
+		for _, child := range sortedChildrenByZ(w.boxChildren(), false) {
 			child.Draw(screen)
 		}
 	}
@@ -923,7 +1048,10 @@ func translateWidgetTree(widget Widget, dx, dy float64) {
 	r.X += dx
 	r.Y += dy
 	widget.SetComputedRect(r)
-	for _, child := range widget.Children() {
+	if bw := baseWidgetOf(widget); bw != nil && bw.listMarker != nil && bw.listMarkerOutside {
+		translateWidgetTree(bw.listMarker, dx, dy)
+	}
+	for _, child := range boxChildren(widget) {
 		translateWidgetTree(child, dx, dy)
 	}
 }
@@ -1229,7 +1357,12 @@ func snapToPixel(v float64) float64 {
 
 // getActiveStyle returns the style based on current state
 func (w *BaseWidget) getActiveStyle() *Style {
-	switch w.state {
+	return w.styleForState(w.state)
+}
+
+// styleForState returns the style the widget has in a given state.
+func (w *BaseWidget) styleForState(state WidgetState) *Style {
+	switch state {
 	case StateHover:
 		if w.style.HoverStyle != nil {
 			return mergeStyles(w.style, w.style.HoverStyle)
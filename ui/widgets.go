@@ -4,6 +4,7 @@ import (
 	"image"
 	"image/color"
 	"math"
+	"strings"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/text/v2"
@@ -37,8 +38,11 @@ func NewPanel(id string) *Panel {
 // Button is a clickable button widget
 type Button struct {
 	*BaseWidget
-	Label    string
-	FontFace text.Face
+	TextSelection // label selection, with user-select: text
+	Label         string
+	FontFace      text.Face
+
+	labelLayout *textLayout // label laid out for selection
 }
 
 // NewButton creates a new button widget
@@ -54,7 +58,7 @@ func (b *Button) IntrinsicWidth() float64 {
 	if b.Label == "" || b.FontFace == nil {
 		return 0
 	}
-	tw, _ := text.Measure(b.Label, b.FontFace, 0)
+	tw, _ := text.Measure(applyTextTransform(b.Label, b.getActiveStyle().TextTransform), b.FontFace, 0)
 	bw := b.style.BorderWidth
 	return tw + b.style.Padding.Left + b.style.Padding.Right + bw*2
 }
@@ -84,10 +88,10 @@ func (b *Button) Draw(screen *ebiten.Image) {
 	}
 	animGeoM, hasAnimTransform, animOpacity := b.animationTransform()
 	opacity *= animOpacity
-	hasTransform := style.Transform != "" && style.Transform != "none"
+	hasTransform := b.hasTransform(style)
 	hasFilter := style.parsedFilter != nil
 	hasClipPath := style.ClipPath != "" && style.ClipPath != "none"
-	needsOffscreen := opacity < 1 || hasTransform || hasFilter || hasAnimTransform || hasClipPath
+	needsOffscreen := opacity < 1 || hasTransform || hasFilter || hasAnimTransform || hasClipPath || hasCompositeEffects(style)
 
 	drawContent := func(target *ebiten.Image, contentRect Rect, contentStyle *Style) {
 		b.drawContentOnly(target, contentRect, contentStyle)
@@ -114,8 +118,10 @@ func (b *Button) drawLabel(screen *ebiten.Image, r Rect, style *Style) {
 			textColor = applyOpacity(textColor, style.Opacity)
 		}
 
+		label := applyTextTransform(b.Label, style.TextTransform)
+
 		// Measure text for centering
-		textW, _ := text.Measure(b.Label, b.FontFace, 0)
+		textW, _ := text.Measure(label, b.FontFace, 0)
 		metrics := b.FontFace.Metrics()
 		// Use cap-height for more visually balanced vertical centering if available,
 		// otherwise fall back to em-height.
@@ -123,43 +129,71 @@ func (b *Button) drawLabel(screen *ebiten.Image, r Rect, style *Style) {
 		descent := metrics.HDescent
 		emHeight := ascent + descent
 
-		bw := style.BorderWidth
-		rContent := r.Inset(
-			style.Padding.Top+bw,
-			style.Padding.Right+bw,
-			style.Padding.Bottom+bw,
-			style.Padding.Left+bw,
-		)
-		x := rContent.X + (rContent.W-textW)/2
-		y := rContent.Y + (rContent.H-emHeight)/2
+		x, y := b.labelOrigin(r, style, textW)
+		if b.HasSelection() && style.UserSelect == "text" {
+			if layout := b.selectionLayout(); layout != nil {
+				start, end := b.selectionRange()
+				for _, rect := range layout.SelectionRects(start, end) {
+					rect.X += x
+					rect.Y, rect.H = y, emHeight
+					DrawRoundedRectPath(screen, rect, 2, b.selectionColor())
+				}
+			}
+		}
 
-		drawTextShadows(screen, b.Label, b.FontFace, x, y, style)
+		drawTextShadows(screen, label, b.FontFace, x, y, style)
 
 		// Original label drawing
 		op := &text.DrawOptions{}
 		op.GeoM.Translate(snapToPixel(x), snapToPixel(y))
 		op.ColorScale.ScaleWithColor(textColor)
-		text.Draw(screen, b.Label, b.FontFace, op)
+		drawText(screen, label, b.FontFace, op)
+
+		decorations, decorationColor := textDecorations(style, textColor)
+		op.ColorScale.Reset()
+		op.ColorScale.ScaleWithColor(decorationColor)
+		for _, decoration := range decorations {
+			drawTextDecoration(screen, textW, b.FontFace, op, decoration, style.TextDecorationThickness)
+		}
 	}
 }
 
+// labelOrigin returns the top-left of the label's em box, centered in the
+// button's content box.
+func (b *Button) labelOrigin(r Rect, style *Style, textW float64) (float64, float64) {
+	metrics := b.FontFace.Metrics()
+	emHeight := metrics.HAscent + metrics.HDescent
+	bw := style.BorderWidth
+	rContent := r.Inset(
+		style.Padding.Top+bw,
+		style.Padding.Right+bw,
+		style.Padding.Bottom+bw,
+		style.Padding.Left+bw,
+	)
+	return rContent.X + (rContent.W-textW)/2, rContent.Y + (rContent.H-emHeight)/2
+}
+
 // Text is a text display widget with word wrapping support
 type Text struct {
 	*BaseWidget
-	Content  string
-	FontFace text.Face
+	TextSelection // with user-select: text
+	Content       string
+	Spans         []TextSpan // rich text runs of Content, set by SetMarkup
+	FontFace      text.Face
+
+	markup       bool          // bindings set the content through SetMarkup
+	resolveSpans func(t *Text) // resolves span faces and icons, set by the UI
+	onLinkClick  func(command string)
 
 	// Cached layout state
-	wrappedLines     []string
-	lastWidth        float64
-	layoutCache      *textLayout
-	layoutText       string
-	layoutFace       text.Face
-	layoutLineHeight float64
-	layoutWrap       bool
-	HoveredCluster   int
-	onClusterHover   func(TextHit)
-	onClusterLeave   func()
+	wrappedLines   []string
+	layoutCache    *textLayout
+	layoutText     string
+	layoutFace     text.Face
+	layoutOptions  textLayoutOptions
+	HoveredCluster int
+	onClusterHover func(TextHit)
+	onClusterLeave func()
 }
 
 // NewText creates a new text widget
@@ -176,7 +210,12 @@ func (t *Text) IntrinsicWidth() float64 {
 	if t.Content == "" || t.FontFace == nil {
 		return 0
 	}
-	tw, _ := text.Measure(t.Content, t.FontFace, 0)
+	style := t.getActiveStyle()
+	content := applyTextTransform(t.Content, style.TextTransform)
+	tw, _ := text.Measure(content, t.FontFace, 0)
+	if len(t.Spans) > 0 || style.WhiteSpace != "" || style.LineClamp > 0 {
+		tw = newTextLayout(content, t.FontFace, t.textLayoutOptions(style, 0, false)).width
+	}
 	bw := t.style.BorderWidth
 	return tw + t.style.Padding.Left + t.style.Padding.Right + bw*2
 }
@@ -187,12 +226,8 @@ func (t *Text) IntrinsicHeight() float64 {
 		return 0
 	}
 	style := t.getActiveStyle()
-	layout := newTextLayout(t.Content, t.FontFace, textLayoutOptions{
-		Wrap:                   false,
-		WhiteSpace:             textWhiteSpaceNormal,
-		LineHeight:             resolveTextLineHeight(t.FontFace, style),
-		TrimTrailingWhitespace: true,
-	})
+	content := applyTextTransform(t.Content, style.TextTransform)
+	layout := newTextLayout(content, t.FontFace, t.textLayoutOptions(style, 0, false))
 	th := layout.height
 	if th <= 0 {
 		th = resolveTextLineHeight(t.FontFace, style)
@@ -202,10 +237,13 @@ func (t *Text) IntrinsicHeight() float64 {
 	return th + t.style.Padding.Top + t.style.Padding.Bottom + bw*2
 }
 
-// SetContent sets the text content and invalidates cache
+// SetContent sets plain text content, dropping any rich text spans, and
+// invalidates cache
 func (t *Text) SetContent(content string) {
-	if t.Content != content {
+	if t.Content != content || t.Spans != nil {
 		t.Content = content
+		t.Spans = nil
+		t.ClearSelection()
 		t.invalidateLayout()
 	}
 }
@@ -215,9 +253,7 @@ func (t *Text) invalidateLayout() {
 	t.layoutCache = nil
 	t.layoutText = ""
 	t.layoutFace = nil
-	t.layoutLineHeight = 0
-	t.layoutWrap = false
-	t.lastWidth = 0
+	t.layoutOptions = textLayoutOptions{}
 	t.ClearHoveredCluster()
 }
 
@@ -250,34 +286,24 @@ func (t *Text) ensureLayout(maxWidth float64, style *Style) *textLayout {
 		style = t.getActiveStyle()
 	}
 
-	lineHeight := resolveTextLineHeight(t.FontFace, style)
-	wrap := style.TextWrap != "nowrap"
-	displayText := t.Content
-	if !wrap && style.TextOverflow == "ellipsis" && maxWidth > 0 {
+	wrap := style.TextWrap != "nowrap" && textWhiteSpaceFromStyle(style).wraps()
+	displayText := applyTextTransform(t.Content, style.TextTransform)
+	if !wrap && style.TextOverflow == "ellipsis" && maxWidth > 0 && style.LineClamp <= 0 {
 		displayText = truncateTextWithEllipsis(displayText, t.FontFace, maxWidth)
 	}
 
+	opts := t.textLayoutOptions(style, maxWidth, wrap)
 	if t.layoutCache != nil &&
 		t.layoutText == displayText &&
 		t.layoutFace == t.FontFace &&
-		t.layoutLineHeight == lineHeight &&
-		t.layoutWrap == wrap &&
-		t.lastWidth == maxWidth {
+		t.layoutOptions.sameLayout(opts) {
 		return t.layoutCache
 	}
 
-	t.layoutCache = newTextLayout(displayText, t.FontFace, textLayoutOptions{
-		MaxWidth:               maxWidth,
-		Wrap:                   wrap,
-		WhiteSpace:             textWhiteSpaceNormal,
-		LineHeight:             lineHeight,
-		TrimTrailingWhitespace: true,
-	})
+	t.layoutCache = newTextLayout(displayText, t.FontFace, opts)
 	t.layoutText = displayText
 	t.layoutFace = t.FontFace
-	t.layoutLineHeight = lineHeight
-	t.layoutWrap = wrap
-	t.lastWidth = maxWidth
+	t.layoutOptions = opts
 
 	t.wrappedLines = t.wrappedLines[:0]
 	for _, line := range t.layoutCache.lines {
@@ -286,6 +312,28 @@ func (t *Text) ensureLayout(maxWidth float64, style *Style) *textLayout {
 	return t.layoutCache
 }
 
+// textLayoutOptions returns the layout options of the text under a style.
+func (t *Text) textLayoutOptions(style *Style, maxWidth float64, wrap bool) textLayoutOptions {
+	whiteSpace := textWhiteSpaceFromStyle(style)
+	tabSize := style.TabSize
+	if tabSize <= 0 {
+		tabSize = 8
+	}
+	return textLayoutOptions{
+		MaxWidth:               maxWidth,
+		Wrap:                   wrap,
+		WhiteSpace:             whiteSpace,
+		LineHeight:             resolveTextLineHeight(t.FontFace, style),
+		TrimTrailingWhitespace: whiteSpace != textWhiteSpacePre,
+		Direction:              styleDirection(style),
+		Spans:                  t.Spans,
+		TabSize:                tabSize,
+		WordBreak:              style.WordBreak,
+		OverflowWrap:           style.OverflowWrap,
+		MaxLines:               style.LineClamp,
+	}
+}
+
 func resolveTextLineHeight(face text.Face, style *Style) float64 {
 	if style != nil && style.LineHeight > 0 {
 		return style.LineHeight
@@ -305,9 +353,10 @@ func (t *Text) textStartY(r Rect, layout *textLayout, style *Style) float64 {
 
 func (t *Text) lineOriginX(r Rect, lineWidth float64, style *Style) float64 {
 	x := r.X
-	if style.TextAlign == "center" {
+	switch resolveTextAlign(style) {
+	case "center":
 		x = r.X + (r.W-lineWidth)/2
-	} else if style.TextAlign == "right" {
+	case "right":
 		x = r.X + r.W - lineWidth
 	}
 	return x
@@ -325,11 +374,10 @@ func (t *Text) HitTest(x, y float64) (TextHit, bool) {
 		return TextHit{}, false
 	}
 
-	lineHeight := layout.lineHeight
-	lineTop := t.textStartY(r, layout, style)
+	startY := t.textStartY(r, layout, style)
 	for lineIndex, line := range layout.lines {
-		if y < lineTop || y > lineTop+lineHeight {
-			lineTop += lineHeight
+		lineTop := startY + line.Y
+		if y < lineTop || y > lineTop+line.Height {
 			continue
 		}
 		lineX := t.lineOriginX(r, line.Width, style)
@@ -342,19 +390,10 @@ func (t *Text) HitTest(x, y float64) (TextHit, bool) {
 			if localX < cluster.X || localX > cluster.X+cluster.Width {
 				continue
 			}
-			return TextHit{
-				LineIndex:    lineIndex,
-				ClusterIndex: clusterIndex,
-				Text:         cluster.Text,
-				RuneStart:    cluster.RuneStart,
-				RuneEnd:      cluster.RuneEnd,
-				Rect: Rect{
-					X: lineX + cluster.X,
-					Y: lineTop,
-					W: cluster.Width,
-					H: lineHeight,
-				},
-			}, true
+			hit := layout.clusterHit(lineIndex, clusterIndex)
+			hit.Rect.X += lineX
+			hit.Rect.Y += startY
+			return hit, true
 		}
 		return TextHit{}, false
 	}
@@ -397,10 +436,10 @@ func (t *Text) Draw(screen *ebiten.Image) {
 	}
 	animGeoM, hasAnimTransform, animOpacity := t.animationTransform()
 	opacity *= animOpacity
-	hasTransform := style.Transform != "" && style.Transform != "none"
+	hasTransform := t.hasTransform(style)
 	hasFilter := style.parsedFilter != nil
 	hasClipPath := style.ClipPath != "" && style.ClipPath != "none"
-	needsOffscreen := opacity < 1 || hasTransform || hasFilter || hasAnimTransform || hasClipPath
+	needsOffscreen := opacity < 1 || hasTransform || hasFilter || hasAnimTransform || hasClipPath || hasCompositeEffects(style)
 
 	drawContent := func(target *ebiten.Image, contentRect Rect, contentStyle *Style) {
 		t.drawContentOnly(target, contentRect, contentStyle)
@@ -444,26 +483,64 @@ func (t *Text) drawTextContent(screen *ebiten.Image, widgetRect Rect, style *Sty
 	emHeight := metrics.HAscent + metrics.HDescent
 	halfLeading := (layout.lineHeight - emHeight) / 2
 	startY := t.textStartY(r, layout, style)
+	decorations, decorationColor := textDecorations(style, textColor)
+	t.drawSelection(screen, r, layout, style, startY)
 
 	// In Ebitengine v2 text/v2, the origin is the top-left of the glyph's em-box.
 	// So we only need to move to the top of the centered em-box.
-	y := startY + halfLeading
 	for _, line := range layout.lines {
 		x := t.lineOriginX(r, line.Width, style)
+		y := startY + line.Y + halfLeading
 
-		drawTextShadows(screen, line.Text, t.FontFace, x, y, style)
+		drawTextShadowsWith(screen, x, y, style, func(dst *ebiten.Image, op *text.DrawOptions) {
+			layout.drawLineShadow(dst, line, op)
+		})
 
 		// Original text drawing
 		op := &text.DrawOptions{}
 		op.GeoM.Translate(snapToPixel(x), snapToPixel(y))
 		op.ColorScale.ScaleWithColor(textColor)
-		text.Draw(screen, line.Text, t.FontFace, op)
-
-		y += layout.lineHeight
+		layout.drawLine(screen, line, op)
+		if len(decorations) > 0 {
+			op.ColorScale.Reset()
+			op.ColorScale.ScaleWithColor(decorationColor)
+			for _, decoration := range decorations {
+				layout.drawLineDecoration(screen, line, op, decoration, style.TextDecorationThickness)
+			}
+		}
 	}
 }
 
 func drawTextShadows(screen *ebiten.Image, value string, face text.Face, x, y float64, style *Style) {
+	drawTextShadowsWith(screen, x, y, style, func(dst *ebiten.Image, op *text.DrawOptions) {
+		drawText(dst, value, face, op)
+	})
+}
+
+// textDecorations returns the decoration lines of a style and the color to
+// draw them in, the text color unless text-decoration-color is set.
+func textDecorations(style *Style, textColor color.Color) ([]string, color.Color) {
+	var lines []string
+	for _, line := range strings.Fields(style.TextDecoration) {
+		switch line {
+		case "underline", "overline", "line-through":
+			lines = append(lines, line)
+		}
+	}
+	if style.TextDecorationColor != "" {
+		if c := parseColor(style.TextDecorationColor); c != nil {
+			if style.Opacity > 0 && style.Opacity < 1 {
+				c = applyOpacity(c, style.Opacity)
+			}
+			return lines, c
+		}
+	}
+	return lines, textColor
+}
+
+// drawTextShadowsWith draws the style's text shadows of text drawn by draw
+// at the origin of the options it is given.
+func drawTextShadowsWith(screen *ebiten.Image, x, y float64, style *Style, draw func(dst *ebiten.Image, op *text.DrawOptions)) {
 	shadows := style.parsedTextShadows
 	if len(shadows) == 0 && style.TextShadow != "" {
 		shadows = ParseTextShadowList(style.TextShadow)
@@ -476,11 +553,11 @@ func drawTextShadows(screen *ebiten.Image, value string, face text.Face, x, y fl
 		shadows = []*TextShadow{style.parsedTextShadow}
 	}
 	for _, shadow := range shadows {
-		drawTextShadow(screen, value, face, x, y, shadow)
+		drawTextShadow(screen, x, y, shadow, draw)
 	}
 }
 
-func drawTextShadow(screen *ebiten.Image, value string, face text.Face, x, y float64, shadow *TextShadow) {
+func drawTextShadow(screen *ebiten.Image, x, y float64, shadow *TextShadow, draw func(dst *ebiten.Image, op *text.DrawOptions)) {
 	if shadow == nil {
 		return
 	}
@@ -492,7 +569,7 @@ func drawTextShadow(screen *ebiten.Image, value string, face text.Face, x, y flo
 		op := &text.DrawOptions{}
 		op.GeoM.Translate(snapToPixel(x+shadow.OffsetX), snapToPixel(y+shadow.OffsetY))
 		op.ColorScale.ScaleWithColor(shadowColor)
-		text.Draw(screen, value, face, op)
+		draw(screen, op)
 		return
 	}
 
@@ -505,7 +582,7 @@ func drawTextShadow(screen *ebiten.Image, value string, face text.Face, x, y flo
 	op := &text.DrawOptions{}
 	op.GeoM.Translate(snapToPixel(x+shadow.OffsetX), snapToPixel(y+shadow.OffsetY))
 	op.ColorScale.ScaleWithColor(shadowColor)
-	text.Draw(layer, value, face, op)
+	draw(layer, op)
 
 	blurred := applyGaussianBlur(layer, shadow.Blur)
 	cropped := blurred.SubImage(image.Rect(0, 0, w, h)).(*ebiten.Image)
@@ -520,6 +597,7 @@ func drawTextShadow(screen *ebiten.Image, value string, face text.Face, x, y flo
 type Image struct {
 	*BaseWidget
 	Source *ebiten.Image
+	Slice  *NineSlice // when set, drawn stretched over the widget instead of Source
 }
 
 // NewImage creates a new image widget
@@ -541,7 +619,15 @@ func (img *Image) Draw(screen *ebiten.Image) {
 	// Draw base
 	img.BaseWidget.Draw(screen)
 
-	if img.Source != nil {
+	if img.Slice != nil {
+		r := img.computedRect
+		var scale *ebiten.ColorScale
+		if img.style.Opacity > 0 && img.style.Opacity < 1 {
+			scale = &ebiten.ColorScale{}
+			scale.ScaleAlpha(float32(img.style.Opacity))
+		}
+		img.Slice.Draw(screen, r.X, r.Y, r.W, r.H, scale)
+	} else if img.Source != nil {
 		r := img.computedRect
 		srcW := float64(img.Source.Bounds().Dx())
 		srcH := float64(img.Source.Bounds().Dy())
@@ -825,7 +911,7 @@ func (c *Checkbox) Draw(screen *ebiten.Image) {
 		op := &text.DrawOptions{}
 		op.GeoM.Translate(snapToPixel(x), snapToPixel(y))
 		op.ColorScale.ScaleWithColor(textColor)
-		text.Draw(screen, c.Label, c.FontFace, op)
+		drawText(screen, c.Label, c.FontFace, op)
 	}
 }
 
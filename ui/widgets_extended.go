@@ -91,7 +91,7 @@ func (t *Toggle) Draw(screen *ebiten.Image) {
 		op := &text.DrawOptions{}
 		op.GeoM.Translate(snapToPixel(x), snapToPixel(y))
 		op.ColorScale.ScaleWithColor(textColor)
-		text.Draw(screen, t.Label, t.FontFace, op)
+		drawText(screen, t.Label, t.FontFace, op)
 	}
 }
 
@@ -253,7 +253,7 @@ func (rb *RadioButton) Draw(screen *ebiten.Image) {
 		op := &text.DrawOptions{}
 		op.GeoM.Translate(snapToPixel(x), snapToPixel(y))
 		op.ColorScale.ScaleWithColor(textColor)
-		text.Draw(screen, rb.Label, rb.FontFace, op)
+		drawText(screen, rb.Label, rb.FontFace, op)
 	}
 }
 
@@ -433,7 +433,7 @@ func (d *Dropdown) Draw(screen *ebiten.Image) {
 		op := &text.DrawOptions{}
 		op.GeoM.Translate(snapToPixel(textX), snapToPixel(textY))
 		op.ColorScale.ScaleWithColor(textColor)
-		text.Draw(screen, displayText, d.FontFace, op)
+		drawText(screen, displayText, d.FontFace, op)
 
 		// Draw dropdown arrow
 		arrowX := r.X + r.W - 24
@@ -510,7 +510,7 @@ func (d *Dropdown) drawDropdownList(screen *ebiten.Image) {
 			op := &text.DrawOptions{}
 			op.GeoM.Translate(snapToPixel(textX), snapToPixel(textY))
 			op.ColorScale.ScaleWithColor(textColor)
-			text.Draw(screen, opt.Label, d.FontFace, op)
+			drawText(screen, opt.Label, d.FontFace, op)
 		}
 	}
 }
@@ -702,7 +702,7 @@ func (m *Modal) Draw(screen *ebiten.Image) {
 			op := &text.DrawOptions{}
 			op.GeoM.Translate(snapToPixel(titleX), snapToPixel(titleY))
 			op.ColorScale.ScaleWithColor(textColor)
-			text.Draw(screen, m.Title, titleFace, op)
+			drawText(screen, m.Title, titleFace, op)
 		}
 	}
 
@@ -724,7 +724,7 @@ func (m *Modal) Draw(screen *ebiten.Image) {
 			op := &text.DrawOptions{}
 			op.GeoM.Translate(snapToPixel(x), snapToPixel(y))
 			op.ColorScale.ScaleWithColor(contentColor)
-			text.Draw(screen, line, m.FontFace, op)
+			drawText(screen, line, m.FontFace, op)
 
 			y += lineHeight
 		}
@@ -847,7 +847,7 @@ func (t *Tooltip) Draw(screen *ebiten.Image) {
 	op := &text.DrawOptions{}
 	op.GeoM.Translate(snapToPixel(tooltipX+paddingX), snapToPixel(tooltipY+paddingY))
 	op.ColorScale.ScaleWithColor(textColor)
-	text.Draw(screen, t.Text, t.FontFace, op)
+	drawText(screen, t.Text, t.FontFace, op)
 }
 
 // ============================================================================
@@ -919,7 +919,7 @@ func (b *Badge) Draw(screen *ebiten.Image) {
 	op := &text.DrawOptions{}
 	op.GeoM.Translate(snapToPixel(textX), snapToPixel(textY))
 	op.ColorScale.ScaleWithColor(textColor)
-	text.Draw(screen, b.Text, b.FontFace, op)
+	drawText(screen, b.Text, b.FontFace, op)
 }
 
 // ============================================================================
@@ -1121,6 +1121,6 @@ func (t *Toast) Draw(screen *ebiten.Image) {
 		op := &text.DrawOptions{}
 		op.GeoM.Translate(snapToPixel(toastX+paddingX), snapToPixel(toastY+paddingY))
 		op.ColorScale.ScaleWithColor(textColor)
-		text.Draw(screen, t.Message, t.FontFace, op)
+		drawText(screen, t.Message, t.FontFace, op)
 	}
 }